@@ -2,31 +2,52 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
 
+	"github.com/cypherlabdev/odds-optimizer-service/internal/alerting"
 	"github.com/cypherlabdev/odds-optimizer-service/internal/cache"
 	"github.com/cypherlabdev/odds-optimizer-service/internal/config"
 	httpHandler "github.com/cypherlabdev/odds-optimizer-service/internal/handler/http"
 	"github.com/cypherlabdev/odds-optimizer-service/internal/messaging"
 	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/store"
 	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
 )
 
 func main() {
+	configPath := flag.String("config", "config/config.yaml", "path to the config file")
+	validateOnly := flag.Bool("validate-config", false, "validate the config, print a summary and exit without starting the service")
+	flag.Parse()
+
+	if *validateOnly {
+		if err := validateConfig(*configPath, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load configuration
-	cfg, err := config.LoadConfig("config/config.yaml")
+	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load config")
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("invalid config")
+	}
 
 	// Setup logger
 	logger := setupLogger(cfg.Logging)
@@ -35,51 +56,388 @@ func main() {
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	shutdown := &shutdownPlan{stopConsumer: cancel}
+
+	// Pre-match odds use redis.ttl unless redis.prematch_ttl overrides it
+	prematchTTL := cfg.Redis.TTL
+	if cfg.Redis.PrematchTTL > 0 {
+		prematchTTL = cfg.Redis.PrematchTTL
+	}
 
 	// Create Redis cache
 	redisCache := cache.NewRedisCache(
 		cache.RedisCacheConfig{
-			Addr:     cfg.Redis.Addr,
-			Password: cfg.Redis.Password,
-			DB:       cfg.Redis.DB,
-			TTL:      cfg.Redis.TTL,
+			Addr:      cfg.Redis.Addr,
+			Password:  cfg.Redis.Password,
+			DB:        cfg.Redis.DB,
+			TTL:       prematchTTL,
+			InPlayTTL: cfg.Redis.InPlayTTL,
+			OpTimeout: cfg.Redis.OpTimeout,
+
+			Cluster:      cfg.Redis.Cluster,
+			ClusterAddrs: cfg.Redis.ClusterAddrs,
+
+			MaxKeyComponentLen: cfg.Redis.MaxKeyComponentLen,
+			PipelineChunk:      cfg.Redis.PipelineChunk,
+			StaleGrace:         cfg.Redis.StaleGrace,
+
+			// Every instance keeps the shared watermark the freshness
+			// watchdog reads, whether or not it runs the watchdog itself
+			TrackFreshness: true,
 		},
 		logger,
 	)
-	defer redisCache.Close()
+	shutdown.addCloser("redis_cache", redisCache)
+
+	// Optionally degrade to an in-memory cache while Redis is down
+	var oddsCache service.Cache = redisCache
+	var fallbackCache *cache.FallbackCache
+	if cfg.Redis.MemoryFallback {
+		fallbackCache = cache.NewFallbackCache(
+			redisCache,
+			cache.FallbackCacheConfig{
+				TTL:           prematchTTL,
+				InPlayTTL:     cfg.Redis.InPlayTTL,
+				ProbeInterval: cfg.Redis.ProbeInterval,
+				Registerer:    prometheus.DefaultRegisterer,
+			},
+			logger,
+		)
+		go fallbackCache.Start(ctx)
+		oddsCache = fallbackCache
+	}
 
 	// Test Redis connection
 	if err := redisCache.Ping(ctx); err != nil {
-		logger.Fatal().Err(err).Msg("failed to connect to Redis")
+		if fallbackCache == nil {
+			logger.Fatal().Err(err).Msg("failed to connect to Redis")
+		}
+		fallbackCache.Degrade(err)
+	} else {
+		logger.Info().Str("addr", cfg.Redis.Addr).Msg("connected to Redis")
+
+		// Catch a Redis that accepts connections but cannot serve the cache
+		if cfg.Redis.StartupSelftest {
+			if err := redisCache.SelfTest(ctx); err != nil {
+				logger.Fatal().Err(err).Str("addr", cfg.Redis.Addr).Msg("redis startup self-test failed")
+			}
+		}
 	}
-	logger.Info().Str("addr", cfg.Redis.Addr).Msg("connected to Redis")
 
 	// Create optimizer
 	opt := optimizer.NewOptimizer(
 		cfg.Optimization.ToOptimizationParams(),
 		logger,
 	)
+	opt.RegisterMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"profile": "default"}, prometheus.DefaultRegisterer))
 	logger.Info().Msg("optimizer initialized")
 
 	// Create optimizer service layer
-	optimizerService := service.NewOptimizerService(opt, redisCache, logger)
+	optimizerService := service.NewOptimizerService(opt, oddsCache, logger)
+	optimizerService.SetEventReadCoalescing(cfg.Redis.CoalesceEventReads)
+	optimizerService.SetMaxServeAge(cfg.Optimization.MaxServeAge, cfg.Optimization.DeleteOverAge)
+	optimizerService.SetLatencyBudget(cfg.Optimization.LatencyBudget)
 	logger.Info().Msg("optimizer service initialized")
 
 	// Create Kafka consumer
 	consumer := messaging.NewKafkaConsumer(
 		messaging.KafkaConsumerConfig{
-			Brokers: cfg.Kafka.Brokers,
-			Topic:   cfg.Kafka.Topic,
-			GroupID: cfg.Kafka.GroupID,
+			Brokers:               cfg.Kafka.Brokers,
+			Topic:                 cfg.Kafka.Topic,
+			GroupID:               cfg.Kafka.GroupID,
+			BackpressureThreshold: cfg.Kafka.BackpressureThreshold,
+			BackpressurePause:     cfg.Kafka.BackpressurePause,
+			SkipLowPriority:       cfg.Kafka.SkipLowPriority,
+			AutoCommit:            cfg.Kafka.AutoCommit,
+			CommitInterval:        cfg.Kafka.CommitInterval,
+			CommitAfterPublish:    cfg.Kafka.CommitAfterPublish,
+			MaxInflight:           cfg.Kafka.MaxInflight,
+			PollTimeout:           cfg.Kafka.PollTimeout,
+			GapThreshold:          cfg.Kafka.GapThreshold,
+			GapConfidencePenalty:  cfg.Kafka.GapConfidencePenalty,
+			SportAllowlist:        cfg.Kafka.SportAllowlist,
+			SportDenylist:         cfg.Kafka.SportDenylist,
+			SideMergeWindow:       cfg.Kafka.SideMergeWindow,
+			MessageFormat:         cfg.Kafka.MessageFormat,
+			Registerer:            prometheus.DefaultRegisterer,
 		},
 		opt,
-		redisCache,
+		oddsCache,
 		logger,
 	)
-	defer consumer.Close()
+	shutdown.addCloser("kafka_consumer", consumer)
+
+	// Without a poll timeout a quiet feed never wakes the fetch loop, so its
+	// last poll says nothing about liveness
+	maxPollStaleness := cfg.Kafka.MaxPollStaleness
+	if maxPollStaleness > 0 && cfg.Kafka.PollTimeout <= 0 {
+		logger.Warn().Msg("kafka.max_poll_staleness requires kafka.poll_timeout, ignoring")
+		maxPollStaleness = 0
+	}
+
+	// Register named optimizer profiles (optional)
+	statsSources := []alerting.StatsSource{opt}
+	if profileParams := cfg.Optimization.ToProfileParams(); len(profileParams) > 0 {
+		profiles := make(map[string]service.Optimizer, len(profileParams))
+		for name, params := range profileParams {
+			profileOpt := optimizer.NewOptimizer(params, logger.With().Str("profile", name).Logger())
+			profileOpt.RegisterMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"profile": name}, prometheus.DefaultRegisterer))
+			profiles[name] = profileOpt
+			statsSources = append(statsSources, profileOpt)
+		}
+		consumer.SetProfiles(profiles)
+		logger.Info().Int("count", len(profiles)).Msg("optimizer profiles registered")
+	}
+
+	// Alert on optimizer anomalies (optional)
+	if cfg.Alerting.WebhookURL != "" {
+		detector := alerting.NewAnomalyDetector(
+			alerting.AnomalyDetectorConfig{
+				WebhookURL:                  cfg.Alerting.WebhookURL,
+				Window:                      cfg.Alerting.Window,
+				CheckInterval:               cfg.Alerting.CheckInterval,
+				Debounce:                    cfg.Alerting.Debounce,
+				Timeout:                     cfg.Alerting.Timeout,
+				RejectionRateThreshold:      cfg.Alerting.RejectionRateThreshold,
+				NegativeMarginRateThreshold: cfg.Alerting.NegativeMarginRateThreshold,
+				MinSamples:                  cfg.Alerting.MinSamples,
+				Registerer:                  prometheus.DefaultRegisterer,
+			},
+			statsSources,
+			logger,
+		)
+		go detector.Start(ctx)
+		logger.Info().Dur("window", cfg.Alerting.Window).Msg("alerting on optimizer anomalies")
+	}
+
+	// Register downstream sinks for optimized odds (optional)
+	sinks := service.NewSinkRegistry(prometheus.DefaultRegisterer, logger)
+	changeOnly := service.ChangeOnlyConfig{
+		MinChange:    decimal.NewFromFloat(cfg.Sinks.ChangeOnly.MinChange),
+		MinChangePct: decimal.NewFromFloat(cfg.Sinks.ChangeOnly.MinChangePct),
+		TTL:          cfg.Sinks.ChangeOnly.TTL,
+	}
+	registerSink := func(name string, sink service.OddsSink) {
+		if slices.Contains(cfg.Sinks.ChangeOnly.Sinks, name) {
+			sink = service.NewChangeOnlySink(sink, changeOnly)
+			logger.Info().Str("sink", name).Msg("publishing only changed odds to sink")
+		}
+		sinks.Register(name, sink)
+	}
+	for _, name := range cfg.Sinks.Enabled {
+		switch name {
+		case "kafka":
+			if cfg.Kafka.OutputTopic == "" {
+				logger.Warn().Msg("kafka sink enabled without kafka.output_topic, skipping")
+				continue
+			}
+			producer := messaging.NewKafkaProducer(
+				messaging.KafkaProducerConfig{
+					Brokers:       cfg.Kafka.Brokers,
+					Topic:         cfg.Kafka.OutputTopic,
+					MaxBatchSize:  cfg.Kafka.OutputMaxBatch,
+					FlushInterval: cfg.Kafka.OutputFlushInterval,
+					MaxPending:    cfg.Kafka.OutputMaxPending,
+					Synchronous:   cfg.Kafka.CommitAfterPublish, // Commits wait for the odds to be written, not buffered
+					Registerer:    prometheus.DefaultRegisterer,
+				},
+				logger,
+			)
+			shutdown.addCloser("kafka_producer", producer)
+			go producer.Start(ctx)
+			registerSink(name, producer)
+			logger.Info().Str("topic", cfg.Kafka.OutputTopic).Msg("publishing optimized odds to Kafka")
+
+		case "webhook":
+			if cfg.Sinks.Webhook.URL == "" {
+				logger.Fatal().Msg("webhook sink enabled without sinks.webhook.url")
+			}
+			registerSink(name, messaging.NewWebhookSink(
+				messaging.WebhookSinkConfig{
+					URL:     cfg.Sinks.Webhook.URL,
+					Timeout: cfg.Sinks.Webhook.Timeout,
+				},
+				logger,
+			))
+			logger.Info().Msg("publishing optimized odds to webhook")
+
+		case "analytics":
+			if cfg.Analytics.WebhookURL == "" {
+				logger.Fatal().Msg("analytics sink enabled without analytics.webhook_url")
+			}
+			registerSink(name, service.NewSampledSink(
+				messaging.NewWebhookSink(
+					messaging.WebhookSinkConfig{
+						URL:     cfg.Analytics.WebhookURL,
+						Timeout: cfg.Analytics.Timeout,
+					},
+					logger,
+				),
+				cfg.Analytics.SampleRate,
+			))
+			logger.Info().Float64("sample_rate", cfg.Analytics.SampleRate).Msg("publishing sampled optimized odds to analytics")
+
+		default:
+			logger.Fatal().Str("sink", name).Msg("unknown sink")
+		}
+	}
+
+	// Capture closing lines shortly before kickoff (optional)
+	if cfg.ClosingLine.Enabled {
+		closingLines := cache.NewRedisClosingLines(
+			cache.RedisClosingLinesConfig{
+				Addr:               cfg.Redis.Addr,
+				Password:           cfg.Redis.Password,
+				DB:                 cfg.Redis.DB,
+				Cluster:            cfg.Redis.Cluster,
+				ClusterAddrs:       cfg.Redis.ClusterAddrs,
+				MaxKeyComponentLen: cfg.Redis.MaxKeyComponentLen,
+				TTL:                cfg.ClosingLine.TTL,
+			},
+			logger,
+		)
+		shutdown.addCloser("redis_closing_lines", closingLines)
+		optimizerService.SetClosingLines(closingLines)
+
+		scheduler := service.NewClosingLineScheduler(
+			oddsCache,
+			closingLines,
+			service.ClosingLineSchedulerConfig{
+				Lead:          cfg.ClosingLine.Lead,
+				CheckInterval: cfg.ClosingLine.CheckInterval,
+			},
+			logger,
+		)
+		go scheduler.Start(ctx)
+		sinks.Register("closing_line", scheduler)
+		logger.Info().Dur("lead", cfg.ClosingLine.Lead).Msg("capturing closing lines")
+	}
+	// Write every optimized price to Postgres for long-term queries (optional)
+	if cfg.Store.Enabled {
+		postgresStore, err := store.NewPostgresStore(
+			ctx,
+			store.PostgresStoreConfig{
+				DSN:          cfg.Store.DSN,
+				MaxOpenConns: cfg.Store.MaxOpenConns,
+			},
+			logger,
+		)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to open odds store")
+		}
+
+		storeWriter := service.NewStoreWriter(
+			postgresStore,
+			service.StoreWriterConfig{
+				QueueSize:    cfg.Store.QueueSize,
+				WriteTimeout: cfg.Store.WriteTimeout,
+				Registerer:   prometheus.DefaultRegisterer,
+			},
+			logger,
+		)
+		shutdown.addCloser("postgres_store", storeWriter)
+		optimizerService.SetStore(storeWriter)
+		sinks.Register("store", storeWriter)
+		go storeWriter.Start(ctx)
+		logger.Info().Int("queue_size", cfg.Store.QueueSize).Msg("storing optimized odds in Postgres")
+	}
+
+	if sinks.Len() > 0 {
+		consumer.SetSinks(sinks)
+	}
+
+	// Skip batches redelivered after a rebalance (optional)
+	if cfg.Kafka.DedupWindow > 0 {
+		redisDedup := cache.NewRedisDedup(
+			cache.RedisDedupConfig{
+				Addr:         cfg.Redis.Addr,
+				Password:     cfg.Redis.Password,
+				DB:           cfg.Redis.DB,
+				Cluster:      cfg.Redis.Cluster,
+				ClusterAddrs: cfg.Redis.ClusterAddrs,
+				Window:       cfg.Kafka.DedupWindow,
+			},
+			logger,
+		)
+		shutdown.addCloser("redis_dedup", redisDedup)
+		consumer.SetDedup(redisDedup)
+		logger.Info().Dur("window", cfg.Kafka.DedupWindow).Msg("de-duplicating redelivered batches")
+	}
+
+	// Create odds history store (optional)
+	if cfg.History.Enabled {
+		redisHistory := cache.NewRedisHistory(
+			cache.RedisHistoryConfig{
+				Addr:               cfg.Redis.Addr,
+				Password:           cfg.Redis.Password,
+				DB:                 cfg.Redis.DB,
+				Cluster:            cfg.Redis.Cluster,
+				ClusterAddrs:       cfg.Redis.ClusterAddrs,
+				MaxKeyComponentLen: cfg.Redis.MaxKeyComponentLen,
+				MaxLen:             cfg.History.MaxLen,
+				Retention:          cfg.History.Retention,
+				TrimInterval:       cfg.History.TrimInterval,
+			},
+			logger,
+		)
+		shutdown.addCloser("redis_history", redisHistory)
+		optimizerService.SetHistory(redisHistory)
+		consumer.SetHistory(redisHistory)
+		go redisHistory.Start(ctx)
+		logger.Info().
+			Int64("max_len", cfg.History.MaxLen).
+			Dur("retention", cfg.History.Retention).
+			Msg("recording optimized odds history")
+
+		// Weight confidence by recent price stability (optional)
+		if cfg.Optimization.StabilityWeight > 0 {
+			prices := service.NewHistoryPrices(redisHistory, opt.Params(), cfg.Redis.OpTimeout, logger)
+			optimizerService.SetPriceHistory(prices)
+			consumer.SetPriceHistory(prices)
+			logger.Info().Float64("weight", cfg.Optimization.StabilityWeight).Msg("weighting confidence by price stability")
+		}
+	} else if cfg.Optimization.StabilityWeight > 0 {
+		logger.Warn().Msg("optimization.stability_weight requires history.enabled, ignoring")
+	}
+
+	// Fail readiness while the optimizer stops producing sane prices (optional)
+	var canary *service.Canary
+	if cfg.Canary.Enabled {
+		canary = service.NewCanary(
+			opt,
+			service.CanaryConfig{
+				Interval:      cfg.Canary.Interval,
+				FailureWindow: cfg.Canary.FailureWindow,
+				Registerer:    prometheus.DefaultRegisterer,
+			},
+			logger,
+		)
+		go canary.Start(ctx)
+		logger.Info().Dur("interval", cfg.Canary.Interval).Msg("optimizer canary enabled")
+	}
+
+	// Refuse live odds reads while no fresh data is flowing cluster-wide (optional)
+	var freshness *service.FreshnessWatchdog
+	if cfg.Freshness.Enabled {
+		freshness = service.NewFreshnessWatchdog(
+			redisCache,
+			service.FreshnessWatchdogConfig{
+				MaxStaleness:  cfg.Freshness.MaxStaleness,
+				CheckInterval: cfg.Freshness.CheckInterval,
+				Registerer:    prometheus.DefaultRegisterer,
+			},
+			logger,
+		)
+		go freshness.Start(ctx)
+		logger.Info().Dur("max_staleness", cfg.Freshness.MaxStaleness).Msg("freshness watchdog enabled")
+	}
 
 	// Start Kafka consumer in goroutine
+	consumerDone := make(chan struct{})
+	shutdown.consumerDone = consumerDone
+	consumerStarted := time.Now()
 	go func() {
+		defer close(consumerDone)
 		if err := consumer.Start(ctx); err != nil {
 			logger.Error().Err(err).Msg("Kafka consumer failed")
 		}
@@ -87,6 +445,14 @@ func main() {
 
 	// Initialize HTTP handler
 	oddsHandler := httpHandler.NewOddsHandler(optimizerService, logger)
+	if err := oddsHandler.SetJSONCase(cfg.Server.JSONCase); err != nil {
+		logger.Fatal().Err(err).Msg("invalid server.json_case")
+	}
+	oddsHandler.SetEventOddsLimits(cfg.Server.EventOddsMaxSelections, cfg.Server.EventOddsMaxBytes)
+	oddsHandler.SetEnvelope(cfg.Server.Envelope)
+	if freshness != nil {
+		oddsHandler.SetFreshness(freshness)
+	}
 	logger.Info().Msg("HTTP handler initialized")
 
 	// Setup HTTP server routes
@@ -95,25 +461,94 @@ func main() {
 	// Health and monitoring endpoints
 	mux.HandleFunc("/health", healthHandler)
 	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		readyHandler(w, r, redisCache)
+		readyHandler(w, r, readinessChecks{
+			cache:            oddsCache,
+			canary:           canary,
+			consumer:         consumer,
+			maxPollStaleness: maxPollStaleness,
+			startedAt:        consumerStarted,
+		})
 	})
 	mux.Handle("/metrics", promhttp.Handler())
 
 	// Register API routes
 	oddsHandler.RegisterRoutes(mux)
+
+	// Register admin routes (API-key gated)
+	adminHandler := httpHandler.NewAdminHandler(optimizerService, consumer, cfg.Server.APIKey, logger)
+	adminHandler.SetSeeker(consumer)
+	adminHandler.SetPauser(consumer)
+	adminHandler.RegisterRoutes(mux)
 	logger.Info().Msg("API routes registered")
 
+	// Cancel and answer handlers that overrun the handler timeout (optional)
+	var handler http.Handler = mux
+	if cfg.Server.HandlerTimeout > 0 {
+		timeoutHandler := httpHandler.NewTimeoutHandler(
+			httpHandler.TimeoutHandlerConfig{
+				Timeout:    cfg.Server.HandlerTimeout,
+				Exempt:     append([]string{"/health", "/ready"}, adminHandler.UntimedPaths()...),
+				Registerer: prometheus.DefaultRegisterer,
+			},
+			logger,
+		)
+		handler = timeoutHandler.Wrap(handler)
+		logger.Info().Dur("handler_timeout", cfg.Server.HandlerTimeout).Msg("HTTP handler timeout enabled")
+	}
+
+	// Shed load beyond the concurrent handler limit (optional)
+	if cfg.Server.MaxConcurrent > 0 {
+		limiter := httpHandler.NewConcurrencyLimiter(
+			httpHandler.ConcurrencyLimiterConfig{
+				MaxConcurrent: cfg.Server.MaxConcurrent,
+				Exempt:        []string{"/health", "/ready"},
+				Registerer:    prometheus.DefaultRegisterer,
+			},
+			logger,
+		)
+		handler = limiter.Wrap(handler)
+		logger.Info().Int("max_concurrent", cfg.Server.MaxConcurrent).Msg("HTTP load shedding enabled")
+	}
+
+	// Answer handler panics with a 500 instead of a dropped connection
+	recoverer := httpHandler.NewRecoverer(
+		httpHandler.RecovererConfig{Registerer: prometheus.DefaultRegisterer},
+		logger,
+	)
+	handler = recoverer.Wrap(handler)
+
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler:      mux,
+		Handler:      handler,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
+	// Terminate TLS in-process when enabled (mTLS when a client CA is set)
+	if cfg.Server.TLS.Enabled {
+		tlsConfig, err := cfg.Server.TLS.ServerTLSConfig()
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to configure TLS")
+		}
+		server.TLSConfig = tlsConfig
+	}
+
 	// Start HTTP server in goroutine
 	go func() {
-		logger.Info().Int("port", cfg.Server.Port).Msg("starting HTTP server")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Info().
+			Int("port", cfg.Server.Port).
+			Bool("tls", server.TLSConfig != nil).
+			Bool("mtls", cfg.Server.TLS.Enabled && cfg.Server.TLS.ClientCA != "").
+			Msg("starting HTTP server")
+
+		var err error
+		if server.TLSConfig != nil {
+			// Certificates are already loaded into TLSConfig
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Error().Err(err).Msg("HTTP server failed")
 		}
 	}()
@@ -123,18 +558,11 @@ func main() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
-	logger.Info().Msg("shutting down gracefully...")
-
-	// Cancel context to stop consumer
-	cancel()
+	logger.Info().Dur("timeout", cfg.Server.ShutdownTimeout).Msg("shutting down gracefully...")
 
-	// Shutdown HTTP server
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer shutdownCancel()
-
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.Error().Err(err).Msg("HTTP server shutdown failed")
-	}
+	// Drain HTTP, then the consumer, then close Redis and sinks
+	shutdown.server = server
+	shutdown.run(cfg.Server.ShutdownTimeout, logger)
 
 	logger.Info().Msg("shutdown complete")
 }
@@ -162,15 +590,47 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
 
+// readinessChecks holds what readyHandler checks
+type readinessChecks struct {
+	cache            service.Cache
+	canary           *service.Canary // nil when disabled
+	consumer         *messaging.KafkaConsumer
+	maxPollStaleness time.Duration // 0 disables the consumer liveness check
+	startedAt        time.Time     // A consumer that has never polled fails the liveness check maxPollStaleness after this
+}
+
 // readyHandler returns 200 if service is ready to accept traffic
-func readyHandler(w http.ResponseWriter, r *http.Request, cache *cache.RedisCache) {
-	// Check Redis connection
-	if err := cache.Ping(r.Context()); err != nil {
+func readyHandler(w http.ResponseWriter, r *http.Request, checks readinessChecks) {
+	// Check Redis connection (or the in-memory fallback while Redis is down)
+	if err := checks.cache.Ping(r.Context()); err != nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write([]byte("Redis unavailable"))
 		return
 	}
 
+	// Check the consumer's fetch loop is still polling, or started polling
+	// within the staleness window of startup
+	if checks.maxPollStaleness > 0 {
+		last := checks.consumer.LastPoll()
+		if last.IsZero() {
+			last = checks.startedAt
+		}
+		if time.Since(last) > checks.maxPollStaleness {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Kafka consumer not polling"))
+			return
+		}
+	}
+
+	// Check the optimizer still produces sane prices
+	if checks.canary != nil {
+		if err := checks.canary.Ready(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("Optimizer canary failing"))
+			return
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("READY"))
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/cache"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/messaging"
+)
+
+// TestReadyHandler_ConsumerNeverPolled tests that a consumer that has not
+// polled yet is ready during the startup grace, and not ready after it
+func TestReadyHandler_ConsumerNeverPolled(t *testing.T) {
+	tests := []struct {
+		name       string
+		startedAgo time.Duration
+		expected   int
+	}{
+		{name: "Within startup grace", startedAgo: time.Second, expected: http.StatusOK},
+		{name: "After startup grace", startedAgo: time.Minute, expected: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := messaging.NewKafkaConsumer(messaging.KafkaConsumerConfig{
+				Brokers: []string{"localhost:9092"},
+				Topic:   "normalized_odds",
+				GroupID: "test-group",
+			}, nil, nil, zerolog.Nop())
+			defer consumer.Close()
+
+			rec := httptest.NewRecorder()
+			readyHandler(rec, httptest.NewRequest(http.MethodGet, "/ready", nil), readinessChecks{
+				cache:            cache.NewMemoryCache(time.Minute, zerolog.Nop()),
+				consumer:         consumer,
+				maxPollStaleness: 30 * time.Second,
+				startedAt:        time.Now().Add(-tt.startedAgo),
+			})
+
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// httpShutdowner is satisfied by *http.Server
+type httpShutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// namedCloser is a resource closed at the end of shutdown
+type namedCloser struct {
+	name   string
+	closer io.Closer
+}
+
+// shutdownPlan holds everything stopped on shutdown
+type shutdownPlan struct {
+	server       httpShutdowner
+	stopConsumer context.CancelFunc // Stops fetching; also stops background loops
+	consumerDone <-chan struct{}    // Closed once the consumer has drained
+	closers      []namedCloser      // Closed in reverse order, like defers
+}
+
+// addCloser registers a resource to close once HTTP and the consumer have drained
+func (p *shutdownPlan) addCloser(name string, closer io.Closer) {
+	p.closers = append(p.closers, namedCloser{name: name, closer: closer})
+}
+
+// run shuts down in dependency order within timeout:
+//  1. stop accepting HTTP connections and drain in-flight requests
+//  2. stop the consumer and wait for it to finish and commit its current message
+//  3. close sinks, stores and Redis, most recently created first
+//
+// Resources are closed even when an earlier step runs out of time.
+func (p *shutdownPlan) run(timeout time.Duration, logger zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := p.server.Shutdown(ctx); err != nil {
+		logger.Error().Err(err).Msg("HTTP server shutdown failed")
+	}
+
+	p.stopConsumer()
+	select {
+	case <-p.consumerDone:
+	case <-ctx.Done():
+		logger.Error().Dur("timeout", timeout).Msg("Kafka consumer did not drain before the shutdown timeout")
+	}
+
+	for i := len(p.closers) - 1; i >= 0; i-- {
+		if err := p.closers[i].closer.Close(); err != nil {
+			logger.Error().Err(err).Str("resource", p.closers[i].name).Msg("failed to close")
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// shutdownRecorder records the order shutdown steps happen in
+type shutdownRecorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *shutdownRecorder) record(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *shutdownRecorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.steps...)
+}
+
+// fakeServer records Shutdown, waiting drain for in-flight requests
+type fakeServer struct {
+	recorder *shutdownRecorder
+	drain    time.Duration
+}
+
+func (s *fakeServer) Shutdown(ctx context.Context) error {
+	s.recorder.record("http_stop_accepting")
+	select {
+	case <-time.After(s.drain):
+		s.recorder.record("http_drained")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fakeCloser records Close
+type fakeCloser struct {
+	name     string
+	recorder *shutdownRecorder
+	err      error
+}
+
+func (c *fakeCloser) Close() error {
+	c.recorder.record("close_" + c.name)
+	return c.err
+}
+
+// newFakeConsumer returns a stop function and done channel for a consumer
+// that takes drain to finish its current message once stopped
+func newFakeConsumer(recorder *shutdownRecorder, drain time.Duration) (context.CancelFunc, <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		time.Sleep(drain)
+		recorder.record("consumer_committed")
+	}()
+	stop := func() {
+		recorder.record("consumer_stopping")
+		cancel()
+	}
+	return stop, done
+}
+
+// TestShutdownPlan_Order tests that HTTP drains before the consumer, which
+// drains before any resource is closed
+func TestShutdownPlan_Order(t *testing.T) {
+	recorder := &shutdownRecorder{}
+	stopConsumer, consumerDone := newFakeConsumer(recorder, 20*time.Millisecond)
+
+	plan := &shutdownPlan{
+		server:       &fakeServer{recorder: recorder, drain: 20 * time.Millisecond},
+		stopConsumer: stopConsumer,
+		consumerDone: consumerDone,
+	}
+	plan.addCloser("redis_cache", &fakeCloser{name: "redis_cache", recorder: recorder})
+	plan.addCloser("kafka_consumer", &fakeCloser{name: "kafka_consumer", recorder: recorder})
+	plan.addCloser("kafka_producer", &fakeCloser{name: "kafka_producer", recorder: recorder, err: errors.New("flush failed")})
+	plan.addCloser("redis_history", &fakeCloser{name: "redis_history", recorder: recorder})
+
+	plan.run(time.Second, zerolog.Nop())
+
+	assert.Equal(t, []string{
+		"http_stop_accepting",
+		"http_drained",
+		"consumer_stopping",
+		"consumer_committed",
+		"close_redis_history",
+		"close_kafka_producer",
+		"close_kafka_consumer",
+		"close_redis_cache",
+	}, recorder.recorded())
+}
+
+// TestShutdownPlan_Timeout tests that resources are still closed when draining overruns the timeout
+func TestShutdownPlan_Timeout(t *testing.T) {
+	recorder := &shutdownRecorder{}
+	stopConsumer, consumerDone := newFakeConsumer(recorder, time.Hour)
+
+	plan := &shutdownPlan{
+		server:       &fakeServer{recorder: recorder, drain: time.Hour},
+		stopConsumer: stopConsumer,
+		consumerDone: consumerDone,
+	}
+	plan.addCloser("redis_cache", &fakeCloser{name: "redis_cache", recorder: recorder})
+
+	start := time.Now()
+	plan.run(50*time.Millisecond, zerolog.Nop())
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []string{
+		"http_stop_accepting",
+		"consumer_stopping",
+		"close_redis_cache",
+	}, recorder.recorded())
+}
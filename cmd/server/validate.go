@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/config"
+)
+
+// validateConfig loads and validates the config at path, as the service
+// would at startup, and writes a summary of it to w. It never connects to
+// Kafka, Redis or opens a listener, so it is safe to run in CI.
+func validateConfig(path string, w io.Writer) error {
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config %s:\n%w", path, err)
+	}
+
+	profiles := make([]string, 0, len(cfg.Optimization.Profiles))
+	for name := range cfg.Optimization.ToProfileParams() {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+
+	fmt.Fprintf(w, "config %s is valid\n", path)
+	fmt.Fprintf(w, "  server:       port %d, json_case %s, tls %t\n", cfg.Server.Port, cfg.Server.JSONCase, cfg.Server.TLS.Enabled)
+	fmt.Fprintf(w, "  kafka:        %s, topic %s, group %s\n", strings.Join(cfg.Kafka.Brokers, ","), cfg.Kafka.Topic, cfg.Kafka.GroupID)
+	fmt.Fprintf(w, "  redis:        %s, ttl %s\n", cfg.Redis.Addr, cfg.Redis.TTL)
+	fmt.Fprintf(w, "  optimization: margin %v-%v, target confidence %v\n", cfg.Optimization.MinMargin, cfg.Optimization.MaxMargin, cfg.Optimization.TargetConfidence)
+	fmt.Fprintf(w, "  profiles:     %s\n", listOrNone(profiles))
+	fmt.Fprintf(w, "  sinks:        %s\n", listOrNone(cfg.Sinks.Enabled))
+
+	return nil
+}
+
+// listOrNone joins items for display, or returns "none"
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes a config file to a temp dir and returns its path
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+// TestValidateConfig tests the -validate-config path with a good and a bad config file
+func TestValidateConfig(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		path := writeConfig(t, `
+kafka:
+  brokers: [kafka-1:9092, kafka-2:9092]
+optimization:
+  min_margin: 0.03
+  profiles:
+    sharp:
+      min_margin: 0.01
+      max_margin: 0.04
+  min_confidence_by_market:
+    outright: 0.3
+`)
+
+		var out bytes.Buffer
+		require.NoError(t, validateConfig(path, &out))
+		assert.Contains(t, out.String(), "is valid")
+		assert.Contains(t, out.String(), "kafka-1:9092,kafka-2:9092")
+		assert.Contains(t, out.String(), "profiles:     sharp")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		path := writeConfig(t, `
+server:
+  json_case: kebab
+optimization:
+  min_margin: 0.05
+  max_margin: 0.02
+  ladder_policy: interpolate
+  profiles:
+    wide:
+      max_margin: 1.5
+  min_confidence_by_market:
+    outright: 1.2
+`)
+
+		var out bytes.Buffer
+		err := validateConfig(path, &out)
+		require.Error(t, err)
+		assert.Empty(t, out.String())
+		for _, problem := range []string{
+			"server.json_case",
+			"optimization.max_margin 0.02 below min_margin 0.05",
+			"optimization.ladder_policy",
+			"optimization.min_confidence_by_market.outright",
+			"optimization.profiles.wide.max_margin 1.5 must be below 1",
+		} {
+			assert.Contains(t, err.Error(), problem)
+		}
+	})
+
+	t.Run("Unreadable", func(t *testing.T) {
+		err := validateConfig(writeConfig(t, "optimization: [not, a, map"), &bytes.Buffer{})
+		assert.Error(t, err)
+	})
+}
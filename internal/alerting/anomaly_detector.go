@@ -0,0 +1,220 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// Alert names
+const (
+	AlertRejectionRate      = "high_rejection_rate"
+	AlertNegativeMarginRate = "high_negative_margin_rate"
+)
+
+// StatsSource provides optimizer counters; satisfied by *optimizer.Optimizer
+type StatsSource interface {
+	Stats() optimizer.Stats
+}
+
+// Alert is the JSON body POSTed to the alert webhook
+type Alert struct {
+	Alert     string    `json:"alert"`     // AlertRejectionRate or AlertNegativeMarginRate
+	Service   string    `json:"service"`   // Always "odds-optimizer-service"
+	Rate      float64   `json:"rate"`      // Observed rate over the window
+	Threshold float64   `json:"threshold"` // Configured threshold that was crossed
+	Samples   uint64    `json:"samples"`   // Selections (rejections) or books (negative margins) in the window
+	Window    string    `json:"window"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AnomalyDetector samples optimizer counters, tracks rolling rejection and
+// negative-margin rates and POSTs an Alert to a webhook when a rate crosses
+// its threshold. Repeats of the same alert are debounced.
+type AnomalyDetector struct {
+	config  AnomalyDetectorConfig
+	sources []StatsSource
+	client  *http.Client
+	metrics *alertMetrics
+	logger  zerolog.Logger
+
+	mu        sync.Mutex
+	snapshots []snapshot           // Summed counters within the window, oldest first
+	lastSent  map[string]time.Time // Last successful alert per name, for debouncing
+}
+
+// AnomalyDetectorConfig holds anomaly detector configuration
+type AnomalyDetectorConfig struct {
+	WebhookURL    string        // Alert endpoint, e.g. a pager integration URL
+	Window        time.Duration // Rolling window rates are computed over (default 5m)
+	CheckInterval time.Duration // How often counters are sampled (default 30s)
+	Debounce      time.Duration // Minimum time between repeats of the same alert (default 15m)
+	Timeout       time.Duration // Per-request webhook timeout (default 5s)
+
+	RejectionRateThreshold      float64 // Rejected / (optimized + rejected) selections that alerts (0 disables)
+	NegativeMarginRateThreshold float64 // Negative-margin / checked books that alerts (0 disables)
+	MinSamples                  uint64  // Selections or books required in the window before a rate is judged
+
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// snapshot is the summed optimizer counters at a point in time
+type snapshot struct {
+	at    time.Time
+	stats optimizer.Stats
+}
+
+// NewAnomalyDetector creates an anomaly detector over the given optimizers
+func NewAnomalyDetector(config AnomalyDetectorConfig, sources []StatsSource, logger zerolog.Logger) *AnomalyDetector {
+	if config.Window <= 0 {
+		config.Window = 5 * time.Minute
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 30 * time.Second
+	}
+	if config.Debounce <= 0 {
+		config.Debounce = 15 * time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	return &AnomalyDetector{
+		config:   config,
+		sources:  sources,
+		client:   &http.Client{Timeout: config.Timeout},
+		metrics:  newAlertMetrics(config.Registerer),
+		lastSent: make(map[string]time.Time),
+		logger:   logger.With().Str("component", "anomaly_detector").Logger(),
+	}
+}
+
+// Start samples counters every CheckInterval until ctx is cancelled
+func (d *AnomalyDetector) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.config.CheckInterval)
+	defer ticker.Stop()
+
+	d.check(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.check(ctx, now)
+		}
+	}
+}
+
+// check records a snapshot taken at now and alerts on any rate over threshold
+func (d *AnomalyDetector) check(ctx context.Context, now time.Time) {
+	var current optimizer.Stats
+	for _, source := range d.sources {
+		stats := source.Stats()
+		current.Optimized += stats.Optimized
+		current.Rejected += stats.Rejected
+		current.BooksChecked += stats.BooksChecked
+		current.NegativeMargin += stats.NegativeMargin
+	}
+
+	d.mu.Lock()
+	d.snapshots = append(d.snapshots, snapshot{at: now, stats: current})
+	// Keep the newest snapshot at or before the window start as the baseline
+	cutoff := now.Add(-d.config.Window)
+	for len(d.snapshots) > 1 && !d.snapshots[1].at.After(cutoff) {
+		d.snapshots = d.snapshots[1:]
+	}
+	oldest := d.snapshots[0].stats
+	d.mu.Unlock()
+
+	rejected := current.Rejected - oldest.Rejected
+	selections := current.Optimized - oldest.Optimized + rejected
+	d.evaluate(ctx, now, AlertRejectionRate, rejected, selections, d.config.RejectionRateThreshold)
+
+	negative := current.NegativeMargin - oldest.NegativeMargin
+	books := current.BooksChecked - oldest.BooksChecked
+	d.evaluate(ctx, now, AlertNegativeMarginRate, negative, books, d.config.NegativeMarginRateThreshold)
+}
+
+// evaluate alerts when count/samples exceeds threshold and the alert is not debounced
+func (d *AnomalyDetector) evaluate(ctx context.Context, now time.Time, name string, count, samples uint64, threshold float64) {
+	if threshold <= 0 || samples == 0 || samples < d.config.MinSamples {
+		return
+	}
+
+	rate := float64(count) / float64(samples)
+	if rate <= threshold {
+		return
+	}
+
+	d.mu.Lock()
+	last, sent := d.lastSent[name]
+	d.mu.Unlock()
+	if sent && now.Sub(last) < d.config.Debounce {
+		return
+	}
+
+	alert := Alert{
+		Alert:     name,
+		Service:   "odds-optimizer-service",
+		Rate:      rate,
+		Threshold: threshold,
+		Samples:   samples,
+		Window:    d.config.Window.String(),
+		Timestamp: now.UTC(),
+	}
+	if err := d.send(ctx, alert); err != nil {
+		// Not debounced: the next check retries
+		d.metrics.failures.Inc()
+		d.logger.Error().Err(err).Str("alert", name).Msg("failed to send alert")
+		return
+	}
+
+	d.mu.Lock()
+	d.lastSent[name] = now
+	d.mu.Unlock()
+
+	d.metrics.sent.WithLabelValues(name).Inc()
+	d.logger.Warn().
+		Str("alert", name).
+		Float64("rate", rate).
+		Float64("threshold", threshold).
+		Uint64("samples", samples).
+		Msg("anomaly alert sent")
+}
+
+// send POSTs the alert; any non-2xx response is an error
+func (d *AnomalyDetector) send(ctx context.Context, alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.config.WebhookURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call alert webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// fakeStats is a StatsSource with settable counters
+type fakeStats struct {
+	mu    sync.Mutex
+	stats optimizer.Stats
+}
+
+func (f *fakeStats) Stats() optimizer.Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+func (f *fakeStats) add(delta optimizer.Stats) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats.Optimized += delta.Optimized
+	f.stats.Rejected += delta.Rejected
+	f.stats.BooksChecked += delta.BooksChecked
+	f.stats.NegativeMargin += delta.NegativeMargin
+}
+
+// alertRecorder is an httptest webhook target recording received alerts
+type alertRecorder struct {
+	mu     sync.Mutex
+	alerts []Alert
+	status int
+}
+
+func newAlertRecorder(t *testing.T) (*alertRecorder, *httptest.Server) {
+	recorder := &alertRecorder{status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		recorder.alerts = append(recorder.alerts, alert)
+		w.WriteHeader(recorder.status)
+	}))
+	t.Cleanup(server.Close)
+	return recorder, server
+}
+
+func (r *alertRecorder) received() []Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Alert(nil), r.alerts...)
+}
+
+// newTestDetector creates a detector posting to url over source
+func newTestDetector(url string, source StatsSource, reg prometheus.Registerer) *AnomalyDetector {
+	return NewAnomalyDetector(
+		AnomalyDetectorConfig{
+			WebhookURL:                  url,
+			Window:                      time.Minute,
+			Debounce:                    10 * time.Minute,
+			RejectionRateThreshold:      0.2,
+			NegativeMarginRateThreshold: 0.1,
+			MinSamples:                  10,
+			Registerer:                  reg,
+		},
+		[]StatsSource{source},
+		zerolog.Nop(),
+	)
+}
+
+// TestAnomalyDetector_RejectionRate tests that an alert fires above threshold and is debounced
+func TestAnomalyDetector_RejectionRate(t *testing.T) {
+	recorder, server := newAlertRecorder(t)
+	source := &fakeStats{}
+	reg := prometheus.NewRegistry()
+	detector := newTestDetector(server.URL, source, reg)
+
+	ctx := context.Background()
+	start := time.Now()
+	detector.check(ctx, start)
+
+	// 10% rejected: below threshold
+	source.add(optimizer.Stats{Optimized: 90, Rejected: 10})
+	detector.check(ctx, start.Add(10*time.Second))
+	assert.Empty(t, recorder.received())
+
+	// 60 of 200 rejected in the window (30%): alert
+	source.add(optimizer.Stats{Optimized: 50, Rejected: 50})
+	detector.check(ctx, start.Add(20*time.Second))
+
+	alerts := recorder.received()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertRejectionRate, alerts[0].Alert)
+	assert.Equal(t, "odds-optimizer-service", alerts[0].Service)
+	assert.InDelta(t, 0.3, alerts[0].Rate, 0.0001)
+	assert.Equal(t, 0.2, alerts[0].Threshold)
+	assert.Equal(t, uint64(200), alerts[0].Samples)
+	assert.Equal(t, "1m0s", alerts[0].Window)
+
+	// Still above threshold: debounced
+	source.add(optimizer.Stats{Optimized: 10, Rejected: 90})
+	detector.check(ctx, start.Add(30*time.Second))
+	detector.check(ctx, start.Add(40*time.Second))
+	assert.Len(t, recorder.received(), 1)
+
+	// After the debounce period the alert repeats
+	source.add(optimizer.Stats{Optimized: 10, Rejected: 90})
+	detector.check(ctx, start.Add(11*time.Minute))
+	assert.Len(t, recorder.received(), 2)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(detector.metrics.sent.WithLabelValues(AlertRejectionRate)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(detector.metrics.failures))
+}
+
+// TestAnomalyDetector_NegativeMarginRate tests alerting on negative-margin books
+func TestAnomalyDetector_NegativeMarginRate(t *testing.T) {
+	recorder, server := newAlertRecorder(t)
+	source := &fakeStats{}
+	detector := newTestDetector(server.URL, source, nil)
+
+	ctx := context.Background()
+	start := time.Now()
+	detector.check(ctx, start)
+
+	// 3 negative of 20 books (15%)
+	source.add(optimizer.Stats{Optimized: 60, BooksChecked: 20, NegativeMargin: 3})
+	detector.check(ctx, start.Add(10*time.Second))
+
+	alerts := recorder.received()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertNegativeMarginRate, alerts[0].Alert)
+	assert.InDelta(t, 0.15, alerts[0].Rate, 0.0001)
+	assert.Equal(t, uint64(20), alerts[0].Samples)
+}
+
+// TestAnomalyDetector_Window tests that rates only cover the rolling window
+// and that too few samples are not judged
+func TestAnomalyDetector_Window(t *testing.T) {
+	recorder, server := newAlertRecorder(t)
+	source := &fakeStats{}
+	detector := newTestDetector(server.URL, source, nil)
+
+	ctx := context.Background()
+	start := time.Now()
+	detector.check(ctx, start)
+
+	// Too few samples to judge, however high the rate
+	source.add(optimizer.Stats{Rejected: 5})
+	detector.check(ctx, start.Add(10*time.Second))
+	assert.Empty(t, recorder.received())
+
+	// A healthy minute pushes the early rejections out of the window
+	source.add(optimizer.Stats{Optimized: 100})
+	detector.check(ctx, start.Add(80*time.Second))
+	source.add(optimizer.Stats{Optimized: 100, Rejected: 10})
+	detector.check(ctx, start.Add(90*time.Second))
+	assert.Empty(t, recorder.received())
+}
+
+// TestAnomalyDetector_WebhookFailure tests that failed deliveries are retried on the next check
+func TestAnomalyDetector_WebhookFailure(t *testing.T) {
+	recorder, server := newAlertRecorder(t)
+	recorder.status = http.StatusInternalServerError
+	source := &fakeStats{}
+	detector := newTestDetector(server.URL, source, nil)
+
+	ctx := context.Background()
+	start := time.Now()
+	detector.check(ctx, start)
+
+	source.add(optimizer.Stats{Optimized: 50, Rejected: 50})
+	detector.check(ctx, start.Add(10*time.Second))
+	assert.Equal(t, 1.0, testutil.ToFloat64(detector.metrics.failures))
+
+	recorder.mu.Lock()
+	recorder.status = http.StatusOK
+	recorder.mu.Unlock()
+
+	detector.check(ctx, start.Add(20*time.Second))
+	assert.Len(t, recorder.received(), 2)
+	assert.Equal(t, 1.0, testutil.ToFloat64(detector.metrics.sent.WithLabelValues(AlertRejectionRate)))
+}
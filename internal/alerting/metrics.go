@@ -0,0 +1,32 @@
+package alerting
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// alertMetrics holds Prometheus metrics for the anomaly detector
+type alertMetrics struct {
+	sent     *prometheus.CounterVec
+	failures prometheus.Counter
+}
+
+// newAlertMetrics creates alert metrics and registers them with reg.
+// Metrics are still recorded but not exported when reg is nil.
+func newAlertMetrics(reg prometheus.Registerer) *alertMetrics {
+	m := &alertMetrics{
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "anomaly_alerts_sent_total",
+			Help: "Anomaly alerts delivered to the alert webhook, by alert.",
+		}, []string{"alert"}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "anomaly_alert_failures_total",
+			Help: "Anomaly alerts that could not be delivered to the alert webhook.",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.sent, m.failures)
+	}
+
+	return m
+}
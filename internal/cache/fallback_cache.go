@@ -0,0 +1,312 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+)
+
+// Cache backend names reported by the cache_backend gauge
+const (
+	backendPrimary  = "redis"
+	backendFallback = "memory"
+)
+
+// FallbackCache serves from a primary cache (Redis) and transparently
+// degrades to an in-memory cache when the primary fails. While degraded,
+// reads and writes go to memory and the primary is probed periodically;
+// once it answers, entries written during the outage are resynced to it
+// and traffic returns to the primary.
+type FallbackCache struct {
+	primary       service.Cache
+	fallback      *MemoryCache
+	probeInterval time.Duration
+	backend       *prometheus.GaugeVec
+	logger        zerolog.Logger
+
+	degraded atomic.Bool
+	resyncMu sync.Mutex   // Serializes recovery attempts
+	writeMu  sync.RWMutex // Held shared by writes; recovery holds it to resync and switch back
+}
+
+// FallbackCacheConfig holds fallback cache configuration
+type FallbackCacheConfig struct {
+	TTL           time.Duration         // TTL of in-memory entries while degraded
+	InPlayTTL     time.Duration         // TTL of in-memory in-play entries (0 uses TTL)
+	ProbeInterval time.Duration         // How often to probe the primary while degraded (default 5s)
+	Registerer    prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// NewFallbackCache wraps primary with an in-memory fallback
+func NewFallbackCache(primary service.Cache, config FallbackCacheConfig, logger zerolog.Logger) *FallbackCache {
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = 5 * time.Second
+	}
+
+	c := &FallbackCache{
+		primary:       primary,
+		fallback:      NewMemoryCache(config.TTL, logger),
+		probeInterval: config.ProbeInterval,
+		backend: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cache_backend",
+			Help: "1 for the cache backend currently serving traffic (redis or memory), 0 otherwise.",
+		}, []string{"backend"}),
+		logger: logger.With().Str("component", "fallback_cache").Logger(),
+	}
+	c.fallback.SetInPlayTTL(config.InPlayTTL)
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(c.backend)
+	}
+	c.setBackend(backendPrimary)
+
+	return c
+}
+
+// Degraded reports whether the cache is currently serving from memory
+func (c *FallbackCache) Degraded() bool {
+	return c.degraded.Load()
+}
+
+// Set caches optimized odds
+func (c *FallbackCache) Set(ctx context.Context, odds *models.OptimizedOdds) error {
+	c.writeMu.RLock()
+	defer c.writeMu.RUnlock()
+
+	if !c.degraded.Load() {
+		err := c.primary.Set(ctx, odds)
+		if err == nil || !c.failover(ctx, err) {
+			return err
+		}
+	}
+	return c.fallback.Set(ctx, odds)
+}
+
+// Get retrieves cached optimized odds
+func (c *FallbackCache) Get(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, error) {
+	if !c.degraded.Load() {
+		odds, err := c.primary.Get(ctx, eventID, market, selection)
+		if err == nil || !c.failover(ctx, err) {
+			return odds, err
+		}
+	}
+	return c.fallback.Get(ctx, eventID, market, selection)
+}
+
+// GetWithMeta retrieves cached optimized odds with their remaining TTL and age
+func (c *FallbackCache) GetWithMeta(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, models.CacheMeta, error) {
+	if !c.degraded.Load() {
+		odds, meta, err := c.primary.GetWithMeta(ctx, eventID, market, selection)
+		if err == nil || !c.failover(ctx, err) {
+			return odds, meta, err
+		}
+	}
+	return c.fallback.GetWithMeta(ctx, eventID, market, selection)
+}
+
+// Touch resets the TTL of cached odds without rewriting the value
+func (c *FallbackCache) Touch(ctx context.Context, eventID, market, selection string) error {
+	c.writeMu.RLock()
+	defer c.writeMu.RUnlock()
+
+	if !c.degraded.Load() {
+		err := c.primary.Touch(ctx, eventID, market, selection)
+		if err == nil || !c.failover(ctx, err) {
+			return err
+		}
+	}
+	return c.fallback.Touch(ctx, eventID, market, selection)
+}
+
+// Delete removes cached odds
+func (c *FallbackCache) Delete(ctx context.Context, eventID, market, selection string) error {
+	c.writeMu.RLock()
+	defer c.writeMu.RUnlock()
+
+	if !c.degraded.Load() {
+		err := c.primary.Delete(ctx, eventID, market, selection)
+		if err == nil || !c.failover(ctx, err) {
+			return err
+		}
+	}
+	return c.fallback.Delete(ctx, eventID, market, selection)
+}
+
+// SetBatch caches multiple optimized odds
+func (c *FallbackCache) SetBatch(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	c.writeMu.RLock()
+	defer c.writeMu.RUnlock()
+
+	if !c.degraded.Load() {
+		err := c.primary.SetBatch(ctx, oddsList)
+		if err == nil || !c.failover(ctx, err) {
+			return err
+		}
+	}
+	return c.fallback.SetBatch(ctx, oddsList)
+}
+
+// GetByEvent retrieves all cached odds for an event
+func (c *FallbackCache) GetByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
+	if !c.degraded.Load() {
+		oddsList, err := c.primary.GetByEvent(ctx, eventID)
+		if err == nil || !c.failover(ctx, err) {
+			return oddsList, err
+		}
+	}
+	return c.fallback.GetByEvent(ctx, eventID)
+}
+
+// GetByEvents retrieves all cached odds for several events
+func (c *FallbackCache) GetByEvents(ctx context.Context, eventIDs []string) (map[string][]*models.OptimizedOdds, error) {
+	if !c.degraded.Load() {
+		results, err := c.primary.GetByEvents(ctx, eventIDs)
+		if err == nil || !c.failover(ctx, err) {
+			return results, err
+		}
+	}
+	return c.fallback.GetByEvents(ctx, eventIDs)
+}
+
+// Scan walks the backend currently serving traffic. A primary failure is
+// returned rather than retried on the fallback, since fn may already have
+// seen part of the primary's entries.
+func (c *FallbackCache) Scan(ctx context.Context, eventID string, fn func(*models.OptimizedOdds) error) error {
+	if c.degraded.Load() {
+		return c.fallback.Scan(ctx, eventID, fn)
+	}
+	return c.primary.Scan(ctx, eventID, fn)
+}
+
+// Stats returns the combined counters of the primary and fallback caches
+func (c *FallbackCache) Stats() models.CacheStats {
+	primary := c.primary.Stats()
+	fallback := c.fallback.Stats()
+	return models.CacheStats{
+		Hits:   primary.Hits + fallback.Hits,
+		Misses: primary.Misses + fallback.Misses,
+		Errors: primary.Errors + fallback.Errors,
+		Sets:   primary.Sets + fallback.Sets,
+	}
+}
+
+// Ping succeeds while either backend can serve traffic
+func (c *FallbackCache) Ping(ctx context.Context) error {
+	if c.degraded.Load() {
+		return c.fallback.Ping(ctx)
+	}
+	return c.primary.Ping(ctx)
+}
+
+// Close closes the primary cache
+func (c *FallbackCache) Close() error {
+	return c.primary.Close()
+}
+
+// Start probes the primary every probe interval while degraded and
+// recovers once it answers, until ctx is done
+func (c *FallbackCache) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if c.degraded.Load() {
+				if err := c.recover(ctx); err != nil {
+					c.logger.Debug().Err(err).Msg("primary cache still unavailable")
+				}
+			}
+		}
+	}
+}
+
+// Degrade switches to the in-memory fallback, e.g. when the primary is
+// unreachable at startup
+func (c *FallbackCache) Degrade(reason error) {
+	c.degrade(reason)
+}
+
+// failover reports whether a primary failure should be retried on the
+// fallback, degrading the cache when it should. Only the primary being
+// unavailable degrades it: misses, malformed entries and the caller's own
+// cancellation or deadline are returned to the caller as they are.
+func (c *FallbackCache) failover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrNotFound) || malformed(err) {
+		return false
+	}
+	c.degrade(err)
+	return true
+}
+
+// malformed reports whether err is an entry failing to encode or decode
+func malformed(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var unsupportedErr *json.UnsupportedValueError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.As(err, &unsupportedErr)
+}
+
+// degrade switches traffic to the in-memory fallback
+func (c *FallbackCache) degrade(reason error) {
+	if c.degraded.Swap(true) {
+		return
+	}
+	c.setBackend(backendFallback)
+	c.logger.Error().
+		Err(reason).
+		Msg("primary cache failed, falling back to in-memory cache")
+}
+
+// recover resyncs entries written during the outage to the primary and
+// switches traffic back to it. Writes wait for the resync, so none lands in
+// memory after the snapshot and is lost when the fallback is cleared.
+func (c *FallbackCache) recover(ctx context.Context) error {
+	c.resyncMu.Lock()
+	defer c.resyncMu.Unlock()
+
+	if err := c.primary.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping primary cache: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	pending := c.fallback.Snapshot()
+	if err := c.primary.SetBatch(ctx, pending); err != nil {
+		return fmt.Errorf("failed to resync primary cache: %w", err)
+	}
+
+	c.degraded.Store(false)
+	c.fallback.Clear()
+	c.setBackend(backendPrimary)
+
+	c.logger.Info().
+		Int("resynced", len(pending)).
+		Msg("primary cache recovered")
+
+	return nil
+}
+
+// setBackend marks backend as the one serving traffic
+func (c *FallbackCache) setBackend(active string) {
+	for _, backend := range []string{backendPrimary, backendFallback} {
+		value := 0.0
+		if backend == active {
+			value = 1
+		}
+		c.backend.WithLabelValues(backend).Set(value)
+	}
+}
@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// setupTestFallbackCache wraps a miniredis-backed RedisCache with a fallback
+func setupTestFallbackCache(t *testing.T, probeInterval time.Duration) (*FallbackCache, *RedisCache, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	redisCache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute}, zerolog.Nop())
+	t.Cleanup(func() { redisCache.Close() })
+
+	c := NewFallbackCache(redisCache, FallbackCacheConfig{
+		TTL:           time.Minute,
+		ProbeInterval: probeInterval,
+		Registerer:    prometheus.NewRegistry(),
+	}, zerolog.Nop())
+
+	return c, redisCache, mr
+}
+
+// assertBackend checks the cache_backend gauge
+func assertBackend(t *testing.T, c *FallbackCache, active string) {
+	t.Helper()
+	for _, backend := range []string{backendPrimary, backendFallback} {
+		expected := 0.0
+		if backend == active {
+			expected = 1
+		}
+		assert.Equal(t, expected, testutil.ToFloat64(c.backend.WithLabelValues(backend)), backend)
+	}
+}
+
+// TestFallbackCache_Healthy tests that a healthy primary serves traffic
+func TestFallbackCache_Healthy(t *testing.T) {
+	c, redisCache, _ := setupTestFallbackCache(t, time.Hour)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, newCachedOdds("Team A", 2.50)))
+
+	_, err := redisCache.Get(ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+
+	// A miss is not a failure
+	_, err = c.Get(ctx, "event-123", "match_winner", "Team B")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.False(t, c.Degraded())
+	assertBackend(t, c, backendPrimary)
+}
+
+// TestFallbackCache_RedisDown tests falling back to and serving from memory
+func TestFallbackCache_RedisDown(t *testing.T) {
+	c, _, mr := setupTestFallbackCache(t, time.Hour)
+	ctx := context.Background()
+
+	mr.SetError("connection refused")
+
+	// Writes succeed against memory
+	require.NoError(t, c.SetBatch(ctx, []*models.OptimizedOdds{
+		newCachedOdds("Team A", 2.50),
+		newCachedOdds("Team B", 3.20),
+	}))
+	assert.True(t, c.Degraded())
+	assertBackend(t, c, backendFallback)
+
+	// Reads are served from memory
+	odds, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.Equal(t, "Team A", odds.Selection)
+
+	oddsList, err := c.GetByEvent(ctx, "event-123")
+	require.NoError(t, err)
+	assert.Len(t, oddsList, 2)
+
+	assert.NoError(t, c.Touch(ctx, "event-123", "match_winner", "Team A"))
+	assert.ErrorIs(t, c.Touch(ctx, "event-123", "match_winner", "Team C"), ErrNotFound)
+
+	assert.NoError(t, c.Ping(ctx))
+}
+
+// TestFallbackCache_ReadFailureDegrades tests that a failing read also falls back
+func TestFallbackCache_ReadFailureDegrades(t *testing.T) {
+	c, _, mr := setupTestFallbackCache(t, time.Hour)
+	ctx := context.Background()
+
+	mr.SetError("connection refused")
+
+	_, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.True(t, c.Degraded())
+}
+
+// TestFallbackCache_CancelledRequestKeepsPrimary tests that a request
+// cancelled by its caller does not degrade the cache
+func TestFallbackCache_CancelledRequestKeepsPrimary(t *testing.T) {
+	c, _, _ := setupTestFallbackCache(t, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	assert.Error(t, err)
+	assert.Error(t, c.Set(ctx, newCachedOdds("Team A", 2.50)))
+	assert.False(t, c.Degraded())
+	assertBackend(t, c, backendPrimary)
+}
+
+// TestFallbackCache_MalformedEntryKeepsPrimary tests that an entry failing
+// to decode is returned as an error rather than degrading the cache
+func TestFallbackCache_MalformedEntryKeepsPrimary(t *testing.T) {
+	c, _, mr := setupTestFallbackCache(t, time.Hour)
+	ctx := context.Background()
+
+	require.NoError(t, mr.Set(oddsKey("event-123", "match_winner", "Team A"), "not json"))
+
+	_, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.False(t, c.Degraded())
+}
+
+// TestFallbackCache_Recovery tests resyncing to Redis once it recovers
+func TestFallbackCache_Recovery(t *testing.T) {
+	c, redisCache, mr := setupTestFallbackCache(t, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mr.SetError("connection refused")
+	require.NoError(t, c.Set(ctx, newCachedOdds("Team A", 2.50)))
+	require.True(t, c.Degraded())
+
+	go c.Start(ctx)
+
+	// Still down: the probe keeps serving from memory
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, c.Degraded())
+
+	mr.SetError("")
+	require.Eventually(t, func() bool { return !c.Degraded() }, time.Second, 5*time.Millisecond)
+	assertBackend(t, c, backendPrimary)
+
+	// Entries written during the outage were resynced to Redis
+	odds, err := redisCache.Get(ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.Equal(t, "Team A", odds.Selection)
+	assert.Zero(t, c.fallback.Len())
+}
+
+// resyncGatedCache pauses the resync to the primary until resume is closed
+type resyncGatedCache struct {
+	*RedisCache
+	resyncing chan struct{}
+	resume    chan struct{}
+}
+
+func (c *resyncGatedCache) SetBatch(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	close(c.resyncing)
+	<-c.resume
+	return c.RedisCache.SetBatch(ctx, oddsList)
+}
+
+// TestFallbackCache_RecoveryKeepsRacingWrites tests that a write racing the
+// resync is not lost with the fallback contents
+func TestFallbackCache_RecoveryKeepsRacingWrites(t *testing.T) {
+	_, redisCache, mr := setupTestFallbackCache(t, time.Hour)
+	primary := &resyncGatedCache{RedisCache: redisCache, resyncing: make(chan struct{}), resume: make(chan struct{})}
+	c := NewFallbackCache(primary, FallbackCacheConfig{TTL: time.Minute, ProbeInterval: time.Hour}, zerolog.Nop())
+	ctx := context.Background()
+
+	mr.SetError("connection refused")
+	require.NoError(t, c.Set(ctx, newCachedOdds("Team A", 2.50)))
+	require.True(t, c.Degraded())
+	mr.SetError("")
+
+	recovered := make(chan error, 1)
+	go func() { recovered <- c.recover(ctx) }()
+	<-primary.resyncing
+
+	written := make(chan error, 1)
+	go func() { written <- c.Set(ctx, newCachedOdds("Team B", 1.80)) }()
+	time.Sleep(20 * time.Millisecond)
+	close(primary.resume)
+
+	require.NoError(t, <-recovered)
+	require.NoError(t, <-written)
+	assert.False(t, c.Degraded())
+
+	for _, selection := range []string{"Team A", "Team B"} {
+		_, err := redisCache.Get(ctx, "event-123", "match_winner", selection)
+		assert.NoError(t, err, selection)
+	}
+}
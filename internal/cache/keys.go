@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+)
+
+// keyHashLen is the number of hex digits of a component's SHA-256 appended
+// when it is truncated
+const keyHashLen = 16
+
+// minKeyComponentLen is the smallest component limit honored: room for the
+// separator and hash of a truncated component
+const minKeyComponentLen = keyHashLen + 1
+
+// keyBuilder builds Redis keys, truncating components longer than
+// maxComponentLen so a malformed feed string (e.g. a kilobytes-long outright
+// selection) cannot produce an oversized key. A truncated component keeps its
+// leading bytes followed by "~" and a hash of the whole component, so distinct
+// components still map to distinct keys; the untruncated values live in the
+// stored payload.
+type keyBuilder struct {
+	maxComponentLen int       // 0 disables truncation
+	warned          *sync.Map // Components whose truncation was logged at warn level
+	logger          zerolog.Logger
+}
+
+// newKeyBuilder creates a key builder; limits below minKeyComponentLen are raised to it
+func newKeyBuilder(maxComponentLen int, logger zerolog.Logger) keyBuilder {
+	if maxComponentLen > 0 && maxComponentLen < minKeyComponentLen {
+		maxComponentLen = minKeyComponentLen
+	}
+	return keyBuilder{maxComponentLen: maxComponentLen, warned: &sync.Map{}, logger: logger}
+}
+
+// odds builds the cache key of a selection: odds:{event_id}:{market}:{selection}
+func (b keyBuilder) odds(eventID, market, selection string) string {
+	return oddsKey(
+		b.component("event_id", eventID),
+		b.component("market", market),
+		b.component("selection", selection),
+	)
+}
+
+// eventPattern builds the SCAN pattern matching every cached selection of an event
+func (b keyBuilder) eventPattern(eventID string) string {
+	return fmt.Sprintf("odds:%s:*", b.component("event_id", eventID))
+}
+
+// history builds the history stream key of a selection: history:{event_id}:{market}:{selection}
+func (b keyBuilder) history(eventID, market, selection string) string {
+	return historyKey(
+		b.component("event_id", eventID),
+		b.component("market", market),
+		b.component("selection", selection),
+	)
+}
+
+// marketHistoryPattern builds the SCAN pattern matching every history stream of a market
+func (b keyBuilder) marketHistoryPattern(eventID, market string) string {
+	return historyKey(b.component("event_id", eventID), b.component("market", market), "*")
+}
+
+// closing builds the closing line key of a selection: closing:{event_id}:{market}:{selection}
+func (b keyBuilder) closing(eventID, market, selection string) string {
+	return closingKey(
+		b.component("event_id", eventID),
+		b.component("market", market),
+		b.component("selection", selection),
+	)
+}
+
+// closingPattern builds the SCAN pattern matching every closing line of an event
+func (b keyBuilder) closingPattern(eventID string) string {
+	return fmt.Sprintf("closing:%s:*", b.component("event_id", eventID))
+}
+
+// component returns value, truncated with a hash suffix when it exceeds the
+// limit. The first truncation of each component is logged as a warning and
+// later ones at debug level, so a feed repeating an oversized value cannot
+// flood the log.
+func (b keyBuilder) component(name, value string) string {
+	if b.maxComponentLen <= 0 || len(value) <= b.maxComponentLen {
+		return value
+	}
+
+	// Cut on a rune boundary so the key stays valid UTF-8
+	cut := b.maxComponentLen - minKeyComponentLen
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	sum := sha256.Sum256([]byte(value))
+	truncated := value[:cut] + "~" + hex.EncodeToString(sum[:])[:keyHashLen]
+
+	event := b.logger.Debug()
+	if _, warned := b.warned.LoadOrStore(name, true); !warned {
+		event = b.logger.Warn()
+	}
+	event.
+		Str("key_component", name).
+		Int("length", len(value)).
+		Int("max_length", b.maxComponentLen).
+		Str("truncated", truncated).
+		Msg("truncated oversized cache key component")
+
+	return truncated
+}
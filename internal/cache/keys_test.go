@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// TestRedisCache_OversizedKeyComponents tests that oversized selections are
+// truncated in keys, stay distinct, and round-trip via the stored payload
+func TestRedisCache_OversizedKeyComponents(t *testing.T) {
+	const maxLen = 64
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), MaxKeyComponentLen: maxLen}, zerolog.Nop())
+	defer cache.Close()
+	ctx := context.Background()
+
+	// Two kilobytes-long selections sharing a long prefix
+	long := strings.Repeat("Any Other Player ", 300)
+	selections := []string{long + "A", long + "B", "Team A"}
+	for _, selection := range selections {
+		require.NoError(t, cache.Set(ctx, &models.OptimizedOdds{
+			EventID:   "event-123",
+			Market:    "outright",
+			Selection: selection,
+		}))
+	}
+
+	keys := mr.Keys()
+	require.Len(t, keys, len(selections))
+	for _, key := range keys {
+		assert.LessOrEqual(t, len(key), len("odds:event-123:outright:")+maxLen, key)
+	}
+	assert.Contains(t, keys, "odds:event-123:outright:Team A")
+
+	for _, selection := range selections {
+		odds, err := cache.Get(ctx, "event-123", "outright", selection)
+		require.NoError(t, err)
+		assert.Equal(t, selection, odds.Selection)
+	}
+
+	byEvent, err := cache.GetByEvent(ctx, "event-123")
+	require.NoError(t, err)
+	assert.Len(t, byEvent, len(selections))
+}
+
+// TestKeyBuilder_Component tests truncation boundaries
+func TestKeyBuilder_Component(t *testing.T) {
+	builder := newKeyBuilder(20, zerolog.Nop())
+
+	assert.Equal(t, strings.Repeat("a", 20), builder.component("selection", strings.Repeat("a", 20)))
+
+	truncated := builder.component("selection", strings.Repeat("a", 21))
+	assert.Len(t, truncated, 20)
+	assert.True(t, strings.HasPrefix(truncated, "aaa~"), truncated)
+
+	// Multi-byte runes are never split
+	truncated = builder.component("selection", strings.Repeat("é", 30))
+	assert.True(t, strings.HasPrefix(truncated, "é~"), truncated)
+
+	// Limits too small for the hash are raised
+	assert.Len(t, newKeyBuilder(5, zerolog.Nop()).component("selection", strings.Repeat("a", 40)), minKeyComponentLen)
+
+	// Zero disables truncation
+	long := strings.Repeat("a", 1000)
+	assert.Equal(t, long, newKeyBuilder(0, zerolog.Nop()).component("selection", long))
+}
+
+// TestKeyBuilder_WarnsOncePerComponent tests that repeated truncations of a
+// component log a single warning
+func TestKeyBuilder_WarnsOncePerComponent(t *testing.T) {
+	var buf bytes.Buffer
+	builder := newKeyBuilder(20, zerolog.New(&buf).Level(zerolog.WarnLevel))
+
+	for i := 0; i < 3; i++ {
+		builder.odds(strings.Repeat("e", 30), "match_winner", strings.Repeat("s", 30))
+	}
+
+	assert.Equal(t, 2, strings.Count(buf.String(), "truncated oversized cache key component"), buf.String())
+	assert.Equal(t, 1, strings.Count(buf.String(), `"key_component":"selection"`))
+	assert.Equal(t, 1, strings.Count(buf.String(), `"key_component":"event_id"`))
+}
+
+// TestHistoryAndClosingLines_OversizedKeyComponents tests that history and
+// closing line keys truncate oversized selections like cache keys
+func TestHistoryAndClosingLines_OversizedKeyComponents(t *testing.T) {
+	const maxLen = 64
+	mr := miniredis.RunT(t)
+	history := NewRedisHistory(RedisHistoryConfig{Addr: mr.Addr(), MaxKeyComponentLen: maxLen}, zerolog.Nop())
+	defer history.Close()
+	closingLines := NewRedisClosingLines(RedisClosingLinesConfig{Addr: mr.Addr(), MaxKeyComponentLen: maxLen, TTL: time.Hour}, zerolog.Nop())
+	defer closingLines.Close()
+	ctx := context.Background()
+
+	long := strings.Repeat("Any Other Player ", 300)
+	odds := &models.OptimizedOdds{EventID: "event-123", Market: "outright", Selection: long}
+	require.NoError(t, history.Append(ctx, []*models.OptimizedOdds{odds}))
+	require.NoError(t, closingLines.Capture(ctx, []*models.OptimizedOdds{odds}))
+
+	keys := mr.Keys()
+	require.Len(t, keys, 2)
+	for _, key := range keys {
+		assert.LessOrEqual(t, len(key), len("history:event-123:outright:")+maxLen, key)
+	}
+
+	recent, err := history.Recent(ctx, "event-123", "outright", long, 1)
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, long, recent[0].Selection)
+
+	captured, err := closingLines.GetByEvent(ctx, "event-123")
+	require.NoError(t, err)
+	require.Len(t, captured, 1)
+	assert.Equal(t, long, captured[0].Selection)
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// MemoryCache caches optimized odds in process memory. It is used as a
+// fallback while Redis is unavailable and shares RedisCache's key format.
+type MemoryCache struct {
+	ttl       time.Duration
+	inPlayTTL time.Duration
+	logger    zerolog.Logger
+
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	sets   atomic.Uint64
+}
+
+// memoryEntry is a cached value with its expiry (zero means no expiry)
+type memoryEntry struct {
+	odds      models.OptimizedOdds
+	expiresAt time.Time
+}
+
+// expired reports whether the entry has passed its expiry at now
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// NewMemoryCache creates a new in-memory cache; a zero ttl never expires entries
+func NewMemoryCache(ttl time.Duration, logger zerolog.Logger) *MemoryCache {
+	return &MemoryCache{
+		ttl:     ttl,
+		entries: make(map[string]memoryEntry),
+		logger:  logger.With().Str("component", "memory_cache").Logger(),
+	}
+}
+
+// SetInPlayTTL sets a shorter TTL for in-play odds (0 uses the cache's TTL)
+func (c *MemoryCache) SetInPlayTTL(ttl time.Duration) {
+	c.inPlayTTL = ttl
+}
+
+// expiryFor returns when odds cached at now expire (zero means never)
+func (c *MemoryCache) expiryFor(odds *models.OptimizedOdds, now time.Time) time.Time {
+	ttl := c.ttl
+	if odds.InPlay && c.inPlayTTL > 0 {
+		ttl = c.inPlayTTL
+	}
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
+
+// Set caches optimized odds
+func (c *MemoryCache) Set(ctx context.Context, odds *models.OptimizedOdds) error {
+	return c.SetBatch(ctx, []*models.OptimizedOdds{odds})
+}
+
+// Get retrieves cached optimized odds
+func (c *MemoryCache) Get(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, error) {
+	key := oddsKey(eventID, market, selection)
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || entry.expired(time.Now()) {
+		c.misses.Add(1)
+		return nil, ErrNotFound
+	}
+
+	c.hits.Add(1)
+	odds := entry.odds
+	return &odds, nil
+}
+
+// GetWithMeta retrieves cached optimized odds with their remaining TTL and age
+func (c *MemoryCache) GetWithMeta(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, models.CacheMeta, error) {
+	key := oddsKey(eventID, market, selection)
+	now := time.Now()
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || entry.expired(now) {
+		c.misses.Add(1)
+		return nil, models.CacheMeta{}, ErrNotFound
+	}
+
+	c.hits.Add(1)
+	odds := entry.odds
+	meta := models.CacheMeta{Age: now.Sub(odds.OptimizedAt)}
+	if !entry.expiresAt.IsZero() {
+		meta.TTLRemaining = entry.expiresAt.Sub(now)
+	}
+	return &odds, meta, nil
+}
+
+// Touch resets the expiry of cached odds without rewriting the value
+func (c *MemoryCache) Touch(ctx context.Context, eventID, market, selection string) error {
+	key := oddsKey(eventID, market, selection)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || entry.expired(now) {
+		return ErrNotFound
+	}
+	if expiresAt := c.expiryFor(&entry.odds, now); !expiresAt.IsZero() {
+		entry.expiresAt = expiresAt
+		c.entries[key] = entry
+	}
+
+	return nil
+}
+
+// Delete removes cached odds; deleting odds that are not cached is not an error
+func (c *MemoryCache) Delete(ctx context.Context, eventID, market, selection string) error {
+	key := oddsKey(eventID, market, selection)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+
+	return nil
+}
+
+// SetBatch caches multiple optimized odds
+func (c *MemoryCache) SetBatch(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	if len(oddsList) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	for _, odds := range oddsList {
+		c.entries[oddsKey(odds.EventID, odds.Market, odds.Selection)] = memoryEntry{
+			odds:      *odds,
+			expiresAt: c.expiryFor(odds, now),
+		}
+	}
+	c.mu.Unlock()
+	c.sets.Add(uint64(len(oddsList)))
+
+	return nil
+}
+
+// GetByEvent retrieves all cached odds for an event
+func (c *MemoryCache) GetByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
+	prefix := fmt.Sprintf("odds:%s:", eventID)
+	now := time.Now()
+
+	c.mu.RLock()
+	var oddsList []*models.OptimizedOdds
+	for key, entry := range c.entries {
+		if strings.HasPrefix(key, prefix) && !entry.expired(now) {
+			odds := entry.odds
+			oddsList = append(oddsList, &odds)
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(oddsList) > 0 {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+
+	return oddsList, nil
+}
+
+// GetByEvents retrieves all cached odds for several events; events with no
+// cached odds map to an empty slice
+func (c *MemoryCache) GetByEvents(ctx context.Context, eventIDs []string) (map[string][]*models.OptimizedOdds, error) {
+	results := make(map[string][]*models.OptimizedOdds, len(eventIDs))
+	for _, eventID := range eventIDs {
+		if _, seen := results[eventID]; seen {
+			continue
+		}
+		oddsList, _ := c.GetByEvent(ctx, eventID)
+		if oddsList == nil {
+			oddsList = []*models.OptimizedOdds{}
+		}
+		results[eventID] = oddsList
+	}
+	return results, nil
+}
+
+// Scan calls fn for every unexpired entry, or only an event's when eventID
+// is set, stopping at the first error fn returns
+func (c *MemoryCache) Scan(ctx context.Context, eventID string, fn func(*models.OptimizedOdds) error) error {
+	var oddsList []*models.OptimizedOdds
+	if eventID != "" {
+		oddsList, _ = c.GetByEvent(ctx, eventID)
+	} else {
+		oddsList = c.Snapshot()
+	}
+
+	for _, odds := range oddsList {
+		if err := fn(odds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot returns all unexpired entries
+func (c *MemoryCache) Snapshot() []*models.OptimizedOdds {
+	now := time.Now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	oddsList := make([]*models.OptimizedOdds, 0, len(c.entries))
+	for _, entry := range c.entries {
+		if !entry.expired(now) {
+			odds := entry.odds
+			oddsList = append(oddsList, &odds)
+		}
+	}
+	return oddsList
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *MemoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Clear removes all entries
+func (c *MemoryCache) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]memoryEntry)
+	c.mu.Unlock()
+}
+
+// Stats returns a snapshot of the cache's operational counters
+func (c *MemoryCache) Stats() models.CacheStats {
+	return models.CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Sets:   c.sets.Load(),
+	}
+}
+
+// Ping always succeeds for the in-memory cache
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close is a no-op for the in-memory cache
+func (c *MemoryCache) Close() error {
+	return nil
+}
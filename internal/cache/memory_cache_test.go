@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// newCachedOdds creates optimized odds for a selection of event-123
+func newCachedOdds(selection string, backPrice float64) *models.OptimizedOdds {
+	return &models.OptimizedOdds{
+		EventID:       "event-123",
+		Market:        "match_winner",
+		Selection:     selection,
+		OptimizedBack: decimal.NewFromFloat(backPrice),
+	}
+}
+
+// TestMemoryCache_SetAndGet tests round-tripping odds through memory
+func TestMemoryCache_SetAndGet(t *testing.T) {
+	c := NewMemoryCache(time.Minute, zerolog.Nop())
+	ctx := context.Background()
+
+	_, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	odds := newCachedOdds("Team A", 2.50)
+	require.NoError(t, c.Set(ctx, odds))
+
+	// Mutating the original does not affect the cached copy
+	odds.OptimizedBack = decimal.NewFromFloat(9.99)
+
+	cached, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(2.50).Equal(cached.OptimizedBack))
+
+	assert.Equal(t, models.CacheStats{Hits: 1, Misses: 1, Sets: 1}, c.Stats())
+}
+
+// TestMemoryCache_GetByEvent tests event lookups and expiry
+func TestMemoryCache_GetByEvent(t *testing.T) {
+	c := NewMemoryCache(20*time.Millisecond, zerolog.Nop())
+	ctx := context.Background()
+
+	other := newCachedOdds("Team A", 2.00)
+	other.EventID = "event-1234"
+	require.NoError(t, c.SetBatch(ctx, []*models.OptimizedOdds{
+		newCachedOdds("Team A", 2.50),
+		newCachedOdds("Team B", 3.20),
+		other,
+	}))
+
+	oddsList, err := c.GetByEvent(ctx, "event-123")
+	require.NoError(t, err)
+	assert.Len(t, oddsList, 2)
+
+	// Entries expire after the TTL
+	time.Sleep(30 * time.Millisecond)
+	oddsList, err = c.GetByEvent(ctx, "event-123")
+	require.NoError(t, err)
+	assert.Empty(t, oddsList)
+	assert.Empty(t, c.Snapshot())
+}
+
+// TestMemoryCache_InPlayTTL tests that in-play entries expire on the short TTL
+func TestMemoryCache_InPlayTTL(t *testing.T) {
+	c := NewMemoryCache(time.Minute, zerolog.Nop())
+	c.SetInPlayTTL(20 * time.Millisecond)
+	ctx := context.Background()
+
+	inPlay := newCachedOdds("Team A", 2.50)
+	inPlay.InPlay = true
+	require.NoError(t, c.SetBatch(ctx, []*models.OptimizedOdds{inPlay, newCachedOdds("Team B", 1.80)}))
+
+	time.Sleep(30 * time.Millisecond)
+	_, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.Get(ctx, "event-123", "match_winner", "Team B")
+	assert.NoError(t, err)
+}
+
+// TestMemoryCache_Touch tests extending and missing entries
+func TestMemoryCache_Touch(t *testing.T) {
+	c := NewMemoryCache(40*time.Millisecond, zerolog.Nop())
+	ctx := context.Background()
+
+	assert.ErrorIs(t, c.Touch(ctx, "event-123", "match_winner", "Team A"), ErrNotFound)
+
+	require.NoError(t, c.Set(ctx, newCachedOdds("Team A", 2.50)))
+	time.Sleep(25 * time.Millisecond)
+	require.NoError(t, c.Touch(ctx, "event-123", "match_winner", "Team A"))
+
+	// Past the original expiry, but within the touched one
+	time.Sleep(25 * time.Millisecond)
+	_, err := c.Get(ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.ErrorIs(t, c.Touch(ctx, "event-123", "match_winner", "Team A"), ErrNotFound)
+}
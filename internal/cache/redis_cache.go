@@ -3,7 +3,10 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,116 +15,449 @@ import (
 	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
 )
 
+var (
+	// ErrNotFound is returned by Get and Touch when no odds are cached for the key
+	ErrNotFound = errors.New("odds not found in cache")
+
+	// ErrTimeout is returned when a Redis operation exceeds the configured op timeout
+	ErrTimeout = errors.New("redis operation timed out")
+)
+
+// oddsKey builds the cache key: odds:{event_id}:{market}:{selection}
+func oddsKey(eventID, market, selection string) string {
+	return fmt.Sprintf("odds:%s:%s:%s", eventID, market, selection)
+}
+
 // RedisCache caches optimized odds in Redis
 type RedisCache struct {
-	client *redis.Client
-	ttl    time.Duration
-	logger zerolog.Logger
+	client     redis.UniversalClient
+	cluster    bool
+	scanNodes  func(ctx context.Context) ([]redis.Cmdable, error) // Servers SCANs walk: nodes, or a fake in tests
+	ttl        time.Duration
+	inPlayTTL  time.Duration
+	opTimeout  time.Duration
+	chunk      int
+	staleGrace time.Duration
+	trackFresh bool
+	keys       keyBuilder
+	logger     zerolog.Logger
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+	sets   atomic.Uint64
 }
 
 // RedisCacheConfig holds Redis cache configuration
 type RedisCacheConfig struct {
-	Addr     string        // e.g., "localhost:6379"
+	Addr     string // e.g., "localhost:6379"
 	Password string
 	DB       int
-	TTL      time.Duration // e.g., 15 * time.Minute
+	TTL      time.Duration // Pre-match TTL, e.g., 15 * time.Minute
+
+	Cluster      bool     // Connect to a Redis Cluster, following MOVED/ASK redirects; DB must be 0
+	ClusterAddrs []string // Seed nodes of the cluster (empty uses Addr)
+
+	InPlayTTL time.Duration // TTL of in-play odds, which go stale in seconds (0 uses TTL)
+
+	OpTimeout time.Duration // Per-operation deadline (0 uses only the caller's context)
+
+	MaxKeyComponentLen int // Longer event IDs, markets and selections are truncated with a hash in keys (0 disables)
+
+	PipelineChunk int // Most writes SetBatch sends in one pipeline; larger batches use several in turn (0 sends one pipeline)
+
+	StaleGrace time.Duration // How long past its TTL odds are still served, flagged Stale (0 disables)
+
+	TrackFreshness bool // Record the newest OptimizedAt written in a shared watermark (see LastOptimizedAt)
 }
 
 // NewRedisCache creates a new Redis cache
 func NewRedisCache(config RedisCacheConfig, logger zerolog.Logger) *RedisCache {
-	client := redis.NewClient(&redis.Options{
-		Addr:     config.Addr,
-		Password: config.Password,
-		DB:       config.DB,
+	client := newRedisClient(redisClientOptions{
+		Addr:         config.Addr,
+		Password:     config.Password,
+		DB:           config.DB,
+		Cluster:      config.Cluster,
+		ClusterAddrs: config.ClusterAddrs,
 	})
 
-	return &RedisCache{
-		client: client,
-		ttl:    config.TTL,
-		logger: logger.With().Str("component", "redis_cache").Logger(),
+	logger = logger.With().Str("component", "redis_cache").Logger()
+
+	c := &RedisCache{
+		client:     client,
+		cluster:    config.Cluster,
+		ttl:        config.TTL,
+		inPlayTTL:  config.InPlayTTL,
+		opTimeout:  config.OpTimeout,
+		chunk:      config.PipelineChunk,
+		staleGrace: config.StaleGrace,
+		trackFresh: config.TrackFreshness,
+		keys:       newKeyBuilder(config.MaxKeyComponentLen, logger),
+		logger:     logger,
 	}
+	c.scanNodes = c.nodes
+	return c
+}
+
+// nodes returns the servers to SCAN: every master of a cluster, or the
+// single server
+func (c *RedisCache) nodes(ctx context.Context) ([]redis.Cmdable, error) {
+	return masterNodes(ctx, c.client)
+}
+
+// getValues reads the values of keys scanned on node, nil for keys expired
+// since the scan. Cluster keys on one node may still span hash slots, which
+// MGET rejects, so they are read with pipelined GETs instead.
+func (c *RedisCache) getValues(ctx context.Context, node redis.Cmdable, keys []string) ([]interface{}, error) {
+	if !c.cluster {
+		return node.MGet(ctx, keys...).Result()
+	}
+
+	pipe := node.Pipeline()
+	gets := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		gets[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, get := range gets {
+		if value, err := get.Result(); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+// withOpTimeout derives the context for a single Redis operation
+func (c *RedisCache) withOpTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.opTimeout)
+}
+
+// ttlFor returns the TTL to cache odds with: the in-play TTL for in-play
+// odds when configured, else the pre-match TTL
+func (c *RedisCache) ttlFor(odds *models.OptimizedOdds) time.Duration {
+	if odds.InPlay && c.inPlayTTL > 0 {
+		return c.inPlayTTL
+	}
+	return c.ttl
+}
+
+// expiryFor returns how long Redis keeps odds: their TTL plus the stale
+// grace window, or no expiry when they have no TTL
+func (c *RedisCache) expiryFor(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return ttl
+	}
+	return ttl + c.staleGrace
+}
+
+// markStale flags odds whose key has at most the stale grace window left,
+// i.e. whose TTL has passed, and returns the TTL remaining before they go
+// stale
+func (c *RedisCache) markStale(odds *models.OptimizedOdds, remaining time.Duration) time.Duration {
+	if c.staleGrace <= 0 || remaining <= 0 {
+		return remaining
+	}
+	if remaining <= c.staleGrace {
+		odds.Stale = true
+		return 0
+	}
+	return remaining - c.staleGrace
+}
+
+// wrapErr converts failures caused by the op timeout into ErrTimeout
+func (c *RedisCache) wrapErr(opCtx context.Context, err error, format string) error {
+	if c.opTimeout > 0 && errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %v", ErrTimeout, c.opTimeout, err)
+	}
+	return fmt.Errorf(format, err)
 }
 
 // Set caches optimized odds
 func (c *RedisCache) Set(ctx context.Context, odds *models.OptimizedOdds) error {
 	// Create Redis key: odds:{event_id}:{market}:{selection}
-	key := fmt.Sprintf("odds:%s:%s:%s", odds.EventID, odds.Market, odds.Selection)
+	key := c.keys.odds(odds.EventID, odds.Market, odds.Selection)
 
 	// Serialize to JSON
 	data, err := json.Marshal(odds)
 	if err != nil {
+		c.errors.Add(1)
 		return fmt.Errorf("failed to marshal odds: %w", err)
 	}
 
 	// Set in Redis with TTL
-	if err := c.client.Set(ctx, key, data, c.ttl).Err(); err != nil {
-		return fmt.Errorf("failed to set in Redis: %w", err)
+	ttl := c.ttlFor(odds)
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+	if c.trackFresh {
+		_, err = c.client.Pipelined(opCtx, func(pipe redis.Pipeliner) error {
+			pipe.Set(opCtx, key, data, c.expiryFor(ttl))
+			c.trackFreshness(opCtx, pipe, []*models.OptimizedOdds{odds})
+			return nil
+		})
+	} else {
+		err = c.client.Set(opCtx, key, data, c.expiryFor(ttl)).Err()
+	}
+	if err != nil {
+		c.errors.Add(1)
+		return c.wrapErr(opCtx, err, "failed to set in Redis: %w")
 	}
+	c.sets.Add(1)
 
 	c.logger.Debug().
 		Str("key", key).
-		Dur("ttl", c.ttl).
+		Dur("ttl", ttl).
 		Msg("cached optimized odds")
 
 	return nil
 }
 
-// Get retrieves cached optimized odds
+// Get retrieves cached optimized odds. With a stale grace window, odds past
+// their TTL but within the window are returned flagged Stale.
 func (c *RedisCache) Get(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, error) {
-	key := fmt.Sprintf("odds:%s:%s:%s", eventID, market, selection)
+	if c.staleGrace > 0 {
+		// Staleness needs the key's TTL
+		odds, _, err := c.GetWithMeta(ctx, eventID, market, selection)
+		return odds, err
+	}
+
+	key := c.keys.odds(eventID, market, selection)
 
 	// Get from Redis
-	data, err := c.client.Get(ctx, key).Bytes()
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+	data, err := c.client.Get(opCtx, key).Bytes()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("odds not found in cache")
+		c.misses.Add(1)
+		return nil, ErrNotFound
 	} else if err != nil {
-		return nil, fmt.Errorf("failed to get from Redis: %w", err)
+		c.errors.Add(1)
+		return nil, c.wrapErr(opCtx, err, "failed to get from Redis: %w")
 	}
 
 	// Deserialize
 	var odds models.OptimizedOdds
 	if err := json.Unmarshal(data, &odds); err != nil {
+		c.errors.Add(1)
 		return nil, fmt.Errorf("failed to unmarshal odds: %w", err)
 	}
 
+	c.hits.Add(1)
 	return &odds, nil
 }
 
-// SetBatch caches multiple optimized odds
+// GetWithMeta retrieves cached optimized odds with their remaining TTL and
+// age, reading the value and its TTL in one round trip. The remaining TTL
+// excludes the stale grace window; stale odds report none.
+func (c *RedisCache) GetWithMeta(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, models.CacheMeta, error) {
+	key := c.keys.odds(eventID, market, selection)
+
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+	pipe := c.client.Pipeline()
+	get := pipe.Get(opCtx, key)
+	ttl := pipe.TTL(opCtx, key)
+	if _, err := pipe.Exec(opCtx); err != nil && err != redis.Nil {
+		c.errors.Add(1)
+		return nil, models.CacheMeta{}, c.wrapErr(opCtx, err, "failed to get from Redis: %w")
+	}
+
+	data, err := get.Bytes()
+	if err == redis.Nil {
+		c.misses.Add(1)
+		return nil, models.CacheMeta{}, ErrNotFound
+	}
+
+	var odds models.OptimizedOdds
+	if err := json.Unmarshal(data, &odds); err != nil {
+		c.errors.Add(1)
+		return nil, models.CacheMeta{}, fmt.Errorf("failed to unmarshal odds: %w", err)
+	}
+	c.hits.Add(1)
+
+	// TTL is negative for keys without an expiry
+	meta := models.CacheMeta{Age: time.Since(odds.OptimizedAt)}
+	if remaining := ttl.Val(); remaining > 0 {
+		meta.TTLRemaining = c.markStale(&odds, remaining)
+	}
+	return &odds, meta, nil
+}
+
+// Touch resets the TTL of cached odds without rewriting the value. With an
+// in-play TTL configured the value is read first, so in-play odds keep
+// their short TTL.
+func (c *RedisCache) Touch(ctx context.Context, eventID, market, selection string) error {
+	key := c.keys.odds(eventID, market, selection)
+
+	ttl := c.ttl
+	if c.inPlayTTL > 0 {
+		odds, err := c.Get(ctx, eventID, market, selection)
+		if err != nil {
+			return err
+		}
+		ttl = c.ttlFor(odds)
+	}
+
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+
+	// EXPIRE with a zero TTL would delete the key; without a TTL there is
+	// nothing to extend, so only check the key still exists
+	var ok bool
+	var err error
+	if ttl > 0 {
+		ok, err = c.client.Expire(opCtx, key, c.expiryFor(ttl)).Result()
+	} else {
+		var n int64
+		n, err = c.client.Exists(opCtx, key).Result()
+		ok = n > 0
+	}
+	if err != nil {
+		c.errors.Add(1)
+		return c.wrapErr(opCtx, err, "failed to touch in Redis: %w")
+	}
+	if !ok {
+		return ErrNotFound
+	}
+
+	c.logger.Debug().
+		Str("key", key).
+		Dur("ttl", ttl).
+		Msg("touched cached odds")
+
+	return nil
+}
+
+// Delete removes cached odds; deleting odds that are not cached is not an error
+func (c *RedisCache) Delete(ctx context.Context, eventID, market, selection string) error {
+	key := c.keys.odds(eventID, market, selection)
+
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+	if err := c.client.Del(opCtx, key).Err(); err != nil {
+		c.errors.Add(1)
+		return c.wrapErr(opCtx, err, "failed to delete from Redis: %w")
+	}
+
+	c.logger.Debug().
+		Str("key", key).
+		Msg("deleted cached odds")
+
+	return nil
+}
+
+// SetBatch caches multiple optimized odds. Batches larger than the pipeline
+// chunk are written in several pipelines, one after another, each with its
+// own op timeout; a failed chunk stops the batch, leaving earlier chunks
+// cached.
 func (c *RedisCache) SetBatch(ctx context.Context, oddsList []*models.OptimizedOdds) error {
 	if len(oddsList) == 0 {
 		return nil
 	}
 
+	for _, chunk := range chunkOdds(oddsList, c.chunk) {
+		if err := c.setChunk(ctx, chunk); err != nil {
+			return err
+		}
+	}
+
+	c.logger.Info().
+		Int("count", len(oddsList)).
+		Msg("cached batch of optimized odds")
+
+	return nil
+}
+
+// setChunk caches optimized odds in a single pipeline
+func (c *RedisCache) setChunk(ctx context.Context, oddsList []*models.OptimizedOdds) error {
 	// Use pipeline for batch operations
 	pipe := c.client.Pipeline()
 
+	queued := 0
 	for _, odds := range oddsList {
-		key := fmt.Sprintf("odds:%s:%s:%s", odds.EventID, odds.Market, odds.Selection)
+		key := c.keys.odds(odds.EventID, odds.Market, odds.Selection)
 		data, err := json.Marshal(odds)
 		if err != nil {
+			c.errors.Add(1)
 			c.logger.Error().Err(err).Msg("failed to marshal odds")
 			continue
 		}
-		pipe.Set(ctx, key, data, c.ttl)
+		pipe.Set(ctx, key, data, c.expiryFor(c.ttlFor(odds)))
+		queued++
 	}
+	c.trackFreshness(ctx, pipe, oddsList)
 
 	// Execute pipeline
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("failed to execute pipeline: %w", err)
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+	if _, err := pipe.Exec(opCtx); err != nil {
+		c.errors.Add(1)
+		return c.wrapErr(opCtx, err, "failed to execute pipeline: %w")
 	}
-
-	c.logger.Info().
-		Int("count", len(oddsList)).
-		Msg("cached batch of optimized odds")
+	c.sets.Add(uint64(queued))
 
 	return nil
 }
 
-// GetByEvent retrieves all cached odds for an event
+// chunkOdds splits oddsList into chunks of at most size; a size of zero or
+// less keeps it whole
+func chunkOdds(oddsList []*models.OptimizedOdds, size int) [][]*models.OptimizedOdds {
+	if size <= 0 || len(oddsList) <= size {
+		return [][]*models.OptimizedOdds{oddsList}
+	}
+
+	chunks := make([][]*models.OptimizedOdds, 0, (len(oddsList)+size-1)/size)
+	for len(oddsList) > size {
+		chunks = append(chunks, oddsList[:size])
+		oddsList = oddsList[size:]
+	}
+	return append(chunks, oddsList)
+}
+
+// GetByEvent retrieves all cached odds for an event, flagging odds within
+// the stale grace window Stale. In cluster mode every master is scanned.
 func (c *RedisCache) GetByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
-	pattern := fmt.Sprintf("odds:%s:*", eventID)
+	pattern := c.keys.eventPattern(eventID)
 
+	// One deadline covers the whole scan and fetch
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+
+	nodes, err := c.scanNodes(opCtx)
+	if err != nil {
+		c.errors.Add(1)
+		return nil, c.wrapErr(opCtx, err, "failed to list cluster masters: %w")
+	}
+
+	var oddsList []*models.OptimizedOdds
+	for _, node := range nodes {
+		nodeOdds, err := c.getByEventOnNode(opCtx, node, pattern)
+		if err != nil {
+			return nil, err
+		}
+		oddsList = append(oddsList, nodeOdds...)
+	}
+	if oddsList == nil {
+		oddsList = []*models.OptimizedOdds{}
+	}
+
+	if len(oddsList) > 0 {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+
+	return oddsList, nil
+}
+
+// getByEventOnNode retrieves the odds matching pattern held by one node
+func (c *RedisCache) getByEventOnNode(opCtx context.Context, node redis.Cmdable, pattern string) ([]*models.OptimizedOdds, error) {
 	// Scan for keys matching pattern
 	var cursor uint64
 	var keys []string
@@ -129,9 +465,10 @@ func (c *RedisCache) GetByEvent(ctx context.Context, eventID string) ([]*models.
 	for {
 		var scanKeys []string
 		var err error
-		scanKeys, cursor, err = c.client.Scan(ctx, cursor, pattern, 100).Result()
+		scanKeys, cursor, err = node.Scan(opCtx, cursor, pattern, 100).Result()
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan keys: %w", err)
+			c.errors.Add(1)
+			return nil, c.wrapErr(opCtx, err, "failed to scan keys: %w")
 		}
 
 		keys = append(keys, scanKeys...)
@@ -144,8 +481,12 @@ func (c *RedisCache) GetByEvent(ctx context.Context, eventID string) ([]*models.
 	// Get all values
 	oddsList := make([]*models.OptimizedOdds, 0, len(keys))
 	for _, key := range keys {
-		data, err := c.client.Get(ctx, key).Bytes()
+		data, err := node.Get(opCtx, key).Bytes()
 		if err != nil {
+			if errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+				c.errors.Add(1)
+				return nil, c.wrapErr(opCtx, err, "failed to get from Redis: %w")
+			}
 			c.logger.Warn().Err(err).Str("key", key).Msg("failed to get key")
 			continue
 		}
@@ -156,15 +497,170 @@ func (c *RedisCache) GetByEvent(ctx context.Context, eventID string) ([]*models.
 			continue
 		}
 
+		if c.staleGrace > 0 {
+			if remaining, err := node.TTL(opCtx, key).Result(); err == nil {
+				c.markStale(&odds, remaining)
+			}
+		}
+
 		oddsList = append(oddsList, &odds)
 	}
 
 	return oddsList, nil
 }
 
+// getByEventsConcurrency bounds the event scans GetByEvents runs at once
+const getByEventsConcurrency = 8
+
+// GetByEvents retrieves all cached odds for several events, scanning events
+// concurrently. Every requested event is a key of the result; events with no
+// cached odds map to an empty slice.
+func (c *RedisCache) GetByEvents(ctx context.Context, eventIDs []string) (map[string][]*models.OptimizedOdds, error) {
+	// Fill in every key before any scan starts writing results
+	results := make(map[string][]*models.OptimizedOdds, len(eventIDs))
+	unique := make([]string, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		if _, seen := results[eventID]; seen {
+			continue
+		}
+		results[eventID] = []*models.OptimizedOdds{}
+		unique = append(unique, eventID)
+	}
+
+	var mu sync.Mutex
+	var errs []error
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, getByEventsConcurrency)
+	for _, eventID := range unique {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(eventID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			oddsList, err := c.GetByEvent(ctx, eventID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("event %s: %w", eventID, err))
+				return
+			}
+			results[eventID] = oddsList
+		}(eventID)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return results, nil
+}
+
+// scanPageSize is the number of keys Scan fetches per SCAN and MGET round trip
+const scanPageSize = 100
+
+// Scan calls fn for every cached odds, or only an event's when eventID is
+// set, stopping at the first error fn returns. Keys are walked with SCAN a
+// page at a time, so the cache is never loaded into memory at once; each
+// page is bounded by the op timeout rather than the whole scan.
+func (c *RedisCache) Scan(ctx context.Context, eventID string, fn func(*models.OptimizedOdds) error) error {
+	pattern := "odds:*"
+	if eventID != "" {
+		pattern = c.keys.eventPattern(eventID)
+	}
+
+	opCtx, cancel := c.withOpTimeout(ctx)
+	nodes, err := c.scanNodes(opCtx)
+	if err != nil {
+		c.errors.Add(1)
+		err = c.wrapErr(opCtx, err, "failed to list cluster masters: %w")
+	}
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		var cursor uint64
+		for {
+			page, next, err := c.scanPage(ctx, node, cursor, pattern)
+			if err != nil {
+				return err
+			}
+
+			for _, odds := range page {
+				if err := fn(odds); err != nil {
+					return err
+				}
+			}
+
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// scanPage fetches the odds of one SCAN page of a node; keys expiring mid-scan are skipped
+func (c *RedisCache) scanPage(ctx context.Context, node redis.Cmdable, cursor uint64, pattern string) ([]*models.OptimizedOdds, uint64, error) {
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+
+	keys, next, err := node.Scan(opCtx, cursor, pattern, scanPageSize).Result()
+	if err != nil {
+		c.errors.Add(1)
+		return nil, 0, c.wrapErr(opCtx, err, "failed to scan keys: %w")
+	}
+	if len(keys) == 0 {
+		return nil, next, nil
+	}
+
+	values, err := c.getValues(opCtx, node, keys)
+	if err != nil {
+		c.errors.Add(1)
+		return nil, 0, c.wrapErr(opCtx, err, "failed to get from Redis: %w")
+	}
+
+	oddsList := make([]*models.OptimizedOdds, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Expired since the scan
+		}
+
+		var odds models.OptimizedOdds
+		if err := json.Unmarshal([]byte(data), &odds); err != nil {
+			c.logger.Warn().Err(err).Str("key", keys[i]).Msg("failed to unmarshal odds")
+			continue
+		}
+		oddsList = append(oddsList, &odds)
+	}
+
+	return oddsList, next, nil
+}
+
+// Stats returns a snapshot of the cache's operational counters
+func (c *RedisCache) Stats() models.CacheStats {
+	return models.CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+		Sets:   c.sets.Load(),
+	}
+}
+
 // Ping checks Redis connection
 func (c *RedisCache) Ping(ctx context.Context) error {
-	return c.client.Ping(ctx).Err()
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+	if err := c.client.Ping(opCtx).Err(); err != nil {
+		return c.wrapErr(opCtx, err, "failed to ping Redis: %w")
+	}
+	return nil
 }
 
 // Close closes the Redis connection
@@ -2,11 +2,15 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -632,3 +636,491 @@ func TestNewRedisCache_Configuration(t *testing.T) {
 		cache.Close()
 	}
 }
+
+// TestStats_Counters tests operational counters after a sequence of operations
+func TestStats_Counters(t *testing.T) {
+	setup := setupTestRedisCache(t)
+	defer setup.cleanup()
+
+	newOdds := func(selection string) *models.OptimizedOdds {
+		return &models.OptimizedOdds{
+			ID:            uuid.New(),
+			EventID:       "event-123",
+			Market:        "match_winner",
+			Selection:     selection,
+			OptimizedBack: decimal.NewFromFloat(2.45),
+			OptimizedLay:  decimal.NewFromFloat(2.55),
+			OptimizedAt:   time.Now(),
+		}
+	}
+
+	assert.Equal(t, models.CacheStats{}, setup.cache.Stats())
+
+	// 1 set + 2 batch sets
+	require.NoError(t, setup.cache.Set(setup.ctx, newOdds("Team A")))
+	require.NoError(t, setup.cache.SetBatch(setup.ctx, []*models.OptimizedOdds{newOdds("Team B"), newOdds("Draw")}))
+
+	// 2 hits, 1 miss
+	_, err := setup.cache.Get(setup.ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+	_, err = setup.cache.Get(setup.ctx, "event-123", "match_winner", "Missing")
+	require.Error(t, err)
+	_, err = setup.cache.GetByEvent(setup.ctx, "event-123")
+	require.NoError(t, err)
+
+	// 1 error
+	setup.miniRedis.SetError("READONLY You can't write against a read only replica")
+	require.Error(t, setup.cache.Set(setup.ctx, newOdds("Team C")))
+	setup.miniRedis.SetError("")
+
+	stats := setup.cache.Stats()
+	assert.Equal(t, uint64(3), stats.Sets)
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.InDelta(t, 2.0/3.0, stats.HitRatio(), 1e-9)
+}
+
+// newBlackholeRedis starts a TCP server that accepts connections but never replies
+func newBlackholeRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var conns []net.Conn
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		<-done
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	return ln.Addr().String()
+}
+
+// TestOpTimeout tests that every cache operation returns ErrTimeout within the op timeout
+func TestOpTimeout(t *testing.T) {
+	const opTimeout = 50 * time.Millisecond
+
+	cache := NewRedisCache(RedisCacheConfig{
+		Addr:      newBlackholeRedis(t),
+		TTL:       time.Minute,
+		OpTimeout: opTimeout,
+	}, zerolog.Nop())
+	defer cache.Close()
+
+	odds := &models.OptimizedOdds{EventID: "event-123", Market: "match_winner", Selection: "Team A"}
+
+	tests := []struct {
+		name string
+		op   func(ctx context.Context) error
+	}{
+		{name: "Set", op: func(ctx context.Context) error { return cache.Set(ctx, odds) }},
+		{name: "Get", op: func(ctx context.Context) error {
+			_, err := cache.Get(ctx, "event-123", "match_winner", "Team A")
+			return err
+		}},
+		{name: "SetBatch", op: func(ctx context.Context) error {
+			return cache.SetBatch(ctx, []*models.OptimizedOdds{odds})
+		}},
+		{name: "GetByEvent", op: func(ctx context.Context) error {
+			_, err := cache.GetByEvent(ctx, "event-123")
+			return err
+		}},
+		{name: "Ping", op: cache.Ping},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := tt.op(context.Background())
+			elapsed := time.Since(start)
+
+			assert.ErrorIs(t, err, ErrTimeout)
+			assert.Less(t, elapsed, opTimeout+250*time.Millisecond)
+		})
+	}
+}
+
+// TestOpTimeout_Disabled tests that a zero op timeout leaves the caller's context in charge
+func TestOpTimeout_Disabled(t *testing.T) {
+	cache := NewRedisCache(RedisCacheConfig{Addr: newBlackholeRedis(t)}, zerolog.Nop())
+	defer cache.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := cache.Get(ctx, "event-123", "match_winner", "Team A")
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrTimeout)
+}
+
+// TestTouch_ResetsTTL tests that Touch restores the full TTL without rewriting the value
+func TestTouch_ResetsTTL(t *testing.T) {
+	setup := setupTestRedisCache(t)
+	defer setup.cleanup()
+
+	odds := &models.OptimizedOdds{
+		ID:            uuid.New(),
+		EventID:       "event-123",
+		Market:        "match_winner",
+		Selection:     "Team A",
+		OptimizedBack: decimal.NewFromFloat(2.45),
+		OptimizedLay:  decimal.NewFromFloat(2.55),
+	}
+	require.NoError(t, setup.cache.Set(setup.ctx, odds))
+
+	key := "odds:event-123:match_winner:Team A"
+	before, err := setup.miniRedis.Get(key)
+	require.NoError(t, err)
+
+	setup.miniRedis.FastForward(10 * time.Minute)
+	assert.Equal(t, 5*time.Minute, setup.miniRedis.TTL(key))
+
+	require.NoError(t, setup.cache.Touch(setup.ctx, "event-123", "match_winner", "Team A"))
+	assert.Equal(t, 15*time.Minute, setup.miniRedis.TTL(key))
+
+	after, err := setup.miniRedis.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	assert.Equal(t, uint64(1), setup.cache.Stats().Sets)
+}
+
+// TestSet_InPlayTTL tests that in-play odds get the short TTL, pre-match odds
+// the long one, and that touching keeps in-play odds short
+func TestSet_InPlayTTL(t *testing.T) {
+	setup := setupTestRedisCache(t)
+	defer setup.cleanup()
+
+	c := NewRedisCache(RedisCacheConfig{
+		Addr:      setup.miniRedis.Addr(),
+		TTL:       15 * time.Minute,
+		InPlayTTL: 30 * time.Second,
+	}, zerolog.Nop())
+	defer c.Close()
+
+	prematch := &models.OptimizedOdds{EventID: "event-123", Market: "match_winner", Selection: "Team A"}
+	inPlay := &models.OptimizedOdds{EventID: "event-456", Market: "match_winner", Selection: "Team A", InPlay: true}
+
+	require.NoError(t, c.SetBatch(setup.ctx, []*models.OptimizedOdds{prematch, inPlay}))
+	assert.Equal(t, 15*time.Minute, setup.miniRedis.TTL("odds:event-123:match_winner:Team A"))
+	assert.Equal(t, 30*time.Second, setup.miniRedis.TTL("odds:event-456:match_winner:Team A"))
+
+	inPlay.Selection = "Team B"
+	require.NoError(t, c.Set(setup.ctx, inPlay))
+	assert.Equal(t, 30*time.Second, setup.miniRedis.TTL("odds:event-456:match_winner:Team B"))
+
+	setup.miniRedis.FastForward(20 * time.Second)
+	require.NoError(t, c.Touch(setup.ctx, "event-456", "match_winner", "Team B"))
+	assert.Equal(t, 30*time.Second, setup.miniRedis.TTL("odds:event-456:match_winner:Team B"))
+	require.NoError(t, c.Touch(setup.ctx, "event-123", "match_winner", "Team A"))
+	assert.Equal(t, 15*time.Minute, setup.miniRedis.TTL("odds:event-123:match_winner:Team A"))
+
+	// Past the in-play TTL only pre-match odds remain
+	setup.miniRedis.FastForward(time.Minute)
+	_, err := c.Get(setup.ctx, "event-456", "match_winner", "Team B")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.Get(setup.ctx, "event-123", "match_winner", "Team A")
+	assert.NoError(t, err)
+}
+
+// TestTouch_NotFound tests touching missing and expired keys
+func TestTouch_NotFound(t *testing.T) {
+	setup := setupTestRedisCache(t)
+	defer setup.cleanup()
+
+	err := setup.cache.Touch(setup.ctx, "event-123", "match_winner", "Team A")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, setup.cache.Set(setup.ctx, &models.OptimizedOdds{
+		EventID:   "event-123",
+		Market:    "match_winner",
+		Selection: "Team A",
+	}))
+	setup.miniRedis.FastForward(16 * time.Minute)
+
+	err = setup.cache.Touch(setup.ctx, "event-123", "match_winner", "Team A")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, uint64(0), setup.cache.Stats().Errors)
+}
+
+// TestTouch_NoTTL tests that Touch never deletes keys cached without a TTL
+func TestTouch_NoTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr()}, zerolog.Nop())
+	defer cache.Close()
+	ctx := context.Background()
+
+	require.NoError(t, cache.Set(ctx, &models.OptimizedOdds{
+		EventID:   "event-123",
+		Market:    "match_winner",
+		Selection: "Team A",
+	}))
+
+	require.NoError(t, cache.Touch(ctx, "event-123", "match_winner", "Team A"))
+	assert.True(t, mr.Exists("odds:event-123:match_winner:Team A"))
+
+	err := cache.Touch(ctx, "event-123", "match_winner", "Team B")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestGetByEvents tests retrieving several events at once
+func TestGetByEvents(t *testing.T) {
+	setup := setupTestRedisCache(t)
+	defer setup.cleanup()
+
+	var oddsList []*models.OptimizedOdds
+	for _, eventID := range []string{"event-1", "event-2"} {
+		for _, selection := range []string{"Team A", "Team B"} {
+			oddsList = append(oddsList, &models.OptimizedOdds{
+				ID:            uuid.New(),
+				EventID:       eventID,
+				Market:        "match_winner",
+				Selection:     selection,
+				OptimizedBack: decimal.NewFromFloat(2.45),
+			})
+		}
+	}
+	require.NoError(t, setup.cache.SetBatch(setup.ctx, oddsList))
+
+	events, err := setup.cache.GetByEvents(setup.ctx, []string{"event-1", "event-2", "event-3", "event-1"})
+	require.NoError(t, err)
+
+	require.Len(t, events, 3)
+	assert.Len(t, events["event-1"], 2)
+	assert.Len(t, events["event-2"], 2)
+	for _, odds := range events["event-2"] {
+		assert.Equal(t, "event-2", odds.EventID)
+	}
+
+	// An event with no data is present with an empty slice
+	empty, ok := events["event-3"]
+	require.True(t, ok)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+}
+
+// TestGetByEvents_RedisDown tests that scan failures fail the whole lookup
+func TestGetByEvents_RedisDown(t *testing.T) {
+	setup := setupTestRedisCache(t)
+	defer setup.cleanup()
+
+	setup.miniRedis.SetError("connection refused")
+
+	events, err := setup.cache.GetByEvents(setup.ctx, []string{"event-1", "event-2"})
+	assert.Error(t, err)
+	assert.Nil(t, events)
+}
+
+// TestGetWithMeta tests that the metadata reflects the remaining TTL and the
+// odds' age
+func TestGetWithMeta(t *testing.T) {
+	setup := setupTestRedisCache(t)
+	defer setup.cleanup()
+
+	odds := &models.OptimizedOdds{
+		ID:            uuid.New(),
+		EventID:       "event-123",
+		Market:        "match_winner",
+		Selection:     "Team A",
+		OptimizedBack: decimal.NewFromFloat(2.45),
+		OptimizedAt:   time.Now().Add(-2 * time.Minute),
+	}
+	require.NoError(t, setup.cache.Set(setup.ctx, odds))
+	setup.miniRedis.FastForward(5 * time.Minute)
+
+	cached, meta, err := setup.cache.GetWithMeta(setup.ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.True(t, odds.OptimizedBack.Equal(cached.OptimizedBack))
+	assert.Equal(t, 10*time.Minute, meta.TTLRemaining)
+	assert.InDelta(t, (2 * time.Minute).Seconds(), meta.Age.Seconds(), 1)
+
+	// A key without an expiry reports no TTL
+	setup.miniRedis.SetTTL(setup.cache.keys.odds("event-123", "match_winner", "Team A"), 0)
+	_, meta, err = setup.cache.GetWithMeta(setup.ctx, "event-123", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.Zero(t, meta.TTLRemaining)
+
+	_, _, err = setup.cache.GetWithMeta(setup.ctx, "event-123", "match_winner", "Team B")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestSetBatch_PipelineChunks tests that large batches are split into
+// pipeline chunks and every item is stored
+func TestSetBatch_PipelineChunks(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute, PipelineChunk: 100}, zerolog.Nop())
+	defer cache.Close()
+
+	oddsList := make([]*models.OptimizedOdds, 1050)
+	for i := range oddsList {
+		oddsList[i] = &models.OptimizedOdds{
+			ID:            uuid.New(),
+			EventID:       fmt.Sprintf("event-%d", i),
+			Market:        "match_winner",
+			Selection:     "Team A",
+			OptimizedBack: decimal.NewFromFloat(2.45),
+		}
+	}
+
+	chunks := chunkOdds(oddsList, 100)
+	require.Len(t, chunks, 11)
+	assert.Len(t, chunks[0], 100)
+	assert.Len(t, chunks[10], 50)
+	assert.Len(t, chunkOdds(oddsList, 0), 1)
+	assert.Len(t, chunkOdds(oddsList[:100], 100), 1)
+
+	require.NoError(t, cache.SetBatch(context.Background(), oddsList))
+	assert.Len(t, mr.Keys(), len(oddsList))
+	assert.Equal(t, uint64(len(oddsList)), cache.Stats().Sets)
+
+	cached, err := cache.Get(context.Background(), "event-1049", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.Equal(t, oddsList[1049].ID, cached.ID)
+}
+
+// TestGet_StaleGrace tests odds are fresh within their TTL, flagged stale
+// within the grace window after it, and missing beyond it
+func TestGet_StaleGrace(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute, StaleGrace: 30 * time.Second}, zerolog.Nop())
+	defer cache.Close()
+	ctx := context.Background()
+
+	odds := &models.OptimizedOdds{
+		ID:            uuid.New(),
+		EventID:       "event-1",
+		Market:        "match_winner",
+		Selection:     "Team A",
+		OptimizedBack: decimal.NewFromFloat(2.45),
+		OptimizedAt:   time.Now(),
+	}
+	require.NoError(t, cache.Set(ctx, odds))
+	assert.Equal(t, 90*time.Second, mr.TTL(oddsKey("event-1", "match_winner", "Team A")))
+
+	// Fresh
+	cached, err := cache.Get(ctx, "event-1", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.False(t, cached.Stale)
+	_, meta, err := cache.GetWithMeta(ctx, "event-1", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, meta.TTLRemaining)
+
+	// Within grace
+	mr.FastForward(70 * time.Second)
+	cached, err = cache.Get(ctx, "event-1", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.True(t, cached.Stale)
+	assert.Equal(t, odds.ID, cached.ID)
+	_, meta, err = cache.GetWithMeta(ctx, "event-1", "match_winner", "Team A")
+	require.NoError(t, err)
+	assert.Zero(t, meta.TTLRemaining)
+	byEvent, err := cache.GetByEvent(ctx, "event-1")
+	require.NoError(t, err)
+	require.Len(t, byEvent, 1)
+	assert.True(t, byEvent[0].Stale)
+
+	// Beyond grace
+	mr.FastForward(30 * time.Second)
+	_, err = cache.Get(ctx, "event-1", "match_winner", "Team A")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestRedisCache_ClusterMode tests cluster mode connects with a cluster
+// client, which writes, reads and scans through the cluster's slot map
+func TestRedisCache_ClusterMode(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute, Cluster: true}, zerolog.Nop())
+	defer cache.Close()
+	ctx := context.Background()
+
+	_, ok := cache.client.(*redis.ClusterClient)
+	require.True(t, ok, "cluster mode should use a cluster client")
+
+	oddsList := []*models.OptimizedOdds{
+		{ID: uuid.New(), EventID: "event-1", Market: "match_winner", Selection: "Home"},
+		{ID: uuid.New(), EventID: "event-1", Market: "match_winner", Selection: "Away"},
+		{ID: uuid.New(), EventID: "event-2", Market: "match_winner", Selection: "Home"},
+	}
+	require.NoError(t, cache.SetBatch(ctx, oddsList))
+
+	cached, err := cache.Get(ctx, "event-1", "match_winner", "Away")
+	require.NoError(t, err)
+	assert.Equal(t, oddsList[1].ID, cached.ID)
+
+	byEvent, err := cache.GetByEvent(ctx, "event-1")
+	require.NoError(t, err)
+	assert.Len(t, byEvent, 2)
+
+	scanned := 0
+	require.NoError(t, cache.Scan(ctx, "", func(*models.OptimizedOdds) error {
+		scanned++
+		return nil
+	}))
+	assert.Equal(t, 3, scanned)
+}
+
+// TestRedisCache_MultiNodeScan tests GetByEvent and Scan aggregate keys held
+// by every node of a cluster
+func TestRedisCache_MultiNodeScan(t *testing.T) {
+	ctx := context.Background()
+	cache := NewRedisCache(RedisCacheConfig{Addr: miniredis.RunT(t).Addr(), Cluster: true}, zerolog.Nop())
+	defer cache.Close()
+
+	// Two masters, each holding part of event-1
+	var nodes []redis.Cmdable
+	for _, selection := range []string{"Home", "Away"} {
+		node := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+		defer node.Close()
+		nodes = append(nodes, node)
+
+		data, err := json.Marshal(&models.OptimizedOdds{EventID: "event-1", Market: "match_winner", Selection: selection})
+		require.NoError(t, err)
+		require.NoError(t, node.Set(ctx, oddsKey("event-1", "match_winner", selection), data, 0).Err())
+		require.NoError(t, node.Set(ctx, oddsKey("event-2", "match_winner", selection), data, 0).Err())
+	}
+	cache.scanNodes = func(context.Context) ([]redis.Cmdable, error) { return nodes, nil }
+
+	byEvent, err := cache.GetByEvent(ctx, "event-1")
+	require.NoError(t, err)
+	selections := make([]string, 0, len(byEvent))
+	for _, odds := range byEvent {
+		selections = append(selections, odds.Selection)
+	}
+	assert.ElementsMatch(t, []string{"Home", "Away"}, selections)
+
+	scanned := 0
+	require.NoError(t, cache.Scan(ctx, "", func(*models.OptimizedOdds) error {
+		scanned++
+		return nil
+	}))
+	assert.Equal(t, 4, scanned)
+}
+
+// TestDelete tests cached odds are removed and deleting missing odds succeeds
+func TestDelete(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute}, zerolog.Nop())
+	defer cache.Close()
+	ctx := context.Background()
+
+	require.NoError(t, cache.Set(ctx, &models.OptimizedOdds{EventID: "event-1", Market: "match_winner", Selection: "Home"}))
+	require.NoError(t, cache.Delete(ctx, "event-1", "match_winner", "Home"))
+
+	_, err := cache.Get(ctx, "event-1", "match_winner", "Home")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, cache.Delete(ctx, "event-1", "match_winner", "Home"))
+}
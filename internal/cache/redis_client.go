@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisClientOptions are the connection settings every Redis store shares
+type redisClientOptions struct {
+	Addr         string
+	Password     string
+	DB           int
+	Cluster      bool     // Connect to a Redis Cluster, following MOVED/ASK redirects; DB must be 0
+	ClusterAddrs []string // Seed nodes of the cluster (empty uses Addr)
+}
+
+// newRedisClient connects to a single Redis server, or to a Redis Cluster
+// when opts.Cluster is set
+func newRedisClient(opts redisClientOptions) redis.UniversalClient {
+	if opts.Cluster {
+		addrs := opts.ClusterAddrs
+		if len(addrs) == 0 {
+			addrs = []string{opts.Addr}
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:                 addrs,
+			Password:              opts.Password,
+			ContextTimeoutEnabled: true,
+		})
+	}
+	return redis.NewClient(&redis.Options{
+		Addr:                  opts.Addr,
+		Password:              opts.Password,
+		DB:                    opts.DB,
+		ContextTimeoutEnabled: true, // Honor op timeout deadlines on socket reads/writes
+	})
+}
+
+// masterNodes returns the servers to SCAN, in turn: every master of a
+// cluster, whose keyspaces together hold every key, or the single server
+func masterNodes(ctx context.Context, client redis.UniversalClient) ([]redis.Cmdable, error) {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return []redis.Cmdable{client}, nil
+	}
+
+	var mu sync.Mutex
+	var nodes []redis.Cmdable
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		nodes = append(nodes, node)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// closingKey builds the closing line key: closing:{event_id}:{market}:{selection}
+func closingKey(eventID, market, selection string) string {
+	return fmt.Sprintf("closing:%s:%s:%s", eventID, market, selection)
+}
+
+// RedisClosingLines stores the closing line, the last optimized price of
+// each selection before its event started, for model evaluation
+type RedisClosingLines struct {
+	client redis.UniversalClient
+	keys   keyBuilder
+	ttl    time.Duration
+	logger zerolog.Logger
+}
+
+// RedisClosingLinesConfig holds Redis closing line store configuration
+type RedisClosingLinesConfig struct {
+	Addr     string // e.g., "localhost:6379"
+	Password string
+	DB       int
+
+	Cluster      bool     // Connect to a Redis Cluster, following MOVED/ASK redirects; DB must be 0
+	ClusterAddrs []string // Seed nodes of the cluster (empty uses Addr)
+
+	MaxKeyComponentLen int           // Longer event IDs, markets and selections are truncated with a hash in keys (0 disables)
+	TTL                time.Duration // How long closing lines are kept (0 keeps them forever)
+}
+
+// NewRedisClosingLines creates a new Redis closing line store
+func NewRedisClosingLines(config RedisClosingLinesConfig, logger zerolog.Logger) *RedisClosingLines {
+	client := newRedisClient(redisClientOptions{
+		Addr:         config.Addr,
+		Password:     config.Password,
+		DB:           config.DB,
+		Cluster:      config.Cluster,
+		ClusterAddrs: config.ClusterAddrs,
+	})
+
+	logger = logger.With().Str("component", "redis_closing_lines").Logger()
+	return &RedisClosingLines{
+		client: client,
+		keys:   newKeyBuilder(config.MaxKeyComponentLen, logger),
+		ttl:    config.TTL,
+		logger: logger,
+	}
+}
+
+// Capture stores each odds as its selection's closing line, replacing any
+// earlier capture
+func (c *RedisClosingLines) Capture(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	if len(oddsList) == 0 {
+		return nil
+	}
+
+	pipe := c.client.Pipeline()
+
+	for _, odds := range oddsList {
+		data, err := json.Marshal(odds)
+		if err != nil {
+			c.logger.Error().Err(err).Msg("failed to marshal odds")
+			continue
+		}
+		pipe.Set(ctx, c.keys.closing(odds.EventID, odds.Market, odds.Selection), data, c.ttl)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to execute pipeline: %w", err)
+	}
+
+	return nil
+}
+
+// GetByEvent returns the captured closing lines of an event; none before capture
+func (c *RedisClosingLines) GetByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
+	pattern := c.keys.closingPattern(eventID)
+
+	var keys []string
+	iter := c.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get from Redis: %w", err)
+	}
+
+	oddsList := make([]*models.OptimizedOdds, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // Expired since the scan
+		}
+
+		var odds models.OptimizedOdds
+		if err := json.Unmarshal([]byte(data), &odds); err != nil {
+			c.logger.Warn().Err(err).Str("key", keys[i]).Msg("failed to unmarshal odds")
+			continue
+		}
+		oddsList = append(oddsList, &odds)
+	}
+
+	return oddsList, nil
+}
+
+// Close closes the Redis connection
+func (c *RedisClosingLines) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisDedup tests that marked keys are seen until the window expires
+func TestRedisDedup(t *testing.T) {
+	mr := miniredis.RunT(t)
+	dedup := NewRedisDedup(RedisDedupConfig{Addr: mr.Addr(), Window: time.Minute}, zerolog.Nop())
+	defer dedup.Close()
+	ctx := context.Background()
+
+	seen, err := dedup.Seen(ctx, "normalized_odds:0:42:batch-1")
+	require.NoError(t, err)
+	assert.False(t, seen)
+
+	require.NoError(t, dedup.Mark(ctx, "normalized_odds:0:42:batch-1"))
+
+	seen, err = dedup.Seen(ctx, "normalized_odds:0:42:batch-1")
+	require.NoError(t, err)
+	assert.True(t, seen)
+
+	// Expires after the window
+	mr.FastForward(time.Minute + time.Second)
+	seen, err = dedup.Seen(ctx, "normalized_odds:0:42:batch-1")
+	require.NoError(t, err)
+	assert.False(t, seen)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// freshnessKey holds the newest OptimizedAt written by any instance, as the
+// score of freshnessMember; it lives outside the odds: namespace so event
+// scans never see it
+const (
+	freshnessKey    = "freshness:odds"
+	freshnessMember = "last_optimized_at"
+)
+
+// newestOptimizedAt returns the latest OptimizedAt of oddsList
+func newestOptimizedAt(oddsList []*models.OptimizedOdds) time.Time {
+	var newest time.Time
+	for _, odds := range oddsList {
+		if odds.OptimizedAt.After(newest) {
+			newest = odds.OptimizedAt
+		}
+	}
+	return newest
+}
+
+// trackFreshness queues raising the shared freshness watermark to the newest
+// OptimizedAt of oddsList. ZADD GT only ever raises it, so instances writing
+// concurrently or out of order leave the newest time across all of them.
+func (c *RedisCache) trackFreshness(ctx context.Context, pipe redis.Pipeliner, oddsList []*models.OptimizedOdds) {
+	newest := newestOptimizedAt(oddsList)
+	if !c.trackFresh || newest.IsZero() {
+		return
+	}
+	pipe.ZAddGT(ctx, freshnessKey, redis.Z{Score: float64(newest.UnixMilli()), Member: freshnessMember})
+}
+
+// LastOptimizedAt returns the newest OptimizedAt written to the cache by any
+// instance tracking freshness, to millisecond precision, or the zero time
+// when none has been written
+func (c *RedisCache) LastOptimizedAt(ctx context.Context) (time.Time, error) {
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+
+	score, err := c.client.ZScore(opCtx, freshnessKey, freshnessMember).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, c.wrapErr(opCtx, err, "failed to read freshness watermark: %w")
+	}
+	return time.UnixMilli(int64(score)).UTC(), nil
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// TestRedisCache_LastOptimizedAt tests that the freshness watermark tracks
+// the newest OptimizedAt written by any instance, never moving backwards
+func TestRedisCache_LastOptimizedAt(t *testing.T) {
+	mr := miniredis.RunT(t)
+	config := RedisCacheConfig{Addr: mr.Addr(), TTL: time.Hour, TrackFreshness: true}
+	first := NewRedisCache(config, zerolog.Nop())
+	defer first.Close()
+	second := NewRedisCache(config, zerolog.Nop())
+	defer second.Close()
+	ctx := context.Background()
+
+	odds := func(selection string, optimizedAt time.Time) *models.OptimizedOdds {
+		return &models.OptimizedOdds{EventID: "event-123", Market: "match_winner", Selection: selection, OptimizedAt: optimizedAt}
+	}
+	base := time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)
+
+	last, err := first.LastOptimizedAt(ctx)
+	require.NoError(t, err)
+	assert.True(t, last.IsZero())
+
+	require.NoError(t, first.SetBatch(ctx, []*models.OptimizedOdds{
+		odds("Team A", base.Add(time.Second)),
+		odds("Team B", base.Add(3*time.Second)),
+	}))
+	last, err = second.LastOptimizedAt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, base.Add(3*time.Second), last)
+
+	// An older write from another instance does not lower it
+	require.NoError(t, second.Set(ctx, odds("Team A", base.Add(2*time.Second))))
+	last, err = first.LastOptimizedAt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, base.Add(3*time.Second), last)
+
+	require.NoError(t, second.Set(ctx, odds("Draw", base.Add(5*time.Second))))
+	last, err = first.LastOptimizedAt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, base.Add(5*time.Second), last)
+
+	// The watermark stays out of event scans
+	eventOdds, err := first.GetByEvent(ctx, "event-123")
+	require.NoError(t, err)
+	assert.Len(t, eventOdds, 3)
+	count := 0
+	require.NoError(t, first.Scan(ctx, "", func(*models.OptimizedOdds) error {
+		count++
+		return nil
+	}))
+	assert.Equal(t, 3, count)
+}
+
+// TestRedisCache_LastOptimizedAt_Untracked tests that writes leave no
+// watermark unless freshness tracking is enabled
+func TestRedisCache_LastOptimizedAt_Untracked(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Hour}, zerolog.Nop())
+	defer cache.Close()
+	ctx := context.Background()
+
+	require.NoError(t, cache.Set(ctx, &models.OptimizedOdds{EventID: "event-123", Market: "match_winner", Selection: "Team A", OptimizedAt: time.Now()}))
+
+	assert.False(t, mr.Exists(freshnessKey))
+	last, err := cache.LastOptimizedAt(ctx)
+	require.NoError(t, err)
+	assert.True(t, last.IsZero())
+}
@@ -0,0 +1,316 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// historyField is the stream entry field holding the JSON-encoded odds
+const historyField = "odds"
+
+// RedisHistory records time-indexed snapshots of optimized odds in Redis
+// Streams, one stream per selection. Entry IDs are assigned by Redis at
+// append time, so a snapshot is current from its entry ID until the next one.
+//
+// Streams are trimmed approximately on each append, to MaxLen entries and to
+// the Retention window; Start also trims streams no longer appended to.
+type RedisHistory struct {
+	client       redis.UniversalClient
+	keys         keyBuilder
+	scanNodes    func(ctx context.Context) ([]redis.Cmdable, error) // Servers SCANs walk: every master, or a fake in tests
+	maxLen       int64
+	retention    time.Duration
+	trimInterval time.Duration
+	now          func() time.Time
+	logger       zerolog.Logger
+}
+
+// RedisHistoryConfig holds Redis history configuration
+type RedisHistoryConfig struct {
+	Addr     string // e.g., "localhost:6379"
+	Password string
+	DB       int
+
+	Cluster      bool     // Connect to a Redis Cluster, following MOVED/ASK redirects; DB must be 0
+	ClusterAddrs []string // Seed nodes of the cluster (empty uses Addr)
+
+	MaxKeyComponentLen int // Longer event IDs, markets and selections are truncated with a hash in keys (0 disables)
+
+	MaxLen       int64         // Approximate snapshots kept per selection (0 keeps all)
+	Retention    time.Duration // Snapshots older than this are trimmed (0 keeps all)
+	TrimInterval time.Duration // How often Start trims every stream to Retention
+}
+
+// NewRedisHistory creates a new Redis history store
+func NewRedisHistory(config RedisHistoryConfig, logger zerolog.Logger) *RedisHistory {
+	client := newRedisClient(redisClientOptions{
+		Addr:         config.Addr,
+		Password:     config.Password,
+		DB:           config.DB,
+		Cluster:      config.Cluster,
+		ClusterAddrs: config.ClusterAddrs,
+	})
+
+	logger = logger.With().Str("component", "redis_history").Logger()
+	h := &RedisHistory{
+		client:       client,
+		keys:         newKeyBuilder(config.MaxKeyComponentLen, logger),
+		maxLen:       config.MaxLen,
+		retention:    config.Retention,
+		trimInterval: config.TrimInterval,
+		now:          time.Now,
+		logger:       logger,
+	}
+	h.scanNodes = func(ctx context.Context) ([]redis.Cmdable, error) {
+		return masterNodes(ctx, h.client)
+	}
+	return h
+}
+
+// scan calls fn with every history stream key matching pattern and the
+// server holding it, walking every master of a cluster in turn
+func (h *RedisHistory) scan(ctx context.Context, pattern string, fn func(node redis.Cmdable, key string) error) error {
+	nodes, err := h.scanNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list Redis nodes: %w", err)
+	}
+
+	for _, node := range nodes {
+		iter := node.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			if err := fn(node, iter.Val()); err != nil {
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("failed to scan history streams: %w", err)
+		}
+	}
+	return nil
+}
+
+// historyKey builds the stream key: history:{event_id}:{market}:{selection}
+func historyKey(eventID, market, selection string) string {
+	return fmt.Sprintf("history:%s:%s:%s", eventID, market, selection)
+}
+
+// Append records a snapshot of each optimized selection
+func (h *RedisHistory) Append(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	if len(oddsList) == 0 {
+		return nil
+	}
+
+	pipe := h.client.Pipeline()
+
+	for _, odds := range oddsList {
+		data, err := json.Marshal(odds)
+		if err != nil {
+			h.logger.Error().Err(err).Msg("failed to marshal odds")
+			continue
+		}
+		key := h.keys.history(odds.EventID, odds.Market, odds.Selection)
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: h.maxLen,
+			Approx: h.maxLen > 0,
+			Values: []string{historyField, string(data)},
+		})
+		if h.retention > 0 {
+			pipe.XTrimMinIDApprox(ctx, key, h.minID(), 0)
+		}
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to execute pipeline: %w", err)
+	}
+
+	h.logger.Debug().
+		Int("count", len(oddsList)).
+		Msg("recorded optimized odds snapshots")
+
+	return nil
+}
+
+// At returns the latest snapshot recorded at or before at, or nil if the
+// selection has no snapshot that old
+func (h *RedisHistory) At(ctx context.Context, eventID, market, selection string, at time.Time) (*models.OptimizedOdds, error) {
+	return h.streamAt(ctx, h.client, h.keys.history(eventID, market, selection), at)
+}
+
+// MarketAt returns the latest snapshot recorded at or before at of each
+// selection of a market; selections with no snapshot that old are omitted
+func (h *RedisHistory) MarketAt(ctx context.Context, eventID, market string, at time.Time) ([]*models.OptimizedOdds, error) {
+	var snapshots []*models.OptimizedOdds
+	err := h.scan(ctx, h.keys.marketHistoryPattern(eventID, market), func(node redis.Cmdable, key string) error {
+		odds, err := h.streamAt(ctx, node, key, at)
+		if err != nil {
+			return err
+		}
+		if odds != nil {
+			snapshots = append(snapshots, odds)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// streamAt returns the latest snapshot of a history stream on node recorded
+// at or before at, or nil if there is none
+func (h *RedisHistory) streamAt(ctx context.Context, node redis.Cmdable, key string, at time.Time) (*models.OptimizedOdds, error) {
+	// A millisecond-only upper bound includes every sequence number in that millisecond
+	end := strconv.FormatInt(at.UnixMilli(), 10)
+	entries, err := node.XRevRangeN(ctx, key, end, "-", 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history from Redis: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	data, ok := entries[0].Values[historyField].(string)
+	if !ok {
+		return nil, fmt.Errorf("history entry %s has no %s field", entries[0].ID, historyField)
+	}
+
+	var odds models.OptimizedOdds
+	if err := json.Unmarshal([]byte(data), &odds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal odds: %w", err)
+	}
+
+	return &odds, nil
+}
+
+// Recent returns up to n of the selection's latest snapshots, newest first
+func (h *RedisHistory) Recent(ctx context.Context, eventID, market, selection string, n int) ([]*models.OptimizedOdds, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	key := h.keys.history(eventID, market, selection)
+	entries, err := h.client.XRevRangeN(ctx, key, "+", "-", int64(n)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history from Redis: %w", err)
+	}
+
+	return decodeSnapshots(entries)
+}
+
+// RecentBatch returns up to n of each selection's latest snapshots, newest
+// first, reading every stream in one pipeline
+func (h *RedisHistory) RecentBatch(ctx context.Context, keys []models.SelectionKey, n int) ([][]*models.OptimizedOdds, error) {
+	if n <= 0 || len(keys) == 0 {
+		return make([][]*models.OptimizedOdds, len(keys)), nil
+	}
+
+	pipe := h.client.Pipeline()
+	cmds := make([]*redis.XMessageSliceCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.XRevRangeN(ctx, h.keys.history(key.EventID, key.Market, key.Selection), "+", "-", int64(n))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read history from Redis: %w", err)
+	}
+
+	snapshots := make([][]*models.OptimizedOdds, len(keys))
+	for i, cmd := range cmds {
+		var err error
+		if snapshots[i], err = decodeSnapshots(cmd.Val()); err != nil {
+			return nil, err
+		}
+	}
+	return snapshots, nil
+}
+
+// decodeSnapshots decodes history stream entries into snapshots, in order
+func decodeSnapshots(entries []redis.XMessage) ([]*models.OptimizedOdds, error) {
+	snapshots := make([]*models.OptimizedOdds, 0, len(entries))
+	for _, entry := range entries {
+		data, ok := entry.Values[historyField].(string)
+		if !ok {
+			return nil, fmt.Errorf("history entry %s has no %s field", entry.ID, historyField)
+		}
+
+		var odds models.OptimizedOdds
+		if err := json.Unmarshal([]byte(data), &odds); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal odds: %w", err)
+		}
+		snapshots = append(snapshots, &odds)
+	}
+
+	return snapshots, nil
+}
+
+// minID returns the oldest stream entry ID within the retention window
+func (h *RedisHistory) minID() string {
+	return strconv.FormatInt(h.now().Add(-h.retention).UnixMilli(), 10)
+}
+
+// Trim drops snapshots older than the retention window from every history
+// stream, including selections that are no longer updated
+func (h *RedisHistory) Trim(ctx context.Context) error {
+	if h.retention <= 0 {
+		return nil
+	}
+
+	minID := h.minID()
+	var trimmed int64
+	err := h.scan(ctx, "history:*", func(node redis.Cmdable, key string) error {
+		n, err := node.XTrimMinIDApprox(ctx, key, minID, 0).Result()
+		if err != nil {
+			return fmt.Errorf("failed to trim history stream %s: %w", key, err)
+		}
+		trimmed += n
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	h.logger.Debug().
+		Int64("trimmed", trimmed).
+		Dur("retention", h.retention).
+		Msg("trimmed odds history")
+
+	return nil
+}
+
+// Start trims every history stream to the retention window each trim
+// interval, until ctx is done. It returns at once when retention or the trim
+// interval is disabled.
+func (h *RedisHistory) Start(ctx context.Context) {
+	if h.retention <= 0 || h.trimInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(h.trimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := h.Trim(ctx); err != nil {
+				h.logger.Warn().Err(err).Msg("failed to trim odds history")
+			}
+		}
+	}
+}
+
+// Close closes the Redis connection
+func (h *RedisHistory) Close() error {
+	return h.client.Close()
+}
@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// setupTestRedisHistory creates a history store backed by miniredis
+func setupTestRedisHistory(t *testing.T) (*RedisHistory, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	history := NewRedisHistory(RedisHistoryConfig{Addr: mr.Addr()}, zerolog.Nop())
+	t.Cleanup(func() { history.Close() })
+	return history, mr
+}
+
+// newHistoryOdds creates optimized odds for Team A at the given back price
+func newHistoryOdds(backPrice float64) *models.OptimizedOdds {
+	return &models.OptimizedOdds{
+		EventID:       "event-123",
+		Market:        "match_winner",
+		Selection:     "Team A",
+		OptimizedBack: decimal.NewFromFloat(backPrice),
+	}
+}
+
+// TestRedisHistory_At tests point-in-time lookups across several snapshots
+func TestRedisHistory_At(t *testing.T) {
+	history, mr := setupTestRedisHistory(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	for i, price := range []float64{2.10, 2.20, 2.30} {
+		mr.SetTime(base.Add(time.Duration(i*10) * time.Minute))
+		require.NoError(t, history.Append(ctx, []*models.OptimizedOdds{newHistoryOdds(price)}))
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected float64 // 0 means no snapshot
+	}{
+		{name: "Before first snapshot", at: base.Add(-time.Second), expected: 0},
+		{name: "Exactly at first snapshot", at: base, expected: 2.10},
+		{name: "Between snapshots", at: base.Add(15 * time.Minute), expected: 2.20},
+		{name: "Exactly at last snapshot", at: base.Add(20 * time.Minute), expected: 2.30},
+		{name: "After last snapshot", at: base.Add(24 * time.Hour), expected: 2.30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			odds, err := history.At(ctx, "event-123", "match_winner", "Team A", tt.at)
+			require.NoError(t, err)
+
+			if tt.expected == 0 {
+				assert.Nil(t, odds)
+				return
+			}
+			require.NotNil(t, odds)
+			assert.True(t, decimal.NewFromFloat(tt.expected).Equal(odds.OptimizedBack),
+				"expected %v, got %s", tt.expected, odds.OptimizedBack)
+		})
+	}
+}
+
+// TestRedisHistory_UnknownSelection tests lookups for a selection without history
+func TestRedisHistory_UnknownSelection(t *testing.T) {
+	history, _ := setupTestRedisHistory(t)
+
+	odds, err := history.At(context.Background(), "event-123", "match_winner", "Team B", time.Now())
+
+	require.NoError(t, err)
+	assert.Nil(t, odds)
+}
+
+// TestRedisHistory_MarketAt tests reading every selection of a market at a point in time
+func TestRedisHistory_MarketAt(t *testing.T) {
+	history, mr := setupTestRedisHistory(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	mr.SetTime(base)
+	require.NoError(t, history.Append(ctx, []*models.OptimizedOdds{newHistoryOdds(2.10)}))
+	mr.SetTime(base.Add(10 * time.Minute))
+	teamB := newHistoryOdds(3.40)
+	teamB.Selection = "Team B"
+	other := newHistoryOdds(1.90)
+	other.Market = "total_goals"
+	require.NoError(t, history.Append(ctx, []*models.OptimizedOdds{newHistoryOdds(2.20), teamB, other}))
+
+	snapshots, err := history.MarketAt(ctx, "event-123", "match_winner", base.Add(5*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.True(t, decimal.NewFromFloat(2.10).Equal(snapshots[0].OptimizedBack))
+
+	snapshots, err = history.MarketAt(ctx, "event-123", "match_winner", base.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	prices := map[string]string{}
+	for _, odds := range snapshots {
+		prices[odds.Selection] = odds.OptimizedBack.String()
+	}
+	assert.Equal(t, map[string]string{"Team A": "2.2", "Team B": "3.4"}, prices)
+}
+
+// TestRedisHistory_Recent tests that the latest snapshots are returned newest first
+func TestRedisHistory_Recent(t *testing.T) {
+	history, _ := setupTestRedisHistory(t)
+	ctx := context.Background()
+
+	for _, price := range []float64{2.10, 2.20, 2.30} {
+		require.NoError(t, history.Append(ctx, []*models.OptimizedOdds{newHistoryOdds(price)}))
+	}
+
+	recent, err := history.Recent(ctx, "event-123", "match_winner", "Team A", 2)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.True(t, decimal.NewFromFloat(2.30).Equal(recent[0].OptimizedBack))
+	assert.True(t, decimal.NewFromFloat(2.20).Equal(recent[1].OptimizedBack))
+
+	recent, err = history.Recent(ctx, "event-123", "match_winner", "Team B", 2)
+	require.NoError(t, err)
+	assert.Empty(t, recent)
+
+	batch, err := history.RecentBatch(ctx, []models.SelectionKey{
+		{EventID: "event-123", Market: "match_winner", Selection: "Team B"},
+		{EventID: "event-123", Market: "match_winner", Selection: "Team A"},
+	}, 2)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	assert.Empty(t, batch[0])
+	require.Len(t, batch[1], 2)
+	assert.True(t, decimal.NewFromFloat(2.30).Equal(batch[1][0].OptimizedBack))
+	assert.True(t, decimal.NewFromFloat(2.20).Equal(batch[1][1].OptimizedBack))
+}
+
+// TestRedisHistory_RedisError tests that Redis failures are surfaced
+func TestRedisHistory_RedisError(t *testing.T) {
+	history, mr := setupTestRedisHistory(t)
+	mr.SetError("connection refused")
+
+	err := history.Append(context.Background(), []*models.OptimizedOdds{newHistoryOdds(2.10)})
+	assert.Error(t, err)
+
+	_, err = history.At(context.Background(), "event-123", "match_winner", "Team A", time.Now())
+	assert.Error(t, err)
+
+	_, err = history.Recent(context.Background(), "event-123", "match_winner", "Team A", 5)
+	assert.Error(t, err)
+
+	_, err = history.RecentBatch(context.Background(), []models.SelectionKey{{EventID: "event-123", Market: "match_winner", Selection: "Team A"}}, 5)
+	assert.Error(t, err)
+}
+
+// TestRedisHistory_MaxLen tests that appending past the max length trims the oldest snapshots
+func TestRedisHistory_MaxLen(t *testing.T) {
+	mr := miniredis.RunT(t)
+	history := NewRedisHistory(RedisHistoryConfig{Addr: mr.Addr(), MaxLen: 3}, zerolog.Nop())
+	t.Cleanup(func() { history.Close() })
+	ctx := context.Background()
+
+	for _, price := range []float64{2.10, 2.20, 2.30, 2.40, 2.50} {
+		require.NoError(t, history.Append(ctx, []*models.OptimizedOdds{newHistoryOdds(price)}))
+	}
+
+	recent, err := history.Recent(ctx, "event-123", "match_winner", "Team A", 10)
+	require.NoError(t, err)
+	require.Len(t, recent, 3)
+	assert.True(t, decimal.NewFromFloat(2.50).Equal(recent[0].OptimizedBack))
+	assert.True(t, decimal.NewFromFloat(2.30).Equal(recent[2].OptimizedBack))
+}
+
+// TestRedisHistory_Retention tests that snapshots older than the retention
+// window are dropped on append and by Trim for idle streams
+func TestRedisHistory_Retention(t *testing.T) {
+	mr := miniredis.RunT(t)
+	history := NewRedisHistory(RedisHistoryConfig{Addr: mr.Addr(), Retention: time.Hour}, zerolog.Nop())
+	t.Cleanup(func() { history.Close() })
+	ctx := context.Background()
+
+	base := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	idle := newHistoryOdds(1.90)
+	idle.Selection = "Team B"
+	for i, price := range []float64{2.10, 2.20, 2.30} {
+		now := base.Add(time.Duration(i*45) * time.Minute)
+		mr.SetTime(now)
+		history.now = func() time.Time { return now }
+
+		oddsList := []*models.OptimizedOdds{newHistoryOdds(price)}
+		if i == 0 {
+			oddsList = append(oddsList, idle)
+		}
+		require.NoError(t, history.Append(ctx, oddsList))
+	}
+
+	// The 14:00 snapshot is over an hour older than the 15:30 append
+	recent, err := history.Recent(ctx, "event-123", "match_winner", "Team A", 10)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.True(t, decimal.NewFromFloat(2.30).Equal(recent[0].OptimizedBack))
+	assert.True(t, decimal.NewFromFloat(2.20).Equal(recent[1].OptimizedBack))
+
+	// Team B is no longer appended to, so only Trim drops its snapshot
+	recent, err = history.Recent(ctx, "event-123", "match_winner", "Team B", 10)
+	require.NoError(t, err)
+	assert.Len(t, recent, 1)
+
+	require.NoError(t, history.Trim(ctx))
+	recent, err = history.Recent(ctx, "event-123", "match_winner", "Team B", 10)
+	require.NoError(t, err)
+	assert.Empty(t, recent)
+
+	recent, err = history.Recent(ctx, "event-123", "match_winner", "Team A", 10)
+	require.NoError(t, err)
+	assert.Len(t, recent, 2)
+}
+
+// TestRedisHistory_ClusterMode tests that cluster mode connects with a
+// cluster client and that MarketAt and Trim walk the streams held by every
+// master of the cluster
+func TestRedisHistory_ClusterMode(t *testing.T) {
+	history := NewRedisHistory(RedisHistoryConfig{Addr: miniredis.RunT(t).Addr(), Cluster: true, Retention: time.Hour}, zerolog.Nop())
+	t.Cleanup(func() { history.Close() })
+	ctx := context.Background()
+
+	_, ok := history.client.(*redis.ClusterClient)
+	require.True(t, ok, "cluster mode should use a cluster client")
+
+	// Two masters, each holding one selection's stream with an old and a new snapshot
+	now := time.Date(2024, 3, 1, 16, 0, 0, 0, time.UTC)
+	history.now = func() time.Time { return now }
+	var nodes []redis.Cmdable
+	for _, selection := range []string{"Team A", "Team B"} {
+		mr := miniredis.RunT(t)
+		node := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { node.Close() })
+		nodes = append(nodes, node)
+
+		odds := newHistoryOdds(2.10)
+		odds.Selection = selection
+		data, err := json.Marshal(odds)
+		require.NoError(t, err)
+		for _, at := range []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Minute)} {
+			require.NoError(t, node.XAdd(ctx, &redis.XAddArgs{
+				Stream: historyKey(odds.EventID, odds.Market, selection),
+				ID:     strconv.FormatInt(at.UnixMilli(), 10) + "-0",
+				Values: []string{historyField, string(data)},
+			}).Err())
+		}
+	}
+	history.scanNodes = func(context.Context) ([]redis.Cmdable, error) { return nodes, nil }
+
+	snapshots, err := history.MarketAt(ctx, "event-123", "match_winner", now)
+	require.NoError(t, err)
+	selections := make([]string, 0, len(snapshots))
+	for _, odds := range snapshots {
+		selections = append(selections, odds.Selection)
+	}
+	assert.ElementsMatch(t, []string{"Team A", "Team B"}, selections)
+
+	require.NoError(t, history.Trim(ctx))
+	for i, selection := range []string{"Team A", "Team B"} {
+		n, err := nodes[i].XLen(ctx, historyKey("event-123", "match_winner", selection)).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n, "the old %s snapshot should be trimmed", selection)
+	}
+}
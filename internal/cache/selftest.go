@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// ErrSelfTestFailed is returned by SelfTest when Redis accepts connections
+// but cannot round-trip a key
+var ErrSelfTestFailed = errors.New("redis self-test failed")
+
+// selfTestMarket and selfTestTTL describe the sentinel key written by
+// SelfTest; the TTL bounds a sentinel left behind by a failed delete
+const (
+	selfTestMarket = "selftest"
+	selfTestTTL    = time.Minute
+)
+
+// SelfTest writes, reads back and deletes a sentinel odds key, catching a
+// Redis that accepts connections but cannot serve the cache, such as a
+// read-only replica or an ACL without write access to odds keys. The
+// sentinel is a valid odds entry under a unique event ID, so concurrent
+// scans decode it like any other. Cache counters are not touched.
+func (c *RedisCache) SelfTest(ctx context.Context) error {
+	sentinel := &models.OptimizedOdds{
+		ID:          uuid.New(),
+		EventID:     "selftest-" + uuid.NewString(),
+		Market:      selfTestMarket,
+		Selection:   selfTestMarket,
+		OptimizedAt: time.Now().UTC(),
+	}
+	key := c.keys.odds(sentinel.EventID, sentinel.Market, sentinel.Selection)
+	data, err := json.Marshal(sentinel)
+	if err != nil {
+		return fmt.Errorf("%w: failed to marshal sentinel: %v", ErrSelfTestFailed, err)
+	}
+
+	opCtx, cancel := c.withOpTimeout(ctx)
+	defer cancel()
+
+	if err := c.client.Set(opCtx, key, data, selfTestTTL).Err(); err != nil {
+		return fmt.Errorf("%w: SET %s: %v", ErrSelfTestFailed, key, err)
+	}
+
+	read, err := c.client.Get(opCtx, key).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+		return fmt.Errorf("%w: GET %s: key written but not found", ErrSelfTestFailed, key)
+	case err != nil:
+		return fmt.Errorf("%w: GET %s: %v", ErrSelfTestFailed, key, err)
+	case !bytes.Equal(read, data):
+		return fmt.Errorf("%w: GET %s: read back %d bytes differing from the %d written", ErrSelfTestFailed, key, len(read), len(data))
+	}
+
+	if err := c.client.Del(opCtx, key).Err(); err != nil {
+		return fmt.Errorf("%w: DEL %s: %v", ErrSelfTestFailed, key, err)
+	}
+
+	c.logger.Info().Str("key", key).Msg("redis self-test passed")
+	return nil
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readOnlyHook fails write commands like a read-only replica, while reads
+// and PING succeed
+type readOnlyHook struct{}
+
+func (readOnlyHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (readOnlyHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		switch cmd.Name() {
+		case "set", "del":
+			err := errors.New("READONLY You can't write against a read only replica.")
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (readOnlyHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// TestSelfTest tests the round-trip against a working Redis, leaving no
+// sentinel behind and no counters touched
+func TestSelfTest(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute}, zerolog.Nop())
+	defer cache.Close()
+
+	require.NoError(t, cache.SelfTest(context.Background()))
+
+	assert.Empty(t, mr.Keys())
+	assert.Zero(t, cache.Stats())
+}
+
+// TestSelfTest_Failures tests that a Redis accepting connections but unable
+// to round-trip a key fails the self-test with a descriptive error
+func TestSelfTest_Failures(t *testing.T) {
+	t.Run("Read-only replica", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute}, zerolog.Nop())
+		defer cache.Close()
+		cache.client.AddHook(readOnlyHook{})
+
+		require.NoError(t, cache.Ping(context.Background()))
+		err := cache.SelfTest(context.Background())
+
+		require.ErrorIs(t, err, ErrSelfTestFailed)
+		assert.Contains(t, err.Error(), "SET odds:selftest-")
+		assert.Contains(t, err.Error(), "READONLY")
+	})
+
+	t.Run("Redis error", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := NewRedisCache(RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute}, zerolog.Nop())
+		defer cache.Close()
+		mr.SetError("NOPERM this user has no permissions to run the 'set' command")
+
+		err := cache.SelfTest(context.Background())
+
+		require.ErrorIs(t, err, ErrSelfTestFailed)
+		assert.Contains(t, err.Error(), "NOPERM")
+	})
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
 )
 
 // TestLoadConfig_Defaults tests loading configuration with default values
@@ -22,23 +24,88 @@ func TestLoadConfig_Defaults(t *testing.T) {
 	assert.Equal(t, 8081, config.Server.Port)
 	assert.Equal(t, 30*time.Second, config.Server.ReadTimeout)
 	assert.Equal(t, 30*time.Second, config.Server.WriteTimeout)
+	assert.Equal(t, 10*time.Second, config.Server.ShutdownTimeout)
+	assert.Equal(t, "snake", config.Server.JSONCase)
+	assert.False(t, config.Server.Envelope)
+	assert.Zero(t, config.Server.HandlerTimeout)
+	assert.Zero(t, config.Server.EventOddsMaxSelections)
+	assert.Zero(t, config.Server.EventOddsMaxBytes)
 
 	// Verify Kafka defaults
 	assert.Equal(t, []string{"localhost:9092"}, config.Kafka.Brokers)
 	assert.Equal(t, "normalized_odds", config.Kafka.Topic)
 	assert.Equal(t, "odds-optimizer", config.Kafka.GroupID)
+	assert.False(t, config.Kafka.AutoCommit)
+	assert.False(t, config.Kafka.CommitAfterPublish)
+	assert.Equal(t, 1, config.Kafka.MaxInflight)
+	assert.Equal(t, 5*time.Second, config.Kafka.PollTimeout)
+	assert.Zero(t, config.Kafka.SideMergeWindow)
+	assert.Equal(t, "batch", config.Kafka.MessageFormat)
+	assert.Equal(t, 30*time.Second, config.Kafka.MaxPollStaleness)
 
 	// Verify Redis defaults
 	assert.Equal(t, "localhost:6379", config.Redis.Addr)
 	assert.Equal(t, "", config.Redis.Password)
 	assert.Equal(t, 0, config.Redis.DB)
 	assert.Equal(t, 15*time.Minute, config.Redis.TTL)
+	assert.Equal(t, time.Duration(0), config.Redis.PrematchTTL)
+	assert.Equal(t, 30*time.Second, config.Redis.InPlayTTL)
+	assert.Equal(t, 256, config.Redis.MaxKeyComponentLen)
+	assert.True(t, config.Redis.CoalesceEventReads)
+	assert.Equal(t, 1000, config.Redis.PipelineChunk)
+	assert.Zero(t, config.Redis.StaleGrace)
+	assert.False(t, config.Redis.Cluster)
+	assert.False(t, config.Redis.StartupSelftest)
+	assert.False(t, config.Store.Enabled)
+	assert.Equal(t, 1.0, config.Analytics.SampleRate)
+	assert.Equal(t, 1024, config.Store.QueueSize)
+	assert.Equal(t, 5*time.Second, config.Store.WriteTimeout)
+	assert.Equal(t, time.Hour, config.Sinks.ChangeOnly.TTL)
+
+	// Verify history defaults
+	assert.False(t, config.History.Enabled)
+	assert.Equal(t, int64(1000), config.History.MaxLen)
+	assert.Equal(t, 24*time.Hour, config.History.Retention)
+	assert.Equal(t, 5*time.Minute, config.History.TrimInterval)
+
+	// Verify closing line defaults
+	assert.False(t, config.ClosingLine.Enabled)
+	assert.Equal(t, time.Minute, config.ClosingLine.Lead)
+	assert.Equal(t, 10*time.Second, config.ClosingLine.CheckInterval)
+	assert.Equal(t, 7*24*time.Hour, config.ClosingLine.TTL)
+
+	// Verify canary defaults
+	assert.False(t, config.Canary.Enabled)
+	assert.Equal(t, 30*time.Second, config.Canary.Interval)
+	assert.Equal(t, 2*time.Minute, config.Canary.FailureWindow)
+
+	// Verify freshness watchdog defaults
+	assert.False(t, config.Freshness.Enabled)
+	assert.Equal(t, 2*time.Minute, config.Freshness.MaxStaleness)
+	assert.Equal(t, 10*time.Second, config.Freshness.CheckInterval)
 
 	// Verify optimization defaults
 	assert.Equal(t, 0.02, config.Optimization.MinMargin)
 	assert.Equal(t, 0.10, config.Optimization.MaxMargin)
 	assert.Equal(t, 0.05, config.Optimization.MinSpread)
+	assert.Equal(t, 0.0, config.Optimization.MinSpreadPct)
+	assert.Zero(t, config.Optimization.Commission)
+	assert.Equal(t, 1.0, config.Optimization.DrawMultiplier)
+	assert.Equal(t, 1.0, config.Optimization.MissingLayPenalty)
+	assert.Zero(t, config.Optimization.NonFiniteConfidence)
+	assert.Equal(t, 0.001, config.Optimization.MinLayProbability)
+	assert.False(t, config.Optimization.RejectInvalidLay)
+	assert.Zero(t, config.Optimization.LatencyBudget)
+	assert.Equal(t, []string{"Draw", "X", "Tie"}, config.Optimization.DrawLabels)
 	assert.Equal(t, 0.85, config.Optimization.TargetConfidence)
+	assert.Equal(t, "newest", config.Optimization.DuplicatePolicy)
+	assert.Equal(t, "smooth", config.Optimization.LadderPolicy)
+	assert.Empty(t, config.Optimization.SourcePreference)
+	assert.Equal(t, 10000.0, config.Optimization.LiquidityMarginThreshold)
+	assert.Equal(t, 0.0, config.Optimization.MinLiquidity)
+	assert.Equal(t, 20000.0, config.Optimization.LiquidityConfidenceCap)
+	assert.False(t, config.Optimization.AllowEvenMoneyFloor)
+	assert.Equal(t, 1.01, config.Optimization.EvenMoneyFloor)
 
 	// Verify logging defaults
 	assert.Equal(t, "info", config.Logging.Level)
@@ -76,6 +143,9 @@ optimization:
   max_margin: 0.15
   min_spread: 0.08
   target_confidence: 0.90
+  min_margin_sports:
+    - football
+    - tennis
 
 logging:
   level: debug
@@ -113,6 +183,7 @@ logging:
 	assert.Equal(t, 0.15, config.Optimization.MaxMargin)
 	assert.Equal(t, 0.08, config.Optimization.MinSpread)
 	assert.Equal(t, 0.90, config.Optimization.TargetConfidence)
+	assert.Equal(t, []string{"football", "tennis"}, config.Optimization.MinMarginSports)
 
 	// Verify logging config
 	assert.Equal(t, "debug", config.Logging.Level)
@@ -220,7 +291,38 @@ func TestToOptimizationParams(t *testing.T) {
 		MinMargin:        0.03,
 		MaxMargin:        0.12,
 		MinSpread:        0.06,
+		MinSpreadPct:     1.5,
 		TargetConfidence: 0.88,
+		MaxDriftPct:      25,
+
+		MaxTotalOverround:        0.08,
+		Commission:               0.05,
+		DrawMultiplier:           0.8,
+		DrawLabels:               []string{"Empate"},
+		DivisionPrecision:        28,
+		StabilityWeight:          0.3,
+		StabilityWindow:          5,
+		MissingLayPenalty:        0.9,
+		NonFiniteConfidence:      0.5,
+		MinLayProbability:        0.01,
+		RejectInvalidLay:         true,
+		DuplicatePolicy:          "first",
+		LadderPolicy:             "reject",
+		SourcePreference:         []string{"betfair", "pinnacle"},
+		SourceWeights:            map[string]float64{"Pinnacle": 2, "betfair": 0.5},
+		NormalizationMethod:      "power",
+		PricePrecision:           2,
+		MarginPrecision:          4,
+		RoundingMode:             "conservative",
+		LiquidityMarginThreshold: 5000,
+		MinLiquidity:             500,
+		LiquidityConfidenceCap:   50000,
+		BackMarginWeight:         0.7,
+		LayMarginWeight:          0.3,
+		AllowEvenMoneyFloor:      true,
+		EvenMoneyFloor:           1.02,
+		MinPublishConfidence:     0.4,
+		MinConfidenceByMarket:    map[string]float64{"Match_Winner": 0.7},
 	}
 
 	params := optConfig.ToOptimizationParams()
@@ -228,7 +330,38 @@ func TestToOptimizationParams(t *testing.T) {
 	assert.True(t, decimal.NewFromFloat(0.03).Equal(params.MinMargin))
 	assert.True(t, decimal.NewFromFloat(0.12).Equal(params.MaxMargin))
 	assert.True(t, decimal.NewFromFloat(0.06).Equal(params.MinSpread))
+	assert.True(t, decimal.NewFromFloat(1.5).Equal(params.MinSpreadPct))
 	assert.Equal(t, 0.88, params.TargetConfidence)
+	assert.True(t, decimal.NewFromInt(25).Equal(params.MaxDriftPct))
+	assert.True(t, decimal.NewFromFloat(0.08).Equal(params.MaxTotalOverround))
+	assert.True(t, decimal.NewFromFloat(0.05).Equal(params.Commission))
+	assert.True(t, decimal.NewFromFloat(0.8).Equal(params.DrawMultiplier))
+	assert.Equal(t, []string{"Empate"}, params.DrawLabels)
+	assert.Equal(t, int32(28), params.DivisionPrecision)
+	assert.Equal(t, 0.3, params.StabilityWeight)
+	assert.Equal(t, 5, params.StabilityWindow)
+	assert.Equal(t, 0.9, params.MissingLayPenalty)
+	assert.Equal(t, 0.5, params.NonFiniteConfidence)
+	assert.True(t, decimal.NewFromFloat(0.01).Equal(params.MinLayProbability))
+	assert.True(t, params.RejectInvalidLay)
+	assert.Equal(t, "first", params.DuplicatePolicy)
+	assert.Equal(t, "reject", params.LadderPolicy)
+	assert.Equal(t, []string{"betfair", "pinnacle"}, params.SourcePreference)
+	require.Len(t, params.SourceWeights, 2)
+	assert.True(t, decimal.NewFromInt(2).Equal(params.SourceWeights["pinnacle"]))
+	assert.True(t, decimal.NewFromFloat(0.5).Equal(params.SourceWeights["betfair"]))
+	assert.Equal(t, "power", params.NormalizationMethod)
+	assert.Equal(t, int32(2), params.PricePrecision)
+	assert.Equal(t, int32(4), params.MarginPrecision)
+	assert.Equal(t, "conservative", params.RoundingMode)
+	assert.True(t, decimal.NewFromInt(5000).Equal(params.LiquidityMarginThreshold))
+	assert.True(t, decimal.NewFromInt(500).Equal(params.MinLiquidity))
+	assert.True(t, decimal.NewFromInt(50000).Equal(params.LiquidityConfidenceCap))
+	assert.True(t, decimal.NewFromFloat(0.7).Equal(params.BackMarginWeight))
+	assert.True(t, decimal.NewFromFloat(0.3).Equal(params.LayMarginWeight))
+	assert.True(t, decimal.NewFromFloat(1.02).Equal(params.EvenMoneyFloor))
+	assert.Equal(t, 0.4, params.MinPublishConfidence)
+	assert.Equal(t, map[string]float64{"match_winner": 0.7}, params.MinConfidenceByMarket)
 }
 
 // TestToOptimizationParams_ZeroValues tests conversion with zero values
@@ -525,3 +658,185 @@ func TestConfig_AllFields(t *testing.T) {
 	assert.NotEmpty(t, config.Logging.Level)
 	assert.NotEmpty(t, config.Logging.Format)
 }
+
+// TestToProfileParams tests that profiles inherit unset values from the top level
+func TestToProfileParams(t *testing.T) {
+	optConfig := OptimizationConfig{
+		MinMargin:        0.02,
+		MaxMargin:        0.10,
+		MinSpread:        0.05,
+		TargetConfidence: 0.85,
+		Profiles: map[string]ProfileConfig{
+			"aggressive":   {MinMargin: 0.05, MaxMargin: 0.20},
+			"conservative": {TargetConfidence: 0.95},
+		},
+	}
+
+	profiles := optConfig.ToProfileParams()
+
+	require.Len(t, profiles, 2)
+	assert.True(t, decimal.NewFromFloat(0.05).Equal(profiles["aggressive"].MinMargin))
+	assert.True(t, decimal.NewFromFloat(0.20).Equal(profiles["aggressive"].MaxMargin))
+	assert.True(t, decimal.NewFromFloat(0.05).Equal(profiles["aggressive"].MinSpread))
+	assert.Equal(t, 0.85, profiles["aggressive"].TargetConfidence)
+	assert.True(t, decimal.NewFromFloat(0.02).Equal(profiles["conservative"].MinMargin))
+	assert.Equal(t, 0.95, profiles["conservative"].TargetConfidence)
+}
+
+// TestLoadConfig_Profiles tests loading named profiles from file
+func TestLoadConfig_Profiles(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+optimization:
+  profiles:
+    aggressive:
+      min_margin: 0.05
+      max_margin: 0.20
+    conservative:
+      min_margin: 0.01
+`)
+	require.NoError(t, err)
+	tmpFile.Close()
+
+	config, err := LoadConfig(tmpFile.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]ProfileConfig{
+		"aggressive":   {MinMargin: 0.05, MaxMargin: 0.20},
+		"conservative": {MinMargin: 0.01},
+	}, config.Optimization.Profiles)
+}
+
+// TestLoadConfig_ConfidenceBounds tests loading per-sport confidence bounds
+func TestLoadConfig_ConfidenceBounds(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+optimization:
+  confidence_bounds:
+    darts:
+      max: 0.9
+    football:
+      min: 0.6
+`)
+	require.NoError(t, err)
+	tmpFile.Close()
+
+	config, err := LoadConfig(tmpFile.Name())
+	require.NoError(t, err)
+
+	params := config.Optimization.ToOptimizationParams()
+	assert.Equal(t, map[string]models.ConfidenceBounds{
+		"darts":    {Min: 0, Max: 0.9},
+		"football": {Min: 0.6, Max: 1},
+	}, params.ConfidenceBounds)
+}
+
+// TestLoadConfig_RegionRules tests loading ordered region rules, with region
+// codes lowercased
+func TestLoadConfig_RegionRules(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+optimization:
+  region_rules:
+    - sport: football
+      market: first_scorer
+      regions: [GB, " ie "]
+    - competition: College Football
+      regions: [us-nj]
+`)
+	require.NoError(t, err)
+	tmpFile.Close()
+
+	config, err := LoadConfig(tmpFile.Name())
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	params := config.Optimization.ToOptimizationParams()
+	assert.Equal(t, []models.RegionRule{
+		{Sport: "football", Market: "first_scorer", Regions: []string{"gb", "ie"}},
+		{Competition: "College Football", Regions: []string{"us-nj"}},
+	}, params.RegionRules)
+}
+
+// TestLoadConfig_MarginOverrides tests loading margin overrides at each level
+func TestLoadConfig_MarginOverrides(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+optimization:
+  margin_overrides:
+    - sport: football
+      sport_multiplier: 0.9
+    - sport: football
+      market: match_winner
+      max_margin: 0.06
+    - sport: football
+      competition: Premier League
+      min_margin: 0.01
+      sport_multiplier: 0.6
+`)
+	require.NoError(t, err)
+	tmpFile.Close()
+
+	config, err := LoadConfig(tmpFile.Name())
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	params := config.Optimization.ToOptimizationParams()
+	require.Len(t, params.MarginOverrides, 3)
+	assert.Equal(t, "football", params.MarginOverrides[0].Sport)
+	assert.True(t, decimal.NewFromFloat(0.9).Equal(params.MarginOverrides[0].SportMultiplier))
+	assert.Equal(t, "match_winner", params.MarginOverrides[1].Market)
+	assert.True(t, decimal.NewFromFloat(0.06).Equal(params.MarginOverrides[1].MaxMargin))
+	assert.True(t, params.MarginOverrides[1].MinMargin.IsZero())
+	assert.Equal(t, "Premier League", params.MarginOverrides[2].Competition)
+	assert.True(t, decimal.NewFromFloat(0.01).Equal(params.MarginOverrides[2].MinMargin))
+}
+
+// TestLoadConfig_EnvironmentOnly tests configuring nested keys, lists, durations
+// and maps purely from environment variables
+func TestLoadConfig_EnvironmentOnly(t *testing.T) {
+	t.Setenv("ODDS_OPTIMIZER_KAFKA_BROKERS", "kafka-0:9092, kafka-1:9092,kafka-2:9092")
+	t.Setenv("ODDS_OPTIMIZER_KAFKA_GAP_THRESHOLD", "45s")
+	t.Setenv("ODDS_OPTIMIZER_REDIS_TTL", "2m")
+	t.Setenv("ODDS_OPTIMIZER_SERVER_TLS_ENABLED", "true")
+	t.Setenv("ODDS_OPTIMIZER_SINKS_WEBHOOK_URL", "https://client.example.com/odds")
+	t.Setenv("ODDS_OPTIMIZER_ALERTING_WEBHOOK_URL", "https://pager.example.com/alerts")
+	t.Setenv("ODDS_OPTIMIZER_ALERTING_MIN_SAMPLES", "250")
+	t.Setenv("ODDS_OPTIMIZER_OPTIMIZATION_MIN_MARGIN_SPORTS", "football,tennis")
+	t.Setenv("ODDS_OPTIMIZER_OPTIMIZATION_PROFILES", `{"aggressive": {"min_margin": 0.05, "max_margin": 0.2}}`)
+	t.Setenv("ODDS_OPTIMIZER_OPTIMIZATION_CONFIDENCE_BOUNDS", `{"darts": {"max": 0.9}}`)
+	t.Setenv("ODDS_OPTIMIZER_OPTIMIZATION_FX_RATES", `{"gbp": 1.25, "EUR": 1.1}`)
+
+	config, err := LoadConfig("")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"kafka-0:9092", "kafka-1:9092", "kafka-2:9092"}, config.Kafka.Brokers)
+	assert.Equal(t, 45*time.Second, config.Kafka.GapThreshold)
+	assert.Equal(t, 2*time.Minute, config.Redis.TTL)
+	assert.True(t, config.Server.TLS.Enabled)
+	assert.Equal(t, "https://client.example.com/odds", config.Sinks.Webhook.URL)
+	assert.Equal(t, "https://pager.example.com/alerts", config.Alerting.WebhookURL)
+	assert.Equal(t, uint64(250), config.Alerting.MinSamples)
+	assert.Equal(t, 15*time.Minute, config.Alerting.Debounce)
+	assert.Equal(t, []string{"football", "tennis"}, config.Optimization.MinMarginSports)
+	assert.Equal(t, map[string]ProfileConfig{"aggressive": {MinMargin: 0.05, MaxMargin: 0.2}}, config.Optimization.Profiles)
+	assert.Equal(t, map[string]ConfidenceBoundsConfig{"darts": {Max: 0.9}}, config.Optimization.ConfidenceBounds)
+
+	params := config.Optimization.ToOptimizationParams()
+	assert.Equal(t, "USD", params.BaseCurrency)
+	require.Len(t, params.FXRates, 2)
+	assert.True(t, decimal.NewFromFloat(1.25).Equal(params.FXRates["GBP"]))
+	assert.True(t, decimal.NewFromFloat(1.1).Equal(params.FXRates["EUR"]))
+}
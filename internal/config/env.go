@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// Environment variables override every config key: ODDS_OPTIMIZER_ followed
+// by the key path in upper case with dots replaced by underscores, e.g.
+// ODDS_OPTIMIZER_KAFKA_GAP_THRESHOLD=30s. Values are decoded by field type:
+//   - lists are comma-separated; whitespace around items is trimmed,
+//     e.g. ODDS_OPTIMIZER_KAFKA_BROKERS=kafka-0:9092,kafka-1:9092
+//   - durations use Go syntax, e.g. 500ms, 1m30s
+//   - maps (per-sport and per-profile settings) are JSON objects, e.g.
+//     ODDS_OPTIMIZER_OPTIMIZATION_CONFIDENCE_BOUNDS={"darts":{"max":0.9}}
+const envListDelimiter = ","
+
+// bindEnvKeys binds every key of the Config struct to its environment
+// variable, so keys without a default can be set from the environment too
+func bindEnvKeys(v *viper.Viper) error {
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		if err := v.BindEnv(key); err != nil {
+			return fmt.Errorf("failed to bind env for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// configKeys lists the dotted mapstructure keys of t's leaf fields. Maps are
+// leaves: their keys are data, not configuration structure.
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(field.Type, key+".")...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// envDecodeHook decodes environment variable strings into durations, lists and maps
+func envDecodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		stringToTrimmedSliceHookFunc(envListDelimiter),
+		jsonStringToMapHookFunc(),
+	)
+}
+
+// stringToTrimmedSliceHookFunc splits a string on sep into a slice, trimming
+// whitespace around each item and dropping empty items
+func stringToTrimmedSliceHookFunc(sep string) mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Slice {
+			return data, nil
+		}
+
+		items := []string{}
+		for _, item := range strings.Split(data.(string), sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+}
+
+// jsonStringToMapHookFunc parses a JSON object string destined for a map
+func jsonStringToMapHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Map {
+			return data, nil
+		}
+
+		raw := strings.TrimSpace(data.(string))
+		if raw == "" {
+			return map[string]interface{}{}, nil
+		}
+
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+			return nil, fmt.Errorf("expected a JSON object: %w", err)
+		}
+		return decoded, nil
+	}
+}
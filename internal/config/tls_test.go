@@ -0,0 +1,161 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert writes a self-signed certificate for 127.0.0.1, usable
+// as server cert, client cert and CA, returning the cert and key file paths
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "odds-optimizer-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
+// serveTLS starts an HTTPS server with tlsConfig, as main does, returning its URL
+func serveTLS(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		}),
+		TLSConfig: tlsConfig,
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+// newTLSClient creates a client trusting certFile, presenting it when withClientCert is set
+func newTLSClient(t *testing.T, certFile, keyFile string, withClientCert bool) *http.Client {
+	t.Helper()
+
+	caPEM, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caPEM))
+
+	clientTLS := &tls.Config{RootCAs: roots}
+	if withClientCert {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		require.NoError(t, err)
+		clientTLS.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: clientTLS},
+		Timeout:   5 * time.Second,
+	}
+}
+
+// TestServerTLSConfig tests serving over TLS with a self-signed certificate
+func TestServerTLSConfig(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+
+	tlsConfig, err := TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile}.ServerTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+
+	url := serveTLS(t, tlsConfig)
+	resp, err := newTLSClient(t, certFile, keyFile, false).Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", string(body))
+	require.NotNil(t, resp.TLS)
+}
+
+// TestServerTLSConfig_ClientCA tests that a client CA requires verified client certificates
+func TestServerTLSConfig_ClientCA(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+
+	tlsConfig, err := TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientCA: certFile}.ServerTLSConfig()
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+
+	url := serveTLS(t, tlsConfig)
+
+	// No client certificate: handshake rejected
+	_, err = newTLSClient(t, certFile, keyFile, false).Get(url)
+	assert.Error(t, err)
+
+	// Client certificate signed by the CA: accepted
+	resp, err := newTLSClient(t, certFile, keyFile, true).Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// TestServerTLSConfig_Invalid tests configuration errors
+func TestServerTLSConfig_Invalid(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+	emptyCA := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(emptyCA, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name   string
+		config TLSConfig
+	}{
+		{name: "Missing key file", config: TLSConfig{Enabled: true, CertFile: certFile}},
+		{name: "Unreadable certificate", config: TLSConfig{Enabled: true, CertFile: "missing.pem", KeyFile: keyFile}},
+		{name: "Missing client CA", config: TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientCA: "missing.pem"}},
+		{name: "Client CA without certificates", config: TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientCA: emptyCA}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.ServerTLSConfig()
+			assert.Error(t, err)
+		})
+	}
+}
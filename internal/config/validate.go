@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// Validate checks the configuration for values the service would reject or
+// silently misinterpret at runtime, returning every problem found joined
+// into one error, or nil when the configuration is valid
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port %d outside 1-65535", c.Server.Port)
+	check(c.Server.JSONCase == "" || c.Server.JSONCase == "snake" || c.Server.JSONCase == "camel",
+		"server.json_case %q is not snake or camel", c.Server.JSONCase)
+	check(!c.Server.TLS.Enabled || (c.Server.TLS.CertFile != "" && c.Server.TLS.KeyFile != ""),
+		"server.tls requires cert_file and key_file when enabled")
+	check(c.Server.EventOddsMaxSelections >= 0 && c.Server.EventOddsMaxBytes >= 0,
+		"server.event_odds_max_selections and event_odds_max_bytes must not be negative")
+	check(c.Server.HandlerTimeout >= 0, "server.handler_timeout %s is negative", c.Server.HandlerTimeout)
+	check(c.Server.HandlerTimeout == 0 || c.Server.WriteTimeout <= 0 || c.Server.HandlerTimeout < c.Server.WriteTimeout,
+		"server.handler_timeout %s must be below server.write_timeout %s", c.Server.HandlerTimeout, c.Server.WriteTimeout)
+
+	check(len(c.Kafka.Brokers) > 0, "kafka.brokers is empty")
+	check(c.Kafka.Topic != "", "kafka.topic is empty")
+	check(c.Kafka.GroupID != "", "kafka.group_id is empty")
+	check(c.Kafka.GapConfidencePenalty >= 0 && c.Kafka.GapConfidencePenalty <= 1,
+		"kafka.gap_confidence_penalty %v outside [0, 1]", c.Kafka.GapConfidencePenalty)
+	check(c.Kafka.SideMergeWindow >= 0, "kafka.side_merge_window %s is negative", c.Kafka.SideMergeWindow)
+	check(c.Kafka.MessageFormat == "batch" || c.Kafka.MessageFormat == "single",
+		"kafka.message_format %q is not batch or single", c.Kafka.MessageFormat)
+
+	check(!c.Freshness.Enabled || c.Freshness.MaxStaleness > 0, "freshness.max_staleness must be positive while freshness.enabled")
+
+	check(c.Redis.Addr != "", "redis.addr is empty")
+	check(!c.Redis.Cluster || c.Redis.DB == 0, "redis.db %d must be 0 in cluster mode", c.Redis.DB)
+	check(c.Redis.StaleGrace >= 0, "redis.stale_grace %s is negative", c.Redis.StaleGrace)
+	check(c.Redis.PipelineChunk >= 0, "redis.pipeline_chunk %d is negative", c.Redis.PipelineChunk)
+
+	check(c.Sinks.ChangeOnly.MinChange >= 0 && c.Sinks.ChangeOnly.MinChangePct >= 0,
+		"sinks.change_only.min_change and min_change_pct must not be negative")
+	check(c.Sinks.ChangeOnly.TTL > 0, "sinks.change_only.ttl %s must be positive", c.Sinks.ChangeOnly.TTL)
+	check(c.Analytics.SampleRate >= 0 && c.Analytics.SampleRate <= 1,
+		"analytics.sample_rate %v outside [0, 1]", c.Analytics.SampleRate)
+	check(!c.Store.Enabled || c.Store.DSN != "", "store.dsn is empty while store.enabled")
+	check(c.Store.QueueSize >= 0, "store.queue_size %d is negative", c.Store.QueueSize)
+
+	errs = append(errs, c.Optimization.validate("optimization")...)
+	for _, name := range sortedKeys(c.Optimization.Profiles) {
+		merged := c.Optimization.withProfile(c.Optimization.Profiles[name])
+		errs = append(errs, merged.validateMargins("optimization.profiles."+name)...)
+	}
+
+	_, err := zerolog.ParseLevel(c.Logging.Level)
+	check(err == nil, "logging.level %q is not a log level", c.Logging.Level)
+	check(c.Logging.Format == "json" || c.Logging.Format == "console",
+		"logging.format %q is not json or console", c.Logging.Format)
+
+	return errors.Join(errs...)
+}
+
+// validate checks optimization parameters, naming them under prefix
+func (c *OptimizationConfig) validate(prefix string) []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(prefix+"."+format, args...))
+		}
+	}
+
+	errs = append(errs, c.validateMargins(prefix)...)
+
+	check(c.BackMarginWeight >= 0 && c.LayMarginWeight >= 0, "back_margin_weight and lay_margin_weight must not be negative")
+	check(c.Commission >= 0 && c.Commission < 1, "commission %v outside [0, 1)", c.Commission)
+	check(c.DrawMultiplier > 0, "draw_multiplier %v must be positive", c.DrawMultiplier)
+	check(c.StabilityWeight >= 0 && c.StabilityWeight <= 1, "stability_weight %v outside [0, 1]", c.StabilityWeight)
+	check(c.MissingLayPenalty > 0 && c.MissingLayPenalty <= 1, "missing_lay_penalty %v outside (0, 1]", c.MissingLayPenalty)
+	check(c.NonFiniteConfidence >= 0 && c.NonFiniteConfidence <= 1, "non_finite_confidence %v outside [0, 1]", c.NonFiniteConfidence)
+	check(c.MinLayProbability > 0 && c.MinLayProbability < 0.5, "min_lay_probability %v outside (0, 0.5)", c.MinLayProbability)
+	check(c.MaxServeAge >= 0, "max_serve_age %s is negative", c.MaxServeAge)
+	check(c.LatencyBudget >= 0, "latency_budget %s is negative", c.LatencyBudget)
+	check(c.MinLiquidity >= 0, "min_liquidity %v is negative", c.MinLiquidity)
+	check(c.DivisionPrecision >= 0, "division_precision %d is negative", c.DivisionPrecision)
+	check(c.PricePrecision >= 0, "price_precision %d is negative", c.PricePrecision)
+	check(c.MarginPrecision >= 0, "margin_precision %d is negative", c.MarginPrecision)
+	check(!c.AllowEvenMoneyFloor || c.EvenMoneyFloor > 1, "even_money_floor %v must be above 1", c.EvenMoneyFloor)
+
+	check(oneOf(c.DuplicatePolicy, optimizer.DuplicateKeepNewest, optimizer.DuplicateKeepFirst, optimizer.DuplicateKeepLast, optimizer.DuplicateConsensus),
+		"duplicate_policy %q is not newest, first, last or consensus", c.DuplicatePolicy)
+	check(oneOf(c.LadderPolicy, optimizer.LadderSmooth, optimizer.LadderReject),
+		"ladder_policy %q is not smooth or reject", c.LadderPolicy)
+	check(oneOf(c.NormalizationMethod, optimizer.NormalizationProportional, optimizer.NormalizationPower, optimizer.NormalizationLogOdds),
+		"normalization_method %q is not proportional, power or log_odds", c.NormalizationMethod)
+	check(oneOf(c.RoundingMode, optimizer.RoundHalfUp, optimizer.RoundHalfEven, optimizer.RoundConservative),
+		"rounding_mode %q is not half_up, half_even or conservative", c.RoundingMode)
+
+	check(c.MinPublishConfidence >= 0 && c.MinPublishConfidence <= 1, "min_publish_confidence %v outside [0, 1]", c.MinPublishConfidence)
+	for _, market := range sortedKeys(c.MinConfidenceByMarket) {
+		floor := c.MinConfidenceByMarket[market]
+		check(floor >= 0 && floor <= 1, "min_confidence_by_market.%s %v outside [0, 1]", market, floor)
+	}
+	for _, sport := range sortedKeys(c.ConfidenceBounds) {
+		bounds := c.ConfidenceBounds[sport]
+		check(bounds.Min >= 0 && bounds.Min <= 1 && bounds.Max >= 0 && bounds.Max <= 1,
+			"confidence_bounds.%s {%v, %v} outside [0, 1]", sport, bounds.Min, bounds.Max)
+		check(bounds.Max == 0 || bounds.Min <= bounds.Max, "confidence_bounds.%s min %v above max %v", sport, bounds.Min, bounds.Max)
+	}
+	for i, rule := range c.RegionRules {
+		check(len(rule.Regions) > 0, "region_rules[%d] lists no regions", i)
+		for _, region := range rule.Regions {
+			check(strings.TrimSpace(region) != "", "region_rules[%d] has an empty region", i)
+		}
+	}
+	for i, override := range c.MarginOverrides {
+		check(override.Sport != "" || override.Competition != "", "margin_overrides[%d] names no sport or competition", i)
+		check(override.MinMargin >= 0 && override.MaxMargin >= 0 && override.SportMultiplier >= 0,
+			"margin_overrides[%d] values must not be negative", i)
+		check(override.MaxMargin == 0 || override.MinMargin <= override.MaxMargin,
+			"margin_overrides[%d] min_margin %v above max_margin %v", i, override.MinMargin, override.MaxMargin)
+	}
+	for _, source := range sortedKeys(c.SourceWeights) {
+		check(c.SourceWeights[source] >= 0, "source_weights.%s %v is negative", source, c.SourceWeights[source])
+	}
+	for _, currency := range sortedKeys(c.FXRates) {
+		check(c.FXRates[currency] > 0, "fx_rates.%s %v must be positive", currency, c.FXRates[currency])
+	}
+
+	return errs
+}
+
+// validateMargins checks the margin, spread and confidence parameters a
+// profile can override, naming them under prefix
+func (c *OptimizationConfig) validateMargins(prefix string) []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(prefix+"."+format, args...))
+		}
+	}
+
+	check(c.MinMargin >= 0, "min_margin %v is negative", c.MinMargin)
+	check(c.MaxMargin >= c.MinMargin, "max_margin %v below min_margin %v", c.MaxMargin, c.MinMargin)
+	check(c.MaxMargin < 1, "max_margin %v must be below 1", c.MaxMargin)
+	check(c.MinSpread >= 0, "min_spread %v is negative", c.MinSpread)
+	check(c.TargetConfidence >= 0 && c.TargetConfidence <= 1, "target_confidence %v outside [0, 1]", c.TargetConfidence)
+
+	return errs
+}
+
+// oneOf reports whether value is empty (the default) or one of allowed
+func oneOf(value string, allowed ...string) bool {
+	return value == "" || slices.Contains(allowed, value)
+}
+
+// sortedKeys returns a map's keys in order, so problems are reported deterministically
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
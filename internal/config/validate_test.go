@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidate tests that the defaults are valid and that each problem is reported
+func TestValidate(t *testing.T) {
+	config, err := LoadConfig("")
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	config.Server.Port = 0
+	config.Server.HandlerTimeout = time.Minute
+	config.Optimization.RoundingMode = "up"
+	config.Optimization.MinLiquidity = -1
+	config.Optimization.NonFiniteConfidence = 1.5
+	config.Optimization.FXRates = map[string]float64{"gbp": 0}
+	config.Optimization.SourceWeights = map[string]float64{"pinnacle": -1}
+	config.Optimization.ConfidenceBounds = map[string]ConfidenceBoundsConfig{"tennis": {Min: 0.8, Max: 0.5}}
+	config.Optimization.RegionRules = []RegionRuleConfig{{Market: "first_scorer"}}
+	config.Optimization.MarginOverrides = []MarginOverrideConfig{{Market: "match_winner"}, {Sport: "football", MinMargin: 0.05, MaxMargin: 0.03}}
+	config.Analytics.SampleRate = 1.5
+	config.Sinks.ChangeOnly.TTL = 0
+	config.Logging.Level = "loud"
+
+	err = config.Validate()
+	require.Error(t, err)
+	for _, problem := range []string{
+		"server.port 0",
+		"server.handler_timeout 1m0s must be below server.write_timeout 30s",
+		"optimization.rounding_mode \"up\"",
+		"optimization.min_liquidity -1 is negative",
+		"optimization.non_finite_confidence 1.5 outside [0, 1]",
+		"optimization.fx_rates.gbp",
+		"optimization.source_weights.pinnacle -1 is negative",
+		"optimization.confidence_bounds.tennis min 0.8 above max 0.5",
+		"optimization.region_rules[0] lists no regions",
+		"optimization.margin_overrides[0] names no sport or competition",
+		"optimization.margin_overrides[1] min_margin 0.05 above max_margin 0.03",
+		"analytics.sample_rate 1.5",
+		"sinks.change_only.ttl 0s must be positive",
+		"logging.level \"loud\"",
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
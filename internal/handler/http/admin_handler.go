@@ -0,0 +1,388 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/messaging"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+)
+
+// ConsumerStatsProvider exposes runtime counters of the Kafka consumer
+type ConsumerStatsProvider interface {
+	Stats() messaging.ConsumerStats
+}
+
+// ConsumerSeeker repositions the running Kafka consumer
+type ConsumerSeeker interface {
+	Seek(ctx context.Context, req messaging.SeekRequest) error
+}
+
+// ConsumerPauser pauses and resumes fetching of the running Kafka consumer
+type ConsumerPauser interface {
+	Pause()
+	Resume()
+	Paused() bool
+}
+
+// AdminHandler handles operational HTTP requests for on-call diagnosis
+type AdminHandler struct {
+	service   *service.OptimizerService
+	consumer  ConsumerStatsProvider
+	seeker    ConsumerSeeker
+	pauser    ConsumerPauser
+	apiKey    string
+	startedAt time.Time
+	logger    zerolog.Logger
+}
+
+// NewAdminHandler creates a new admin HTTP handler.
+// All admin routes require the X-API-Key header to match apiKey;
+// an empty apiKey disables the admin API.
+func NewAdminHandler(
+	service *service.OptimizerService,
+	consumer ConsumerStatsProvider,
+	apiKey string,
+	logger zerolog.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		service:   service,
+		consumer:  consumer,
+		apiKey:    apiKey,
+		startedAt: time.Now().UTC(),
+		logger:    logger.With().Str("component", "admin_handler").Logger(),
+	}
+}
+
+// RegisterRoutes registers admin HTTP routes with the provided mux
+func (h *AdminHandler) RegisterRoutes(mux *http.ServeMux) {
+	// GET /api/v1/admin/status - Effective params and runtime stats
+	mux.HandleFunc("/api/v1/admin/status", h.requireAPIKey(h.handleStatus))
+
+	// POST /api/v1/admin/consumer/seek - Reprocess from an offset or timestamp
+	mux.HandleFunc("/api/v1/admin/consumer/seek", h.requireAPIKey(h.handleConsumerSeek))
+
+	// POST /api/v1/admin/consumer/pause - Stop fetching, keeping the consumer position
+	mux.HandleFunc("/api/v1/admin/consumer/pause", h.requireAPIKey(h.handleConsumerPause))
+
+	// POST /api/v1/admin/consumer/resume - Resume fetching after a pause
+	mux.HandleFunc("/api/v1/admin/consumer/resume", h.requireAPIKey(h.handleConsumerResume))
+
+	// GET /api/v1/admin/export[?event_id=|?sport=] - Download cached odds as NDJSON
+	mux.HandleFunc("/api/v1/admin/export", h.requireAPIKey(h.handleExport))
+
+	// POST /api/v1/admin/import - Bulk-load the cache from an NDJSON export
+	mux.HandleFunc("/api/v1/admin/import", h.requireAPIKey(h.handleImport))
+}
+
+// UntimedPaths returns the admin routes a TimeoutHandler must exempt: the
+// export streams the whole cache and the import loads it, both for as long
+// as that takes, and a seek waits for the consumer to drain its in-flight work
+func (h *AdminHandler) UntimedPaths() []string {
+	return []string{
+		"/api/v1/admin/consumer/seek",
+		"/api/v1/admin/export",
+		"/api/v1/admin/import",
+	}
+}
+
+// SetSeeker sets the consumer repositioned by POST /api/v1/admin/consumer/seek;
+// the endpoint is unavailable until set
+func (h *AdminHandler) SetSeeker(seeker ConsumerSeeker) {
+	h.seeker = seeker
+}
+
+// SetPauser sets the consumer paused and resumed by POST
+// /api/v1/admin/consumer/pause and /resume; the endpoints are unavailable
+// until set
+func (h *AdminHandler) SetPauser(pauser ConsumerPauser) {
+	h.pauser = pauser
+}
+
+// requireAPIKey rejects requests without a matching X-API-Key header
+func (h *AdminHandler) requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if h.apiKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(h.apiKey)) != 1 {
+			h.logger.Warn().
+				Str("path", r.URL.Path).
+				Str("remote_addr", r.RemoteAddr).
+				Msg("rejected admin request with invalid API key")
+			writeError(w, h.logger, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// StatusResponse is the response body of GET /api/v1/admin/status
+type StatusResponse struct {
+	Service   ServiceStatus   `json:"service"`
+	Optimizer OptimizerStatus `json:"optimizer"`
+	Cache     CacheStatus     `json:"cache"`
+	Consumer  ConsumerStatus  `json:"consumer"`
+}
+
+// ServiceStatus describes the running process
+type ServiceStatus struct {
+	StartedAt     string  `json:"started_at"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// OptimizerStatus holds effective optimizer params and counters
+type OptimizerStatus struct {
+	MinMargin        string  `json:"min_margin"`
+	MaxMargin        string  `json:"max_margin"`
+	MinSpread        string  `json:"min_spread"`
+	TargetConfidence float64 `json:"target_confidence"`
+	Optimized        uint64  `json:"optimized_total"`
+	Rejected         uint64  `json:"rejected_total"`
+}
+
+// CacheStatus holds cache operational counters
+type CacheStatus struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	Errors   uint64  `json:"errors"`
+	Sets     uint64  `json:"sets"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// ConsumerStatus holds Kafka consumer counters and the lag snapshot
+type ConsumerStatus struct {
+	MessagesProcessed uint64 `json:"messages_processed"`
+	MessagesFailed    uint64 `json:"messages_failed"`
+	OddsProcessed     uint64 `json:"odds_processed"`
+	LastOffset        int64  `json:"last_offset"`
+	Lag               int64  `json:"lag"`
+	Paused            bool   `json:"paused"`
+}
+
+// handleStatus handles GET /api/v1/admin/status
+func (h *AdminHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	params := h.service.Params()
+	optStats := h.service.OptimizerStats()
+	cacheStats := h.service.CacheStats()
+
+	resp := StatusResponse{
+		Service: ServiceStatus{
+			StartedAt:     h.startedAt.Format(time.RFC3339),
+			UptimeSeconds: time.Since(h.startedAt).Seconds(),
+		},
+		Optimizer: OptimizerStatus{
+			MinMargin:        params.MinMargin.String(),
+			MaxMargin:        params.MaxMargin.String(),
+			MinSpread:        params.MinSpread.String(),
+			TargetConfidence: params.TargetConfidence,
+			Optimized:        optStats.Optimized,
+			Rejected:         optStats.Rejected,
+		},
+		Cache: CacheStatus{
+			Hits:     cacheStats.Hits,
+			Misses:   cacheStats.Misses,
+			Errors:   cacheStats.Errors,
+			Sets:     cacheStats.Sets,
+			HitRatio: cacheStats.HitRatio(),
+		},
+	}
+
+	if h.consumer != nil {
+		consumerStats := h.consumer.Stats()
+		resp.Consumer = ConsumerStatus{
+			MessagesProcessed: consumerStats.MessagesProcessed,
+			MessagesFailed:    consumerStats.MessagesFailed,
+			OddsProcessed:     consumerStats.OddsProcessed,
+			LastOffset:        consumerStats.LastOffset,
+			Lag:               consumerStats.Lag,
+			Paused:            consumerStats.Paused,
+		}
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, resp)
+}
+
+// ConsumerSeekRequest is the request body of POST /api/v1/admin/consumer/seek.
+// Exactly one of Offset and Timestamp is required.
+type ConsumerSeekRequest struct {
+	Partition int        `json:"partition"`
+	Offset    *int64     `json:"offset,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"` // RFC 3339; seeks to the first offset at or after it
+}
+
+// handleConsumerSeek handles POST /api/v1/admin/consumer/seek
+func (h *AdminHandler) handleConsumerSeek(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.seeker == nil {
+		writeError(w, h.logger, http.StatusServiceUnavailable, "consumer seek is not available")
+		return
+	}
+
+	var body ConsumerSeekRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, h.logger, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if (body.Offset == nil) == (body.Timestamp == nil) {
+		writeError(w, h.logger, http.StatusBadRequest, "exactly one of offset and timestamp is required")
+		return
+	}
+
+	req := messaging.SeekRequest{Partition: body.Partition}
+	if body.Offset != nil {
+		req.Offset = *body.Offset
+	} else {
+		req.At = *body.Timestamp
+	}
+
+	err := h.seeker.Seek(r.Context(), req)
+	switch {
+	case errors.Is(err, messaging.ErrInvalidSeek):
+		writeError(w, h.logger, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, messaging.ErrConsumerNotRunning):
+		writeError(w, h.logger, http.StatusServiceUnavailable, "kafka consumer is not running")
+		return
+	case err != nil:
+		h.logger.Error().Err(err).Int("partition", body.Partition).Msg("failed to seek consumer")
+		writeError(w, h.logger, http.StatusInternalServerError, "failed to seek consumer")
+		return
+	}
+
+	h.logger.Warn().
+		Int("partition", body.Partition).
+		Str("remote_addr", r.RemoteAddr).
+		Msg("consumer seek requested via admin API")
+
+	writeJSON(w, h.logger, http.StatusOK, body)
+}
+
+// ConsumerPauseResponse is the response body of POST
+// /api/v1/admin/consumer/pause and /resume
+type ConsumerPauseResponse struct {
+	Paused bool `json:"paused"`
+}
+
+// handleConsumerPause handles POST /api/v1/admin/consumer/pause
+func (h *AdminHandler) handleConsumerPause(w http.ResponseWriter, r *http.Request) {
+	h.setConsumerPaused(w, r, true)
+}
+
+// handleConsumerResume handles POST /api/v1/admin/consumer/resume
+func (h *AdminHandler) handleConsumerResume(w http.ResponseWriter, r *http.Request) {
+	h.setConsumerPaused(w, r, false)
+}
+
+// setConsumerPaused pauses or resumes the consumer, answering with the new state
+func (h *AdminHandler) setConsumerPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.pauser == nil {
+		writeError(w, h.logger, http.StatusServiceUnavailable, "consumer pause is not available")
+		return
+	}
+
+	if paused {
+		h.pauser.Pause()
+	} else {
+		h.pauser.Resume()
+	}
+
+	h.logger.Warn().
+		Bool("paused", paused).
+		Str("remote_addr", r.RemoteAddr).
+		Msg("consumer pause state changed via admin API")
+
+	writeJSON(w, h.logger, http.StatusOK, ConsumerPauseResponse{Paused: h.pauser.Paused()})
+}
+
+// handleExport handles GET /api/v1/admin/export, streaming cached odds as
+// newline-delimited JSON, one OptimizedOdds per line
+func (h *AdminHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	eventID := r.URL.Query().Get("event_id")
+	sport := r.URL.Query().Get("sport")
+
+	// Headers go out with the first line; an error before then is still a clean 500
+	started := false
+	encoder := json.NewEncoder(w)
+	err := h.service.ExportOdds(r.Context(), eventID, sport, func(odds *models.OptimizedOdds) error {
+		if !started {
+			started = true
+			h.setExportHeaders(w)
+			w.WriteHeader(http.StatusOK)
+		}
+		return encoder.Encode(odds)
+	})
+	if err != nil {
+		h.logger.Error().
+			Err(err).
+			Str("event_id", eventID).
+			Str("sport", sport).
+			Bool("partial", started).
+			Msg("failed to export odds")
+		if !started {
+			writeError(w, h.logger, http.StatusInternalServerError, "failed to export odds")
+		}
+		return
+	}
+
+	if !started {
+		h.setExportHeaders(w)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// setExportHeaders marks the response as a downloadable NDJSON snapshot
+func (h *AdminHandler) setExportHeaders(w http.ResponseWriter) {
+	filename := "odds-export-" + time.Now().UTC().Format("20060102T150405Z") + ".ndjson"
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+}
+
+// handleImport handles POST /api/v1/admin/import, caching each
+// newline-delimited OptimizedOdds in the body and reporting how many were
+// imported and how many failed
+func (h *AdminHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, h.logger, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	result, err := h.service.ImportOdds(r.Context(), r.Body)
+	if err != nil {
+		h.logger.Error().
+			Err(err).
+			Int("imported", result.Imported).
+			Int("failed", result.Failed).
+			Msg("failed to read import")
+		writeJSON(w, h.logger, http.StatusBadRequest, map[string]interface{}{
+			"error":    "failed to read import",
+			"imported": result.Imported,
+			"failed":   result.Failed,
+		})
+		return
+	}
+
+	writeJSON(w, h.logger, http.StatusOK, result)
+}
@@ -0,0 +1,536 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/cache"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/messaging"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+const testAPIKey = "test-api-key"
+
+// fakeConsumerStats is a static ConsumerStatsProvider
+type fakeConsumerStats struct {
+	stats messaging.ConsumerStats
+}
+
+func (f *fakeConsumerStats) Stats() messaging.ConsumerStats {
+	return f.stats
+}
+
+// newTestService creates an optimizer service backed by a real optimizer and a mock cache
+func newTestService(t *testing.T) (*service.OptimizerService, *mocks.MockCache) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	opt := optimizer.NewOptimizer(params, zerolog.Nop())
+
+	return service.NewOptimizerService(opt, mockCache, zerolog.Nop()), mockCache
+}
+
+// newTestNormalizedOdds creates valid normalized odds for a selection
+func newTestNormalizedOdds(selection string, backPrice float64) *models.NormalizedOdds {
+	return &models.NormalizedOdds{
+		ID:          uuid.New(),
+		EventID:     "event-123",
+		EventName:   "Team A vs Team B",
+		Sport:       "football",
+		Competition: "Premier League",
+		Market:      "match_winner",
+		Selection:   selection,
+		BackPrice:   decimal.NewFromFloat(backPrice),
+		LayPrice:    decimal.NewFromFloat(backPrice + 0.1),
+		BackSize:    decimal.NewFromFloat(10000),
+		LaySize:     decimal.NewFromFloat(8000),
+		Timestamp:   time.Now(),
+	}
+}
+
+// TestAdminStatus_Unauthorized tests that the status endpoint requires the API key
+func TestAdminStatus_Unauthorized(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	tests := []struct {
+		name   string
+		apiKey string
+		header string
+	}{
+		{name: "Missing header", apiKey: testAPIKey, header: ""},
+		{name: "Wrong key", apiKey: testAPIKey, header: "wrong"},
+		{name: "Admin API disabled", apiKey: "", header: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAdminHandler(svc, nil, tt.apiKey, zerolog.Nop())
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/status", nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+}
+
+// TestAdminStatus_ReflectsProcessing tests the status sections and counters
+func TestAdminStatus_ReflectsProcessing(t *testing.T) {
+	svc, mockCache := newTestService(t)
+	ctx := context.Background()
+
+	// Two valid selections and one rejected by validation
+	mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil)
+	_, err := svc.OptimizeBatch(ctx, []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 3.20),
+		newTestNormalizedOdds("Draw", 0.90),
+	})
+	require.NoError(t, err)
+
+	mockCache.EXPECT().Stats().Return(models.CacheStats{Hits: 3, Misses: 1, Errors: 2, Sets: 10})
+
+	consumer := &fakeConsumerStats{stats: messaging.ConsumerStats{
+		MessagesProcessed: 4,
+		MessagesFailed:    1,
+		OddsProcessed:     12,
+		LastOffset:        41,
+		Lag:               7,
+	}}
+
+	handler := NewAdminHandler(svc, consumer, testAPIKey, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/status", nil)
+	req.Header.Set("X-API-Key", testAPIKey)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Verify top-level sections
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &raw))
+	for _, section := range []string{"service", "optimizer", "cache", "consumer"} {
+		assert.Contains(t, raw, section)
+	}
+
+	var status StatusResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+
+	assert.Equal(t, "0.02", status.Optimizer.MinMargin)
+	assert.Equal(t, "0.1", status.Optimizer.MaxMargin)
+	assert.Equal(t, 0.85, status.Optimizer.TargetConfidence)
+	assert.Equal(t, uint64(2), status.Optimizer.Optimized)
+	assert.Equal(t, uint64(1), status.Optimizer.Rejected)
+
+	assert.Equal(t, uint64(3), status.Cache.Hits)
+	assert.Equal(t, uint64(1), status.Cache.Misses)
+	assert.Equal(t, uint64(2), status.Cache.Errors)
+	assert.Equal(t, uint64(10), status.Cache.Sets)
+	assert.Equal(t, 0.75, status.Cache.HitRatio)
+
+	assert.Equal(t, uint64(4), status.Consumer.MessagesProcessed)
+	assert.Equal(t, uint64(1), status.Consumer.MessagesFailed)
+	assert.Equal(t, uint64(12), status.Consumer.OddsProcessed)
+	assert.Equal(t, int64(41), status.Consumer.LastOffset)
+	assert.Equal(t, int64(7), status.Consumer.Lag)
+
+	assert.NotEmpty(t, status.Service.StartedAt)
+	assert.GreaterOrEqual(t, status.Service.UptimeSeconds, 0.0)
+}
+
+// TestAdminStatus_MethodNotAllowed tests non-GET requests
+func TestAdminStatus_MethodNotAllowed(t *testing.T) {
+	svc, _ := newTestService(t)
+	handler := NewAdminHandler(svc, nil, testAPIKey, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/status", nil)
+	req.Header.Set("X-API-Key", testAPIKey)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+// fakeSeeker records seek requests and returns err
+type fakeSeeker struct {
+	requests []messaging.SeekRequest
+	err      error
+}
+
+func (f *fakeSeeker) Seek(ctx context.Context, req messaging.SeekRequest) error {
+	f.requests = append(f.requests, req)
+	return f.err
+}
+
+// TestAdminConsumerSeek tests seeking by offset and by timestamp, request
+// validation and API-key gating
+func TestAdminConsumerSeek(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	tests := []struct {
+		name           string
+		apiKey         string
+		body           string
+		seekErr        error
+		expectedStatus int
+		expected       *messaging.SeekRequest
+	}{
+		{
+			name:           "By offset",
+			apiKey:         testAPIKey,
+			body:           `{"partition": 3, "offset": 1200}`,
+			expectedStatus: http.StatusOK,
+			expected:       &messaging.SeekRequest{Partition: 3, Offset: 1200},
+		},
+		{
+			name:           "By timestamp",
+			apiKey:         testAPIKey,
+			body:           `{"partition": 0, "timestamp": "2024-03-01T14:00:00Z"}`,
+			expectedStatus: http.StatusOK,
+			expected:       &messaging.SeekRequest{At: time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)},
+		},
+		{name: "Neither", apiKey: testAPIKey, body: `{"partition": 0}`, expectedStatus: http.StatusBadRequest},
+		{name: "Both", apiKey: testAPIKey, body: `{"offset": 1, "timestamp": "2024-03-01T14:00:00Z"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Invalid seek", apiKey: testAPIKey, body: `{"offset": -5}`, seekErr: messaging.ErrInvalidSeek, expectedStatus: http.StatusBadRequest},
+		{name: "Not running", apiKey: testAPIKey, body: `{"offset": 5}`, seekErr: messaging.ErrConsumerNotRunning, expectedStatus: http.StatusServiceUnavailable},
+		{name: "Wrong key", apiKey: "wrong", body: `{"offset": 5}`, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seeker := &fakeSeeker{err: tt.seekErr}
+			handler := NewAdminHandler(svc, nil, testAPIKey, zerolog.Nop())
+			handler.SetSeeker(seeker)
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/consumer/seek", strings.NewReader(tt.body))
+			req.Header.Set("X-API-Key", tt.apiKey)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expected != nil {
+				require.Len(t, seeker.requests, 1)
+				assert.True(t, tt.expected.At.Equal(seeker.requests[0].At))
+				assert.Equal(t, tt.expected.Partition, seeker.requests[0].Partition)
+				assert.Equal(t, tt.expected.Offset, seeker.requests[0].Offset)
+			}
+		})
+	}
+}
+
+// fakePauser is an in-memory ConsumerPauser that also reports its state in Stats
+type fakePauser struct {
+	paused bool
+}
+
+func (f *fakePauser) Pause()       { f.paused = true }
+func (f *fakePauser) Resume()      { f.paused = false }
+func (f *fakePauser) Paused() bool { return f.paused }
+
+func (f *fakePauser) Stats() messaging.ConsumerStats {
+	return messaging.ConsumerStats{Paused: f.paused}
+}
+
+// TestAdminConsumerPause tests pausing and resuming the consumer and that
+// the paused state is reported by the status endpoint
+func TestAdminConsumerPause(t *testing.T) {
+	svc, mockCache := newTestService(t)
+	mockCache.EXPECT().Stats().Return(models.CacheStats{}).AnyTimes()
+
+	pauser := &fakePauser{}
+	handler := NewAdminHandler(svc, pauser, testAPIKey, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	serve := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	statusPaused := func() bool {
+		rec := serve(http.MethodGet, "/api/v1/admin/status", testAPIKey)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var status StatusResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return status.Consumer.Paused
+	}
+
+	// Unavailable until a pauser is set
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodPost, "/api/v1/admin/consumer/pause", testAPIKey).Code)
+	handler.SetPauser(pauser)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/api/v1/admin/consumer/pause", "wrong-key").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/api/v1/admin/consumer/pause", testAPIKey).Code)
+	assert.False(t, pauser.paused)
+
+	rec := serve(http.MethodPost, "/api/v1/admin/consumer/pause", testAPIKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"paused": true}`, rec.Body.String())
+	assert.True(t, pauser.paused)
+	assert.True(t, statusPaused())
+
+	rec = serve(http.MethodPost, "/api/v1/admin/consumer/resume", testAPIKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"paused": false}`, rec.Body.String())
+	assert.False(t, pauser.paused)
+	assert.False(t, statusPaused())
+}
+
+// newRedisTestService creates a service over a Redis cache backed by miniredis
+func newRedisTestService(t *testing.T) (*service.OptimizerService, *cache.RedisCache) {
+	mr := miniredis.RunT(t)
+	redisCache := cache.NewRedisCache(cache.RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute}, zerolog.Nop())
+	t.Cleanup(func() { redisCache.Close() })
+
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	opt := optimizer.NewOptimizer(params, zerolog.Nop())
+
+	return service.NewOptimizerService(opt, redisCache, zerolog.Nop()), redisCache
+}
+
+// TestAdminExport tests streaming the cache as NDJSON, with and without filters
+func TestAdminExport(t *testing.T) {
+	svc, redisCache := newRedisTestService(t)
+
+	// Enough entries to span several SCAN pages
+	var cached []*models.OptimizedOdds
+	for i := 0; i < 150; i++ {
+		sport := "football"
+		if i%3 == 0 {
+			sport = "tennis"
+		}
+		cached = append(cached, &models.OptimizedOdds{
+			ID:            uuid.New(),
+			EventID:       fmt.Sprintf("event-%d", i%10),
+			Sport:         sport,
+			Market:        "match_winner",
+			Selection:     fmt.Sprintf("Selection %d", i),
+			OptimizedBack: decimal.NewFromFloat(2.50),
+		})
+	}
+	require.NoError(t, redisCache.SetBatch(context.Background(), cached))
+
+	handler := NewAdminHandler(svc, nil, testAPIKey, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	export := func(query, apiKey string) (*httptest.ResponseRecorder, []*models.OptimizedOdds) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/export"+query, nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var exported []*models.OptimizedOdds
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			var odds models.OptimizedOdds
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &odds))
+			exported = append(exported, &odds)
+		}
+		return rec, exported
+	}
+
+	// Everything
+	rec, exported := export("", testAPIKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+	ids := make(map[uuid.UUID]bool, len(exported))
+	for _, odds := range exported {
+		ids[odds.ID] = true
+	}
+	assert.Len(t, ids, len(cached))
+	for _, odds := range cached {
+		assert.True(t, ids[odds.ID], "missing %s", odds.Selection)
+	}
+
+	// By sport
+	rec, exported = export("?sport=Tennis", testAPIKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, exported, 50)
+	for _, odds := range exported {
+		assert.Equal(t, "tennis", odds.Sport)
+	}
+
+	// By event
+	rec, exported = export("?event_id=event-3", testAPIKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, exported, 15)
+	for _, odds := range exported {
+		assert.Equal(t, "event-3", odds.EventID)
+	}
+
+	// Nothing matches: an empty download
+	rec, exported = export("?event_id=event-999", testAPIKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, exported)
+
+	rec, _ = export("", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// postImport issues POST /api/v1/admin/import with an NDJSON body
+func postImport(mux *http.ServeMux, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/import", strings.NewReader(body))
+	req.Header.Set("X-API-Key", testAPIKey)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestAdminImport tests loading a snapshot and reading the entries back
+func TestAdminImport(t *testing.T) {
+	svc, _ := newRedisTestService(t)
+	handler := NewAdminHandler(svc, nil, testAPIKey, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	var snapshot strings.Builder
+	encoder := json.NewEncoder(&snapshot)
+	for _, selection := range []string{"Team A", "Team B", "Draw"} {
+		require.NoError(t, encoder.Encode(&models.OptimizedOdds{
+			ID:            uuid.New(),
+			EventID:       "event-123",
+			Sport:         "football",
+			Market:        "match_winner",
+			Selection:     selection,
+			OptimizedBack: decimal.NewFromFloat(2.50),
+		}))
+	}
+
+	rec := postImport(mux, snapshot.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	var result service.ImportResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, service.ImportResult{Imported: 3, Failed: 0}, result)
+
+	odds, err := svc.GetOptimizedOdds(context.Background(), "event-123", "match_winner", "Draw")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(2.50).Equal(odds.OptimizedBack))
+
+	byEvent, err := svc.GetOptimizedOddsByEvent(context.Background(), "event-123")
+	require.NoError(t, err)
+	assert.Len(t, byEvent, 3)
+
+	// Unauthenticated imports are rejected
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/import", strings.NewReader(snapshot.String()))
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// TestAdminImport_MalformedLines tests that bad records are counted and skipped
+func TestAdminImport_MalformedLines(t *testing.T) {
+	svc, _ := newRedisTestService(t)
+	handler := NewAdminHandler(svc, nil, testAPIKey, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	body := strings.Join([]string{
+		`{"event_id": "event-123", "market": "match_winner", "selection": "Team A", "optimized_back": "2.5"}`,
+		`{"event_id": "event-123", "market": "match_winner"`,
+		``,
+		`{"event_id": "event-123", "market": "", "selection": "Team B"}`,
+		`not json`,
+		`{"event_id": "event-123", "market": "match_winner", "selection": "Team C", "optimized_back": "3.1"}`,
+	}, "\n")
+
+	rec := postImport(mux, body)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var result service.ImportResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, service.ImportResult{Imported: 2, Failed: 3}, result)
+
+	byEvent, err := svc.GetOptimizedOddsByEvent(context.Background(), "event-123")
+	require.NoError(t, err)
+	assert.Len(t, byEvent, 2)
+}
+
+// TestAdminHandler_UntimedPaths tests that the export streams in full and
+// the seek runs without a deadline behind a timeout handler exempting the
+// untimed admin paths
+func TestAdminHandler_UntimedPaths(t *testing.T) {
+	svc, redisCache := newRedisTestService(t)
+	require.NoError(t, redisCache.SetBatch(context.Background(), []*models.OptimizedOdds{
+		{ID: uuid.New(), EventID: "event-1", Market: "match_winner", Selection: "Home"},
+		{ID: uuid.New(), EventID: "event-1", Market: "match_winner", Selection: "Away"},
+	}))
+
+	handler := NewAdminHandler(svc, nil, testAPIKey, zerolog.Nop())
+	seeker := &deadlineSeeker{}
+	handler.SetSeeker(seeker)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	th := NewTimeoutHandler(TimeoutHandlerConfig{Timeout: time.Nanosecond, Exempt: handler.UntimedPaths()}, zerolog.Nop())
+	wrapped := th.Wrap(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/export", nil)
+	req.Header.Set("X-API-Key", testAPIKey)
+	rec := httptest.NewRecorder()
+	wrapped.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 2, strings.Count(rec.Body.String(), "\n"))
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/consumer/seek", strings.NewReader(`{"partition":0,"offset":42}`))
+	req.Header.Set("X-API-Key", testAPIKey)
+	rec = httptest.NewRecorder()
+	wrapped.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.False(t, seeker.hadDeadline)
+	assert.Zero(t, testutil.ToFloat64(th.timeouts))
+}
+
+// deadlineSeeker records whether the seek's context carried a deadline
+type deadlineSeeker struct {
+	hadDeadline bool
+}
+
+func (s *deadlineSeeker) Seek(ctx context.Context, req messaging.SeekRequest) error {
+	_, s.hadDeadline = ctx.Deadline()
+	return nil
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// ConcurrencyLimiter sheds load with 503 once MaxConcurrent handlers are in
+// flight, protecting the server's absolute capacity. It does not queue or
+// rate limit individual clients.
+type ConcurrencyLimiter struct {
+	slots  chan struct{}
+	exempt map[string]bool
+	shed   prometheus.Counter
+	logger zerolog.Logger
+}
+
+// ConcurrencyLimiterConfig holds concurrency limiter configuration
+type ConcurrencyLimiterConfig struct {
+	MaxConcurrent int                   // In-flight handler limit
+	Exempt        []string              // Paths never shed, e.g. "/health", "/ready"
+	Registerer    prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// NewConcurrencyLimiter creates a new concurrency limiter
+func NewConcurrencyLimiter(config ConcurrencyLimiterConfig, logger zerolog.Logger) *ConcurrencyLimiter {
+	exempt := make(map[string]bool, len(config.Exempt))
+	for _, path := range config.Exempt {
+		exempt[path] = true
+	}
+
+	l := &ConcurrencyLimiter{
+		slots:  make(chan struct{}, config.MaxConcurrent),
+		exempt: exempt,
+		shed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "http_requests_shed_total",
+			Help: "HTTP requests rejected with 503 because the concurrent handler limit was reached.",
+		}),
+		logger: logger.With().Str("component", "concurrency_limiter").Logger(),
+	}
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(l.shed)
+	}
+
+	return l
+}
+
+// Wrap limits concurrent requests to next, except for exempt paths
+func (l *ConcurrencyLimiter) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		select {
+		case l.slots <- struct{}{}:
+			defer func() { <-l.slots }()
+			next.ServeHTTP(w, r)
+
+		default:
+			l.shed.Inc()
+			l.logger.Warn().
+				Str("path", r.URL.Path).
+				Int("max_concurrent", cap(l.slots)).
+				Msg("concurrent request limit reached, shedding request")
+			w.Header().Set("Retry-After", "1")
+			writeError(w, l.logger, http.StatusServiceUnavailable, "server overloaded")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConcurrencyLimiter tests shedding beyond the limit and freeing capacity on completion
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{
+		MaxConcurrent: 2,
+		Exempt:        []string{"/health"},
+		Registerer:    prometheus.NewRegistry(),
+	}, zerolog.Nop())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := limiter.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// Fill both slots with slow requests
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = serve("/slow")
+		}()
+		<-started
+	}
+
+	// Beyond the limit: shed
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/api/v1/odds/event-123/match_winner/Team%20A"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(limiter.shed))
+
+	// Exempt paths are always served
+	assert.Equal(t, http.StatusOK, serve("/health"))
+
+	// Completing requests frees capacity
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK}, codes)
+	assert.Equal(t, http.StatusOK, serve("/api/v1/odds/event-123/match_winner/Team%20A"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(limiter.shed))
+}
+
+// TestConcurrencyLimiter_ShedResponse tests the 503 response format
+func TestConcurrencyLimiter_ShedResponse(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 0}, zerolog.Nop())
+	handler := limiter.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not run")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"server overloaded"}`, rec.Body.String())
+}
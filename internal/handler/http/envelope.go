@@ -0,0 +1,69 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// envelopeParam overrides the configured envelope default per request
+const envelopeParam = "envelope"
+
+// Envelope wraps an odds response with when the server generated it and how
+// fresh the odds in it are, for clients doing their own staleness handling
+type Envelope struct {
+	ServerTime time.Time   `json:"server_time"` // When the response was generated
+	DataAsOf   *time.Time  `json:"data_as_of"`  // Newest OptimizedAt among the returned odds; null when none are returned
+	Data       interface{} `json:"data"`        // The response as it is sent without an envelope
+}
+
+// SetEnvelope sets whether odds responses are wrapped in an Envelope by
+// default; ?envelope=true or ?envelope=false overrides it per request
+func (h *OddsHandler) SetEnvelope(enabled bool) {
+	h.envelope = enabled
+}
+
+// parseEnvelope reports whether the request's odds response is enveloped,
+// answering 400 and returning ok false for an invalid envelope parameter
+func (h *OddsHandler) parseEnvelope(w http.ResponseWriter, r *http.Request) (envelope, ok bool) {
+	raw := r.URL.Query().Get(envelopeParam)
+	if raw == "" {
+		return h.envelope, true
+	}
+
+	envelope, err := strconv.ParseBool(raw)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid envelope: expected true or false")
+		return false, false
+	}
+	return envelope, true
+}
+
+// newEnvelope returns an envelope stamped now, dated by the newest
+// OptimizedAt of oddsList
+func newEnvelope(oddsList []*models.OptimizedOdds) *Envelope {
+	env := &Envelope{ServerTime: time.Now().UTC()}
+	for _, odds := range oddsList {
+		if odds == nil || odds.OptimizedAt.IsZero() {
+			continue
+		}
+		if env.DataAsOf == nil || odds.OptimizedAt.After(*env.DataAsOf) {
+			asOf := odds.OptimizedAt
+			env.DataAsOf = &asOf
+		}
+	}
+	return env
+}
+
+// oddsResponse writes a 200 odds response, wrapped in an envelope dated by
+// oddsList when envelope is set
+func (h *OddsHandler) oddsResponse(w http.ResponseWriter, envelope bool, data interface{}, oddsList []*models.OptimizedOdds) {
+	if envelope {
+		env := newEnvelope(oddsList)
+		env.Data = data
+		data = env
+	}
+	h.jsonResponse(w, http.StatusOK, data)
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/cache"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// TestOddsResponses_Envelope tests that enveloped odds responses carry the
+// server time and the newest OptimizedAt of their odds, and that the
+// envelope is opt-in per handler and per request
+func TestOddsResponses_Envelope(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	newest := time.Now().Add(-time.Second).UTC().Truncate(time.Millisecond)
+	optimizedAt := map[string]time.Time{
+		"Team A": newest.Add(-2 * time.Second),
+		"Team B": newest,
+		"Draw":   newest.Add(-time.Second),
+	}
+	for selection, at := range optimizedAt {
+		require.NoError(t, memoryCache.Set(context.Background(), &models.OptimizedOdds{
+			EventID:       "event-123",
+			Sport:         "football",
+			Market:        "match_winner",
+			Selection:     selection,
+			OptimizedBack: decimal.NewFromFloat(2.50),
+			OptimizedLay:  decimal.NewFromFloat(2.45),
+			OptimizedAt:   at,
+		}))
+	}
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	serve := func(method, target string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	type envelope struct {
+		ServerTime time.Time       `json:"server_time"`
+		DataAsOf   *time.Time      `json:"data_as_of"`
+		Data       json.RawMessage `json:"data"`
+	}
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder) envelope {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var env envelope
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &env))
+		require.NotEmpty(t, env.Data)
+		assert.WithinDuration(t, time.Now(), env.ServerTime, time.Minute)
+		return env
+	}
+
+	t.Run("Off by default", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/api/v1/events/event-123/odds", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "data_as_of")
+	})
+
+	t.Run("Event odds", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/api/v1/events/event-123/odds?envelope=true", nil)
+		env := decode(t, rec)
+
+		require.NotNil(t, env.DataAsOf)
+		assert.True(t, newest.Equal(*env.DataAsOf), "expected %s, got %s", newest, env.DataAsOf)
+
+		var data struct {
+			Count int                     `json:"count"`
+			Odds  []*models.OptimizedOdds `json:"odds"`
+		}
+		require.NoError(t, json.Unmarshal(env.Data, &data))
+		assert.Equal(t, 3, data.Count)
+		latest := data.Odds[0].OptimizedAt
+		for _, odds := range data.Odds {
+			if odds.OptimizedAt.After(latest) {
+				latest = odds.OptimizedAt
+			}
+		}
+		assert.True(t, latest.Equal(*env.DataAsOf))
+
+		// The ETag follows the odds, not the server time
+		etag := rec.Header().Get("ETag")
+		assert.Equal(t, serve(http.MethodGet, "/api/v1/events/event-123/odds", nil).Header().Get("ETag"), etag)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/odds?envelope=true", nil)
+		req.Header.Set("If-None-Match", etag)
+		notModified := httptest.NewRecorder()
+		mux.ServeHTTP(notModified, req)
+		assert.Equal(t, http.StatusNotModified, notModified.Code)
+	})
+
+	t.Run("Single odds", func(t *testing.T) {
+		env := decode(t, serve(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A?envelope=true", nil))
+
+		require.NotNil(t, env.DataAsOf)
+		assert.True(t, optimizedAt["Team A"].Equal(*env.DataAsOf))
+		var odds models.OptimizedOdds
+		require.NoError(t, json.Unmarshal(env.Data, &odds))
+		assert.Equal(t, "Team A", odds.Selection)
+	})
+
+	t.Run("Several events", func(t *testing.T) {
+		body, err := json.Marshal(EventsOddsRequest{EventIDs: []string{"event-123", "event-456"}})
+		require.NoError(t, err)
+
+		env := decode(t, serve(http.MethodPost, "/api/v1/events/odds?envelope=true", body))
+
+		require.NotNil(t, env.DataAsOf)
+		assert.True(t, newest.Equal(*env.DataAsOf))
+		assert.Contains(t, string(env.Data), `"event-456":[]`)
+	})
+
+	t.Run("Configured default", func(t *testing.T) {
+		handler.SetEnvelope(true)
+		defer handler.SetEnvelope(false)
+
+		env := decode(t, serve(http.MethodGet, "/api/v1/events/event-123/odds", nil))
+		require.NotNil(t, env.DataAsOf)
+		assert.True(t, newest.Equal(*env.DataAsOf))
+
+		plain := serve(http.MethodGet, "/api/v1/events/event-123/odds?envelope=false", nil)
+		require.Equal(t, http.StatusOK, plain.Code)
+		assert.NotContains(t, plain.Body.String(), "data_as_of")
+	})
+
+	t.Run("No odds", func(t *testing.T) {
+		env := decode(t, serve(http.MethodGet, "/api/v1/events/event-456/odds?envelope=true", nil))
+		assert.Nil(t, env.DataAsOf)
+	})
+
+	t.Run("Invalid parameter", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/api/v1/events/event-123/odds?envelope=maybe", nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// writeJSONWithETag writes a JSON response carrying a strong ETag, the hash
+// of the encoded body. When the request's If-None-Match already holds that
+// ETag, 304 Not Modified is written without a body instead. A non-nil
+// envelope wraps the body after hashing, so the ETag follows the data alone
+// rather than the envelope's server time.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, logger zerolog.Logger, data interface{}, envelope *Envelope) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		logger.Error().Err(err).Msg("failed to encode JSON response")
+		writeError(w, logger, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if envelope != nil {
+		envelope.Data = json.RawMessage(bytes.TrimSpace(body.Bytes()))
+		body.Reset()
+		if err := json.NewEncoder(&body).Encode(envelope); err != nil {
+			logger.Error().Err(err).Msg("failed to encode JSON response")
+			writeError(w, logger, http.StatusInternalServerError, "failed to encode response")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body.Bytes()); err != nil {
+		logger.Error().Err(err).Msg("failed to write JSON response")
+	}
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+// If-None-Match uses weak comparison, so W/ prefixes are ignored.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+)
+
+// FreshnessChecker reports whether odds are fresh enough to serve, failing
+// while no fresh data is flowing cluster-wide (see service.FreshnessWatchdog)
+type FreshnessChecker interface {
+	Fresh() error
+}
+
+// SetFreshness makes live odds reads answer 503 while freshness fails,
+// instead of serving cached prices that are growing stale. Historical
+// endpoints (history, search, closing lines, diffs) are unaffected.
+func (h *OddsHandler) SetFreshness(freshness FreshnessChecker) {
+	h.freshness = freshness
+}
+
+// refuseStale answers 503 and returns true while freshness fails
+func (h *OddsHandler) refuseStale(w http.ResponseWriter) bool {
+	if h.freshness == nil {
+		return false
+	}
+	if err := h.freshness.Fresh(); err != nil {
+		h.logger.Debug().Err(err).Msg("refusing odds read without fresh data")
+		h.errorResponse(w, http.StatusServiceUnavailable, "no fresh odds data")
+		return true
+	}
+	return false
+}
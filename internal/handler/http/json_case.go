@@ -0,0 +1,142 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// JSON field naming styles of odds responses
+const (
+	JSONCaseSnake = "snake" // optimized_back (default)
+	JSONCaseCamel = "camel" // optimizedBack
+)
+
+// oddsResponseSnake is OddsResponse without its MarshalJSON method
+type oddsResponseSnake OddsResponse
+
+// oddsResponseCamel mirrors OddsResponse field for field with camelCase tags
+type oddsResponseCamel struct {
+	EventID       string  `json:"eventId"`
+	EventName     string  `json:"eventName"`
+	Sport         string  `json:"sport"`
+	Competition   string  `json:"competition"`
+	Market        string  `json:"market"`
+	Selection     string  `json:"selection"`
+	Display       string  `json:"displaySelection,omitempty"`
+	OptimizedBack string  `json:"optimizedBack"`
+	OptimizedLay  string  `json:"optimizedLay"`
+	FairPrice     string  `json:"fairPrice"`
+	Sportsbook    string  `json:"sportsbookPrice,omitempty"`
+	OriginalBack  string  `json:"originalBack"`
+	OriginalLay   string  `json:"originalLay"`
+	Margin        string  `json:"margin"`
+	Confidence    float64 `json:"confidence"`
+	Source        string  `json:"source,omitempty"`
+	Stale         bool    `json:"stale,omitempty"`
+	OptimizedAt   string  `json:"optimizedAt"`
+
+	camel bool
+}
+
+// optimizedOddsCamel mirrors models.OptimizedOdds field for field with
+// camelCase tags, so the odds endpoints serving it as is can be converted
+type optimizedOddsCamel struct {
+	ID               uuid.UUID        `json:"id"`
+	EventID          string           `json:"eventId"`
+	EventName        string           `json:"eventName"`
+	Sport            string           `json:"sport"`
+	Competition      string           `json:"competition"`
+	Market           string           `json:"market"`
+	Selection        string           `json:"selection"`
+	DisplaySelection string           `json:"displaySelection,omitempty"`
+	OptimizedBack    decimal.Decimal  `json:"optimizedBack"`
+	OptimizedLay     decimal.Decimal  `json:"optimizedLay"`
+	FairPrice        decimal.Decimal  `json:"fairPrice"`
+	SportsbookPrice  *decimal.Decimal `json:"sportsbookPrice,omitempty"`
+	OriginalBack     decimal.Decimal  `json:"originalBack"`
+	OriginalLay      decimal.Decimal  `json:"originalLay"`
+	BackSize         decimal.Decimal  `json:"backSize"`
+	LaySize          decimal.Decimal  `json:"laySize"`
+	Margin           decimal.Decimal  `json:"margin"`
+	Confidence       float64          `json:"confidence"`
+	InPlay           bool             `json:"inPlay,omitempty"`
+	StartTime        time.Time        `json:"startTime,omitzero"`
+	Source           string           `json:"source,omitempty"`
+	Line             decimal.Decimal  `json:"line,omitzero"`
+	Stale            bool             `json:"stale,omitempty"`
+	Degraded         bool             `json:"degraded,omitempty"`
+	Regions          []string         `json:"regions,omitempty"`
+	Timestamp        time.Time        `json:"timestamp"`
+	OptimizedAt      time.Time        `json:"optimizedAt"`
+}
+
+// fuzzyOddsResponseCamel is FuzzyOddsResponse with camelCase odds fields
+type fuzzyOddsResponseCamel struct {
+	*optimizedOddsCamel
+	Matched string `json:"matched"`
+}
+
+// MarshalJSON encodes the response with snake_case field names, or
+// camelCase when built by a handler configured with JSONCaseCamel
+func (r OddsResponse) MarshalJSON() ([]byte, error) {
+	if r.camel {
+		return json.Marshal(oddsResponseCamel(r))
+	}
+	return json.Marshal(oddsResponseSnake(r))
+}
+
+// SetJSONCase sets the field naming style of odds responses: JSONCaseSnake
+// (default) or JSONCaseCamel
+func (h *OddsHandler) SetJSONCase(jsonCase string) error {
+	switch jsonCase {
+	case "", JSONCaseSnake:
+		h.camelCase = false
+	case JSONCaseCamel:
+		h.camelCase = true
+	default:
+		return fmt.Errorf("unknown JSON case %q, want %s or %s", jsonCase, JSONCaseSnake, JSONCaseCamel)
+	}
+	return nil
+}
+
+// toOddsResponse converts odds to an API response in the configured naming style
+func (h *OddsHandler) toOddsResponse(odds *models.OptimizedOdds) *OddsResponse {
+	resp := ToOddsResponse(odds)
+	resp.camel = h.camelCase
+	return resp
+}
+
+// casedOdds returns odds to encode in the configured naming style
+func (h *OddsHandler) casedOdds(odds *models.OptimizedOdds) interface{} {
+	if !h.camelCase {
+		return odds
+	}
+	return (*optimizedOddsCamel)(odds)
+}
+
+// casedOddsList returns oddsList to encode in the configured naming style
+func (h *OddsHandler) casedOddsList(oddsList []*models.OptimizedOdds) interface{} {
+	if !h.camelCase || oddsList == nil {
+		return oddsList
+	}
+	camel := make([]*optimizedOddsCamel, 0, len(oddsList))
+	for _, odds := range oddsList {
+		camel = append(camel, (*optimizedOddsCamel)(odds))
+	}
+	return camel
+}
+
+// casedFuzzyOdds returns the fuzzy lookup response to encode in the
+// configured naming style
+func (h *OddsHandler) casedFuzzyOdds(odds *models.OptimizedOdds, matched string) interface{} {
+	if !h.camelCase {
+		return FuzzyOddsResponse{OptimizedOdds: odds, Matched: matched}
+	}
+	return fuzzyOddsResponseCamel{optimizedOddsCamel: (*optimizedOddsCamel)(odds), Matched: matched}
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/cache"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// TestOddsResponse_JSONCase tests that both naming styles serialize the same
+// values and that the configured style reaches responses
+func TestOddsResponse_JSONCase(t *testing.T) {
+	svc, _ := newTestService(t)
+	sportsbook := decimal.NewFromFloat(2.30)
+	odds := &models.OptimizedOdds{
+		EventID:          "event-123",
+		EventName:        "Team A vs Team B",
+		Sport:            "football",
+		Competition:      "Premier League",
+		Market:           "match_winner",
+		Selection:        "team a",
+		DisplaySelection: "Team A",
+		OptimizedBack:    decimal.NewFromFloat(2.55),
+		OptimizedLay:     decimal.NewFromFloat(2.45),
+		FairPrice:        decimal.NewFromFloat(2.50),
+		SportsbookPrice:  &sportsbook,
+		OriginalBack:     decimal.NewFromFloat(2.50),
+		OriginalLay:      decimal.NewFromFloat(2.60),
+		Margin:           decimal.NewFromFloat(0.02),
+		Confidence:       0.85,
+		Source:           "betfair",
+		OptimizedAt:      time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC),
+	}
+
+	encode := func(jsonCase string) map[string]interface{} {
+		handler := NewOddsHandler(svc, zerolog.Nop())
+		require.NoError(t, handler.SetJSONCase(jsonCase))
+		data, err := json.Marshal(handler.toOddsResponse(odds))
+		require.NoError(t, err)
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &fields))
+		return fields
+	}
+
+	snake := encode(JSONCaseSnake)
+	assert.Equal(t, snake, encode(""))
+	camel := encode(JSONCaseCamel)
+	require.Len(t, camel, len(snake))
+
+	fields := map[string]string{
+		"event_id":          "eventId",
+		"event_name":        "eventName",
+		"sport":             "sport",
+		"competition":       "competition",
+		"market":            "market",
+		"selection":         "selection",
+		"display_selection": "displaySelection",
+		"optimized_back":    "optimizedBack",
+		"optimized_lay":     "optimizedLay",
+		"fair_price":        "fairPrice",
+		"sportsbook_price":  "sportsbookPrice",
+		"original_back":     "originalBack",
+		"original_lay":      "originalLay",
+		"margin":            "margin",
+		"confidence":        "confidence",
+		"source":            "source",
+		"optimized_at":      "optimizedAt",
+	}
+	require.Len(t, snake, len(fields))
+	for snakeName, camelName := range fields {
+		require.Contains(t, snake, snakeName)
+		assert.Equal(t, snake[snakeName], camel[camelName], "%s vs %s", snakeName, camelName)
+	}
+	assert.Equal(t, "2.55", camel["optimizedBack"])
+
+	t.Run("Configured style reaches responses", func(t *testing.T) {
+		handler := NewOddsHandler(svc, zerolog.Nop())
+		require.NoError(t, handler.SetJSONCase(JSONCaseCamel))
+		mux := http.NewServeMux()
+		handler.RegisterRoutes(mux)
+
+		body, err := json.Marshal(newTestNormalizedOdds("Team A", 2.50))
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/optimize/explain", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Odds map[string]interface{} `json:"odds"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Contains(t, resp.Odds, "optimizedBack")
+		assert.NotContains(t, resp.Odds, "optimized_back")
+	})
+
+	t.Run("Unknown style is rejected", func(t *testing.T) {
+		assert.Error(t, NewOddsHandler(svc, zerolog.Nop()).SetJSONCase("kebab"))
+	})
+}
+
+// TestOddsEndpoints_JSONCase tests that camelCase reaches the read endpoints
+// serving optimized odds as they are cached
+func TestOddsEndpoints_JSONCase(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+	require.NoError(t, memoryCache.Set(context.Background(), &models.OptimizedOdds{
+		EventID:       "event-123",
+		Market:        "match_winner",
+		Selection:     "Team A",
+		OptimizedBack: decimal.NewFromFloat(2.50),
+		OptimizedAt:   time.Now().UTC(),
+	}))
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	require.NoError(t, handler.SetJSONCase(JSONCaseCamel))
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	serve := func(method, target string, body []byte) []byte {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		return rec.Body.Bytes()
+	}
+	requireCamel := func(t *testing.T, odds map[string]interface{}) {
+		t.Helper()
+		assert.Equal(t, "event-123", odds["eventId"])
+		assert.Equal(t, "2.5", odds["optimizedBack"])
+		assert.Contains(t, odds, "optimizedAt")
+		assert.NotContains(t, odds, "event_id")
+		assert.NotContains(t, odds, "optimized_back")
+	}
+
+	t.Run("Single odds", func(t *testing.T) {
+		var odds map[string]interface{}
+		require.NoError(t, json.Unmarshal(serve(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", nil), &odds))
+		requireCamel(t, odds)
+	})
+
+	t.Run("Single odds with fallback", func(t *testing.T) {
+		var odds map[string]interface{}
+		require.NoError(t, json.Unmarshal(serve(http.MethodGet, "/api/v1/odds/event-123/match_winner/team%20a?fallback=fuzzy", nil), &odds))
+		requireCamel(t, odds)
+		assert.Equal(t, "fuzzy", odds["matched"])
+	})
+
+	t.Run("Event odds", func(t *testing.T) {
+		var resp struct {
+			Odds []map[string]interface{} `json:"odds"`
+		}
+		require.NoError(t, json.Unmarshal(serve(http.MethodGet, "/api/v1/events/event-123/odds", nil), &resp))
+		require.Len(t, resp.Odds, 1)
+		requireCamel(t, resp.Odds[0])
+	})
+
+	t.Run("Several events", func(t *testing.T) {
+		body, err := json.Marshal(EventsOddsRequest{EventIDs: []string{"event-123"}})
+		require.NoError(t, err)
+		var resp struct {
+			Events map[string][]map[string]interface{} `json:"events"`
+		}
+		require.NoError(t, json.Unmarshal(serve(http.MethodPost, "/api/v1/events/odds", body), &resp))
+		require.Len(t, resp.Events["event-123"], 1)
+		requireCamel(t, resp.Events["event-123"][0])
+	})
+}
@@ -2,19 +2,34 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
 
 	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
 	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
 )
 
 // OddsHandler handles HTTP requests for optimized odds
 type OddsHandler struct {
-	service *service.OptimizerService
-	logger  zerolog.Logger
+	service   *service.OptimizerService
+	camelCase bool // Odds responses use camelCase field names
+
+	eventMaxSelections int // Event odds responses are truncated past this many selections (0 disables)
+	eventMaxBytes      int // Event odds responses are truncated past this many bytes of response body (0 disables)
+
+	freshness FreshnessChecker // Live odds reads answer 503 while it fails (nil disables)
+	envelope  bool             // Odds responses are wrapped in an Envelope unless ?envelope=false
+
+	logger zerolog.Logger
 }
 
 // NewOddsHandler creates a new odds HTTP handler
@@ -25,13 +40,64 @@ func NewOddsHandler(service *service.OptimizerService, logger zerolog.Logger) *O
 	}
 }
 
+// SetEventOddsLimits caps GET /api/v1/events/:event_id/odds responses at
+// maxSelections selections and maxBytes bytes of response body; responses
+// over either cap are truncated and flagged. 0 disables a cap.
+func (h *OddsHandler) SetEventOddsLimits(maxSelections, maxBytes int) {
+	h.eventMaxSelections = maxSelections
+	h.eventMaxBytes = maxBytes
+}
+
 // RegisterRoutes registers HTTP routes with the provided mux
 func (h *OddsHandler) RegisterRoutes(mux *http.ServeMux) {
-	// GET /api/v1/odds/:event_id/:market/:selection - Get specific optimized odds
+	// Odds endpoints honor ?region= (or X-Region), omitting odds not allowed in that region.
+	// Live odds reads answer 503 while no fresh data is flowing (see SetFreshness).
+	// Odds responses honor ?envelope=true|false, wrapping them in {server_time, data_as_of, data} (see SetEnvelope).
+
+	// GET /api/v1/odds/:event_id/:market/:selection[?fallback=fuzzy] - Get specific optimized odds
 	mux.HandleFunc("/api/v1/odds/", h.handleGetOdds)
 
-	// GET /api/v1/events/:event_id/odds - Get all odds for an event
+	// GET /api/v1/odds/history?event_id=&market=&selection=&at= - Get odds as of a point in time
+	mux.HandleFunc("/api/v1/odds/history", h.handleGetOddsHistory)
+
+	// GET /api/v1/odds/search?sport=&from=&to=[&limit=] - Search the long-term odds store
+	mux.HandleFunc("/api/v1/odds/search", h.handleSearchOdds)
+
+	// GET /api/v1/events/:event_id/odds[?markets=a,b][&format=] - Get all odds for an event, optionally only some markets,
+	// with prices rendered as decimal, fractional or american when a format is given
+	// GET /api/v1/events/:event_id/overround?market= - Get the implied probability sum of a cached book
+	// GET /api/v1/events/:event_id/closing - Get the event's captured closing lines
+	// GET /api/v1/events/:event_id/diff?market=&from=&to= - Get how a market's prices moved between two times
 	mux.HandleFunc("/api/v1/events/", h.handleGetEventOdds)
+
+	// POST /api/v1/events/odds - Get all odds for several events
+	mux.HandleFunc("/api/v1/events/odds", h.handleGetEventsOdds)
+
+	// POST /api/v1/optimize/models - Price odds under every margin model (not cached)
+	mux.HandleFunc("/api/v1/optimize/models", h.handleCompareModels)
+
+	// POST /api/v1/optimize/explain - Optimize one selection and explain the price (not cached)
+	mux.HandleFunc("/api/v1/optimize/explain", h.handleExplain)
+}
+
+// Fallback modes for GET /api/v1/odds/:event_id/:market/:selection
+const (
+	fallbackStrict = ""      // Exact selection only (default)
+	fallbackFuzzy  = "fuzzy" // On an exact miss, match the selection name canonically
+)
+
+// Headers describing the freshness of a cached price on the single-odds endpoint
+const (
+	cacheAgeHeader = "X-Cache-Age"  // Seconds since the odds were optimized
+	cacheTTLHeader = "X-Cache-TTL"  // Seconds until the odds expire from the cache
+	staleHeader    = "X-Odds-Stale" // "true" when the odds are past their TTL, within the stale grace window
+)
+
+// FuzzyOddsResponse is the response of a fallback=fuzzy lookup: the odds
+// plus how the selection was matched, "exact" or "fuzzy"
+type FuzzyOddsResponse struct {
+	*models.OptimizedOdds
+	Matched string `json:"matched"`
 }
 
 // handleGetOdds handles GET /api/v1/odds/:event_id/:market/:selection
@@ -59,34 +125,219 @@ func (h *OddsHandler) handleGetOdds(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fallback := r.URL.Query().Get("fallback")
+	if fallback != fallbackStrict && fallback != fallbackFuzzy {
+		h.errorResponse(w, http.StatusBadRequest, "invalid fallback: expected fuzzy")
+		return
+	}
+	envelope, ok := h.parseEnvelope(w, r)
+	if !ok {
+		return
+	}
+	if h.refuseStale(w) {
+		return
+	}
+
 	// Get optimized odds from service
-	odds, err := h.service.GetOptimizedOdds(r.Context(), eventID, market, selection)
+	var (
+		odds  *models.OptimizedOdds
+		meta  models.CacheMeta
+		fuzzy bool
+		err   error
+	)
+	if fallback == fallbackFuzzy {
+		odds, fuzzy, err = h.service.GetOptimizedOddsFuzzy(r.Context(), eventID, market, selection)
+	} else {
+		odds, meta, err = h.service.GetOptimizedOddsWithMeta(r.Context(), eventID, market, selection)
+	}
 	if err != nil {
 		h.logger.Debug().
 			Err(err).
 			Str("event_id", eventID).
 			Str("market", market).
 			Str("selection", selection).
+			Str("fallback", fallback).
 			Msg("odds not found")
 		h.errorResponse(w, http.StatusNotFound, "odds not found")
 		return
 	}
 
-	h.jsonResponse(w, http.StatusOK, odds)
+	// Odds restricted away from the caller's region are not acknowledged
+	if region := requestRegion(r); !regionAllowed(odds, region) {
+		h.logger.Debug().
+			Str("event_id", eventID).
+			Str("market", market).
+			Str("selection", selection).
+			Str("region", region).
+			Msg("odds not allowed in region")
+		h.errorResponse(w, http.StatusNotFound, "odds not found")
+		return
+	}
+
+	if odds.Stale {
+		w.Header().Set(staleHeader, "true")
+	}
+	if fallback == fallbackStrict {
+		setCacheMetaHeaders(w, meta)
+		h.oddsResponse(w, envelope, h.casedOdds(odds), []*models.OptimizedOdds{odds})
+		return
+	}
+
+	matched := "exact"
+	if fuzzy {
+		matched = "fuzzy"
+	}
+	h.oddsResponse(w, envelope, h.casedFuzzyOdds(odds, matched), []*models.OptimizedOdds{odds})
+}
+
+// setCacheMetaHeaders reports a cached entry's age and remaining TTL in
+// whole seconds; X-Cache-TTL is omitted for entries that do not expire
+func setCacheMetaHeaders(w http.ResponseWriter, meta models.CacheMeta) {
+	w.Header().Set(cacheAgeHeader, strconv.FormatInt(int64(max(meta.Age, 0)/time.Second), 10))
+	if meta.TTLRemaining > 0 {
+		w.Header().Set(cacheTTLHeader, strconv.FormatInt(int64(meta.TTLRemaining/time.Second), 10))
+	}
+}
+
+// handleGetOddsHistory handles GET /api/v1/odds/history?event_id=&market=&selection=&at=<rfc3339>
+func (h *OddsHandler) handleGetOddsHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	eventID := query.Get("event_id")
+	market := query.Get("market")
+	selection := query.Get("selection")
+
+	if eventID == "" || market == "" || selection == "" || query.Get("at") == "" {
+		h.errorResponse(w, http.StatusBadRequest, "event_id, market, selection, and at are required")
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, query.Get("at"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid at: expected RFC 3339 timestamp")
+		return
+	}
+	envelope, ok := h.parseEnvelope(w, r)
+	if !ok {
+		return
+	}
+
+	odds, err := h.service.GetOptimizedOddsAt(r.Context(), eventID, market, selection, at)
+	switch {
+	case errors.Is(err, service.ErrSnapshotNotFound):
+		h.errorResponse(w, http.StatusNotFound, "odds not found")
+		return
+	case errors.Is(err, service.ErrHistoryDisabled):
+		h.errorResponse(w, http.StatusServiceUnavailable, "odds history is not enabled")
+		return
+	case err != nil:
+		h.logger.Error().
+			Err(err).
+			Str("event_id", eventID).
+			Str("market", market).
+			Str("selection", selection).
+			Msg("failed to retrieve odds history")
+		h.errorResponse(w, http.StatusInternalServerError, "failed to retrieve odds history")
+		return
+	}
+	if !regionAllowed(odds, requestRegion(r)) {
+		h.errorResponse(w, http.StatusNotFound, "odds not found")
+		return
+	}
+
+	h.oddsResponse(w, envelope, h.casedOdds(odds), []*models.OptimizedOdds{odds})
+}
+
+// maxSearchResults caps, and is the default of, the odds one search returns
+const maxSearchResults = 1000
+
+// handleSearchOdds handles GET /api/v1/odds/search?sport=&from=&to=[&limit=]
+func (h *OddsHandler) handleSearchOdds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	sport := query.Get("sport")
+	if sport == "" || query.Get("from") == "" || query.Get("to") == "" {
+		h.errorResponse(w, http.StatusBadRequest, "sport, from, and to are required")
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid from: expected RFC 3339 timestamp")
+		return
+	}
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid to: expected RFC 3339 timestamp")
+		return
+	}
+	if !to.After(from) {
+		h.errorResponse(w, http.StatusBadRequest, "to must be after from")
+		return
+	}
+
+	limit := maxSearchResults
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxSearchResults {
+			h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: expected 1-%d", maxSearchResults))
+			return
+		}
+	}
+	envelope, ok := h.parseEnvelope(w, r)
+	if !ok {
+		return
+	}
+
+	oddsList, err := h.service.SearchOdds(r.Context(), sport, from, to, limit)
+	switch {
+	case errors.Is(err, service.ErrStoreDisabled):
+		h.errorResponse(w, http.StatusServiceUnavailable, "odds store is not enabled")
+		return
+	case err != nil:
+		h.logger.Error().
+			Err(err).
+			Str("sport", sport).
+			Time("from", from).
+			Time("to", to).
+			Msg("failed to search odds")
+		h.errorResponse(w, http.StatusInternalServerError, "failed to search odds")
+		return
+	}
+
+	oddsList = filterRegion(oddsList, requestRegion(r))
+	h.oddsResponse(w, envelope, map[string]interface{}{
+		"sport": sport,
+		"count": len(oddsList),
+		"odds":  h.casedOddsList(oddsList),
+	}, oddsList)
 }
 
-// handleGetEventOdds handles GET /api/v1/events/:event_id/odds
+// handleGetEventOdds handles GET /api/v1/events/:event_id/odds, honoring
+// If-None-Match. A markets allow-list restricts the response to those
+// markets, in the requested order; unknown markets are omitted. With a
+// format, odds are returned as display responses whose prices are rendered
+// as decimal (2.50), fractional (3/2) or american (+150); without one, prices
+// stay in their native decimal form.
 func (h *OddsHandler) handleGetEventOdds(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.errorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	// Parse path: /api/v1/events/:event_id/odds
+	// Parse path: /api/v1/events/:event_id/{odds,overround,closing,diff}
 	path := strings.TrimPrefix(r.URL.Path, "/api/v1/events/")
 	parts := strings.Split(path, "/")
 
-	if len(parts) != 2 || parts[1] != "odds" {
+	if len(parts) != 2 || (parts[1] != "odds" && parts[1] != "overround" && parts[1] != "closing" && parts[1] != "diff") {
 		h.errorResponse(w, http.StatusBadRequest, "invalid path: expected /api/v1/events/:event_id/odds")
 		return
 	}
@@ -97,6 +348,36 @@ func (h *OddsHandler) handleGetEventOdds(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	switch parts[1] {
+	case "overround":
+		if h.refuseStale(w) {
+			return
+		}
+		h.handleGetBookOverround(w, r, eventID)
+		return
+	case "closing":
+		h.handleGetClosingLines(w, r, eventID)
+		return
+	case "diff":
+		h.handleGetMarketDiff(w, r, eventID)
+		return
+	}
+
+	// Validate the display format before fetching anything
+	rawFormat := r.URL.Query().Get("format")
+	format, err := optimizer.ParseOddsFormat(rawFormat)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid format: expected decimal, fractional or american")
+		return
+	}
+	envelope, ok := h.parseEnvelope(w, r)
+	if !ok {
+		return
+	}
+	if h.refuseStale(w) {
+		return
+	}
+
 	// Get all odds for event from service
 	oddsList, err := h.service.GetOptimizedOddsByEvent(r.Context(), eventID)
 	if err != nil {
@@ -108,26 +389,554 @@ func (h *OddsHandler) handleGetEventOdds(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h.jsonResponse(w, http.StatusOK, map[string]interface{}{
+	// Order deterministically so unchanged odds always hash to the same ETag
+	marketOrder := parseMarkets(r.URL.Query().Get("markets"))
+	if marketOrder != nil {
+		oddsList = filterMarkets(oddsList, marketOrder)
+	}
+	oddsList = filterRegion(oddsList, requestRegion(r))
+	sort.Slice(oddsList, func(i, j int) bool {
+		if oddsList[i].Market != oddsList[j].Market {
+			if marketOrder != nil {
+				return marketOrder[oddsList[i].Market] < marketOrder[oddsList[j].Market]
+			}
+			return oddsList[i].Market < oddsList[j].Market
+		}
+		return oddsList[i].Selection < oddsList[j].Selection
+	})
+
+	total := len(oddsList)
+	confidence := eventConfidence(oddsList) // Over the whole book, before truncation
+	respond := func(oddsList []*models.OptimizedOdds) interface{} {
+		resp := map[string]interface{}{
+			"event_id":         eventID,
+			"count":            len(oddsList),
+			"odds":             h.casedOddsList(oddsList),
+			"event_confidence": confidence,
+		}
+		if rawFormat != "" {
+			resp["format"] = format
+			resp["odds"] = h.toFormattedResponses(oddsList, format)
+		}
+		if len(oddsList) < total {
+			resp["truncated"] = true
+			resp["total"] = total
+		}
+		return resp
+	}
+	oddsList = h.limitEventOdds(oddsList, respond, envelope)
+	resp := respond(oddsList)
+	if len(oddsList) < total {
+		h.logger.Warn().
+			Str("event_id", eventID).
+			Int("total", total).
+			Int("count", len(oddsList)).
+			Msg("event odds response truncated")
+	}
+
+	// Pollers send If-None-Match to skip re-downloading unchanged books
+	var env *Envelope
+	if envelope {
+		env = newEnvelope(oddsList)
+	}
+	writeJSONWithETag(w, r, h.logger, resp, env)
+}
+
+// eventConfidence aggregates the confidence of an event's selections into
+// the liquidity-weighted mean sum(confidence*liquidity)/sum(liquidity), where
+// a selection's liquidity is its back size plus its lay size (negative sizes
+// count as zero). When no selection reports liquidity it is the plain mean.
+// It is nil for an event without selections, which has no prices to trust.
+func eventConfidence(oddsList []*models.OptimizedOdds) *float64 {
+	if len(oddsList) == 0 {
+		return nil
+	}
+
+	var weighted, liquidity, sum float64
+	for _, odds := range oddsList {
+		size := decimal.Max(odds.BackSize, decimal.Zero).Add(decimal.Max(odds.LaySize, decimal.Zero)).InexactFloat64()
+		weighted += odds.Confidence * size
+		liquidity += size
+		sum += odds.Confidence
+	}
+
+	confidence := sum / float64(len(oddsList))
+	if liquidity > 0 {
+		confidence = weighted / liquidity
+	}
+	return &confidence
+}
+
+// toFormattedResponses converts odds to API responses with every price
+// rendered in format
+func (h *OddsHandler) toFormattedResponses(oddsList []*models.OptimizedOdds, format optimizer.OddsFormat) []*OddsResponse {
+	responses := make([]*OddsResponse, 0, len(oddsList))
+	for _, odds := range oddsList {
+		resp := h.toOddsResponse(odds)
+		resp.OptimizedBack = format.Render(odds.OptimizedBack)
+		resp.OptimizedLay = format.Render(odds.OptimizedLay)
+		resp.FairPrice = format.Render(odds.FairPrice)
+		resp.OriginalBack = format.Render(odds.OriginalBack)
+		resp.OriginalLay = format.Render(odds.OriginalLay)
+		if odds.SportsbookPrice != nil {
+			resp.Sportsbook = format.Render(*odds.SportsbookPrice)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// limitEventOdds returns the leading odds that fit the event odds caps. The
+// byte cap applies to the response body as sent: the response respond builds
+// for the odds, in an envelope when envelope is set.
+func (h *OddsHandler) limitEventOdds(oddsList []*models.OptimizedOdds, respond func([]*models.OptimizedOdds) interface{}, envelope bool) []*models.OptimizedOdds {
+	if h.eventMaxSelections > 0 && len(oddsList) > h.eventMaxSelections {
+		oddsList = oddsList[:h.eventMaxSelections]
+	}
+	if h.eventMaxBytes <= 0 {
+		return oddsList
+	}
+
+	// The body grows with every selection, so find the longest prefix that fits
+	fits := func(n int) bool {
+		return encodedSize(respond(oddsList[:n]), oddsList[:n], envelope) <= h.eventMaxBytes
+	}
+	if fits(len(oddsList)) {
+		return oddsList
+	}
+	return oddsList[:sort.Search(len(oddsList), func(n int) bool { return !fits(n + 1) })]
+}
+
+// encodedSize returns the length of data as writeJSONWithETag sends it,
+// wrapped in an envelope dated by oddsList when envelope is set. Data that
+// fails to encode counts as empty, failing when written instead.
+func encodedSize(data interface{}, oddsList []*models.OptimizedOdds, envelope bool) int {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return 0
+	}
+	if envelope {
+		env := newEnvelope(oddsList)
+		env.ServerTime = env.ServerTime.Truncate(time.Second).Add(time.Second - time.Nanosecond) // At its longest, whenever it is sent
+		env.Data = json.RawMessage(body)
+		if body, err = json.Marshal(env); err != nil {
+			return 0
+		}
+	}
+	return len(body) + 1 // The encoder's trailing newline
+}
+
+// BookOverroundResponse is the response of GET /api/v1/events/:event_id/overround
+type BookOverroundResponse struct {
+	EventID    string          `json:"event_id"`
+	Market     string          `json:"market"`
+	Overround  decimal.Decimal `json:"overround"`  // Summed implied probability of the cached optimized back prices
+	Selections int             `json:"selections"` // Selections summed
+}
+
+// handleGetBookOverround handles GET /api/v1/events/:event_id/overround?market=
+func (h *OddsHandler) handleGetBookOverround(w http.ResponseWriter, r *http.Request, eventID string) {
+	market := r.URL.Query().Get("market")
+	if market == "" {
+		h.errorResponse(w, http.StatusBadRequest, "market is required")
+		return
+	}
+
+	oddsList, err := h.service.GetOptimizedOddsByEvent(r.Context(), eventID)
+	if err != nil {
+		h.logger.Error().
+			Err(err).
+			Str("event_id", eventID).
+			Str("market", market).
+			Msg("failed to compute book overround")
+		h.errorResponse(w, http.StatusInternalServerError, "failed to retrieve odds")
+		return
+	}
+	overround, count := service.BookOverround(filterRegion(oddsList, requestRegion(r)), market)
+	if count == 0 {
+		h.errorResponse(w, http.StatusNotFound, "odds not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, BookOverroundResponse{
+		EventID:    eventID,
+		Market:     market,
+		Overround:  overround,
+		Selections: count,
+	})
+}
+
+// Statuses of a selection in a market diff
+const (
+	diffAdded     = "added"     // Priced at to only
+	diffRemoved   = "removed"   // Priced at from only, e.g. its price aged out before to
+	diffChanged   = "changed"   // Priced at both, at a different back or lay
+	diffUnchanged = "unchanged" // Priced at both, at the same back and lay
+)
+
+// MarketDiffResponse is the response of GET /api/v1/events/:event_id/diff
+type MarketDiffResponse struct {
+	EventID    string          `json:"event_id"`
+	Market     string          `json:"market"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Selections []SelectionDiff `json:"selections"`
+}
+
+// SelectionDiff is how one selection's optimized price moved between from and to
+type SelectionDiff struct {
+	Selection string           `json:"selection"`
+	Status    string           `json:"status"`               // added, removed, changed or unchanged
+	From      *DiffPrice       `json:"from,omitempty"`       // Price current at from; absent when added
+	To        *DiffPrice       `json:"to,omitempty"`         // Price current at to; absent when removed
+	BackDelta *decimal.Decimal `json:"back_delta,omitempty"` // To back minus from back; absent when added or removed
+	LayDelta  *decimal.Decimal `json:"lay_delta,omitempty"`  // To lay minus from lay; absent when added or removed
+}
+
+// DiffPrice is a selection's optimized price at one end of a diff
+type DiffPrice struct {
+	OptimizedBack decimal.Decimal `json:"optimized_back"`
+	OptimizedLay  decimal.Decimal `json:"optimized_lay"`
+	OptimizedAt   time.Time       `json:"optimized_at"`
+}
+
+// handleGetMarketDiff handles GET /api/v1/events/:event_id/diff?market=&from=&to=
+func (h *OddsHandler) handleGetMarketDiff(w http.ResponseWriter, r *http.Request, eventID string) {
+	query := r.URL.Query()
+	market := query.Get("market")
+	if market == "" || query.Get("from") == "" || query.Get("to") == "" {
+		h.errorResponse(w, http.StatusBadRequest, "market, from, and to are required")
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid from: expected RFC 3339 timestamp")
+		return
+	}
+	to, err := time.Parse(time.RFC3339, query.Get("to"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid to: expected RFC 3339 timestamp")
+		return
+	}
+	if to.Before(from) {
+		h.errorResponse(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	before, err := h.service.GetMarketAt(r.Context(), eventID, market, from)
+	var after []*models.OptimizedOdds
+	if err == nil {
+		after, err = h.service.GetMarketAt(r.Context(), eventID, market, to)
+	}
+	switch {
+	case errors.Is(err, service.ErrHistoryDisabled):
+		h.errorResponse(w, http.StatusServiceUnavailable, "odds history is not enabled")
+		return
+	case err != nil:
+		h.logger.Error().
+			Err(err).
+			Str("event_id", eventID).
+			Str("market", market).
+			Msg("failed to retrieve market history")
+		h.errorResponse(w, http.StatusInternalServerError, "failed to retrieve odds history")
+		return
+	}
+
+	region := requestRegion(r)
+	selections := diffMarket(filterRegion(before, region), filterRegion(after, region))
+	if len(selections) == 0 {
+		h.errorResponse(w, http.StatusNotFound, "odds not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, MarketDiffResponse{
+		EventID:    eventID,
+		Market:     market,
+		From:       from,
+		To:         to,
+		Selections: selections,
+	})
+}
+
+// diffMarket pairs each selection's snapshots at from and at to, ordered by selection
+func diffMarket(before, after []*models.OptimizedOdds) []SelectionDiff {
+	diffs := make(map[string]*SelectionDiff)
+	entry := func(selection string) *SelectionDiff {
+		if diffs[selection] == nil {
+			diffs[selection] = &SelectionDiff{Selection: selection}
+		}
+		return diffs[selection]
+	}
+	for _, odds := range before {
+		entry(odds.Selection).From = &DiffPrice{OptimizedBack: odds.OptimizedBack, OptimizedLay: odds.OptimizedLay, OptimizedAt: odds.OptimizedAt}
+	}
+	for _, odds := range after {
+		entry(odds.Selection).To = &DiffPrice{OptimizedBack: odds.OptimizedBack, OptimizedLay: odds.OptimizedLay, OptimizedAt: odds.OptimizedAt}
+	}
+
+	selections := make([]SelectionDiff, 0, len(diffs))
+	for _, diff := range diffs {
+		switch {
+		case diff.From == nil:
+			diff.Status = diffAdded
+		case diff.To == nil:
+			diff.Status = diffRemoved
+		default:
+			backDelta := diff.To.OptimizedBack.Sub(diff.From.OptimizedBack)
+			layDelta := diff.To.OptimizedLay.Sub(diff.From.OptimizedLay)
+			diff.BackDelta, diff.LayDelta = &backDelta, &layDelta
+			diff.Status = diffUnchanged
+			if !backDelta.IsZero() || !layDelta.IsZero() {
+				diff.Status = diffChanged
+			}
+		}
+		selections = append(selections, *diff)
+	}
+	sort.Slice(selections, func(i, j int) bool {
+		return selections[i].Selection < selections[j].Selection
+	})
+	return selections
+}
+
+// handleGetClosingLines handles GET /api/v1/events/:event_id/closing
+func (h *OddsHandler) handleGetClosingLines(w http.ResponseWriter, r *http.Request, eventID string) {
+	envelope, ok := h.parseEnvelope(w, r)
+	if !ok {
+		return
+	}
+
+	oddsList, err := h.service.GetClosingLines(r.Context(), eventID)
+	oddsList = filterRegion(oddsList, requestRegion(r))
+	switch {
+	case errors.Is(err, service.ErrClosingLinesDisabled):
+		h.errorResponse(w, http.StatusServiceUnavailable, "closing line capture is not enabled")
+		return
+	case err != nil:
+		h.logger.Error().
+			Err(err).
+			Str("event_id", eventID).
+			Msg("failed to retrieve closing lines")
+		h.errorResponse(w, http.StatusInternalServerError, "failed to retrieve closing lines")
+		return
+	case len(oddsList) == 0:
+		h.errorResponse(w, http.StatusNotFound, "closing line not captured")
+		return
+	}
+
+	sort.Slice(oddsList, func(i, j int) bool {
+		if oddsList[i].Market != oddsList[j].Market {
+			return oddsList[i].Market < oddsList[j].Market
+		}
+		return oddsList[i].Selection < oddsList[j].Selection
+	})
+
+	h.oddsResponse(w, envelope, map[string]interface{}{
 		"event_id": eventID,
 		"count":    len(oddsList),
-		"odds":     oddsList,
+		"odds":     h.casedOddsList(oddsList),
+	}, oddsList)
+}
+
+// parseMarkets parses a comma-separated markets allow-list into each
+// market's position; nil means no filter
+func parseMarkets(raw string) map[string]int {
+	if raw == "" {
+		return nil
+	}
+
+	order := make(map[string]int)
+	for _, market := range strings.Split(raw, ",") {
+		market = strings.TrimSpace(market)
+		if _, seen := order[market]; market == "" || seen {
+			continue
+		}
+		order[market] = len(order)
+	}
+	return order
+}
+
+// filterMarkets keeps odds whose market is in the allow-list
+func filterMarkets(oddsList []*models.OptimizedOdds, allowed map[string]int) []*models.OptimizedOdds {
+	filtered := make([]*models.OptimizedOdds, 0, len(oddsList))
+	for _, odds := range oddsList {
+		if _, ok := allowed[odds.Market]; ok {
+			filtered = append(filtered, odds)
+		}
+	}
+	return filtered
+}
+
+// maxEventsPerRequest caps the events one POST /api/v1/events/odds may request
+const maxEventsPerRequest = 50
+
+// EventsOddsRequest is the request body of POST /api/v1/events/odds
+type EventsOddsRequest struct {
+	EventIDs []string `json:"event_ids"`
+}
+
+// handleGetEventsOdds handles POST /api/v1/events/odds
+func (h *OddsHandler) handleGetEventsOdds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req EventsOddsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.EventIDs) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "event_ids are required")
+		return
+	}
+	if len(req.EventIDs) > maxEventsPerRequest {
+		h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("at most %d event_ids per request", maxEventsPerRequest))
+		return
+	}
+	for _, eventID := range req.EventIDs {
+		if eventID == "" {
+			h.errorResponse(w, http.StatusBadRequest, "event_ids must not be empty")
+			return
+		}
+	}
+	envelope, ok := h.parseEnvelope(w, r)
+	if !ok {
+		return
+	}
+	if h.refuseStale(w) {
+		return
+	}
+
+	events, err := h.service.GetOptimizedOddsByEvents(r.Context(), req.EventIDs)
+	if err != nil {
+		h.logger.Error().
+			Err(err).
+			Int("event_count", len(req.EventIDs)).
+			Msg("failed to retrieve events odds")
+		h.errorResponse(w, http.StatusInternalServerError, "failed to retrieve odds")
+		return
+	}
+	region := requestRegion(r)
+	var allOdds []*models.OptimizedOdds
+	cased := make(map[string]interface{}, len(events))
+	for eventID, eventOdds := range events {
+		eventOdds = filterRegion(eventOdds, region)
+		allOdds = append(allOdds, eventOdds...)
+		cased[eventID] = h.casedOddsList(eventOdds)
+	}
+
+	h.oddsResponse(w, envelope, map[string]interface{}{
+		"count":  len(cased),
+		"events": cased,
+	}, allOdds)
+}
+
+// CompareModelsRequest is the request body of POST /api/v1/optimize/models
+type CompareModelsRequest struct {
+	Odds []*models.NormalizedOdds `json:"odds"`
+}
+
+// handleCompareModels handles POST /api/v1/optimize/models
+func (h *OddsHandler) handleCompareModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req CompareModelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Odds) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "odds are required")
+		return
+	}
+
+	results, err := h.service.CompareMarginModels(req.Odds)
+	if err != nil {
+		h.logger.Debug().
+			Err(err).
+			Int("count", len(req.Odds)).
+			Msg("margin model comparison failed")
+		h.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	byModel := make(map[string][]*OddsResponse, len(results))
+	for model, oddsList := range results {
+		responses := make([]*OddsResponse, 0, len(oddsList))
+		for _, odds := range oddsList {
+			responses = append(responses, h.toOddsResponse(odds))
+		}
+		byModel[string(model)] = responses
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"count":  len(req.Odds),
+		"models": byModel,
+	})
+}
+
+// handleExplain handles POST /api/v1/optimize/explain
+func (h *OddsHandler) handleExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.errorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var normalized models.NormalizedOdds
+	if err := json.NewDecoder(r.Body).Decode(&normalized); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	odds, explanation, err := h.service.ExplainOdds(r.Context(), &normalized)
+	switch {
+	case errors.Is(err, optimizer.ErrInvalidBackPrice), errors.Is(err, optimizer.ErrNonTradeable):
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		h.logger.Debug().
+			Err(err).
+			Str("event_id", normalized.EventID).
+			Str("selection", normalized.Selection).
+			Msg("explain failed")
+		h.errorResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"odds":        h.toOddsResponse(odds),
+		"explanation": explanation,
 	})
 }
 
 // jsonResponse writes a JSON response
 func (h *OddsHandler) jsonResponse(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// errorResponse writes a JSON error response
+func (h *OddsHandler) errorResponse(w http.ResponseWriter, status int, message string) {
+	writeError(w, h.logger, status, message)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, logger zerolog.Logger, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		h.logger.Error().Err(err).Msg("failed to encode JSON response")
+		logger.Error().Err(err).Msg("failed to encode JSON response")
 	}
 }
 
-// errorResponse writes a JSON error response
-func (h *OddsHandler) errorResponse(w http.ResponseWriter, status int, message string) {
-	h.jsonResponse(w, status, map[string]string{
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, logger zerolog.Logger, status int, message string) {
+	writeJSON(w, logger, status, map[string]string{
 		"error": message,
 	})
 }
@@ -140,30 +949,45 @@ type OddsResponse struct {
 	Competition   string  `json:"competition"`
 	Market        string  `json:"market"`
 	Selection     string  `json:"selection"`
+	Display       string  `json:"display_selection,omitempty"`
 	OptimizedBack string  `json:"optimized_back"`
 	OptimizedLay  string  `json:"optimized_lay"`
+	FairPrice     string  `json:"fair_price"`
+	Sportsbook    string  `json:"sportsbook_price,omitempty"`
 	OriginalBack  string  `json:"original_back"`
 	OriginalLay   string  `json:"original_lay"`
 	Margin        string  `json:"margin"`
 	Confidence    float64 `json:"confidence"`
+	Source        string  `json:"source,omitempty"`
+	Stale         bool    `json:"stale,omitempty"`
 	OptimizedAt   string  `json:"optimized_at"`
+
+	camel bool // Encode with camelCase field names
 }
 
 // ToOddsResponse converts OptimizedOdds to API response format
 func ToOddsResponse(odds *models.OptimizedOdds) *OddsResponse {
-	return &OddsResponse{
+	resp := &OddsResponse{
 		EventID:       odds.EventID,
 		EventName:     odds.EventName,
 		Sport:         odds.Sport,
 		Competition:   odds.Competition,
 		Market:        odds.Market,
 		Selection:     odds.Selection,
+		Display:       odds.DisplaySelection,
 		OptimizedBack: odds.OptimizedBack.String(),
 		OptimizedLay:  odds.OptimizedLay.String(),
+		FairPrice:     odds.FairPrice.String(),
 		OriginalBack:  odds.OriginalBack.String(),
 		OriginalLay:   odds.OriginalLay.String(),
 		Margin:        odds.Margin.String(),
 		Confidence:    odds.Confidence,
+		Source:        odds.Source,
+		Stale:         odds.Stale,
 		OptimizedAt:   odds.OptimizedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
+	if odds.SportsbookPrice != nil {
+		resp.Sportsbook = odds.SportsbookPrice.String()
+	}
+	return resp
 }
@@ -0,0 +1,1377 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/cache"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// getOddsHistory issues GET /api/v1/odds/history for Team A at the given raw timestamp
+func getOddsHistory(t *testing.T, mux *http.ServeMux, at string) *httptest.ResponseRecorder {
+	t.Helper()
+	query := url.Values{
+		"event_id":  {"event-123"},
+		"market":    {"match_winner"},
+		"selection": {"Team A"},
+		"at":        {at},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/odds/history?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestGetOddsHistory tests point-in-time queries against several snapshots
+func TestGetOddsHistory(t *testing.T) {
+	svc, mockCache := newTestService(t)
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	mr := miniredis.RunT(t)
+	history := cache.NewRedisHistory(cache.RedisHistoryConfig{Addr: mr.Addr()}, zerolog.Nop())
+	defer history.Close()
+	svc.SetHistory(history)
+
+	// Price Team A three times, ten minutes apart
+	base := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	prices := make([]string, 0, 3)
+	for i, backPrice := range []float64{2.50, 2.80, 3.10} {
+		mr.SetTime(base.Add(time.Duration(i*10) * time.Minute))
+		optimized, err := svc.OptimizeOdds(context.Background(), newTestNormalizedOdds("Team A", backPrice))
+		require.NoError(t, err)
+		prices = append(prices, optimized.OptimizedBack.String())
+	}
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected string
+	}{
+		{name: "First snapshot", at: base.Add(5 * time.Minute), expected: prices[0]},
+		{name: "Second snapshot", at: base.Add(10 * time.Minute), expected: prices[1]},
+		{name: "Latest snapshot", at: base.Add(time.Hour), expected: prices[2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getOddsHistory(t, mux, tt.at.Format(time.RFC3339))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var odds models.OptimizedOdds
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &odds))
+			assert.Equal(t, tt.expected, odds.OptimizedBack.String())
+		})
+	}
+
+	t.Run("Before first snapshot", func(t *testing.T) {
+		rec := getOddsHistory(t, mux, base.Add(-time.Minute).Format(time.RFC3339))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Invalid timestamp", func(t *testing.T) {
+		rec := getOddsHistory(t, mux, "yesterday at 14:32")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+// TestGetOddsHistory_Disabled tests the endpoint without a history store
+func TestGetOddsHistory_Disabled(t *testing.T) {
+	svc, _ := newTestService(t)
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	rec := getOddsHistory(t, mux, time.Now().Format(time.RFC3339))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+// TestGetMarketDiff tests diffing a market's prices between two times
+func TestGetMarketDiff(t *testing.T) {
+	svc, _ := newTestService(t)
+	mr := miniredis.RunT(t)
+	history := cache.NewRedisHistory(cache.RedisHistoryConfig{Addr: mr.Addr()}, zerolog.Nop())
+	defer history.Close()
+	svc.SetHistory(history)
+	svc.SetMaxServeAge(10*time.Minute, false)
+
+	base := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	record := func(at time.Time, prices map[string]float64) {
+		mr.SetTime(at)
+		oddsList := make([]*models.OptimizedOdds, 0, len(prices))
+		for selection, backPrice := range prices {
+			oddsList = append(oddsList, &models.OptimizedOdds{
+				EventID:       "event-123",
+				Market:        "match_winner",
+				Selection:     selection,
+				OptimizedBack: decimal.NewFromFloat(backPrice),
+				OptimizedLay:  decimal.NewFromFloat(backPrice + 0.1),
+				OptimizedAt:   at,
+			})
+		}
+		require.NoError(t, history.Append(context.Background(), oddsList))
+	}
+
+	// Team A moves and Team B holds; the Draw is not repriced, so its price
+	// ages out before to, and Team C is first priced after from
+	record(base, map[string]float64{"Team A": 2.50, "Team B": 3.00, "Draw": 3.40})
+	record(base.Add(15*time.Minute), map[string]float64{"Team A": 2.80, "Team B": 3.00, "Team C": 5.00})
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	diff := func(query url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/diff?"+query.Encode(), nil))
+		return rec
+	}
+	valid := url.Values{
+		"market": {"match_winner"},
+		"from":   {base.Add(5 * time.Minute).Format(time.RFC3339)},
+		"to":     {base.Add(20 * time.Minute).Format(time.RFC3339)},
+	}
+
+	rec := diff(valid)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp MarketDiffResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "event-123", resp.EventID)
+	assert.Equal(t, "match_winner", resp.Market)
+	require.Len(t, resp.Selections, 4)
+
+	draw, teamA, teamB, teamC := resp.Selections[0], resp.Selections[1], resp.Selections[2], resp.Selections[3]
+
+	assert.Equal(t, "Draw", draw.Selection)
+	assert.Equal(t, "removed", draw.Status)
+	require.NotNil(t, draw.From)
+	assert.Equal(t, "3.4", draw.From.OptimizedBack.String())
+	assert.Nil(t, draw.To)
+	assert.Nil(t, draw.BackDelta)
+
+	assert.Equal(t, "Team A", teamA.Selection)
+	assert.Equal(t, "changed", teamA.Status)
+	require.NotNil(t, teamA.BackDelta)
+	require.NotNil(t, teamA.LayDelta)
+	assert.Equal(t, "0.3", teamA.BackDelta.String())
+	assert.Equal(t, "0.3", teamA.LayDelta.String())
+	assert.Equal(t, "2.5", teamA.From.OptimizedBack.String())
+	assert.Equal(t, "2.8", teamA.To.OptimizedBack.String())
+
+	assert.Equal(t, "Team B", teamB.Selection)
+	assert.Equal(t, "unchanged", teamB.Status)
+	require.NotNil(t, teamB.BackDelta)
+	assert.True(t, teamB.BackDelta.IsZero())
+
+	assert.Equal(t, "Team C", teamC.Selection)
+	assert.Equal(t, "added", teamC.Status)
+	assert.Nil(t, teamC.From)
+	require.NotNil(t, teamC.To)
+	assert.Equal(t, "5", teamC.To.OptimizedBack.String())
+
+	t.Run("No snapshots", func(t *testing.T) {
+		query := url.Values{"market": {"match_winner"}, "from": {base.Add(-time.Hour).Format(time.RFC3339)}, "to": {base.Add(-time.Minute).Format(time.RFC3339)}}
+		assert.Equal(t, http.StatusNotFound, diff(query).Code)
+	})
+
+	for name, query := range map[string]url.Values{
+		"Missing market":    {"from": valid["from"], "to": valid["to"]},
+		"Invalid from":      {"market": valid["market"], "from": {"yesterday"}, "to": valid["to"]},
+		"To before from":    {"market": valid["market"], "from": valid["to"], "to": valid["from"]},
+		"Missing timestamp": {"market": valid["market"], "from": valid["from"]},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, diff(query).Code)
+		})
+	}
+
+	t.Run("History disabled", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		mux := http.NewServeMux()
+		NewOddsHandler(svc, zerolog.Nop()).RegisterRoutes(mux)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/diff?"+valid.Encode(), nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+}
+
+// TestSearchOdds tests searching the odds store by sport and time range
+func TestSearchOdds(t *testing.T) {
+	svc, _ := newTestService(t)
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	search := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/odds/search?"+query, nil))
+		return rec
+	}
+	from := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	valid := url.Values{"sport": {"football"}, "from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}}
+
+	// Without a store
+	assert.Equal(t, http.StatusServiceUnavailable, search(valid.Encode()).Code)
+
+	store := mocks.NewMockStore(gomock.NewController(t))
+	svc.SetStore(service.NewStoreWriter(store, service.StoreWriterConfig{}, zerolog.Nop()))
+
+	store.EXPECT().Search(gomock.Any(), "football", from, to, maxSearchResults).
+		Return([]*models.OptimizedOdds{{EventID: "event-123", Sport: "football"}}, nil)
+	rec := search(valid.Encode())
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Count int                     `json:"count"`
+		Odds  []*models.OptimizedOdds `json:"odds"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Count)
+	assert.Equal(t, "event-123", resp.Odds[0].EventID)
+
+	store.EXPECT().Search(gomock.Any(), "football", from, to, 10).Return(nil, errors.New("connection refused"))
+	assert.Equal(t, http.StatusInternalServerError, search(valid.Encode()+"&limit=10").Code)
+
+	for _, query := range []string{
+		"from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339),
+		"sport=football&from=yesterday&to=" + to.Format(time.RFC3339),
+		"sport=football&from=" + to.Format(time.RFC3339) + "&to=" + from.Format(time.RFC3339),
+		valid.Encode() + "&limit=0",
+		valid.Encode() + "&limit=" + strconv.Itoa(maxSearchResults+1),
+	} {
+		assert.Equal(t, http.StatusBadRequest, search(query).Code, query)
+	}
+}
+
+// TestGetClosingLines tests retrieving an event's captured closing lines
+func TestGetClosingLines(t *testing.T) {
+	svc, _ := newTestService(t)
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/closing", nil))
+		return rec
+	}
+
+	// Without a closing line store
+	assert.Equal(t, http.StatusServiceUnavailable, get().Code)
+
+	mr := miniredis.RunT(t)
+	closingLines := cache.NewRedisClosingLines(cache.RedisClosingLinesConfig{Addr: mr.Addr(), TTL: time.Hour}, zerolog.Nop())
+	defer closingLines.Close()
+	svc.SetClosingLines(closingLines)
+
+	// Not captured yet
+	assert.Equal(t, http.StatusNotFound, get().Code)
+
+	kickoff := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	var captured []*models.OptimizedOdds
+	for _, selection := range []string{"Team B", "Team A"} {
+		captured = append(captured, &models.OptimizedOdds{
+			EventID:       "event-123",
+			Market:        "match_winner",
+			Selection:     selection,
+			OptimizedBack: decimal.NewFromFloat(2.40),
+			StartTime:     kickoff,
+		})
+	}
+	require.NoError(t, closingLines.Capture(context.Background(), captured))
+
+	rec := get()
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		EventID string                  `json:"event_id"`
+		Count   int                     `json:"count"`
+		Odds    []*models.OptimizedOdds `json:"odds"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "event-123", body.EventID)
+	require.Equal(t, 2, body.Count)
+	assert.Equal(t, "Team A", body.Odds[0].Selection)
+	assert.Equal(t, "Team B", body.Odds[1].Selection)
+	assert.True(t, kickoff.Equal(body.Odds[0].StartTime))
+	assert.True(t, decimal.NewFromFloat(2.40).Equal(body.Odds[0].OptimizedBack))
+}
+
+// TestGetOdds_NormalizedSelections tests that selection variants share one cache entry
+func TestGetOdds_NormalizedSelections(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:           decimal.NewFromFloat(0.02),
+		MaxMargin:           decimal.NewFromFloat(0.10),
+		MinSpread:           decimal.NewFromFloat(0.05),
+		TargetConfidence:    0.85,
+		NormalizeSelections: true,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("team a", 2.50),
+		newTestNormalizedOdds("TEAM  A", 2.50),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, memoryCache.Len())
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	// Any variant finds the canonical entry
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var odds models.OptimizedOdds
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &odds))
+	assert.Equal(t, "team a", odds.Selection)
+	assert.Equal(t, "TEAM A", odds.DisplaySelection) // Last write wins
+}
+
+// TestGetOdds_CacheMetaHeaders tests that the single-odds endpoint reports
+// the cached price's age and remaining TTL
+func TestGetOdds_CacheMetaHeaders(t *testing.T) {
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(models.OptimizationParams{}, zerolog.Nop()), memoryCache, zerolog.Nop())
+	require.NoError(t, memoryCache.Set(context.Background(), &models.OptimizedOdds{
+		EventID:     "event-123",
+		Market:      "match_winner",
+		Selection:   "Team A",
+		OptimizedAt: time.Now().Add(-90 * time.Second),
+	}))
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, "90", rec.Header().Get("X-Cache-Age"))
+	ttl, err := strconv.Atoi(rec.Header().Get("X-Cache-TTL"))
+	require.NoError(t, err)
+	assert.InDelta(t, 60, ttl, 1)
+}
+
+// TestGetOdds_StaleHeader tests odds served within the stale grace window
+// carry X-Odds-Stale and fresh odds do not
+func TestGetOdds_StaleHeader(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache := cache.NewRedisCache(cache.RedisCacheConfig{Addr: mr.Addr(), TTL: time.Minute, StaleGrace: time.Minute}, zerolog.Nop())
+	defer redisCache.Close()
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(models.OptimizationParams{}, zerolog.Nop()), redisCache, zerolog.Nop())
+	require.NoError(t, redisCache.Set(context.Background(), &models.OptimizedOdds{
+		EventID:     "event-123",
+		Market:      "match_winner",
+		Selection:   "Team A",
+		OptimizedAt: time.Now(),
+	}))
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	rec := get()
+	assert.Empty(t, rec.Header().Get("X-Odds-Stale"))
+	assert.NotContains(t, rec.Body.String(), `"stale"`)
+
+	mr.FastForward(90 * time.Second)
+	rec = get()
+	assert.Equal(t, "true", rec.Header().Get("X-Odds-Stale"))
+	assert.Empty(t, rec.Header().Get("X-Cache-TTL"))
+	assert.Contains(t, rec.Body.String(), `"stale":true`)
+}
+
+// TestGetOdds_FuzzyFallback tests exact hits, fuzzy hits and misses with
+// fallback=fuzzy, and that the default lookup stays strict
+func TestGetOdds_FuzzyFallback(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Draw", 3.40),
+	})
+	require.NoError(t, err)
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		name              string
+		path              string
+		expectedStatus    int
+		expectedMatched   string
+		expectedSelection string
+	}{
+		{name: "Exact hit", path: "/api/v1/odds/event-123/match_winner/Team%20A?fallback=fuzzy", expectedStatus: http.StatusOK, expectedMatched: "exact", expectedSelection: "Team A"},
+		{name: "Fuzzy hit", path: "/api/v1/odds/event-123/match_winner/%20team%20%20a?fallback=fuzzy", expectedStatus: http.StatusOK, expectedMatched: "fuzzy", expectedSelection: "Team A"},
+		{name: "No match", path: "/api/v1/odds/event-123/match_winner/Team%20C?fallback=fuzzy", expectedStatus: http.StatusNotFound},
+		{name: "Other market", path: "/api/v1/odds/event-123/over_under/team%20a?fallback=fuzzy", expectedStatus: http.StatusNotFound},
+		{name: "Strict by default", path: "/api/v1/odds/event-123/match_winner/team%20a", expectedStatus: http.StatusNotFound},
+		{name: "Unknown fallback", path: "/api/v1/odds/event-123/match_winner/team%20a?fallback=nearest", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				models.OptimizedOdds
+				Matched string `json:"matched"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedMatched, body.Matched)
+			assert.Equal(t, tt.expectedSelection, body.Selection)
+			assert.Equal(t, "match_winner", body.Market)
+		})
+	}
+}
+
+// TestCompareModels tests that every margin model is returned and that they
+// disagree on an asymmetric book; the mock cache fails the test on any write
+func TestCompareModels(t *testing.T) {
+	svc, _ := newTestService(t)
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	// Heavy favourite against two longshots, 12.5% overround
+	body, err := json.Marshal(CompareModelsRequest{Odds: []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 1.50),
+		newTestNormalizedOdds("Draw", 3.00),
+		newTestNormalizedOdds("Team B", 8.00),
+	}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/optimize/models", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Models map[string][]*OddsResponse `json:"models"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Models, len(optimizer.MarginModels))
+
+	fairPrices := make(map[string]string, len(optimizer.MarginModels))
+	for _, model := range optimizer.MarginModels {
+		oddsList, ok := resp.Models[string(model)]
+		require.True(t, ok, "missing model %s", model)
+		require.Len(t, oddsList, 3)
+		assert.Equal(t, "Team B", oddsList[2].Selection)
+		fairPrices[string(model)] = oddsList[2].FairPrice
+	}
+
+	// The models spread the overround differently, so the longshot's fair price differs
+	assert.NotEqual(t, fairPrices["additive"], fairPrices["proportional"])
+	assert.NotEqual(t, fairPrices["proportional"], fairPrices["shin"])
+	assert.NotEqual(t, fairPrices["additive"], fairPrices["shin"])
+
+	t.Run("Empty body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/optimize/models", strings.NewReader(`{"odds":[]}`))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/optimize/models", nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+// TestExplain tests that the explain endpoint returns the price with its breakdown
+func TestExplain(t *testing.T) {
+	svc, _ := newTestService(t)
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	explain := func(normalized *models.NormalizedOdds) *httptest.ResponseRecorder {
+		body, err := json.Marshal(normalized)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/optimize/explain", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := explain(newTestNormalizedOdds("Team A", 2.50))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Odds        *OddsResponse          `json:"odds"`
+		Explanation *optimizer.Explanation `json:"explanation"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Odds)
+	require.NotNil(t, resp.Explanation)
+	assert.Equal(t, "Team A", resp.Odds.Selection)
+	assert.Equal(t, resp.Odds.Margin, resp.Explanation.Margin.Applied.String())
+	assert.True(t, decimal.NewFromFloat(0.8).Equal(resp.Explanation.Margin.SportMultiplier))
+	assert.Equal(t, resp.Odds.Confidence, resp.Explanation.Confidence.Confidence)
+
+	t.Run("Invalid back price", func(t *testing.T) {
+		rec := explain(newTestNormalizedOdds("Team A", 0.99))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Non-tradeable back price", func(t *testing.T) {
+		rec := explain(newTestNormalizedOdds("Team A", 1.0))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "non-tradeable")
+	})
+}
+
+// getEventOdds issues GET /api/v1/events/event-123/odds with an optional If-None-Match
+func getEventOdds(t *testing.T, mux *http.ServeMux, ifNoneMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/odds", nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestGetEventOdds_ETag tests conditional requests against the event odds endpoint
+func TestGetEventOdds_ETag(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 1.80),
+		newTestNormalizedOdds("Draw", 3.40),
+	})
+	require.NoError(t, err)
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	// First request: full body and an ETag
+	first := getEventOdds(t, mux, "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.True(t, strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`), "strong ETag %s", etag)
+
+	var body struct {
+		Count int                     `json:"count"`
+		Odds  []*models.OptimizedOdds `json:"odds"`
+	}
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &body))
+	assert.Equal(t, 3, body.Count)
+
+	// Unchanged odds hash to the same ETag regardless of cache iteration order
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, etag, getEventOdds(t, mux, "").Header().Get("ETag"))
+	}
+
+	// Conditional request: not modified
+	notModified := getEventOdds(t, mux, etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.Bytes())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	// Any matching entry in a list matches, weak or strong
+	assert.Equal(t, http.StatusNotModified, getEventOdds(t, mux, `"other", W/`+etag).Code)
+
+	// Changed odds: full body with a new ETag
+	_, err = svc.OptimizeOdds(context.Background(), newTestNormalizedOdds("Team A", 2.70))
+	require.NoError(t, err)
+
+	changed := getEventOdds(t, mux, etag)
+	require.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+	assert.NotEmpty(t, changed.Body.Bytes())
+}
+
+// TestGetEventOdds_Limits tests that event odds within the caps are served
+// in full and that odds over a cap are truncated and flagged
+func TestGetEventOdds_Limits(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 1.80),
+		newTestNormalizedOdds("Draw", 3.40),
+	})
+	require.NoError(t, err)
+
+	// Size of the response truncated to two selections, for a byte cap fitting two
+	capped := NewOddsHandler(svc, zerolog.Nop())
+	capped.SetEventOddsLimits(2, 0)
+	cappedMux := http.NewServeMux()
+	capped.RegisterRoutes(cappedMux)
+	twoSelections := getEventOdds(t, cappedMux, "").Body.Len()
+
+	tests := []struct {
+		name          string
+		maxSelections int
+		maxBytes      int
+		expected      []string
+		truncated     bool
+	}{
+		{name: "No caps", expected: []string{"Draw", "Team A", "Team B"}},
+		{name: "Under the caps", maxSelections: 3, maxBytes: 1 << 20, expected: []string{"Draw", "Team A", "Team B"}},
+		{name: "Over the selection cap", maxSelections: 2, expected: []string{"Draw", "Team A"}, truncated: true},
+		{name: "Over the byte cap", maxBytes: twoSelections, expected: []string{"Draw", "Team A"}, truncated: true},
+		{name: "Both caps", maxSelections: 2, maxBytes: 1, expected: []string{}, truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewOddsHandler(svc, zerolog.Nop())
+			handler.SetEventOddsLimits(tt.maxSelections, tt.maxBytes)
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			rec := getEventOdds(t, mux, "")
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var body struct {
+				Count     int                     `json:"count"`
+				Total     int                     `json:"total"`
+				Truncated bool                    `json:"truncated"`
+				Odds      []*models.OptimizedOdds `json:"odds"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+			selections := make([]string, 0, len(body.Odds))
+			for _, odds := range body.Odds {
+				selections = append(selections, odds.Selection)
+			}
+			assert.Equal(t, tt.expected, selections)
+			assert.Equal(t, len(tt.expected), body.Count)
+			assert.Equal(t, tt.truncated, body.Truncated)
+			if tt.truncated {
+				assert.Equal(t, 3, body.Total)
+			} else {
+				assert.NotContains(t, rec.Body.String(), `"truncated"`)
+				assert.NotContains(t, rec.Body.String(), `"total"`)
+			}
+		})
+	}
+}
+
+// TestGetEventOdds_ByteLimitRepresentation tests that the byte cap applies
+// to the response as sent, in every naming style, format and envelope
+func TestGetEventOdds_ByteLimitRepresentation(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 1.80),
+		newTestNormalizedOdds("Draw", 3.40),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		jsonCase string
+		query    string
+	}{
+		{name: "Snake case", jsonCase: JSONCaseSnake},
+		{name: "Camel case", jsonCase: JSONCaseCamel},
+		{name: "Display format", query: "?format=fractional"},
+		{name: "Envelope", query: "?envelope=true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get := func(maxBytes int) *httptest.ResponseRecorder {
+				handler := NewOddsHandler(svc, zerolog.Nop())
+				require.NoError(t, handler.SetJSONCase(tt.jsonCase))
+				handler.SetEventOddsLimits(0, maxBytes)
+				mux := http.NewServeMux()
+				handler.RegisterRoutes(mux)
+
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/odds"+tt.query, nil))
+				require.Equal(t, http.StatusOK, rec.Code)
+				return rec
+			}
+
+			// A cap one byte short of the full response drops the last selection
+			maxBytes := get(0).Body.Len() - 1
+			rec := get(maxBytes)
+			assert.LessOrEqual(t, rec.Body.Len(), maxBytes)
+			assert.Contains(t, rec.Body.String(), `"count":2`)
+			assert.Contains(t, rec.Body.String(), `"truncated":true`)
+		})
+	}
+}
+
+// TestGetEventOdds_EventConfidence tests the liquidity-weighted aggregate
+// confidence of an event's odds
+func TestGetEventOdds_EventConfidence(t *testing.T) {
+	odds := func(selection string, confidence, backSize, laySize float64) *models.OptimizedOdds {
+		return &models.OptimizedOdds{
+			ID:          uuid.New(),
+			EventID:     "event-123",
+			Market:      "match_winner",
+			Selection:   selection,
+			BackSize:    decimal.NewFromFloat(backSize),
+			LaySize:     decimal.NewFromFloat(laySize),
+			Confidence:  confidence,
+			OptimizedAt: time.Now(),
+		}
+	}
+	ptr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		odds     []*models.OptimizedOdds
+		expected *float64
+	}{
+		{
+			// (0.9*3000 + 0.6*1000 + 0.3*0) / 4000
+			name: "Liquidity-weighted",
+			odds: []*models.OptimizedOdds{
+				odds("Team A", 0.9, 2000, 1000),
+				odds("Team B", 0.6, 500, 500),
+				odds("Draw", 0.3, 0, 0),
+			},
+			expected: ptr(0.825),
+		},
+		{
+			name: "No liquidity uses the plain mean",
+			odds: []*models.OptimizedOdds{
+				odds("Team A", 0.9, 0, 0),
+				odds("Team B", 0.6, 0, -100),
+			},
+			expected: ptr(0.75),
+		},
+		{
+			name:     "Empty event",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+			for _, o := range tt.odds {
+				require.NoError(t, memoryCache.Set(context.Background(), o))
+			}
+			svc := service.NewOptimizerService(optimizer.NewOptimizer(models.OptimizationParams{}, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+			// A selection cap does not change the aggregate over the whole book
+			handler := NewOddsHandler(svc, zerolog.Nop())
+			handler.SetEventOddsLimits(1, 0)
+			mux := http.NewServeMux()
+			handler.RegisterRoutes(mux)
+
+			rec := getEventOdds(t, mux, "")
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var body struct {
+				EventConfidence *float64 `json:"event_confidence"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			if tt.expected == nil {
+				assert.Nil(t, body.EventConfidence)
+				assert.Contains(t, rec.Body.String(), `"event_confidence":null`)
+				return
+			}
+			require.NotNil(t, body.EventConfidence)
+			assert.InDelta(t, *tt.expected, *body.EventConfidence, 1e-9)
+		})
+	}
+}
+
+// TestGetOdds_Region tests that odds restricted to some regions are hidden
+// from other regions and visible to allowed regions and unfiltered callers
+func TestGetOdds_Region(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+		RegionRules:      []models.RegionRule{{Market: "first_scorer", Regions: []string{"gb", "ie"}}},
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	restricted := newTestNormalizedOdds("Player X", 6.00)
+	restricted.Market = "first_scorer"
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		restricted,
+	})
+	require.NoError(t, err)
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		name     string
+		query    string
+		header   string
+		visible  bool
+		expected []string // market/selection of the event odds
+	}{
+		{name: "Disallowed region", query: "?region=us-nj", expected: []string{"match_winner/Team A"}},
+		{name: "Disallowed region by header", header: "US-NJ", expected: []string{"match_winner/Team A"}},
+		{name: "Allowed region", query: "?region=IE", visible: true, expected: []string{"first_scorer/Player X", "match_winner/Team A"}},
+		{name: "Query overrides header", query: "?region=gb", header: "us-nj", visible: true, expected: []string{"first_scorer/Player X", "match_winner/Team A"}},
+		{name: "No region", visible: true, expected: []string{"first_scorer/Player X", "match_winner/Team A"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			get := func(path string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, path+tt.query, nil)
+				if tt.header != "" {
+					req.Header.Set(regionHeader, tt.header)
+				}
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, req)
+				return rec
+			}
+
+			single := get("/api/v1/odds/event-123/first_scorer/Player%20X")
+			if tt.visible {
+				assert.Equal(t, http.StatusOK, single.Code)
+				assert.Contains(t, single.Body.String(), `"regions":["gb","ie"]`)
+			} else {
+				assert.Equal(t, http.StatusNotFound, single.Code)
+			}
+			assert.Equal(t, http.StatusOK, get("/api/v1/odds/event-123/match_winner/Team%20A").Code)
+
+			event := get("/api/v1/events/event-123/odds")
+			require.Equal(t, http.StatusOK, event.Code)
+			var body struct {
+				Count int                     `json:"count"`
+				Odds  []*models.OptimizedOdds `json:"odds"`
+			}
+			require.NoError(t, json.Unmarshal(event.Body.Bytes(), &body))
+			got := make([]string, 0, len(body.Odds))
+			for _, odds := range body.Odds {
+				got = append(got, odds.Market+"/"+odds.Selection)
+			}
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, len(tt.expected), body.Count)
+		})
+	}
+}
+
+// TestOddsReads_Region tests that the history, diff, closing line and
+// overround reads hide odds restricted away from the caller's region
+func TestOddsReads_Region(t *testing.T) {
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(models.OptimizationParams{}, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	mr := miniredis.RunT(t)
+	history := cache.NewRedisHistory(cache.RedisHistoryConfig{Addr: mr.Addr()}, zerolog.Nop())
+	defer history.Close()
+	svc.SetHistory(history)
+	closingLines := cache.NewRedisClosingLines(cache.RedisClosingLinesConfig{Addr: mr.Addr(), TTL: time.Hour}, zerolog.Nop())
+	defer closingLines.Close()
+	svc.SetClosingLines(closingLines)
+
+	// Team A is restricted to gb; Team B is untagged
+	now := time.Now().UTC().Truncate(time.Second)
+	mr.SetTime(now)
+	oddsList := []*models.OptimizedOdds{
+		{EventID: "event-123", Market: "match_winner", Selection: "Team A", OptimizedBack: decimal.NewFromFloat(2.50), OptimizedAt: now, Regions: []string{"gb"}},
+		{EventID: "event-123", Market: "match_winner", Selection: "Team B", OptimizedBack: decimal.NewFromFloat(1.60), OptimizedAt: now},
+	}
+	require.NoError(t, memoryCache.SetBatch(context.Background(), oddsList))
+	require.NoError(t, history.Append(context.Background(), oddsList))
+	require.NoError(t, closingLines.Capture(context.Background(), oddsList))
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	get := func(path string, query url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"?"+query.Encode(), nil))
+		return rec
+	}
+	withRegion := func(query url.Values, region string) url.Values {
+		withRegion := url.Values{"region": {region}}
+		for key, values := range query {
+			withRegion[key] = values
+		}
+		return withRegion
+	}
+
+	t.Run("History", func(t *testing.T) {
+		query := url.Values{"event_id": {"event-123"}, "market": {"match_winner"}, "selection": {"Team A"}, "at": {now.Add(time.Minute).Format(time.RFC3339)}}
+		assert.Equal(t, http.StatusOK, get("/api/v1/odds/history", query).Code)
+		assert.Equal(t, http.StatusOK, get("/api/v1/odds/history", withRegion(query, "GB")).Code)
+		assert.Equal(t, http.StatusNotFound, get("/api/v1/odds/history", withRegion(query, "us-nj")).Code)
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		query := url.Values{"market": {"match_winner"}, "from": {now.Add(-time.Minute).Format(time.RFC3339)}, "to": {now.Add(time.Minute).Format(time.RFC3339)}}
+		selections := func(region string) []string {
+			rec := get("/api/v1/events/event-123/diff", withRegion(query, region))
+			require.Equal(t, http.StatusOK, rec.Code)
+			var resp MarketDiffResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			got := make([]string, 0, len(resp.Selections))
+			for _, diff := range resp.Selections {
+				got = append(got, diff.Selection)
+			}
+			return got
+		}
+		assert.Equal(t, []string{"Team A", "Team B"}, selections("gb"))
+		assert.Equal(t, []string{"Team B"}, selections("us-nj"))
+	})
+
+	t.Run("Closing lines", func(t *testing.T) {
+		selections := func(region string) []string {
+			rec := get("/api/v1/events/event-123/closing", url.Values{"region": {region}})
+			require.Equal(t, http.StatusOK, rec.Code)
+			var body struct {
+				Count int                     `json:"count"`
+				Odds  []*models.OptimizedOdds `json:"odds"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			got := make([]string, 0, len(body.Odds))
+			for _, odds := range body.Odds {
+				got = append(got, odds.Selection)
+			}
+			assert.Equal(t, len(got), body.Count)
+			return got
+		}
+		assert.Equal(t, []string{"Team A", "Team B"}, selections("gb"))
+		assert.Equal(t, []string{"Team B"}, selections("us-nj"))
+	})
+
+	t.Run("Overround", func(t *testing.T) {
+		selections := func(region string) int {
+			rec := get("/api/v1/events/event-123/overround", url.Values{"market": {"match_winner"}, "region": {region}})
+			require.Equal(t, http.StatusOK, rec.Code)
+			var body BookOverroundResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			return body.Selections
+		}
+		assert.Equal(t, 2, selections("gb"))
+		assert.Equal(t, 1, selections("us-nj"))
+	})
+}
+
+// TestGetEventOdds_Format tests rendering a known cached book's prices in
+// each display format, and rejecting unknown formats
+func TestGetEventOdds_Format(t *testing.T) {
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	for _, odds := range []*models.OptimizedOdds{
+		{Selection: "Team A", OptimizedBack: decimal.NewFromFloat(2.50), OptimizedLay: decimal.NewFromFloat(2.60), OriginalBack: decimal.NewFromFloat(2.55)},
+		{Selection: "Team B", OptimizedBack: decimal.NewFromFloat(1.80), OptimizedLay: decimal.NewFromFloat(1.909), OriginalBack: decimal.NewFromFloat(1.85)},
+	} {
+		odds.ID = uuid.New()
+		odds.EventID = "event-123"
+		odds.Market = "match_winner"
+		odds.FairPrice = odds.OptimizedBack
+		odds.OptimizedAt = time.Now()
+		require.NoError(t, memoryCache.Set(context.Background(), odds))
+	}
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(models.OptimizationParams{}, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	// back, lay, original back and original lay of Team A then Team B
+	tests := []struct {
+		format   string
+		expected [][4]string
+	}{
+		{format: "decimal", expected: [][4]string{{"2.50", "2.60", "2.55", ""}, {"1.80", "1.91", "1.85", ""}}},
+		{format: "fractional", expected: [][4]string{{"3/2", "8/5", "31/20", ""}, {"4/5", "10/11", "17/20", ""}}},
+		{format: "American", expected: [][4]string{{"+150", "+160", "+155", ""}, {"-125", "-110", "-118", ""}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/odds?format="+tt.format, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var body struct {
+				Format string `json:"format"`
+				Odds   []struct {
+					Selection     string `json:"selection"`
+					OptimizedBack string `json:"optimized_back"`
+					OptimizedLay  string `json:"optimized_lay"`
+					FairPrice     string `json:"fair_price"`
+					OriginalBack  string `json:"original_back"`
+					OriginalLay   string `json:"original_lay"`
+				} `json:"odds"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, strings.ToLower(tt.format), body.Format)
+			require.Len(t, body.Odds, 2)
+			for i, odds := range body.Odds {
+				assert.Equal(t, tt.expected[i], [4]string{odds.OptimizedBack, odds.OptimizedLay, odds.OriginalBack, odds.OriginalLay}, odds.Selection)
+				assert.Equal(t, odds.OptimizedBack, odds.FairPrice)
+			}
+		})
+	}
+
+	t.Run("Native prices without a format", func(t *testing.T) {
+		rec := getEventOdds(t, mux, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"optimized_back":"2.5"`)
+		assert.NotContains(t, rec.Body.String(), `"format"`)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/odds?format=hongkong", nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "invalid format")
+	})
+}
+
+// TestGetEventOdds_Markets tests filtering an event's odds to a markets
+// allow-list, in the requested order
+func TestGetEventOdds_Markets(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	var normalized []*models.NormalizedOdds
+	for _, market := range []string{"match_winner", "over_under", "correct_score", "first_scorer"} {
+		for _, selection := range []string{"Team B", "Team A"} {
+			odds := newTestNormalizedOdds(selection, 2.50)
+			odds.Market = market
+			normalized = append(normalized, odds)
+		}
+	}
+	_, err := svc.OptimizeBatch(context.Background(), normalized)
+	require.NoError(t, err)
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string // market/selection in response order
+	}{
+		{
+			name:     "Requested order",
+			query:    "?markets=over_under,match_winner",
+			expected: []string{"over_under/Team A", "over_under/Team B", "match_winner/Team A", "match_winner/Team B"},
+		},
+		{
+			name:     "Unknown and duplicate markets omitted",
+			query:    "?markets=first_scorer,%20handicap,,first_scorer",
+			expected: []string{"first_scorer/Team A", "first_scorer/Team B"},
+		},
+		{
+			name:     "Only unknown markets",
+			query:    "?markets=handicap",
+			expected: []string{},
+		},
+		{
+			name:  "No filter",
+			query: "",
+			expected: []string{
+				"correct_score/Team A", "correct_score/Team B", "first_scorer/Team A", "first_scorer/Team B",
+				"match_winner/Team A", "match_winner/Team B", "over_under/Team A", "over_under/Team B",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/odds"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var body struct {
+				Count int                     `json:"count"`
+				Odds  []*models.OptimizedOdds `json:"odds"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+			got := make([]string, 0, len(body.Odds))
+			for _, odds := range body.Odds {
+				got = append(got, odds.Market+"/"+odds.Selection)
+			}
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, len(tt.expected), body.Count)
+		})
+	}
+}
+
+// TestGetBookOverround tests the overround endpoint for a cached book
+func TestGetBookOverround(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 1.70),
+		newTestNormalizedOdds("Team B", 1.90),
+	})
+	require.NoError(t, err)
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/event-123/overround"+query, nil))
+		return rec
+	}
+
+	rec := get("?market=match_winner")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body BookOverroundResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "event-123", body.EventID)
+	assert.Equal(t, "match_winner", body.Market)
+	assert.Equal(t, 2, body.Selections)
+	cached, err := svc.GetOptimizedOddsByEvent(context.Background(), "event-123")
+	require.NoError(t, err)
+	expected := decimal.Zero
+	for _, odds := range cached {
+		expected = expected.Add(decimal.NewFromInt(1).Div(odds.OptimizedBack))
+	}
+	assert.True(t, expected.Equal(body.Overround), "overround %s, expected %s", body.Overround, expected)
+
+	assert.Equal(t, http.StatusNotFound, get("?market=handicap").Code)
+	assert.Equal(t, http.StatusBadRequest, get("").Code)
+}
+
+// postEventsOdds issues POST /api/v1/events/odds with the given event IDs
+func postEventsOdds(t *testing.T, mux *http.ServeMux, eventIDs []string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(EventsOddsRequest{EventIDs: eventIDs})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events/odds", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestGetEventsOdds tests retrieving odds for several events in one call
+func TestGetEventsOdds(t *testing.T) {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), memoryCache, zerolog.Nop())
+
+	other := newTestNormalizedOdds("Team C", 1.90)
+	other.EventID = "event-456"
+	_, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 1.80),
+		other,
+	})
+	require.NoError(t, err)
+
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	rec := postEventsOdds(t, mux, []string{"event-123", "event-456", "event-789"})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Count  int                                `json:"count"`
+		Events map[string][]*models.OptimizedOdds `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Count)
+	assert.Len(t, resp.Events["event-123"], 2)
+	require.Len(t, resp.Events["event-456"], 1)
+	assert.Equal(t, "Team C", resp.Events["event-456"][0].Selection)
+
+	// An event with no data is an empty list, not missing or null
+	assert.Contains(t, rec.Body.String(), `"event-789":[]`)
+
+	// The single-event route is unaffected
+	assert.Equal(t, http.StatusOK, getEventOdds(t, mux, "").Code)
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		tooMany := make([]string, maxEventsPerRequest+1)
+		for i := range tooMany {
+			tooMany[i] = fmt.Sprintf("event-%d", i)
+		}
+
+		assert.Equal(t, http.StatusBadRequest, postEventsOdds(t, mux, nil).Code)
+		assert.Equal(t, http.StatusBadRequest, postEventsOdds(t, mux, []string{"event-123", ""}).Code)
+		assert.Equal(t, http.StatusBadRequest, postEventsOdds(t, mux, tooMany).Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events/odds", nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+// fakeFreshness fails while stale is set
+type fakeFreshness struct {
+	stale bool
+}
+
+func (f *fakeFreshness) Fresh() error {
+	if f.stale {
+		return service.ErrNoFreshData
+	}
+	return nil
+}
+
+// TestOddsReads_Freshness tests that live odds reads answer 503 while the
+// freshness switch is tripped and serve again once it clears
+func TestOddsReads_Freshness(t *testing.T) {
+	memoryCache := cache.NewMemoryCache(time.Minute, zerolog.Nop())
+	svc := service.NewOptimizerService(optimizer.NewOptimizer(models.OptimizationParams{}, zerolog.Nop()), memoryCache, zerolog.Nop())
+	for selection, price := range map[string]float64{"Team A": 1.90, "Team B": 1.95} {
+		require.NoError(t, memoryCache.Set(context.Background(), &models.OptimizedOdds{
+			EventID:       "event-123",
+			Market:        "match_winner",
+			Selection:     selection,
+			OptimizedBack: decimal.NewFromFloat(price),
+			OptimizedAt:   time.Now(),
+		}))
+	}
+
+	freshness := &fakeFreshness{}
+	handler := NewOddsHandler(svc, zerolog.Nop())
+	handler.SetFreshness(freshness)
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	reads := []struct {
+		method, target, body string
+	}{
+		{http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", ""},
+		{http.MethodGet, "/api/v1/events/event-123/odds", ""},
+		{http.MethodGet, "/api/v1/events/event-123/overround?market=match_winner", ""},
+		{http.MethodPost, "/api/v1/events/odds", `{"event_ids": ["event-123"]}`},
+	}
+
+	for _, read := range reads {
+		assert.Equal(t, http.StatusOK, serve(read.method, read.target, read.body).Code, read.target)
+	}
+
+	freshness.stale = true
+	for _, read := range reads {
+		rec := serve(read.method, read.target, read.body)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, read.target)
+		assert.Contains(t, rec.Body.String(), "no fresh odds data")
+	}
+
+	// Bad requests are still rejected as such
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/api/v1/events/event-123/odds?format=roman", "").Code)
+
+	freshness.stale = false
+	for _, read := range reads {
+		assert.Equal(t, http.StatusOK, serve(read.method, read.target, read.body).Code, read.target)
+	}
+}
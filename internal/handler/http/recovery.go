@@ -0,0 +1,76 @@
+package http
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// requestIDHeader carries the caller's request ID, echoed in panic logs
+const requestIDHeader = "X-Request-ID"
+
+// Recoverer turns handler panics into a logged 500 JSON error instead of a
+// dropped connection, keeping the server up for subsequent requests
+type Recoverer struct {
+	panics prometheus.Counter
+	logger zerolog.Logger
+}
+
+// RecovererConfig holds panic recovery configuration
+type RecovererConfig struct {
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// NewRecoverer creates a new panic recovery middleware
+func NewRecoverer(config RecovererConfig, logger zerolog.Logger) *Recoverer {
+	rc := &Recoverer{
+		panics: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "http_panics_total",
+			Help: "HTTP handler panics recovered and answered with 500.",
+		}),
+		logger: logger.With().Str("component", "recoverer").Logger(),
+	}
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(rc.panics)
+	}
+
+	return rc
+}
+
+// Wrap recovers panics from next, logging the stack with the request ID
+func (rc *Recoverer) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// http.ErrAbortHandler deliberately aborts the response
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			requestID := r.Header.Get(requestIDHeader)
+			if requestID == "" {
+				requestID = uuid.NewString()
+			}
+
+			rc.panics.Inc()
+			rc.logger.Error().
+				Str("request_id", requestID).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Interface("panic", recovered).
+				Bytes("stack", debug.Stack()).
+				Msg("recovered from handler panic")
+
+			w.Header().Set(requestIDHeader, requestID)
+			writeError(w, rc.logger, http.StatusInternalServerError, "internal server error")
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecoverer tests that a panicking handler gets a 500 JSON error and the
+// server keeps serving
+func TestRecoverer(t *testing.T) {
+	recoverer := NewRecoverer(RecovererConfig{Registerer: prometheus.NewRegistry()}, zerolog.Nop())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(recoverer.Wrap(mux))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/panic", nil)
+	require.NoError(t, err)
+	req.Header.Set(requestIDHeader, "req-123")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "req-123", resp.Header.Get(requestIDHeader))
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "internal server error", body["error"])
+	assert.Equal(t, 1.0, testutil.ToFloat64(recoverer.panics))
+
+	// The server stays up
+	resp, err = server.Client().Get(server.URL + "/ok")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1.0, testutil.ToFloat64(recoverer.panics))
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// regionHeader names the caller's region when the region query parameter is
+// absent, for gateways that resolve it per client
+const regionHeader = "X-Region"
+
+// requestRegion returns the region odds are served to, from ?region= or else
+// the X-Region header, lowercased; empty when the caller names none and odds
+// are not filtered
+func requestRegion(r *http.Request) string {
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		region = r.Header.Get(regionHeader)
+	}
+	return strings.ToLower(strings.TrimSpace(region))
+}
+
+// regionAllowed reports whether odds may be served to region. Untagged odds
+// are allowed everywhere, and every odds are allowed without a region.
+func regionAllowed(odds *models.OptimizedOdds, region string) bool {
+	if region == "" || len(odds.Regions) == 0 {
+		return true
+	}
+	for _, allowed := range odds.Regions {
+		if strings.EqualFold(allowed, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRegion returns the odds that may be served to region, preserving order
+func filterRegion(oddsList []*models.OptimizedOdds, region string) []*models.OptimizedOdds {
+	if region == "" {
+		return oddsList
+	}
+
+	filtered := make([]*models.OptimizedOdds, 0, len(oddsList))
+	for _, odds := range oddsList {
+		if regionAllowed(odds, region) {
+			filtered = append(filtered, odds)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// TimeoutHandler bounds how long a handler may run. When a handler overruns,
+// its request context is cancelled, so service calls abort promptly, and the
+// client gets a 503 JSON error instead of a connection cut by the server's
+// write timeout. Responses are buffered until the handler returns, so
+// streaming and long-running routes belong in Exempt.
+type TimeoutHandler struct {
+	timeout  time.Duration
+	exempt   map[string]bool
+	timeouts prometheus.Counter
+	logger   zerolog.Logger
+}
+
+// TimeoutHandlerConfig holds handler timeout configuration
+type TimeoutHandlerConfig struct {
+	Timeout    time.Duration         // Longest a handler may run
+	Exempt     []string              // Paths never timed out, e.g. "/health", "/ready"
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// NewTimeoutHandler creates a new handler timeout middleware
+func NewTimeoutHandler(config TimeoutHandlerConfig, logger zerolog.Logger) *TimeoutHandler {
+	exempt := make(map[string]bool, len(config.Exempt))
+	for _, path := range config.Exempt {
+		exempt[path] = true
+	}
+
+	th := &TimeoutHandler{
+		timeout: config.Timeout,
+		exempt:  exempt,
+		timeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "http_handler_timeouts_total",
+			Help: "HTTP handlers that overran the handler timeout and were answered with 503.",
+		}),
+		logger: logger.With().Str("component", "timeout_handler").Logger(),
+	}
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(th.timeouts)
+	}
+
+	return th
+}
+
+// Wrap times out next after the handler timeout, except for exempt paths.
+// A panic in next is re-raised on the serving goroutine.
+func (th *TimeoutHandler) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if th.exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), th.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					panicked <- recovered
+				}
+			}()
+			next.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case recovered := <-panicked:
+			panic(recovered)
+
+		case <-done:
+			tw.flush(w)
+
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+
+			// The client went away: there is no one to answer
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return
+			}
+
+			th.timeouts.Inc()
+			th.logger.Warn().
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Dur("timeout", th.timeout).
+				Msg("handler timed out")
+			writeError(w, th.logger, http.StatusServiceUnavailable, "request timed out")
+		}
+	})
+}
+
+// timeoutWriter buffers a handler's response until it returns, discarding
+// writes made after the handler timed out
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header implements http.ResponseWriter
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// Write implements http.ResponseWriter
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.body.Write(p)
+}
+
+// WriteHeader implements http.ResponseWriter
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(status)
+}
+
+// writeHeaderLocked records the status; tw.mu must be held
+func (tw *timeoutWriter) writeHeaderLocked(status int) {
+	tw.wroteHeader = true
+	tw.status = status
+}
+
+// flush writes the buffered response to w once the handler has returned
+func (tw *timeoutWriter) flush(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	dst := w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	_, _ = w.Write(tw.body.Bytes())
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimeoutHandler_SlowHandler tests that an overrunning handler is
+// answered with a 503 JSON error and has its request context cancelled
+func TestTimeoutHandler_SlowHandler(t *testing.T) {
+	th := NewTimeoutHandler(TimeoutHandlerConfig{
+		Timeout:    20 * time.Millisecond,
+		Registerer: prometheus.NewRegistry(),
+	}, zerolog.Nop())
+
+	cancelled := make(chan error, 1)
+	served := make(chan struct{})
+	lateWrite := make(chan error, 1)
+	handler := th.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			cancelled <- r.Context().Err()
+		case <-time.After(5 * time.Second):
+			cancelled <- nil
+		}
+		<-served
+		_, err := w.Write([]byte("too late"))
+		lateWrite <- err
+	}))
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", nil))
+	close(served)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"request timed out"}`, rec.Body.String())
+	assert.Equal(t, 1.0, testutil.ToFloat64(th.timeouts))
+
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+
+	// Writes after the timeout are discarded
+	assert.ErrorIs(t, <-lateWrite, http.ErrHandlerTimeout)
+	assert.JSONEq(t, `{"error":"request timed out"}`, rec.Body.String())
+}
+
+// TestTimeoutHandler_FastHandler tests that a handler finishing in time is
+// answered with its own status, headers and body
+func TestTimeoutHandler_FastHandler(t *testing.T) {
+	th := NewTimeoutHandler(TimeoutHandlerConfig{Timeout: time.Second}, zerolog.Nop())
+	handler := th.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.True(t, hasDeadline)
+		writeJSON(w, zerolog.Nop(), http.StatusCreated, map[string]string{"status": "ok"})
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", nil))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+	assert.Zero(t, testutil.ToFloat64(th.timeouts))
+}
+
+// TestTimeoutHandler_Exempt tests that exempt paths run without a deadline
+func TestTimeoutHandler_Exempt(t *testing.T) {
+	th := NewTimeoutHandler(TimeoutHandlerConfig{
+		Timeout: time.Millisecond,
+		Exempt:  []string{"/health"},
+	}, zerolog.Nop())
+	handler := th.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline := r.Context().Deadline()
+		assert.False(t, hasDeadline)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// TestTimeoutHandler_Panic tests that a handler panic reaches the recoverer
+func TestTimeoutHandler_Panic(t *testing.T) {
+	th := NewTimeoutHandler(TimeoutHandlerConfig{Timeout: time.Second}, zerolog.Nop())
+	rc := NewRecoverer(RecovererConfig{}, zerolog.Nop())
+	handler := rc.Wrap(th.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	rec := httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/odds/event-123/match_winner/Team%20A", nil))
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -0,0 +1,144 @@
+package messaging
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// messageResult is how a processed message bears on its partition's commits
+type messageResult int
+
+const (
+	resultCommit messageResult = iota // Processed; commit it
+	resultSkip                        // Failed; not committed itself, but later messages commit past it
+	resultHold                        // Failed to publish under CommitAfterPublish; nothing past it is committed until it is fetched again
+)
+
+// offsetTracker orders commits for fetched messages. Kafka commits are
+// cumulative per partition, so a message may only be committed once every
+// earlier message fetched from its partition has completed; otherwise a
+// crash could skip a message that was still in flight.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int][]*trackedMessage // In-flight messages per partition, in fetch order
+	held       map[int]int64             // Offset of the held message per partition whose commits are held
+}
+
+// trackedMessage is a fetched message awaiting completion. A message whose
+// one-sided updates are held for merging completes only once every held side
+// has been optimized and cached, in a later message or by the side flusher.
+type trackedMessage struct {
+	msg         kafka.Message
+	done        bool
+	result      messageResult
+	waiting     atomic.Int32 // Held sides not yet resolved
+	sidesFailed bool         // A held side failed to optimize or cache
+}
+
+// newOffsetTracker creates an empty offset tracker
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[int][]*trackedMessage),
+		held:       make(map[int]int64),
+	}
+}
+
+// track registers a fetched message; call in fetch order. Fetching a held
+// message again, after a rebalance or seek, releases its partition.
+func (t *offsetTracker) track(msg kafka.Message) *trackedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked := &trackedMessage{msg: msg}
+	if offset, ok := t.held[msg.Partition]; ok {
+		if msg.Offset > offset {
+			return tracked // Never committed while its partition is held
+		}
+		delete(t.held, msg.Partition)
+	}
+	t.partitions[msg.Partition] = append(t.partitions[msg.Partition], tracked)
+	return tracked
+}
+
+// complete marks a message finished and returns the latest successful
+// message that is now safe to commit, if any. Skipped messages are not
+// committed themselves but do not hold back commits of later messages; a
+// held message stops its partition's commits at its offset, so a restart or
+// rebalance redelivers it and everything after it. A message with held sides
+// waits for them too (see resolve).
+func (t *offsetTracker) complete(tracked *trackedMessage, result messageResult) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked.done = true
+	tracked.result = result
+	return t.advance(tracked.msg.Partition)
+}
+
+// find returns the tracked entry of msg, or nil if it is not awaiting
+// completion
+func (t *offsetTracker) find(msg kafka.Message) *trackedMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tracked := range t.partitions[msg.Partition] {
+		if tracked.msg.Offset == msg.Offset {
+			return tracked
+		}
+	}
+	return nil
+}
+
+// resolve records that one of tracked's held sides was optimized and cached,
+// or failed to be, which leaves the message uncommitted like a skipped one;
+// it returns the latest message now safe to commit, if any
+func (t *offsetTracker) resolve(tracked *trackedMessage, cached bool) (kafka.Message, bool) {
+	tracked.waiting.Add(-1)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !cached {
+		tracked.sidesFailed = true
+	}
+	return t.advance(tracked.msg.Partition)
+}
+
+// advance pops the completed messages at the head of partition and returns
+// the latest one to commit; the caller holds t.mu
+func (t *offsetTracker) advance(partition int) (kafka.Message, bool) {
+	if _, ok := t.held[partition]; ok {
+		return kafka.Message{}, false
+	}
+
+	pending := t.partitions[partition]
+	var commit kafka.Message
+	var ok bool
+	for len(pending) > 0 && pending[0].done && pending[0].waiting.Load() == 0 {
+		switch head := pending[0]; {
+		case head.result == resultHold:
+			t.held[partition] = head.msg.Offset
+			delete(t.partitions, partition)
+			return commit, ok
+		case head.result == resultCommit && !head.sidesFailed:
+			commit, ok = head.msg, true
+		}
+		pending = pending[1:]
+	}
+	t.partitions[partition] = pending
+
+	return commit, ok
+}
+
+// release lifts the hold on partition, if any, and forgets messages still
+// waiting on held sides, so commits resume from the next message fetched;
+// used when a seek repositions the partition
+func (t *offsetTracker) release(partition int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.held, partition)
+	delete(t.partitions, partition)
+}
@@ -2,9 +2,14 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/segmentio/kafka-go"
 
@@ -12,12 +17,82 @@ import (
 	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
 )
 
+// errPublishFailed marks a message whose publish failed under
+// CommitAfterPublish, which holds its partition's commits
+var errPublishFailed = errors.New("failed to publish optimized odds")
+
+// messageReader is the subset of *kafka.Reader used by the consumer
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Config() kafka.ReaderConfig
+	SetOffset(offset int64) error
+	SetOffsetAt(ctx context.Context, t time.Time) error
+	Close() error
+}
+
 // KafkaConsumer consumes normalized odds from Kafka and optimizes them
 type KafkaConsumer struct {
-	reader    *kafka.Reader
+	reader    messageReader
+	reopen    func() messageReader // Opens a fresh reader on the same topic and group, after a group seek
+	lookup    offsetLookup         // Resolves timestamps to offsets for group seeks
 	optimizer service.Optimizer
 	cache     service.Cache
+	profiles  map[string]service.Optimizer
+	sinks     service.OddsSink
+	history   service.History
+	prices    *service.HistoryPrices
+	dedup     service.Deduplicator
+	sports    sportFilter
+	metrics   *consumerMetrics
 	logger    zerolog.Logger
+
+	backpressureThreshold time.Duration
+	backpressurePause     time.Duration
+	backpressure          atomic.Bool
+	skipLowPriority       bool
+	commitAfterPublish    bool
+
+	pollTimeout time.Duration
+	lastPoll    atomic.Int64 // Unix nanoseconds the last fetch returned, with or without a message (0 before Start)
+
+	gapThreshold         time.Duration
+	gapConfidencePenalty float64
+	lastMessageAt        atomic.Int64 // Unix nanoseconds of the last consumed message (0 if none)
+	gapPending           atomic.Bool  // A gap was seen and the next odds batch has not been penalized yet
+
+	sides   *sideMerger    // Pairs one-sided updates across messages (nil disables)
+	flusher sync.WaitGroup // Tracks the side flusher; kept out of workers so a seek does not wait for it
+
+	messageFormat string // MessageFormatBatch or MessageFormatSingle
+
+	inflight chan struct{}  // Semaphore bounding concurrently processed messages (nil when sequential)
+	offsets  *offsetTracker // Orders commits, and holds them after a failed publish
+	workers  sync.WaitGroup
+
+	seekMu      sync.Mutex         // Serializes Seek calls
+	fetchMu     sync.Mutex         // Guards the fields below
+	running     bool               // Start's fetch loop is running
+	pendingSeek *pendingSeek       // Seek waiting for the fetch loop
+	cancelFetch context.CancelFunc // Interrupts the current fetch (nil between fetches)
+	paused      bool               // Fetching is paused by Pause
+	wake        chan struct{}      // Closed to wake the fetch loop waiting while paused (nil when none waits)
+
+	messagesProcessed atomic.Uint64
+	messagesFailed    atomic.Uint64
+	oddsProcessed     atomic.Uint64
+	lastOffset        atomic.Int64
+	lag               atomic.Int64
+}
+
+// ConsumerStats holds a snapshot of the consumer's runtime counters
+type ConsumerStats struct {
+	MessagesProcessed uint64 // Messages optimized and cached
+	MessagesFailed    uint64 // Messages that failed processing
+	OddsProcessed     uint64 // Optimized selections written to cache
+	LastOffset        int64  // Offset of the last processed message (-1 if none)
+	Lag               int64  // Messages behind the partition high water mark at the last fetch
+	Paused            bool   // Fetching is paused by Pause
 }
 
 // KafkaConsumerConfig holds Kafka consumer configuration
@@ -25,6 +100,71 @@ type KafkaConsumerConfig struct {
 	Brokers []string // e.g., ["localhost:9092"]
 	Topic   string   // e.g., "normalized_odds"
 	GroupID string   // e.g., "odds-optimizer"
+
+	// Backpressure: fetching pauses for BackpressurePause whenever a cache
+	// write takes longer than BackpressureThreshold (0 disables)
+	BackpressureThreshold time.Duration
+	BackpressurePause     time.Duration
+	SkipLowPriority       bool // Skip messages with a "low" priority header while backpressure is active
+
+	// Offsets are committed synchronously, and only after a successful cache
+	// write, unless AutoCommit is set. AutoCommit hands commits to kafka-go to
+	// flush every CommitInterval, which can acknowledge offsets that are lost
+	// if the process crashes before the flush; use only where at-least-once
+	// delivery is not required.
+	AutoCommit     bool
+	CommitInterval time.Duration // Flush interval for AutoCommit (default 1s)
+
+	// CommitAfterPublish extends the commit gate to the downstream sinks: a
+	// publish failure fails the message and holds its partition's commits at
+	// its offset, so later messages are still processed but not committed
+	// until a restart, rebalance or seek fetches it again, and it is
+	// reprocessed and re-published rather than dropped. Off by default, where
+	// only the cache write gates the commit and publish failures are logged.
+	CommitAfterPublish bool
+
+	// MaxInflight bounds how many messages are processed concurrently.
+	// Fetching blocks while MaxInflight messages are outstanding, and offsets
+	// are still committed in fetch order per partition. 0 or 1 processes
+	// messages one at a time.
+	MaxInflight int
+
+	// PollTimeout bounds each fetch: during quiet periods the fetch loop wakes
+	// up empty-handed after PollTimeout, recording liveness (see LastPoll).
+	// 0 waits for a message indefinitely.
+	PollTimeout time.Duration
+
+	// Feed gaps: when no message arrives for longer than GapThreshold (0
+	// disables), confidence of the first odds batch after the gap is
+	// multiplied by GapConfidencePenalty, as the market may have moved
+	GapThreshold         time.Duration
+	GapConfidencePenalty float64
+
+	// Sport sharding: only odds_data items whose sport is in SportAllowlist
+	// (empty allows all) and not in SportDenylist are optimized; the rest are
+	// skipped and counted. A message left with no items is committed like an
+	// empty batch.
+	SportAllowlist []string
+	SportDenylist  []string
+
+	// Side merging: a back-only or lay-only update is held for up to
+	// SideMergeWindow (0 disables) waiting for the other side of the same
+	// event+market+selection, and the two are optimized as one two-sided
+	// update. A side still unmatched after the window is optimized alone.
+	// A message with held sides is committed only once they are cached,
+	// merged or alone, so a crash within the window redelivers it; later
+	// messages on its partition wait for it, delaying commits by up to the
+	// window.
+	SideMergeWindow time.Duration
+
+	// MessageFormat is MessageFormatBatch (the default when empty) for
+	// KafkaNormalizedOddsMessage envelopes, or MessageFormatSingle for one
+	// NormalizedOdds per message, as on a log-compacted topic keyed per
+	// selection. Single messages are logged under their key in place of a
+	// batch ID, and tombstones are committed like empty batches.
+	MessageFormat string
+
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
 }
 
 // NewKafkaConsumer creates a new Kafka consumer
@@ -34,96 +174,485 @@ func NewKafkaConsumer(
 	cache service.Cache,
 	logger zerolog.Logger,
 ) *KafkaConsumer {
-	reader := kafka.NewReader(kafka.ReaderConfig{
+	// A zero CommitInterval makes CommitMessages synchronous (explicit commit)
+	var commitInterval time.Duration
+	if config.AutoCommit {
+		commitInterval = config.CommitInterval
+		if commitInterval <= 0 {
+			commitInterval = time.Second
+		}
+	}
+
+	readerConfig := kafka.ReaderConfig{
 		Brokers:        config.Brokers,
 		Topic:          config.Topic,
 		GroupID:        config.GroupID,
 		MinBytes:       1e3,  // 1KB
 		MaxBytes:       10e6, // 10MB
-		CommitInterval: 1000, // Commit every 1 second
-	})
+		CommitInterval: commitInterval,
+	}
 
-	return &KafkaConsumer{
-		reader:    reader,
-		optimizer: opt,
-		cache:     cache,
-		logger:    logger.With().Str("component", "kafka_consumer").Logger(),
+	consumer := &KafkaConsumer{
+		reader:                kafka.NewReader(readerConfig),
+		reopen:                func() messageReader { return kafka.NewReader(readerConfig) },
+		lookup:                newBrokerOffsetLookup(config.Brokers),
+		optimizer:             opt,
+		cache:                 cache,
+		sports:                newSportFilter(config.SportAllowlist, config.SportDenylist),
+		metrics:               newConsumerMetrics(config.Registerer),
+		logger:                logger.With().Str("component", "kafka_consumer").Logger(),
+		backpressureThreshold: config.BackpressureThreshold,
+		backpressurePause:     config.BackpressurePause,
+		skipLowPriority:       config.SkipLowPriority,
+		commitAfterPublish:    config.CommitAfterPublish,
+		pollTimeout:           config.PollTimeout,
+		gapThreshold:          config.GapThreshold,
+		gapConfidencePenalty:  config.GapConfidencePenalty,
+		messageFormat:         config.MessageFormat,
+		offsets:               newOffsetTracker(),
+	}
+	if config.SideMergeWindow > 0 {
+		consumer.sides = newSideMerger(config.SideMergeWindow)
+	}
+	if config.MaxInflight > 1 {
+		consumer.inflight = make(chan struct{}, config.MaxInflight)
 	}
+	consumer.lastOffset.Store(-1)
+
+	return consumer
 }
 
-// Start begins consuming messages from Kafka
+// Start begins consuming messages from Kafka. Cancelling ctx stops fetching;
+// messages already fetched are still processed and committed before Start
+// returns, so shutdown does not abandon a half-written batch.
 func (c *KafkaConsumer) Start(ctx context.Context) error {
+	workCtx := context.WithoutCancel(ctx)
+	c.setRunning(true)
+	defer c.setRunning(false)
+	c.markPoll()
+
 	c.logger.Info().
 		Str("topic", c.reader.Config().Topic).
 		Str("group_id", c.reader.Config().GroupID).
+		Dur("commit_interval", c.reader.Config().CommitInterval).
+		Int("max_inflight", cap(c.inflight)).
 		Msg("started consuming from Kafka")
 
+	if c.sides != nil {
+		c.flusher.Add(1)
+		go func() {
+			defer c.flusher.Done()
+			c.runSideFlush(ctx, workCtx)
+		}()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			c.logger.Info().Msg("stopping Kafka consumer")
+			c.drain(workCtx)
 			return c.reader.Close()
 
 		default:
+			// Hold off fetching while the cache is slow; the next write
+			// after the pause decides whether backpressure clears
+			if c.backpressure.Load() && !c.waitBackpressure(ctx) {
+				continue
+			}
+
+			// Hold off fetching while paused by the admin API
+			if c.waitWhilePaused(ctx) {
+				continue
+			}
+
+			// Wait for a free in-flight slot before fetching
+			if c.inflight != nil && !c.acquireInflight(ctx) {
+				continue
+			}
+
+			// Apply a requested seek in place of the next fetch
+			fetchCtx, cancelFetch, seek := c.nextFetch(ctx)
+			if seek != nil {
+				c.releaseInflight()
+				c.applySeek(workCtx, seek)
+				continue
+			}
+
 			// Read message
-			msg, err := c.reader.FetchMessage(ctx)
+			msg, err := c.reader.FetchMessage(fetchCtx)
+			fetchErr := fetchCtx.Err()
+			c.endFetch(cancelFetch)
+			c.markPoll()
 			if err != nil {
-				if err == context.Canceled {
+				c.releaseInflight()
+				if ctx.Err() != nil {
+					c.drain(workCtx)
 					return nil
 				}
+				if fetchErr != nil {
+					continue // Poll timeout without messages, or interrupted by a seek
+				}
 				c.logger.Error().Err(err).Msg("failed to fetch message")
 				continue
 			}
 
-			// Process message
-			if err := c.processMessage(ctx, msg); err != nil {
-				c.logger.Error().
-					Err(err).
-					Int64("offset", msg.Offset).
-					Str("key", string(msg.Key)).
-					Msg("failed to process message")
-				// Don't commit if processing failed
+			// Track lag relative to the partition high water mark
+			if msg.HighWaterMark > 0 {
+				c.lag.Store(msg.HighWaterMark - msg.Offset - 1)
+			}
+
+			if c.inflight != nil {
+				c.dispatch(workCtx, msg)
 				continue
 			}
 
-			// Commit message
-			if err := c.reader.CommitMessages(ctx, msg); err != nil {
-				c.logger.Error().Err(err).Msg("failed to commit message")
+			// Don't commit if processing failed
+			tracked := c.offsets.track(msg)
+			if commit, ok := c.offsets.complete(tracked, c.handleMessage(workCtx, msg)); ok {
+				c.commit(workCtx, commit)
 			}
 		}
 	}
 }
 
+// drain waits for in-flight messages and the side flusher, which stops with
+// Start's context, then optimizes any sides still held for merging rather
+// than dropping them
+func (c *KafkaConsumer) drain(ctx context.Context) {
+	c.workers.Wait()
+	c.flusher.Wait()
+	if c.sides != nil {
+		c.flushSides(ctx, time.Now().Add(c.sides.window))
+	}
+}
+
+// LastPoll returns when the fetch loop last returned from a fetch, with or
+// without a message; zero before Start. With a PollTimeout it advances during
+// quiet periods, pauses, backpressure and waits for an in-flight slot too, so
+// a stale value means the loop is stuck.
+func (c *KafkaConsumer) LastPoll() time.Time {
+	nanos := c.lastPoll.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// markPoll records that the fetch loop is alive
+func (c *KafkaConsumer) markPoll() {
+	c.lastPoll.Store(time.Now().UnixNano())
+}
+
+// dispatch processes msg on a worker that holds an in-flight slot until it
+// completes, committing offsets in fetch order
+func (c *KafkaConsumer) dispatch(ctx context.Context, msg kafka.Message) {
+	tracked := c.offsets.track(msg)
+
+	c.workers.Add(1)
+	go func() {
+		defer c.workers.Done()
+		defer c.releaseInflight()
+
+		if commit, ok := c.offsets.complete(tracked, c.handleMessage(ctx, msg)); ok {
+			c.commit(ctx, commit)
+		}
+	}()
+}
+
+// waitBackpressure waits out a backpressure pause, recording liveness every
+// poll timeout meanwhile so a long pause does not read as a stuck loop. It
+// reports false if ctx is done first.
+func (c *KafkaConsumer) waitBackpressure(ctx context.Context) bool {
+	pause := time.NewTimer(c.backpressurePause)
+	defer pause.Stop()
+	tick, stop := c.livenessTicker()
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-pause.C:
+			return true
+		case <-tick:
+			c.markPoll()
+		}
+	}
+}
+
+// acquireInflight waits for a free in-flight slot, recording liveness every
+// poll timeout meanwhile so slow workers do not read as a stuck loop. It
+// reports false if ctx is done first.
+func (c *KafkaConsumer) acquireInflight(ctx context.Context) bool {
+	tick, stop := c.livenessTicker()
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case c.inflight <- struct{}{}:
+			return true
+		case <-tick:
+			c.markPoll()
+		}
+	}
+}
+
+// livenessTicker returns a channel ticking every poll timeout, or nil
+// without one, and a function stopping it
+func (c *KafkaConsumer) livenessTicker() (<-chan time.Time, func()) {
+	if c.pollTimeout <= 0 {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(c.pollTimeout)
+	return ticker.C, ticker.Stop
+}
+
+// releaseInflight frees an in-flight slot
+func (c *KafkaConsumer) releaseInflight() {
+	if c.inflight != nil {
+		<-c.inflight
+	}
+}
+
+// handleMessage processes msg, recording and logging failures; it reports
+// how the message bears on its partition's commits
+func (c *KafkaConsumer) handleMessage(ctx context.Context, msg kafka.Message) messageResult {
+	err := c.processMessage(ctx, msg)
+	if err == nil {
+		return resultCommit
+	}
+
+	c.messagesFailed.Add(1)
+	c.logger.Error().
+		Err(err).
+		Int64("offset", msg.Offset).
+		Str("key", string(msg.Key)).
+		Msg("failed to process message")
+
+	if errors.Is(err, errPublishFailed) {
+		c.logger.Warn().
+			Int("partition", msg.Partition).
+			Int64("offset", msg.Offset).
+			Msg("holding partition commits until the unpublished message is redelivered")
+		return resultHold
+	}
+	return resultSkip
+}
+
+// commit commits msg's offset
+func (c *KafkaConsumer) commit(ctx context.Context, msg kafka.Message) {
+	if err := c.reader.CommitMessages(ctx, msg); err != nil {
+		c.logger.Error().Err(err).Msg("failed to commit message")
+	}
+}
+
+// Kafka headers set by upstream for routing
+const (
+	headerSport    = "sport"
+	headerPriority = "priority"
+	headerProfile  = "profile"
+
+	defaultSport    = "unknown"
+	defaultPriority = "normal"
+	priorityLow     = "low"
+)
+
+// messageHeaders holds routing metadata read from Kafka message headers
+type messageHeaders struct {
+	Sport    string
+	Priority string
+	Profile  string // Optimizer profile for the whole message (empty selects the default)
+}
+
+// parseHeaders reads routing headers, defaulting any that are missing or empty
+func parseHeaders(msg kafka.Message) messageHeaders {
+	headers := messageHeaders{
+		Sport:    defaultSport,
+		Priority: defaultPriority,
+	}
+
+	for _, h := range msg.Headers {
+		value := strings.ToLower(strings.TrimSpace(string(h.Value)))
+		if value == "" {
+			continue
+		}
+		switch strings.ToLower(h.Key) {
+		case headerSport:
+			headers.Sport = value
+		case headerPriority:
+			headers.Priority = value
+		case headerProfile:
+			headers.Profile = value
+		}
+	}
+
+	return headers
+}
+
 // processMessage processes a single Kafka message
 func (c *KafkaConsumer) processMessage(ctx context.Context, msg kafka.Message) error {
+	headers := parseHeaders(msg)
+	c.observeGap(msg)
+
+	// Shed low-priority work while the cache is struggling
+	if c.skipLowPriority && headers.Priority == priorityLow && c.backpressure.Load() {
+		c.metrics.messagesSkipped.WithLabelValues(headers.Sport, headers.Priority).Inc()
+		c.logger.Debug().
+			Int64("offset", msg.Offset).
+			Str("sport", headers.Sport).
+			Msg("skipping low-priority message during backpressure")
+		return nil
+	}
+
+	// Skip batches redelivered after a rebalance; a failed check is not fatal
+	dedupKey := messageDedupKey(msg)
+	if c.dedup != nil {
+		seen, err := c.dedup.Seen(ctx, dedupKey)
+		if err != nil {
+			c.logger.Warn().
+				Err(err).
+				Int64("offset", msg.Offset).
+				Msg("failed to check for duplicate batch, processing anyway")
+		}
+		if seen {
+			c.metrics.duplicateBatches.Inc()
+			c.logger.Debug().
+				Int64("offset", msg.Offset).
+				Str("key", string(msg.Key)).
+				Msg("skipping already processed batch")
+			return nil
+		}
+	}
+
 	// Parse message
-	var kafkaMsg models.KafkaNormalizedOddsMessage
-	if err := json.Unmarshal(msg.Value, &kafkaMsg); err != nil {
-		return fmt.Errorf("failed to unmarshal message: %w", err)
+	kafkaMsg, err := c.decodeMessage(msg)
+	if err != nil {
+		return err
+	}
+	c.metrics.batchSize.Observe(float64(len(kafkaMsg.OddsData)))
+	c.metrics.lastBatchSize.Set(float64(len(kafkaMsg.OddsData)))
+
+	// Upstream emits empty batches as heartbeats; commit them without
+	// touching the optimizer or cache
+	if len(kafkaMsg.OddsData) == 0 {
+		c.metrics.emptyBatches.Inc()
+		c.lastOffset.Store(msg.Offset)
+		c.logger.Debug().
+			Int64("offset", msg.Offset).
+			Str("batch_id", kafkaMsg.BatchID).
+			Msg("skipping empty odds batch")
+		return nil
 	}
 
 	c.logger.Debug().
 		Int("odds_count", len(kafkaMsg.OddsData)).
 		Str("batch_id", kafkaMsg.BatchID).
+		Str("sport", headers.Sport).
+		Str("priority", headers.Priority).
 		Msg("processing normalized odds batch")
 
 	// Convert to pointers
 	normalizedOdds := make([]*models.NormalizedOdds, len(kafkaMsg.OddsData))
 	for i := range kafkaMsg.OddsData {
 		normalizedOdds[i] = &kafkaMsg.OddsData[i]
+		if normalizedOdds[i].Currency == "" {
+			normalizedOdds[i].Currency = kafkaMsg.Currency
+		}
+		if normalizedOdds[i].Source == "" {
+			normalizedOdds[i].Source = kafkaMsg.Source
+		}
+	}
+
+	// Skip sports owned by other instances
+	normalizedOdds = c.filterSports(normalizedOdds, headers.Sport)
+	if len(normalizedOdds) == 0 {
+		c.lastOffset.Store(msg.Offset)
+		c.logger.Debug().
+			Int64("offset", msg.Offset).
+			Str("batch_id", kafkaMsg.BatchID).
+			Msg("skipping batch with no sports to process")
+		return nil
+	}
+
+	// Hold one-sided updates until their other side arrives; the messages
+	// whose held sides merge into this batch are committed once it is cached
+	var released []*trackedMessage
+	if c.sides != nil {
+		normalizedOdds, released = c.mergeSides(msg, normalizedOdds, headers.Profile)
+		if len(normalizedOdds) == 0 {
+			c.lastOffset.Store(msg.Offset)
+			c.logger.Debug().
+				Int64("offset", msg.Offset).
+				Str("batch_id", kafkaMsg.BatchID).
+				Msg("holding one-sided odds for merging")
+			return nil
+		}
 	}
 
 	// Optimize odds
-	optimizedOdds, err := c.optimizer.BatchOptimize(normalizedOdds)
+	optimizedOdds, err := c.optimize(ctx, normalizedOdds, headers.Profile)
 	if err != nil {
+		c.resolveSides(ctx, released, false)
 		return fmt.Errorf("failed to optimize odds: %w", err)
 	}
 
+	// The first batch after a feed gap reports reduced confidence
+	if c.gapPending.Swap(false) {
+		c.applyGapPenalty(optimizedOdds)
+	}
+
 	// Cache optimized odds in Redis
-	if err := c.cache.SetBatch(ctx, optimizedOdds); err != nil {
+	start := time.Now()
+	err = c.cache.SetBatch(ctx, optimizedOdds)
+	c.observeCacheLatency(time.Since(start))
+	c.resolveSides(ctx, released, err == nil)
+	if err != nil {
 		return fmt.Errorf("failed to cache odds: %w", err)
 	}
 
+	// Record snapshots for point-in-time queries; like publishing, this is
+	// best-effort once the cache write has succeeded
+	if c.history != nil {
+		if err := c.history.Append(ctx, optimizedOdds); err != nil {
+			c.logger.Error().
+				Err(err).
+				Str("batch_id", kafkaMsg.BatchID).
+				Msg("failed to record optimized odds history")
+		}
+	}
+
+	// Publish downstream. The cache write already succeeded, so a publish
+	// failure is logged rather than failing the message, unless commits are
+	// gated on publishing too.
+	if c.sinks != nil {
+		if err := c.sinks.Publish(ctx, optimizedOdds); err != nil {
+			if c.commitAfterPublish {
+				return fmt.Errorf("%w: %w", errPublishFailed, err)
+			}
+			c.logger.Error().
+				Err(err).
+				Str("batch_id", kafkaMsg.BatchID).
+				Msg("failed to publish optimized odds")
+		}
+	}
+
+	// Remember the batch only once it is cached (and published, when that gates commits), so a failed attempt is retried
+	if c.dedup != nil {
+		if err := c.dedup.Mark(ctx, dedupKey); err != nil {
+			c.logger.Warn().
+				Err(err).
+				Str("batch_id", kafkaMsg.BatchID).
+				Msg("failed to record processed batch for dedup")
+		}
+	}
+
+	c.messagesProcessed.Add(1)
+	c.oddsProcessed.Add(uint64(len(optimizedOdds)))
+	c.lastOffset.Store(msg.Offset)
+	c.metrics.messagesProcessed.WithLabelValues(headers.Sport, headers.Priority).Inc()
+
 	c.logger.Info().
 		Int("input_count", len(normalizedOdds)).
 		Int("output_count", len(optimizedOdds)).
@@ -133,7 +662,167 @@ func (c *KafkaConsumer) processMessage(ctx context.Context, msg kafka.Message) e
 	return nil
 }
 
+// optimize optimizes a batch market by market, routing each selection to
+// its named profile. A profile set on the selection wins over the message
+// header; selections without a profile, or with an unknown one, use the
+// default optimizer.
+func (c *KafkaConsumer) optimize(ctx context.Context, normalized []*models.NormalizedOdds, headerProfile string) ([]*models.OptimizedOdds, error) {
+	c.prices.Attach(ctx, normalized)
+	if len(c.profiles) == 0 {
+		return c.optimizer.BatchOptimizeMarket(normalized)
+	}
+
+	// Group by profile, preserving first-seen order
+	index := make(map[string]int)
+	var names []string
+	var groups [][]*models.NormalizedOdds
+	for _, odds := range normalized {
+		name := strings.ToLower(odds.Profile)
+		if name == "" {
+			name = headerProfile
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			names = append(names, name)
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], odds)
+	}
+
+	optimized := make([]*models.OptimizedOdds, 0, len(normalized))
+	for i, group := range groups {
+		opt, ok := c.profiles[names[i]]
+		if !ok {
+			if names[i] != "" {
+				c.logger.Warn().
+					Str("profile", names[i]).
+					Int("odds_count", len(group)).
+					Msg("unknown optimizer profile, using default")
+			}
+			opt = c.optimizer
+		}
+
+		result, err := opt.BatchOptimizeMarket(group)
+		if err != nil {
+			return nil, err
+		}
+		optimized = append(optimized, result...)
+	}
+
+	return optimized, nil
+}
+
+// SetProfiles sets named optimizers selectable per message (profile header)
+// or per selection (NormalizedOdds.Profile). Names are matched case-insensitively.
+func (c *KafkaConsumer) SetProfiles(profiles map[string]service.Optimizer) {
+	c.profiles = make(map[string]service.Optimizer, len(profiles))
+	for name, opt := range profiles {
+		c.profiles[strings.ToLower(name)] = opt
+	}
+}
+
+// SetSinks sets optional downstream sinks for optimized odds, typically a
+// *service.SinkRegistry fanning out to every configured sink. The Redis
+// cache is not a sink: its write gates offset commits and drives backpressure.
+func (c *KafkaConsumer) SetSinks(sinks service.OddsSink) {
+	c.sinks = sinks
+}
+
+// SetDedup sets an optional store of recently processed batches; messages
+// already processed within its window are skipped and committed
+func (c *KafkaConsumer) SetDedup(dedup service.Deduplicator) {
+	c.dedup = dedup
+}
+
+// messageDedupKey identifies a message for de-duplication: its message key
+// qualified by topic, partition and offset, since upstream keys (e.g. event
+// IDs) are reused across batches
+func messageDedupKey(msg kafka.Message) string {
+	return fmt.Sprintf("%s:%d:%d:%s", msg.Topic, msg.Partition, msg.Offset, msg.Key)
+}
+
+// SetHistory sets an optional snapshot store that records every optimized price
+func (c *KafkaConsumer) SetHistory(history service.History) {
+	c.history = history
+}
+
+// SetPriceHistory sets an optional reader of recent prices, attached to each
+// batch before it is optimized to weight confidence by price stability
+func (c *KafkaConsumer) SetPriceHistory(prices *service.HistoryPrices) {
+	c.prices = prices
+}
+
+// observeCacheLatency toggles backpressure based on cache write latency
+func (c *KafkaConsumer) observeCacheLatency(latency time.Duration) {
+	if c.backpressureThreshold <= 0 {
+		return
+	}
+
+	if latency > c.backpressureThreshold {
+		if !c.backpressure.Swap(true) {
+			c.metrics.backpressureActive.Set(1)
+			c.logger.Warn().
+				Dur("latency", latency).
+				Dur("threshold", c.backpressureThreshold).
+				Dur("pause", c.backpressurePause).
+				Msg("cache writes slow, pausing fetch")
+		}
+		return
+	}
+
+	if c.backpressure.Swap(false) {
+		c.metrics.backpressureActive.Set(0)
+		c.logger.Info().
+			Dur("latency", latency).
+			Msg("cache recovered, resuming fetch")
+	}
+}
+
+// observeGap flags a feed gap when the time since the previous message exceeds the gap threshold
+func (c *KafkaConsumer) observeGap(msg kafka.Message) {
+	if c.gapThreshold <= 0 {
+		return
+	}
+
+	now := time.Now()
+	previous := c.lastMessageAt.Swap(now.UnixNano())
+	if previous == 0 {
+		return
+	}
+
+	if gap := now.Sub(time.Unix(0, previous)); gap > c.gapThreshold {
+		c.gapPending.Store(true)
+		c.metrics.feedGaps.Inc()
+		c.logger.Warn().
+			Dur("gap", gap).
+			Dur("threshold", c.gapThreshold).
+			Int64("offset", msg.Offset).
+			Msg("feed gap detected, penalizing confidence of the next batch")
+	}
+}
+
+// applyGapPenalty scales confidence of a post-gap batch by the gap penalty
+func (c *KafkaConsumer) applyGapPenalty(optimized []*models.OptimizedOdds) {
+	for _, odds := range optimized {
+		odds.Confidence *= c.gapConfidencePenalty
+	}
+}
+
+// Stats returns a snapshot of the consumer's runtime counters
+func (c *KafkaConsumer) Stats() ConsumerStats {
+	return ConsumerStats{
+		MessagesProcessed: c.messagesProcessed.Load(),
+		MessagesFailed:    c.messagesFailed.Load(),
+		OddsProcessed:     c.oddsProcessed.Load(),
+		LastOffset:        c.lastOffset.Load(),
+		Lag:               c.lag.Load(),
+		Paused:            c.Paused(),
+	}
+}
+
 // Close closes the Kafka reader
 func (c *KafkaConsumer) Close() error {
-	return c.reader.Close()
+	return c.currentReader().Close()
 }
@@ -1,20 +1,30 @@
 package messaging
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
 	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
 	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/service"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
 )
 
 // testKafkaConsumerSetup is a helper struct to hold test dependencies
@@ -409,3 +419,1961 @@ func TestKafkaConsumer_Configuration(t *testing.T) {
 	assert.Equal(t, 1000, readerConfig.MinBytes) // 1KB
 	assert.Equal(t, 10000000, readerConfig.MaxBytes) // 10MB
 }
+
+// TestProcessMessage_UpdatesStats tests that processed messages are reflected in Stats
+func TestProcessMessage_UpdatesStats(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	config := KafkaConsumerConfig{
+		Brokers: []string{"localhost:9092"},
+		Topic:   "normalized_odds",
+		GroupID: "test-group",
+	}
+
+	consumer := NewKafkaConsumer(config, setup.mockOptimizer, setup.mockCache, setup.logger)
+	defer consumer.Close()
+
+	assert.Equal(t, int64(-1), consumer.Stats().LastOffset)
+
+	kafkaMsg := models.KafkaNormalizedOddsMessage{
+		OddsData: []models.NormalizedOdds{
+			{EventID: "event-123", Market: "match_winner", Selection: "Team A", BackPrice: decimal.NewFromFloat(2.50)},
+			{EventID: "event-123", Market: "match_winner", Selection: "Team B", BackPrice: decimal.NewFromFloat(3.20)},
+		},
+		Timestamp: time.Now(),
+		BatchID:   "batch-123",
+	}
+	msgBytes, err := json.Marshal(kafkaMsg)
+	require.NoError(t, err)
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}, {EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Len(2)).Return(optimized, nil)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil)
+
+	err = consumer.processMessage(context.Background(), kafka.Message{Value: msgBytes, Offset: 42})
+	require.NoError(t, err)
+
+	stats := consumer.Stats()
+	assert.Equal(t, uint64(1), stats.MessagesProcessed)
+	assert.Equal(t, uint64(2), stats.OddsProcessed)
+	assert.Equal(t, int64(42), stats.LastOffset)
+}
+
+// fakeReader is an in-memory messageReader serving a fixed list of messages
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []kafka.Message
+	onFetch   func(n int) // Called before serving the n-th fetch (0-based)
+	fetches   int
+	seeks     []string // "offset:<n>" or "at:<rfc3339>", in order
+	closed    bool
+
+	noGroup        bool          // Reads partition 0 without a consumer group; only then can it seek, as in kafka-go
+	commitInterval time.Duration // Reported CommitInterval; non-zero when auto committing
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	n := r.fetches
+	r.fetches++
+	onFetch := r.onFetch
+	r.mu.Unlock()
+
+	if onFetch != nil {
+		onFetch(n)
+	}
+
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Config() kafka.ReaderConfig {
+	config := kafka.ReaderConfig{Topic: "normalized_odds", GroupID: "test-group", CommitInterval: r.commitInterval}
+	if r.noGroup {
+		config.GroupID = ""
+	}
+	return config
+}
+
+func (r *fakeReader) SetOffset(offset int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.noGroup {
+		return errors.New("unavailable when GroupID is set")
+	}
+	r.seeks = append(r.seeks, fmt.Sprintf("offset:%d", offset))
+	return nil
+}
+
+func (r *fakeReader) SetOffsetAt(ctx context.Context, t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.noGroup {
+		return errors.New("unavailable when GroupID is set")
+	}
+	r.seeks = append(r.seeks, "at:"+t.UTC().Format(time.RFC3339))
+	return nil
+}
+
+func (r *fakeReader) seekLog() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.seeks...)
+}
+
+func (r *fakeReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *fakeReader) committedCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.committed)
+}
+
+// newTestMessage builds a Kafka message carrying a single-selection batch
+func newTestMessage(t *testing.T, offset int64) kafka.Message {
+	t.Helper()
+	kafkaMsg := models.KafkaNormalizedOddsMessage{
+		OddsData: []models.NormalizedOdds{
+			{EventID: "event-123", Market: "match_winner", Selection: "Team A", BackPrice: decimal.NewFromFloat(2.50)},
+		},
+		Timestamp: time.Now(),
+		BatchID:   fmt.Sprintf("batch-%d", offset),
+	}
+	msgBytes, err := json.Marshal(kafkaMsg)
+	require.NoError(t, err)
+	return kafka.Message{Value: msgBytes, Offset: offset}
+}
+
+// newConsumerWithReader creates a consumer whose Kafka reader is replaced by reader
+func newConsumerWithReader(setup *testKafkaConsumerSetup, config KafkaConsumerConfig, reader messageReader) *KafkaConsumer {
+	config.Brokers = []string{"localhost:9092"}
+	config.Topic = "normalized_odds"
+	config.GroupID = "test-group"
+
+	consumer := NewKafkaConsumer(config, setup.mockOptimizer, setup.mockCache, setup.logger)
+	consumer.reader.Close()
+	consumer.reader = reader
+	return consumer
+}
+
+// TestKafkaConsumer_Backpressure tests that a slow cache pauses fetching until it recovers
+func TestKafkaConsumer_Backpressure(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{messages: []kafka.Message{newTestMessage(t, 1), newTestMessage(t, 2)}}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{
+		BackpressureThreshold: 20 * time.Millisecond,
+		BackpressurePause:     50 * time.Millisecond,
+		Registerer:            prometheus.NewRegistry(),
+	}, reader)
+
+	var firstDone time.Time
+	var pausedFor time.Duration
+	var gaugeAtSecondFetch float64
+	reader.onFetch = func(n int) {
+		if n == 1 {
+			pausedFor = time.Since(firstDone)
+			gaugeAtSecondFetch = testutil.ToFloat64(consumer.metrics.backpressureActive)
+		}
+	}
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil).Times(2)
+	gomock.InOrder(
+		// Slow write drives backpressure
+		setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, odds []*models.OptimizedOdds) error {
+				time.Sleep(40 * time.Millisecond)
+				firstDone = time.Now()
+				return nil
+			}),
+		// Fast write clears it
+		setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return reader.committedCount() == 2 }, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, 1.0, gaugeAtSecondFetch)
+	assert.GreaterOrEqual(t, pausedFor, 50*time.Millisecond)
+	assert.Equal(t, 0.0, testutil.ToFloat64(consumer.metrics.backpressureActive))
+}
+
+// TestKafkaConsumer_BackpressureDisabled tests that a zero threshold never pauses
+func TestKafkaConsumer_BackpressureDisabled(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+
+	consumer.observeCacheLatency(time.Hour)
+
+	assert.False(t, consumer.backpressure.Load())
+	assert.Equal(t, 0.0, testutil.ToFloat64(consumer.metrics.backpressureActive))
+}
+
+// TestParseHeaders tests reading routing headers with defaults
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  []kafka.Header
+		expected messageHeaders
+	}{
+		{
+			name:     "No headers",
+			headers:  nil,
+			expected: messageHeaders{Sport: "unknown", Priority: "normal"},
+		},
+		{
+			name: "Both headers",
+			headers: []kafka.Header{
+				{Key: "sport", Value: []byte("Tennis")},
+				{Key: "priority", Value: []byte("high")},
+			},
+			expected: messageHeaders{Sport: "tennis", Priority: "high"},
+		},
+		{
+			name: "Empty values and unrelated headers",
+			headers: []kafka.Header{
+				{Key: "sport", Value: []byte("  ")},
+				{Key: "trace_id", Value: []byte("abc")},
+				{Key: "Priority", Value: []byte("LOW")},
+			},
+			expected: messageHeaders{Sport: "unknown", Priority: "low"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseHeaders(kafka.Message{Headers: tt.headers}))
+		})
+	}
+}
+
+// TestProcessMessage_HeaderLabels tests that processed messages are labeled by header
+func TestProcessMessage_HeaderLabels(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{Registerer: prometheus.NewRegistry()}, &fakeReader{})
+
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return([]*models.OptimizedOdds{{}}, nil).Times(2)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	msg := newTestMessage(t, 1)
+	msg.Headers = []kafka.Header{
+		{Key: "sport", Value: []byte("football")},
+		{Key: "priority", Value: []byte("high")},
+	}
+	require.NoError(t, consumer.processMessage(context.Background(), msg))
+	require.NoError(t, consumer.processMessage(context.Background(), newTestMessage(t, 2)))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.messagesProcessed.WithLabelValues("football", "high")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.messagesProcessed.WithLabelValues("unknown", "normal")))
+}
+
+// TestProcessMessage_BatchSizeMetrics tests that each message's item count is recorded
+func TestProcessMessage_BatchSizeMetrics(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reg := prometheus.NewRegistry()
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{Registerer: reg}, &fakeReader{})
+
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return([]*models.OptimizedOdds{{}}, nil).Times(2)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	odds := models.NormalizedOdds{EventID: "event-123", Market: "match_winner", BackPrice: decimal.NewFromFloat(2.50)}
+	msgBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{
+		OddsData: []models.NormalizedOdds{odds, odds, odds},
+		BatchID:  "batch-3",
+	})
+	require.NoError(t, err)
+	require.NoError(t, consumer.processMessage(context.Background(), kafka.Message{Value: msgBytes, Offset: 1}))
+	require.NoError(t, consumer.processMessage(context.Background(), newTestMessage(t, 2)))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var found bool
+	for _, family := range families {
+		if family.GetName() != "kafka_batch_size" {
+			continue
+		}
+		found = true
+		histogram := family.GetMetric()[0].GetHistogram()
+		assert.Equal(t, uint64(2), histogram.GetSampleCount())
+		assert.Equal(t, 4.0, histogram.GetSampleSum())
+	}
+	assert.True(t, found, "kafka_batch_size not registered")
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.lastBatchSize))
+}
+
+// TestProcessMessage_SkipLowPriority tests skipping low-priority messages during backpressure
+func TestProcessMessage_SkipLowPriority(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{
+		SkipLowPriority: true,
+		Registerer:      prometheus.NewRegistry(),
+	}, &fakeReader{})
+
+	lowMsg := newTestMessage(t, 1)
+	lowMsg.Headers = []kafka.Header{
+		{Key: "sport", Value: []byte("darts")},
+		{Key: "priority", Value: []byte("low")},
+	}
+
+	// Without backpressure low-priority messages are processed
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return([]*models.OptimizedOdds{{}}, nil).Times(2)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	require.NoError(t, consumer.processMessage(context.Background(), lowMsg))
+
+	// During backpressure they are skipped without optimizing or caching
+	consumer.backpressure.Store(true)
+	require.NoError(t, consumer.processMessage(context.Background(), lowMsg))
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.messagesSkipped.WithLabelValues("darts", "low")))
+
+	// Normal priority is still processed during backpressure
+	require.NoError(t, consumer.processMessage(context.Background(), newTestMessage(t, 2)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.messagesProcessed.WithLabelValues("unknown", "normal")))
+}
+
+// fakePublisher records published batches
+type fakePublisher struct {
+	mu       sync.Mutex
+	batches  [][]*models.OptimizedOdds
+	err      error
+	failures int // Fail only the first failures publishes with err (0 fails every one)
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches = append(p.batches, odds)
+	if p.failures > 0 && len(p.batches) > p.failures {
+		return nil
+	}
+	return p.err
+}
+
+// TestProcessMessage_PublishesOptimizedOdds tests that cached odds are published downstream
+func TestProcessMessage_PublishesOptimizedOdds(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	publisher := &fakePublisher{err: errors.New("broker unavailable")}
+	consumer.SetSinks(publisher)
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil)
+
+	// A publish failure does not fail an already-cached message
+	require.NoError(t, consumer.processMessage(context.Background(), newTestMessage(t, 1)))
+	require.Len(t, publisher.batches, 1)
+	assert.Equal(t, optimized, publisher.batches[0])
+}
+
+// TestKafkaConsumer_CommitAfterPublish tests that with CommitAfterPublish a
+// publish failure holds back the commit of its message and every later one,
+// while without it only the cache write gates the commit
+func TestKafkaConsumer_CommitAfterPublish(t *testing.T) {
+	tests := []struct {
+		name               string
+		commitAfterPublish bool
+		publishErr         error
+		wantCommitted      int
+	}{
+		{name: "gate off, publish fails", publishErr: errors.New("broker unavailable"), wantCommitted: 2},
+		{name: "gate on, publish fails", commitAfterPublish: true, publishErr: errors.New("broker unavailable"), wantCommitted: 0},
+		{name: "gate on, publish succeeds", commitAfterPublish: true, wantCommitted: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTestKafkaConsumer(t)
+			defer setup.cleanup()
+
+			// Only the first message's publish fails; the second follows it on the same partition
+			reader := &fakeReader{messages: []kafka.Message{newTestMessage(t, 3), newTestMessage(t, 4)}}
+			consumer := newConsumerWithReader(setup, KafkaConsumerConfig{CommitAfterPublish: tt.commitAfterPublish}, reader)
+			publisher := &fakePublisher{err: tt.publishErr, failures: 1}
+			consumer.SetSinks(publisher)
+
+			optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+			setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil).Times(2)
+			setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil).Times(2)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- consumer.Start(ctx)
+			}()
+
+			require.Eventually(t, func() bool {
+				return consumer.Stats().MessagesProcessed+consumer.Stats().MessagesFailed == 2
+			}, time.Second, 5*time.Millisecond)
+			cancel()
+			<-done
+
+			assert.Equal(t, tt.wantCommitted, reader.committedCount())
+			assert.Len(t, publisher.batches, 2)
+		})
+	}
+}
+
+// TestProcessMessage_CommitAfterPublishDedup tests that a batch whose publish
+// failed is not remembered, so its redelivery is published again
+func TestProcessMessage_CommitAfterPublishDedup(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{CommitAfterPublish: true}, &fakeReader{})
+	dedup := mocks.NewMockDeduplicator(setup.ctrl)
+	consumer.SetDedup(dedup)
+	consumer.SetSinks(&fakePublisher{err: errors.New("broker unavailable")})
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	dedup.EXPECT().Seen(gomock.Any(), gomock.Any()).Return(false, nil)
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil)
+	// No Mark expectation: marking fails the test
+
+	err := consumer.processMessage(context.Background(), newTestMessage(t, 1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to publish optimized odds")
+}
+
+// TestKafkaConsumer_EmptyBatchSkipsProcessing tests that empty batches are committed
+// without invoking the optimizer or cache
+func TestKafkaConsumer_EmptyBatchSkipsProcessing(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	msgBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{
+		OddsData:  []models.NormalizedOdds{},
+		Timestamp: time.Now(),
+		BatchID:   "batch-heartbeat",
+	})
+	require.NoError(t, err)
+
+	reader := &fakeReader{messages: []kafka.Message{{Value: msgBytes, Offset: 7}}}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{Registerer: prometheus.NewRegistry()}, reader)
+
+	// No optimizer or cache expectations: any call fails the test
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return reader.committedCount() == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.emptyBatches))
+	assert.Equal(t, uint64(0), consumer.Stats().MessagesProcessed)
+	assert.Equal(t, int64(7), consumer.Stats().LastOffset)
+}
+
+// TestNewKafkaConsumer_CommitMode tests that explicit commit disables kafka-go's commit interval
+func TestNewKafkaConsumer_CommitMode(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	tests := []struct {
+		name     string
+		config   KafkaConsumerConfig
+		expected time.Duration
+	}{
+		{name: "Explicit by default", config: KafkaConsumerConfig{CommitInterval: 5 * time.Second}, expected: 0},
+		{name: "Auto commit", config: KafkaConsumerConfig{AutoCommit: true, CommitInterval: 5 * time.Second}, expected: 5 * time.Second},
+		{name: "Auto commit default interval", config: KafkaConsumerConfig{AutoCommit: true}, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Brokers = []string{"localhost:9092"}
+			tt.config.Topic = "normalized_odds"
+			tt.config.GroupID = "test-group"
+
+			consumer := NewKafkaConsumer(tt.config, setup.mockOptimizer, setup.mockCache, setup.logger)
+			defer consumer.Close()
+
+			assert.Equal(t, tt.expected, consumer.reader.Config().CommitInterval)
+		})
+	}
+}
+
+// TestKafkaConsumer_ExplicitCommitAfterCacheWrite tests that offsets only advance
+// after a successful cache write
+func TestKafkaConsumer_ExplicitCommitAfterCacheWrite(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{messages: []kafka.Message{newTestMessage(t, 1), newTestMessage(t, 2)}}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, reader)
+
+	var committedDuringWrite []int
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil).Times(2)
+	gomock.InOrder(
+		// First write fails: its offset must never be committed
+		setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, odds []*models.OptimizedOdds) error {
+				committedDuringWrite = append(committedDuringWrite, reader.committedCount())
+				return errors.New("redis unavailable")
+			}),
+		setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, odds []*models.OptimizedOdds) error {
+				committedDuringWrite = append(committedDuringWrite, reader.committedCount())
+				return nil
+			}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return reader.committedCount() == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Equal(t, []int{0, 0}, committedDuringWrite)
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+	require.Len(t, reader.committed, 1)
+	assert.Equal(t, int64(2), reader.committed[0].Offset)
+}
+
+// newProfileOptimizer creates a real optimizer with the given margin bounds
+func newProfileOptimizer(minMargin, maxMargin float64) *optimizer.Optimizer {
+	return optimizer.NewOptimizer(models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(minMargin),
+		MaxMargin:        decimal.NewFromFloat(maxMargin),
+		MinSpread:        decimal.NewFromFloat(0.01),
+		TargetConfidence: 0.85,
+	}, zerolog.Nop())
+}
+
+// TestProcessMessage_Profiles tests that the same input is priced differently per profile
+func TestProcessMessage_Profiles(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = newProfileOptimizer(0.04, 0.10)
+	consumer.SetProfiles(map[string]service.Optimizer{
+		"Aggressive":   newProfileOptimizer(0.10, 0.20),
+		"conservative": newProfileOptimizer(0.01, 0.02),
+	})
+
+	var cached []*models.OptimizedOdds
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			cached = append(cached, odds...)
+			return nil
+		}).Times(2)
+
+	// Per-selection profiles win over the header; unknown profiles use the default.
+	// Selections are distinct so none is dropped as a duplicate within a batch.
+	newOdds := func(profile string) models.NormalizedOdds {
+		return models.NormalizedOdds{
+			EventID:   "event-123",
+			Market:    "match_winner",
+			Selection: "Team A/" + profile,
+			Sport:     "tennis",
+			BackPrice: decimal.NewFromFloat(2.50),
+			LayPrice:  decimal.NewFromFloat(2.60),
+			BackSize:  decimal.NewFromFloat(10000),
+			LaySize:   decimal.NewFromFloat(10000),
+			Timestamp: time.Now(),
+			Profile:   profile,
+		}
+	}
+	msgBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{
+		OddsData: []models.NormalizedOdds{newOdds(""), newOdds("aggressive"), newOdds("CONSERVATIVE"), newOdds("missing")},
+		BatchID:  "batch-profiles",
+	})
+	require.NoError(t, err)
+	require.NoError(t, consumer.processMessage(context.Background(), kafka.Message{Value: msgBytes, Offset: 1}))
+
+	// The header selects the profile for selections without one
+	headerMsg := kafka.Message{Value: msgBytes, Offset: 2, Headers: []kafka.Header{{Key: "profile", Value: []byte("conservative")}}}
+	require.NoError(t, consumer.processMessage(context.Background(), headerMsg))
+
+	margins := make(map[string]int)
+	for _, odds := range cached {
+		margins[odds.Margin.String()]++
+	}
+	// Tennis has no sport multiplier, so each profile prices at its min margin:
+	// default 0.04 (no profile without header, unknown profile), aggressive 0.1,
+	// conservative 0.01 (by field, and by header for the unprofiled selection)
+	assert.Equal(t, map[string]int{"0.04": 3, "0.1": 2, "0.01": 3}, margins)
+
+	require.Len(t, cached, 8)
+	assert.False(t, cached[0].OptimizedBack.Equal(cached[1].OptimizedBack))
+	assert.False(t, cached[1].OptimizedBack.Equal(cached[2].OptimizedBack))
+}
+
+// fetchCount returns how many fetches have been attempted
+func (r *fakeReader) fetchCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fetches
+}
+
+// TestKafkaConsumer_MaxInflight tests that fetching blocks while max_inflight
+// messages are outstanding and resumes as they complete
+func TestKafkaConsumer_MaxInflight(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{messages: []kafka.Message{
+		newTestMessage(t, 1), newTestMessage(t, 2), newTestMessage(t, 3),
+	}}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{MaxInflight: 2}, reader)
+
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil).Times(3)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}).Times(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+
+	// Two messages in flight: the third is not fetched
+	<-started
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, reader.fetchCount())
+
+	// Completing one frees a slot for the third
+	release <- struct{}{}
+	<-started
+	assert.Equal(t, 3, reader.fetchCount())
+
+	close(release)
+	require.Eventually(t, func() bool {
+		reader.mu.Lock()
+		defer reader.mu.Unlock()
+		n := len(reader.committed)
+		return n > 0 && reader.committed[n-1].Offset == 3
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	// Commits never move backwards
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+	for i := 1; i < len(reader.committed); i++ {
+		assert.Greater(t, reader.committed[i].Offset, reader.committed[i-1].Offset)
+	}
+	assert.Equal(t, uint64(3), consumer.Stats().MessagesProcessed)
+}
+
+// TestOffsetTracker tests that commits wait for earlier messages on the same partition
+func TestOffsetTracker(t *testing.T) {
+	tracker := newOffsetTracker()
+	first := tracker.track(kafka.Message{Partition: 0, Offset: 1})
+	second := tracker.track(kafka.Message{Partition: 0, Offset: 2})
+	third := tracker.track(kafka.Message{Partition: 0, Offset: 3})
+	other := tracker.track(kafka.Message{Partition: 1, Offset: 7})
+
+	// Later messages finishing first are held back
+	_, ok := tracker.complete(third, resultCommit)
+	assert.False(t, ok)
+
+	// Other partitions are independent
+	msg, ok := tracker.complete(other, resultCommit)
+	require.True(t, ok)
+	assert.Equal(t, int64(7), msg.Offset)
+
+	// A failed message is not committed but does not block later ones
+	_, ok = tracker.complete(first, resultSkip)
+	assert.False(t, ok)
+
+	msg, ok = tracker.complete(second, resultCommit)
+	require.True(t, ok)
+	assert.Equal(t, int64(3), msg.Offset)
+}
+
+// TestOffsetTracker_Hold tests that a held message stops its partition's
+// commits until it is fetched again
+func TestOffsetTracker_Hold(t *testing.T) {
+	tracker := newOffsetTracker()
+	first := tracker.track(kafka.Message{Partition: 0, Offset: 1})
+	second := tracker.track(kafka.Message{Partition: 0, Offset: 2})
+	third := tracker.track(kafka.Message{Partition: 0, Offset: 3})
+	other := tracker.track(kafka.Message{Partition: 1, Offset: 7})
+
+	// Messages before the held one are still committed
+	msg, ok := tracker.complete(first, resultCommit)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), msg.Offset)
+
+	_, ok = tracker.complete(third, resultCommit)
+	assert.False(t, ok)
+	_, ok = tracker.complete(second, resultHold)
+	assert.False(t, ok)
+
+	// Later messages, already in flight or fetched after, are not committed
+	fourth := tracker.track(kafka.Message{Partition: 0, Offset: 4})
+	_, ok = tracker.complete(fourth, resultCommit)
+	assert.False(t, ok)
+
+	// Other partitions are independent
+	msg, ok = tracker.complete(other, resultCommit)
+	require.True(t, ok)
+	assert.Equal(t, int64(7), msg.Offset)
+
+	// Fetching the held message again releases the partition
+	redelivered := tracker.track(kafka.Message{Partition: 0, Offset: 2})
+	msg, ok = tracker.complete(redelivered, resultCommit)
+	require.True(t, ok)
+	assert.Equal(t, int64(2), msg.Offset)
+
+	// A seek releases it too
+	held := tracker.track(kafka.Message{Partition: 0, Offset: 3})
+	_, ok = tracker.complete(held, resultHold)
+	assert.False(t, ok)
+	tracker.release(0)
+	next := tracker.track(kafka.Message{Partition: 0, Offset: 9})
+	msg, ok = tracker.complete(next, resultCommit)
+	require.True(t, ok)
+	assert.Equal(t, int64(9), msg.Offset)
+}
+
+// TestProcessMessage_GapConfidencePenalty tests that only the first batch after a feed gap
+// reports reduced confidence
+func TestProcessMessage_GapConfidencePenalty(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{
+		GapThreshold:         50 * time.Millisecond,
+		GapConfidencePenalty: 0.5,
+		Registerer:           prometheus.NewRegistry(),
+	}, &fakeReader{})
+
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).DoAndReturn(
+		func(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+			return []*models.OptimizedOdds{{EventID: "event-123", Confidence: 0.8}}, nil
+		}).Times(4)
+
+	var confidences []float64
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			confidences = append(confidences, odds[0].Confidence)
+			return nil
+		}).Times(4)
+
+	ctx := context.Background()
+	require.NoError(t, consumer.processMessage(ctx, newTestMessage(t, 1)))
+	require.NoError(t, consumer.processMessage(ctx, newTestMessage(t, 2)))
+	time.Sleep(80 * time.Millisecond)
+	require.NoError(t, consumer.processMessage(ctx, newTestMessage(t, 3)))
+	require.NoError(t, consumer.processMessage(ctx, newTestMessage(t, 4)))
+
+	// Steady state, steady state, post-gap, steady state
+	assert.Equal(t, []float64{0.8, 0.8, 0.4, 0.8}, confidences)
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.feedGaps))
+}
+
+// TestProcessMessage_GapThresholdDisabled tests that a zero threshold never penalizes
+func TestProcessMessage_GapThresholdDisabled(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{GapConfidencePenalty: 0.5}, &fakeReader{})
+
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).DoAndReturn(
+		func(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+			return []*models.OptimizedOdds{{EventID: "event-123", Confidence: 0.8}}, nil
+		}).Times(2)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			assert.Equal(t, 0.8, odds[0].Confidence)
+			return nil
+		}).Times(2)
+
+	require.NoError(t, consumer.processMessage(context.Background(), newTestMessage(t, 1)))
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, consumer.processMessage(context.Background(), newTestMessage(t, 2)))
+}
+
+// TestProcessMessage_Dedup tests that a fresh batch is processed and an immediate
+// redelivery of the same message is skipped
+func TestProcessMessage_Dedup(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{Registerer: prometheus.NewRegistry()}, &fakeReader{})
+	dedup := mocks.NewMockDeduplicator(setup.ctrl)
+	consumer.SetDedup(dedup)
+
+	msg := newTestMessage(t, 42)
+	msg.Topic = "normalized_odds"
+	msg.Key = []byte("event-123")
+	key := "normalized_odds:0:42:event-123"
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	gomock.InOrder(
+		// Fresh batch: processed, then remembered
+		dedup.EXPECT().Seen(gomock.Any(), key).Return(false, nil),
+		setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil),
+		setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil),
+		dedup.EXPECT().Mark(gomock.Any(), key).Return(nil),
+		// Redelivery: skipped before optimizing
+		dedup.EXPECT().Seen(gomock.Any(), key).Return(true, nil),
+	)
+
+	require.NoError(t, consumer.processMessage(context.Background(), msg))
+	require.NoError(t, consumer.processMessage(context.Background(), msg))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.duplicateBatches))
+	assert.Equal(t, uint64(1), consumer.Stats().MessagesProcessed)
+}
+
+// TestProcessMessage_DedupFailures tests that dedup store errors never drop a batch,
+// and that a failed cache write is not remembered
+func TestProcessMessage_DedupFailures(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	dedup := mocks.NewMockDeduplicator(setup.ctrl)
+	consumer.SetDedup(dedup)
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil).Times(2)
+	dedup.EXPECT().Seen(gomock.Any(), gomock.Any()).Return(false, errors.New("redis unavailable")).Times(2)
+	gomock.InOrder(
+		setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(errors.New("redis unavailable")),
+		setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil),
+		dedup.EXPECT().Mark(gomock.Any(), gomock.Any()).Return(errors.New("redis unavailable")),
+	)
+
+	assert.Error(t, consumer.processMessage(context.Background(), newTestMessage(t, 1)))
+	assert.NoError(t, consumer.processMessage(context.Background(), newTestMessage(t, 1)))
+}
+
+// TestKafkaConsumer_DrainOnCancel tests that cancelling Start lets the current
+// message finish its cache write and commit
+func TestKafkaConsumer_DrainOnCancel(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{messages: []kafka.Message{newTestMessage(t, 1)}}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, reader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(writeCtx context.Context, odds []*models.OptimizedOdds) error {
+			// Shutdown begins mid-write
+			cancel()
+			return writeCtx.Err()
+		})
+
+	require.NoError(t, consumer.Start(ctx))
+
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+	require.Len(t, reader.committed, 1)
+	assert.Equal(t, int64(1), reader.committed[0].Offset)
+}
+
+// TestProcessMessage_BatchDefaults tests that the batch currency and source
+// apply to selections without their own
+func TestProcessMessage_BatchDefaults(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+
+	msgBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{
+		OddsData: []models.NormalizedOdds{
+			{EventID: "event-123", Market: "match_winner", Selection: "Team A", BackPrice: decimal.NewFromFloat(2.50)},
+			{EventID: "event-123", Market: "match_winner", Selection: "Team B", BackPrice: decimal.NewFromFloat(1.80), Currency: "EUR", Source: "pinnacle"},
+		},
+		Timestamp: time.Now(),
+		BatchID:   "batch-gbp",
+		Currency:  "GBP",
+		Source:    "betfair",
+	})
+	require.NoError(t, err)
+
+	var currencies, sources []string
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).DoAndReturn(
+		func(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+			for _, odds := range normalized {
+				currencies = append(currencies, odds.Currency)
+				sources = append(sources, odds.Source)
+			}
+			return optimized, nil
+		})
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil)
+
+	require.NoError(t, consumer.processMessage(context.Background(), kafka.Message{Value: msgBytes}))
+	assert.Equal(t, []string{"GBP", "EUR"}, currencies)
+	assert.Equal(t, []string{"betfair", "pinnacle"}, sources)
+}
+
+// TestKafkaConsumer_Seek tests seeking by offset and by timestamp: a seek
+// waits for in-flight messages to finish and commit, and wakes a fetch
+// blocked waiting for messages
+func TestKafkaConsumer_Seek(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{messages: []kafka.Message{newTestMessage(t, 1)}, noGroup: true}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{MaxInflight: 2}, reader)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			close(started)
+			<-release
+			return nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+	<-started
+
+	// Seek by offset: held back until the in-flight message completes
+	seeked := make(chan error, 1)
+	go func() {
+		seeked <- consumer.Seek(context.Background(), SeekRequest{Offset: 42})
+	}()
+	select {
+	case <-seeked:
+		t.Fatal("seek completed while a message was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Empty(t, reader.seekLog())
+
+	close(release)
+	require.NoError(t, <-seeked)
+	assert.Equal(t, 1, reader.committedCount())
+	assert.Equal(t, []string{"offset:42"}, reader.seekLog())
+
+	// Seek by timestamp while the fetch loop waits for messages
+	at := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	require.NoError(t, consumer.Seek(context.Background(), SeekRequest{At: at}))
+	assert.Equal(t, []string{"offset:42", "at:2024-03-01T14:00:00Z"}, reader.seekLog())
+
+	cancel()
+	<-done
+
+	assert.ErrorIs(t, consumer.Seek(context.Background(), SeekRequest{Offset: 7}), ErrConsumerNotRunning)
+	assert.ErrorIs(t, consumer.Seek(context.Background(), SeekRequest{Offset: -1}), ErrInvalidSeek)
+}
+
+// TestKafkaConsumer_PollTimeout tests that the last poll advances while the
+// feed is idle and that empty polls are not logged as errors
+func TestKafkaConsumer_PollTimeout(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{PollTimeout: 10 * time.Millisecond}, reader)
+	var logs bytes.Buffer
+	consumer.logger = zerolog.New(&logs)
+	assert.True(t, consumer.LastPoll().IsZero())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return !consumer.LastPoll().IsZero() }, time.Second, time.Millisecond)
+	first := consumer.LastPoll()
+	require.Eventually(t, func() bool { return consumer.LastPoll().After(first) }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		reader.mu.Lock()
+		defer reader.mu.Unlock()
+		return reader.fetches >= 3
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.NotContains(t, logs.String(), "failed to fetch message")
+	assert.NotContains(t, logs.String(), `"level":"error"`)
+}
+
+// TestKafkaConsumer_LivenessWhileWaiting tests that the fetch loop keeps
+// recording liveness while it waits out backpressure or for an in-flight
+// slot rather than fetching
+func TestKafkaConsumer_LivenessWhileWaiting(t *testing.T) {
+	tests := []struct {
+		name  string
+		block func(consumer *KafkaConsumer)
+	}{
+		{name: "Backpressure", block: func(consumer *KafkaConsumer) {
+			consumer.backpressurePause = time.Hour
+			consumer.backpressure.Store(true)
+		}},
+		{name: "In-flight slots taken", block: func(consumer *KafkaConsumer) {
+			consumer.inflight <- struct{}{}
+			consumer.inflight <- struct{}{}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTestKafkaConsumer(t)
+			defer setup.cleanup()
+
+			reader := &fakeReader{}
+			consumer := newConsumerWithReader(setup, KafkaConsumerConfig{PollTimeout: 10 * time.Millisecond, MaxInflight: 2}, reader)
+			tt.block(consumer)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- consumer.Start(ctx)
+			}()
+
+			require.Eventually(t, func() bool { return !consumer.LastPoll().IsZero() }, time.Second, time.Millisecond)
+			first := consumer.LastPoll()
+			require.Eventually(t, func() bool { return consumer.LastPoll().After(first) }, time.Second, 5*time.Millisecond)
+
+			cancel()
+			require.NoError(t, <-done)
+			reader.mu.Lock()
+			defer reader.mu.Unlock()
+			assert.Zero(t, reader.fetches)
+		})
+	}
+}
+
+// TestKafkaConsumer_SportFilter tests allowlist and denylist filtering of
+// odds_data items, and that filtered messages still commit
+func TestKafkaConsumer_SportFilter(t *testing.T) {
+	sportsMessage := func(t *testing.T, offset int64, sports ...string) kafka.Message {
+		t.Helper()
+		kafkaMsg := models.KafkaNormalizedOddsMessage{Timestamp: time.Now(), BatchID: fmt.Sprintf("batch-%d", offset)}
+		for i, sport := range sports {
+			kafkaMsg.OddsData = append(kafkaMsg.OddsData, models.NormalizedOdds{
+				EventID:   "event-123",
+				Sport:     sport,
+				Market:    "match_winner",
+				Selection: fmt.Sprintf("Selection %d", i),
+				BackPrice: decimal.NewFromFloat(2.50),
+			})
+		}
+		msgBytes, err := json.Marshal(kafkaMsg)
+		require.NoError(t, err)
+		return kafka.Message{Value: msgBytes, Offset: offset}
+	}
+
+	tests := []struct {
+		name      string
+		allowlist []string
+		denylist  []string
+		optimized []string // Sports reaching the optimizer, per message
+		filtered  map[string]float64
+	}{
+		{
+			name:      "Allowlist",
+			allowlist: []string{"Football", "tennis"},
+			optimized: []string{"football", "tennis", "football"},
+			filtered:  map[string]float64{"basketball": 2, "cricket": 1},
+		},
+		{
+			name:      "Denylist",
+			denylist:  []string{"basketball", "cricket"},
+			optimized: []string{"football", "tennis", "football"},
+			filtered:  map[string]float64{"basketball": 2, "cricket": 1},
+		},
+		{
+			name:      "Denylist wins",
+			allowlist: []string{"football", "basketball"},
+			denylist:  []string{"basketball"},
+			optimized: []string{"football", "football"},
+			filtered:  map[string]float64{"basketball": 2, "cricket": 1, "tennis": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTestKafkaConsumer(t)
+			defer setup.cleanup()
+
+			reader := &fakeReader{messages: []kafka.Message{
+				sportsMessage(t, 1, "football", "basketball", "tennis"),
+				sportsMessage(t, 2, "basketball", "cricket"), // Nothing left to optimize
+				sportsMessage(t, 3, "football"),
+			}}
+			consumer := newConsumerWithReader(setup, KafkaConsumerConfig{
+				SportAllowlist: tt.allowlist,
+				SportDenylist:  tt.denylist,
+				Registerer:     prometheus.NewRegistry(),
+			}, reader)
+
+			var mu sync.Mutex
+			var optimized []string
+			setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).DoAndReturn(
+				func(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+					mu.Lock()
+					defer mu.Unlock()
+					for _, odds := range normalized {
+						optimized = append(optimized, odds.Sport)
+					}
+					return []*models.OptimizedOdds{{EventID: "event-123"}}, nil
+				}).Times(2)
+			setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- consumer.Start(ctx)
+			}()
+
+			require.Eventually(t, func() bool { return reader.committedCount() == 3 }, time.Second, 5*time.Millisecond)
+			cancel()
+			<-done
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, tt.optimized, optimized)
+			for sport, count := range tt.filtered {
+				assert.Equal(t, count, testutil.ToFloat64(consumer.metrics.oddsFiltered.WithLabelValues(sport)), sport)
+			}
+			assert.Equal(t, int64(3), consumer.Stats().LastOffset)
+		})
+	}
+}
+
+// TestKafkaConsumer_PauseResume tests that no messages are fetched or
+// processed while paused, that a paused loop still records liveness, and
+// that resuming restores processing
+func TestKafkaConsumer_PauseResume(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{messages: []kafka.Message{newTestMessage(t, 1)}, noGroup: true}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{
+		PollTimeout: 10 * time.Millisecond,
+		Registerer:  prometheus.NewRegistry(),
+	}, reader)
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil).Times(2)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+	require.Eventually(t, func() bool { return reader.committedCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	// Pause while the fetch loop waits for messages, then publish one
+	consumer.Pause()
+	consumer.Pause()
+	assert.True(t, consumer.Stats().Paused)
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.paused))
+	time.Sleep(20 * time.Millisecond)
+	fetches := reader.fetchCount()
+	reader.mu.Lock()
+	reader.messages = append(reader.messages, newTestMessage(t, 2))
+	reader.mu.Unlock()
+
+	lastPoll := consumer.LastPoll()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, fetches, reader.fetchCount())
+	assert.Equal(t, 1, reader.committedCount())
+	assert.True(t, consumer.LastPoll().After(lastPoll), "paused loop stopped recording liveness")
+
+	// Seeks still apply while paused
+	require.NoError(t, consumer.Seek(context.Background(), SeekRequest{Offset: 2}))
+	assert.Equal(t, []string{"offset:2"}, reader.seekLog())
+	assert.Equal(t, 1, reader.committedCount())
+
+	consumer.Resume()
+	assert.False(t, consumer.Stats().Paused)
+	assert.Equal(t, 0.0, testutil.ToFloat64(consumer.metrics.paused))
+	require.Eventually(t, func() bool { return reader.committedCount() == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(2), consumer.Stats().LastOffset)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+// TestProcessMessage_SideMerge tests that a back-only message followed by a
+// lay-only message for the same selection within the merge window produces
+// one two-sided optimized result, and that a side left unmatched is optimized
+// alone once the window passes
+func TestProcessMessage_SideMerge(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reg := prometheus.NewRegistry()
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{SideMergeWindow: time.Minute, Registerer: reg}, &fakeReader{})
+	consumer.optimizer = newProfileOptimizer(0.02, 0.10)
+
+	message := func(offset int64, odds ...models.NormalizedOdds) kafka.Message {
+		msgBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{OddsData: odds, BatchID: fmt.Sprintf("batch-%d", offset)})
+		require.NoError(t, err)
+		return kafka.Message{Value: msgBytes, Offset: offset}
+	}
+	side := func(selection string, back, lay float64) models.NormalizedOdds {
+		return models.NormalizedOdds{
+			EventID:   "event-123",
+			Market:    "match_winner",
+			Selection: selection,
+			Sport:     "tennis",
+			BackPrice: decimal.NewFromFloat(back),
+			LayPrice:  decimal.NewFromFloat(lay),
+			BackSize:  decimal.NewFromFloat(back * 1000),
+			LaySize:   decimal.NewFromFloat(lay * 1000),
+			Timestamp: time.Now(),
+		}
+	}
+
+	var cached [][]*models.OptimizedOdds
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			cached = append(cached, odds)
+			return nil
+		}).AnyTimes()
+
+	// The back side alone is held
+	require.NoError(t, consumer.processMessage(context.Background(), message(1, side("Team A", 2.50, 0), side("Team B", 1.80, 0))))
+	assert.Empty(t, cached)
+	assert.Equal(t, int64(1), consumer.Stats().LastOffset)
+
+	// The lay side completes Team A; Team C is two-sided and passes through
+	require.NoError(t, consumer.processMessage(context.Background(), message(2, side("Team A", 0, 2.60), side("Team C", 3.00, 3.10))))
+	require.Len(t, cached, 1)
+	require.Len(t, cached[0], 2)
+	merged := cached[0][0]
+	assert.Equal(t, "Team A", merged.Selection)
+	assert.True(t, decimal.NewFromFloat(2.50).Equal(merged.OriginalBack), "original back %s", merged.OriginalBack)
+	assert.True(t, decimal.NewFromFloat(2.60).Equal(merged.OriginalLay), "original lay %s", merged.OriginalLay)
+	assert.True(t, decimal.NewFromInt(2500).Equal(merged.BackSize))
+	assert.True(t, decimal.NewFromInt(2600).Equal(merged.LaySize))
+	assert.Equal(t, "Team C", cached[0][1].Selection)
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.sidesMerged))
+
+	// Team B never gets a lay side: it is optimized alone after the window
+	consumer.flushSides(context.Background(), time.Now())
+	require.Len(t, cached, 1)
+	consumer.flushSides(context.Background(), time.Now().Add(time.Minute))
+	require.Len(t, cached, 2)
+	require.Len(t, cached[1], 1)
+	assert.Equal(t, "Team B", cached[1][0].Selection)
+	assert.True(t, cached[1][0].OriginalLay.IsZero())
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumer.metrics.sidesUnmatched))
+
+	// A lay side arriving after the window starts a new wait
+	require.NoError(t, consumer.processMessage(context.Background(), message(3, side("Team B", 0, 1.85))))
+	assert.Len(t, cached, 2)
+}
+
+// TestKafkaConsumer_SideMergeDrain tests that sides still held when the
+// consumer stops are optimized rather than dropped, and that their message
+// is committed only then
+func TestKafkaConsumer_SideMergeDrain(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	msg := newTestMessage(t, 1) // Back-only
+	reader := &fakeReader{messages: []kafka.Message{msg}}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{SideMergeWindow: time.Hour}, reader)
+
+	optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Len(1)).Return(optimized, nil)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Start(ctx) }()
+
+	require.Eventually(t, func() bool { return consumer.Stats().LastOffset == 1 }, time.Second, 5*time.Millisecond)
+	assert.Never(t, func() bool { return reader.committedCount() > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, reader.committedCount())
+}
+
+// TestKafkaConsumer_SideMergeCommit tests that a message whose one-sided
+// update is held is committed only once the merged update is cached, and
+// not at all if caching it fails
+func TestKafkaConsumer_SideMergeCommit(t *testing.T) {
+	tests := []struct {
+		name          string
+		cacheErr      error
+		wantCommitted []int64
+	}{
+		{name: "merged update cached", wantCommitted: []int64{1, 2}},
+		{name: "merged update not cached", cacheErr: errors.New("redis unavailable")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTestKafkaConsumer(t)
+			defer setup.cleanup()
+
+			lay := models.NormalizedOdds{EventID: "event-123", Market: "match_winner", Selection: "Team A", LayPrice: decimal.NewFromFloat(2.60)}
+			layBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{OddsData: []models.NormalizedOdds{lay}, BatchID: "batch-2"})
+			require.NoError(t, err)
+			fetching, blocked := make(chan struct{}), make(chan struct{})
+			reader := &fakeReader{
+				messages: []kafka.Message{newTestMessage(t, 1), {Value: layBytes, Offset: 2}}, // Back-only, then its lay side
+				onFetch: func(n int) {
+					if n == 1 {
+						close(fetching)
+						<-blocked // Hold the lay side back until the back side's commit is checked
+					}
+				},
+			}
+			consumer := newConsumerWithReader(setup, KafkaConsumerConfig{SideMergeWindow: time.Hour}, reader)
+
+			optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+			setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Len(1)).Return(optimized, nil)
+			setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(tt.cacheErr)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- consumer.Start(ctx) }()
+
+			// The back side is held, so its message is processed but not committed
+			<-fetching
+			assert.Zero(t, reader.committedCount())
+			close(blocked)
+
+			require.Eventually(t, func() bool {
+				return consumer.Stats().MessagesProcessed+consumer.Stats().MessagesFailed == 1
+			}, time.Second, 5*time.Millisecond)
+			cancel()
+			require.NoError(t, <-done)
+
+			reader.mu.Lock()
+			defer reader.mu.Unlock()
+			var committed []int64
+			for _, msg := range reader.committed {
+				committed = append(committed, msg.Offset)
+			}
+			assert.Equal(t, tt.wantCommitted, committed)
+		})
+	}
+}
+
+// TestDecodeMessage tests decoding envelopes in batch format and keyed
+// selections in single format
+func TestDecodeMessage(t *testing.T) {
+	selection := models.NormalizedOdds{EventID: "event-123", Market: "match_winner", Selection: "Team A", BackPrice: decimal.NewFromFloat(2.50)}
+	single, err := json.Marshal(selection)
+	require.NoError(t, err)
+	envelope, err := json.Marshal(models.KafkaNormalizedOddsMessage{
+		OddsData: []models.NormalizedOdds{selection, selection},
+		BatchID:  "batch-1",
+		Currency: "GBP",
+	})
+	require.NoError(t, err)
+	key := []byte("event-123:match_winner:Team A")
+	sent := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		format    string
+		msg       kafka.Message
+		wantErr   bool
+		wantCount int
+		wantBatch string
+	}{
+		{name: "Batch by default", msg: kafka.Message{Key: key, Value: envelope}, wantCount: 2, wantBatch: "batch-1"},
+		{name: "Batch", format: MessageFormatBatch, msg: kafka.Message{Value: envelope}, wantCount: 2, wantBatch: "batch-1"},
+		{name: "Single", format: MessageFormatSingle, msg: kafka.Message{Key: key, Value: single, Time: sent}, wantCount: 1, wantBatch: string(key)},
+		{name: "Single tombstone", format: MessageFormatSingle, msg: kafka.Message{Key: key, Time: sent}, wantCount: 0, wantBatch: string(key)},
+		{name: "Single malformed", format: MessageFormatSingle, msg: kafka.Message{Key: key, Value: []byte("{")}, wantErr: true},
+		{name: "Batch malformed", format: MessageFormatBatch, msg: kafka.Message{Value: []byte("[]")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := &KafkaConsumer{messageFormat: tt.format}
+
+			batch, err := consumer.decodeMessage(tt.msg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, batch.OddsData, tt.wantCount)
+			assert.Equal(t, tt.wantBatch, batch.BatchID)
+			if tt.format == MessageFormatSingle {
+				assert.Equal(t, sent, batch.Timestamp)
+			}
+			for _, odds := range batch.OddsData {
+				assert.Equal(t, "Team A", odds.Selection)
+				assert.True(t, odds.BackPrice.Equal(decimal.NewFromFloat(2.50)))
+			}
+		})
+	}
+}
+
+// TestKafkaConsumer_SingleMessageFormat tests that keyed single-selection
+// messages run through the same optimize, cache and commit path as batches,
+// with tombstones committed untouched
+func TestKafkaConsumer_SingleMessageFormat(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	singleMessage := func(t *testing.T, offset int64, selection string, price float64) kafka.Message {
+		t.Helper()
+		msgBytes, err := json.Marshal(models.NormalizedOdds{
+			EventID:   "event-123",
+			Market:    "match_winner",
+			Selection: selection,
+			BackPrice: decimal.NewFromFloat(price),
+		})
+		require.NoError(t, err)
+		return kafka.Message{Key: []byte("event-123:match_winner:" + selection), Value: msgBytes, Offset: offset}
+	}
+
+	reader := &fakeReader{messages: []kafka.Message{
+		singleMessage(t, 1, "Team A", 2.50),
+		singleMessage(t, 2, "Team B", 1.80),
+		{Key: []byte("event-123:match_winner:Draw"), Offset: 3}, // Tombstone
+	}}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{
+		MessageFormat: MessageFormatSingle,
+		Registerer:    prometheus.NewRegistry(),
+	}, reader)
+
+	var mu sync.Mutex
+	var optimized []string
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Len(1)).DoAndReturn(
+		func(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			optimized = append(optimized, normalized[0].Selection)
+			return []*models.OptimizedOdds{{EventID: "event-123", Selection: normalized[0].Selection}}, nil
+		}).Times(2)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Len(1)).Return(nil).Times(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- consumer.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return reader.committedCount() == 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"Team A", "Team B"}, optimized)
+	assert.Equal(t, uint64(2), consumer.Stats().MessagesProcessed)
+	assert.Equal(t, float64(1), testutil.ToFloat64(consumer.metrics.emptyBatches))
+}
+
+// TestKafkaConsumer_SeekWithSideMerge tests that a seek does not wait on the
+// side flusher, which runs for as long as the consumer does
+func TestKafkaConsumer_SeekWithSideMerge(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	reader := &fakeReader{noGroup: true}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{SideMergeWindow: time.Second}, reader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Start(ctx) }()
+
+	seekCtx, seekCancel := context.WithTimeout(context.Background(), time.Second)
+	defer seekCancel()
+	var err error
+	require.Eventually(t, func() bool {
+		err = consumer.Seek(seekCtx, SeekRequest{Offset: 42})
+		return !errors.Is(err, ErrConsumerNotRunning)
+	}, time.Second, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"offset:42"}, reader.seekLog())
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+// TestKafkaConsumer_SeekGroup tests that a consumer group reader is sought
+// by committing the target offset through the group and reopening the
+// reader, by offset and by timestamp, and that auto-committing group
+// readers are rejected before anything is drained
+func TestKafkaConsumer_SeekGroup(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	first := &fakeReader{}
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, first)
+	second := &fakeReader{messages: []kafka.Message{newTestMessage(t, 42)}}
+	third := &fakeReader{}
+	reopened := []*fakeReader{second, third}
+	consumer.reopen = func() messageReader {
+		next := reopened[0]
+		reopened = reopened[1:]
+		return next
+	}
+	at := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	consumer.lookup = func(ctx context.Context, topic string, partition int, t time.Time) (int64, error) {
+		if !t.Equal(at) {
+			return 0, errors.New("unexpected timestamp")
+		}
+		return 17, nil
+	}
+
+	setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return([]*models.OptimizedOdds{{EventID: "event-123"}}, nil)
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Start(ctx) }()
+
+	// By offset: the offset before the target is committed, so the group resumes at 42
+	var err error
+	require.Eventually(t, func() bool {
+		err = consumer.Seek(context.Background(), SeekRequest{Partition: 3, Offset: 42})
+		return !errors.Is(err, ErrConsumerNotRunning)
+	}, time.Second, time.Millisecond)
+	require.NoError(t, err)
+
+	first.mu.Lock()
+	require.Len(t, first.committed, 1)
+	assert.Equal(t, 3, first.committed[0].Partition)
+	assert.Equal(t, int64(41), first.committed[0].Offset)
+	assert.Equal(t, "normalized_odds", first.committed[0].Topic)
+	assert.True(t, first.closed)
+	first.mu.Unlock()
+	assert.Empty(t, first.seekLog())
+
+	// The reopened reader is consumed from
+	require.Eventually(t, func() bool { return second.committedCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	// By timestamp: resolved to an offset first
+	require.NoError(t, consumer.Seek(context.Background(), SeekRequest{Partition: 3, At: at}))
+	second.mu.Lock()
+	require.Len(t, second.committed, 2)
+	assert.Equal(t, int64(16), second.committed[1].Offset)
+	assert.True(t, second.closed)
+	second.mu.Unlock()
+
+	cancel()
+	require.NoError(t, <-done)
+
+	autoCommit := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{commitInterval: time.Second})
+	assert.ErrorIs(t, autoCommit.Seek(context.Background(), SeekRequest{Offset: 1}), ErrInvalidSeek)
+	assert.ErrorIs(t, consumer.Seek(context.Background(), SeekRequest{Partition: -1, Offset: 1}), ErrInvalidSeek)
+}
+
+// TestKafkaConsumer_CommitAfterPublishKafkaSink tests the gate against the
+// real Kafka producer: a synchronous producer writes the odds before the
+// offset is committed, and a failing writer holds the commit back
+func TestKafkaConsumer_CommitAfterPublishKafkaSink(t *testing.T) {
+	tests := []struct {
+		name          string
+		writeErr      error
+		wantCommitted int
+		wantWritten   int
+	}{
+		{name: "Writer fails", writeErr: errors.New("broker unavailable"), wantCommitted: 0, wantWritten: 0},
+		{name: "Writer succeeds", wantCommitted: 1, wantWritten: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup := setupTestKafkaConsumer(t)
+			defer setup.cleanup()
+
+			reader := &fakeReader{messages: []kafka.Message{newTestMessage(t, 3)}}
+			consumer := newConsumerWithReader(setup, KafkaConsumerConfig{CommitAfterPublish: true}, reader)
+			writer := &fakeWriter{err: tt.writeErr}
+			// A flush interval far beyond the test: nothing may rely on it
+			consumer.SetSinks(newKafkaProducer(writer, KafkaProducerConfig{FlushInterval: time.Hour, Synchronous: true}, zerolog.Nop()))
+
+			optimized := []*models.OptimizedOdds{{EventID: "event-123"}}
+			setup.mockOptimizer.EXPECT().BatchOptimizeMarket(gomock.Any()).Return(optimized, nil)
+			setup.mockCache.EXPECT().SetBatch(gomock.Any(), optimized).Return(nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- consumer.Start(ctx)
+			}()
+
+			require.Eventually(t, func() bool {
+				return consumer.Stats().MessagesProcessed+consumer.Stats().MessagesFailed == 1
+			}, time.Second, 5*time.Millisecond)
+			cancel()
+			<-done
+
+			assert.Equal(t, tt.wantCommitted, reader.committedCount())
+			assert.Len(t, writer.decoded(t), tt.wantWritten)
+		})
+	}
+}
+
+// TestProcessMessage_MarketFairPrice tests that consumed books are optimized
+// market by market: the cached fair prices carry no overround
+func TestProcessMessage_MarketFairPrice(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = newProfileOptimizer(0.02, 0.10)
+
+	var cached []*models.OptimizedOdds
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			cached = append(cached, odds...)
+			return nil
+		})
+
+	// A 3-way book quoted with about 4.8% overround
+	var book []models.NormalizedOdds
+	for selection, price := range map[string]float64{"Home": 2.10, "Draw": 3.40, "Away": 3.60} {
+		book = append(book, models.NormalizedOdds{
+			EventID:   "event-123",
+			Market:    "match_winner",
+			Selection: selection,
+			Sport:     "tennis",
+			BackPrice: decimal.NewFromFloat(price),
+			LayPrice:  decimal.NewFromFloat(price + 0.10),
+			Timestamp: time.Now(),
+		})
+	}
+	msgBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{OddsData: book, BatchID: "batch-book"})
+	require.NoError(t, err)
+	require.NoError(t, consumer.processMessage(context.Background(), kafka.Message{Value: msgBytes, Offset: 1}))
+
+	require.Len(t, cached, 3)
+	fairBook := decimal.Zero
+	for _, odds := range cached {
+		assert.True(t, odds.FairPrice.GreaterThan(odds.OriginalBack), "%s fair %s, quoted %s", odds.Selection, odds.FairPrice, odds.OriginalBack)
+		fairBook = fairBook.Add(decimal.NewFromInt(1).Div(odds.FairPrice))
+	}
+	assert.InDelta(t, 1.0, fairBook.InexactFloat64(), 0.001)
+}
+
+// bookOdds creates a football match_winner selection of event-123 at a back price
+func bookOdds(selection string, back float64) models.NormalizedOdds {
+	return models.NormalizedOdds{
+		EventID:   "event-123",
+		Market:    "match_winner",
+		Selection: selection,
+		Sport:     "football",
+		BackPrice: decimal.NewFromFloat(back),
+		BackSize:  decimal.NewFromFloat(10000),
+		LaySize:   decimal.NewFromFloat(8000),
+		Timestamp: time.Now(),
+	}
+}
+
+// processBook runs one message carrying odds through the consumer, returning
+// what it cached
+func processBook(t *testing.T, setup *testKafkaConsumerSetup, consumer *KafkaConsumer, odds ...models.NormalizedOdds) []*models.OptimizedOdds {
+	t.Helper()
+	var cached []*models.OptimizedOdds
+	setup.mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			cached = append(cached, odds...)
+			return nil
+		}).AnyTimes()
+
+	msgBytes, err := json.Marshal(models.KafkaNormalizedOddsMessage{OddsData: odds, BatchID: "batch-book"})
+	require.NoError(t, err)
+	require.NoError(t, consumer.processMessage(context.Background(), kafka.Message{Value: msgBytes, Offset: 1}))
+	return cached
+}
+
+// TestProcessMessage_NegativeMarginBook tests that a consumed book whose
+// realized overround ends up negative is counted and, when configured,
+// rejected before it reaches the cache
+func TestProcessMessage_NegativeMarginBook(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	// Without a minimum spread both prices centre on the midpoint, above the
+	// fair price, giving the margin away
+	opt := optimizer.NewOptimizer(models.OptimizationParams{
+		MinMargin:            decimal.NewFromFloat(0.02),
+		MaxMargin:            decimal.NewFromFloat(0.10),
+		TargetConfidence:     0.85,
+		RejectNegativeMargin: true,
+	}, zerolog.Nop())
+	reg := prometheus.NewRegistry()
+	opt.RegisterMetrics(reg)
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = opt
+
+	cached := processBook(t, setup, consumer, bookOdds("Team A", 1.50), bookOdds("Draw", 3.00), bookOdds("Team B", 3.00))
+
+	assert.Empty(t, cached)
+	assert.Equal(t, uint64(1), opt.Stats().NegativeMargin)
+	assert.Equal(t, uint64(3), opt.Stats().Rejected)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP optimizer_negative_margin_total Market books whose realized overround fell below zero after optimization.
+# TYPE optimizer_negative_margin_total counter
+optimizer_negative_margin_total 1
+`), "optimizer_negative_margin_total"))
+}
+
+// newBookOptimizer creates a real optimizer with 2-10% margins and a 5%
+// minimum spread, adjusted by configure
+func newBookOptimizer(configure func(params *models.OptimizationParams)) *optimizer.Optimizer {
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	configure(&params)
+	return optimizer.NewOptimizer(params, zerolog.Nop())
+}
+
+// TestProcessMessage_MaxTotalOverround tests that consumed books have their
+// margins scaled down to the configured total overround cap
+func TestProcessMessage_MaxTotalOverround(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = newBookOptimizer(func(params *models.OptimizationParams) {
+		params.MaxTotalOverround = decimal.NewFromFloat(0.03)
+	})
+
+	cached := processBook(t, setup, consumer, bookOdds("Team A", 2.10), bookOdds("Draw", 3.40), bookOdds("Team B", 3.60))
+
+	require.Len(t, cached, 3)
+	total := decimal.NewFromInt(-1)
+	for _, odds := range cached {
+		assert.True(t, odds.Margin.LessThan(decimal.NewFromFloat(0.02)), "%s margin %s not scaled", odds.Selection, odds.Margin)
+		total = total.Add(decimal.NewFromInt(1).Div(odds.FairPrice)).Add(odds.Margin)
+	}
+	assert.InDelta(t, 0.03, total.InexactFloat64(), 0.001)
+}
+
+// TestProcessMessage_NormalizationMethod tests that the configured method
+// removes the overround of consumed books: power and log-odds normalization
+// load more of it onto the longshot than proportional normalization
+func TestProcessMessage_NormalizationMethod(t *testing.T) {
+	fairPrices := func(method string) map[string]decimal.Decimal {
+		setup := setupTestKafkaConsumer(t)
+		defer setup.cleanup()
+
+		consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+		consumer.optimizer = newBookOptimizer(func(params *models.OptimizationParams) {
+			params.NormalizationMethod = method
+		})
+
+		cached := processBook(t, setup, consumer, bookOdds("Favourite", 1.50), bookOdds("Outsider", 4.00), bookOdds("Longshot", 8.00))
+		require.Len(t, cached, 3)
+		prices := make(map[string]decimal.Decimal, len(cached))
+		book := decimal.Zero
+		for _, odds := range cached {
+			prices[odds.Selection] = odds.FairPrice
+			book = book.Add(decimal.NewFromInt(1).Div(odds.FairPrice))
+		}
+		assert.InDelta(t, 1.0, book.InexactFloat64(), 0.001, method)
+		return prices
+	}
+
+	proportional := fairPrices(optimizer.NormalizationProportional)
+	for _, method := range []string{optimizer.NormalizationPower, optimizer.NormalizationLogOdds} {
+		prices := fairPrices(method)
+		assert.True(t, prices["Longshot"].GreaterThan(proportional["Longshot"]),
+			"%s longshot %s, proportional %s", method, prices["Longshot"], proportional["Longshot"])
+		assert.True(t, prices["Favourite"].LessThan(proportional["Favourite"]),
+			"%s favourite %s, proportional %s", method, prices["Favourite"], proportional["Favourite"])
+	}
+}
+
+// TestProcessMessage_DrawMultiplier tests that the draw of a consumed 3-way
+// book is priced at its own margin while the two outcomes keep theirs
+func TestProcessMessage_DrawMultiplier(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = newBookOptimizer(func(params *models.OptimizationParams) {
+		params.DrawMultiplier = decimal.NewFromFloat(0.5)
+	})
+
+	cached := processBook(t, setup, consumer, bookOdds("Home", 2.10), bookOdds("Draw", 3.40), bookOdds("Away", 3.60))
+
+	require.Len(t, cached, 3)
+	margins := make(map[string]decimal.Decimal, len(cached))
+	for _, odds := range cached {
+		margins[odds.Selection] = odds.Margin
+	}
+	assert.True(t, margins["Home"].Equal(margins["Away"]), "home %s, away %s", margins["Home"], margins["Away"])
+	assert.True(t, margins["Draw"].Equal(margins["Home"].Div(decimal.NewFromInt(2))), "draw %s, home %s", margins["Draw"], margins["Home"])
+}
+
+// TestProcessMessage_MinLiquidity tests that consumed selections below the
+// minimum liquidity are not cached, and the rest of the book normalizes
+// without them
+func TestProcessMessage_MinLiquidity(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = newBookOptimizer(func(params *models.OptimizationParams) {
+		params.MinLiquidity = decimal.NewFromInt(1000)
+	})
+
+	illiquid := bookOdds("Draw", 3.40)
+	illiquid.BackSize = decimal.NewFromInt(200)
+	illiquid.LaySize = decimal.NewFromInt(100)
+	cached := processBook(t, setup, consumer, bookOdds("Home", 1.80), illiquid, bookOdds("Away", 2.40))
+
+	require.Len(t, cached, 2)
+	book := decimal.Zero
+	for _, odds := range cached {
+		assert.NotEqual(t, "Draw", odds.Selection)
+		book = book.Add(decimal.NewFromInt(1).Div(odds.FairPrice))
+	}
+	assert.InDelta(t, 1.0, book.InexactFloat64(), 0.001)
+}
+
+// TestProcessMessage_LadderInversion tests that consumed totals ladders whose
+// prices invert across lines are counted and, with the reject policy, the
+// inverted rungs are not cached
+func TestProcessMessage_LadderInversion(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	opt := newBookOptimizer(func(params *models.OptimizationParams) {
+		params.LadderPolicy = optimizer.LadderReject
+	})
+	reg := prometheus.NewRegistry()
+	opt.RegisterMetrics(reg)
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = opt
+
+	rung := func(selection string, line, back float64) models.NormalizedOdds {
+		odds := bookOdds(selection, back)
+		odds.Market = "total_goals"
+		odds.Line = decimal.NewFromFloat(line)
+		return odds
+	}
+	// Over 3.5 is priced shorter than over 2.5, and under 2.5 shorter than under 3.5
+	cached := processBook(t, setup, consumer,
+		rung("Over", 2.5, 2.00), rung("Under", 2.5, 1.90),
+		rung("Over", 3.5, 1.60), rung("Under", 3.5, 2.40),
+	)
+
+	kept := make([]string, 0, len(cached))
+	for _, odds := range cached {
+		kept = append(kept, odds.Selection+" "+odds.Line.String())
+	}
+	assert.ElementsMatch(t, []string{"Over 2.5", "Under 3.5"}, kept)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP optimizer_ladder_inversions_total Totals ladder rungs priced shorter than a less likely rung, smoothed or rejected.
+# TYPE optimizer_ladder_inversions_total counter
+optimizer_ladder_inversions_total 2
+`), "optimizer_ladder_inversions_total"))
+}
+
+// TestProcessMessage_StabilityPrices tests that a consumed batch's recent
+// prices are read in one history call before optimizing, and that a volatile
+// selection is less confident than a stable one
+func TestProcessMessage_StabilityPrices(t *testing.T) {
+	setup := setupTestKafkaConsumer(t)
+	defer setup.cleanup()
+
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.5, // Keep confidence clear of the clamp
+		StabilityWeight:  0.5,
+		StabilityWindow:  4,
+	}
+	history := mocks.NewMockHistory(setup.ctrl)
+	snapshots := func(prices ...float64) []*models.OptimizedOdds {
+		odds := make([]*models.OptimizedOdds, len(prices))
+		for i, price := range prices {
+			odds[i] = &models.OptimizedOdds{OptimizedBack: decimal.NewFromFloat(price)}
+		}
+		return odds
+	}
+	history.EXPECT().RecentBatch(gomock.Any(), []models.SelectionKey{
+		{EventID: "event-123", Market: "match_winner", Selection: "Team A"},
+		{EventID: "event-123", Market: "match_winner", Selection: "Team B"},
+	}, 4).Return([][]*models.OptimizedOdds{
+		snapshots(2.00, 3.00, 2.20, 2.90),
+		snapshots(2.50, 2.51, 2.49, 2.50),
+	}, nil).Times(1)
+
+	consumer := newConsumerWithReader(setup, KafkaConsumerConfig{}, &fakeReader{})
+	consumer.optimizer = optimizer.NewOptimizer(params, zerolog.Nop())
+	consumer.SetPriceHistory(service.NewHistoryPrices(history, params, time.Second, zerolog.Nop()))
+
+	cached := processBook(t, setup, consumer, bookOdds("Team A", 2.00), bookOdds("Team B", 2.00))
+
+	require.Len(t, cached, 2)
+	confidence := map[string]float64{}
+	for _, odds := range cached {
+		confidence[odds.Selection] = odds.Confidence
+	}
+	assert.Less(t, confidence["Team A"], confidence["Team B"])
+}
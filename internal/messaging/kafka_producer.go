@@ -0,0 +1,239 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// messageWriter is the subset of *kafka.Writer used by the producer
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaProducer publishes optimized odds downstream, batching selections
+// into KafkaOptimizedOddsMessage envelopes to keep message counts low. Each
+// envelope holds one event's odds and is keyed by its event ID, so every
+// update of an event lands on one partition, in order.
+type KafkaProducer struct {
+	writer        messageWriter
+	maxBatchSize  int
+	maxPending    int
+	flushInterval time.Duration
+	synchronous   bool
+	dropped       prometheus.Counter
+	logger        zerolog.Logger
+
+	mu      sync.Mutex
+	pending []models.OptimizedOdds
+}
+
+// KafkaProducerConfig holds Kafka producer configuration
+type KafkaProducerConfig struct {
+	Brokers       []string      // e.g., ["localhost:9092"]
+	Topic         string        // e.g., "optimized_odds"
+	MaxBatchSize  int           // Max selections per output message (default 500)
+	FlushInterval time.Duration // Partial batches are flushed at this interval (default 1s)
+	MaxPending    int           // Max selections buffered while writes fail; the oldest are dropped past it (default 100 batches)
+
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
+
+	// Synchronous makes Publish write every selection before returning,
+	// partial batch included, instead of buffering it for the next flush.
+	// Set it when publishing gates offset commits, so odds are never
+	// acknowledged while they are only held in memory.
+	Synchronous bool
+}
+
+// NewKafkaProducer creates a new Kafka producer
+func NewKafkaProducer(config KafkaProducerConfig, logger zerolog.Logger) *KafkaProducer {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(config.Brokers...),
+		Topic:        config.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+
+	return newKafkaProducer(writer, config, logger)
+}
+
+// newKafkaProducer creates a producer on top of the given writer
+func newKafkaProducer(writer messageWriter, config KafkaProducerConfig, logger zerolog.Logger) *KafkaProducer {
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = 500
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = 100 * config.MaxBatchSize
+	}
+
+	p := &KafkaProducer{
+		writer:        writer,
+		maxBatchSize:  config.MaxBatchSize,
+		maxPending:    max(config.MaxPending, config.MaxBatchSize),
+		flushInterval: config.FlushInterval,
+		synchronous:   config.Synchronous,
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_producer_dropped_total",
+			Help: "Pending optimized odds dropped unpublished because output writes kept failing past the pending cap.",
+		}),
+		logger: logger.With().Str("component", "kafka_producer").Logger(),
+	}
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(p.dropped)
+	}
+
+	return p
+}
+
+// Publish queues optimized odds for output; full batches are written
+// immediately and any remainder waits for the next flush. While writes
+// fail, pending odds past the pending cap are dropped, oldest first. A
+// synchronous producer writes the remainder too, and on failure drops the
+// odds of the call, which the caller retries.
+func (p *KafkaProducer) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	if len(odds) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.synchronous {
+		batch := make([]models.OptimizedOdds, 0, min(len(odds), p.maxBatchSize))
+		for i, o := range odds {
+			batch = append(batch, *o)
+			if len(batch) == p.maxBatchSize || i == len(odds)-1 {
+				if err := p.writeBatch(ctx, batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		return nil
+	}
+
+	for _, o := range odds {
+		p.pending = append(p.pending, *o)
+	}
+	if excess := len(p.pending) - p.maxPending; excess > 0 {
+		p.pending = p.pending[excess:]
+		p.dropped.Add(float64(excess))
+		p.logger.Warn().
+			Int("dropped", excess).
+			Int("max_pending", p.maxPending).
+			Msg("output writes failing, dropped oldest pending odds")
+	}
+
+	for len(p.pending) >= p.maxBatchSize {
+		if err := p.writeBatch(ctx, p.pending[:p.maxBatchSize]); err != nil {
+			return err
+		}
+		p.pending = p.pending[p.maxBatchSize:]
+	}
+
+	return nil
+}
+
+// Flush writes every pending odds, at most a batch per write; odds left
+// unwritten by a failure stay pending for the next flush
+func (p *KafkaProducer) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.pending) > 0 {
+		n := min(len(p.pending), p.maxBatchSize)
+		if err := p.writeBatch(ctx, p.pending[:n]); err != nil {
+			return err
+		}
+		p.pending = p.pending[n:]
+	}
+	p.pending = nil
+
+	return nil
+}
+
+// Start flushes partial batches every flush interval until ctx is done,
+// then performs a final flush
+func (p *KafkaProducer) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := p.Flush(flushCtx); err != nil {
+				p.logger.Error().Err(err).Msg("failed to flush pending odds on shutdown")
+			}
+			cancel()
+			return
+
+		case <-ticker.C:
+			if err := p.Flush(ctx); err != nil {
+				p.logger.Error().Err(err).Msg("failed to flush pending odds")
+			}
+		}
+	}
+}
+
+// writeBatch writes a batch of odds as one output message per event, in
+// the order events first appear; the caller must hold p.mu
+func (p *KafkaProducer) writeBatch(ctx context.Context, odds []models.OptimizedOdds) error {
+	var eventIDs []string
+	byEvent := make(map[string][]models.OptimizedOdds)
+	for _, o := range odds {
+		if _, ok := byEvent[o.EventID]; !ok {
+			eventIDs = append(eventIDs, o.EventID)
+		}
+		byEvent[o.EventID] = append(byEvent[o.EventID], o)
+	}
+
+	now := time.Now().UTC()
+	msgs := make([]kafka.Message, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		data, err := json.Marshal(models.KafkaOptimizedOddsMessage{
+			OddsData:  byEvent[eventID],
+			Timestamp: now,
+			BatchID:   uuid.New().String(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal optimized odds message: %w", err)
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(eventID),
+			Value: data,
+		})
+	}
+
+	if err := p.writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("failed to write optimized odds message: %w", err)
+	}
+
+	p.logger.Debug().
+		Int("odds_count", len(odds)).
+		Int("message_count", len(msgs)).
+		Msg("published optimized odds batch")
+
+	return nil
+}
+
+// Close flushes pending odds and closes the Kafka writer
+func (p *KafkaProducer) Close() error {
+	if err := p.Flush(context.Background()); err != nil {
+		p.logger.Error().Err(err).Msg("failed to flush pending odds on close")
+	}
+	return p.writer.Close()
+}
@@ -0,0 +1,258 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// fakeWriter records written messages
+type fakeWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	err      error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+// decoded returns the written envelopes
+func (w *fakeWriter) decoded(t *testing.T) []models.KafkaOptimizedOddsMessage {
+	t.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out := make([]models.KafkaOptimizedOddsMessage, len(w.messages))
+	for i, msg := range w.messages {
+		require.NoError(t, json.Unmarshal(msg.Value, &out[i]))
+		for _, odds := range out[i].OddsData {
+			assert.Equal(t, odds.EventID, string(msg.Key))
+		}
+	}
+	return out
+}
+
+// newOptimizedBatch creates n optimized selections for one event
+func newOptimizedBatch(n int) []*models.OptimizedOdds {
+	odds := make([]*models.OptimizedOdds, n)
+	for i := range odds {
+		odds[i] = &models.OptimizedOdds{
+			EventID:   "event-123",
+			Market:    "match_winner",
+			Selection: fmt.Sprintf("Selection %d", i),
+		}
+	}
+	return odds
+}
+
+// TestKafkaProducer_BatchSplitting tests that N selections produce ceil(N/max) messages
+func TestKafkaProducer_BatchSplitting(t *testing.T) {
+	tests := []struct {
+		name         string
+		count        int
+		maxBatch     int
+		expectedMsgs int
+	}{
+		{name: "Single message", count: 5, maxBatch: 10, expectedMsgs: 1},
+		{name: "Exact multiple", count: 6, maxBatch: 3, expectedMsgs: 2},
+		{name: "Partial last batch", count: 7, maxBatch: 3, expectedMsgs: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &fakeWriter{}
+			producer := newKafkaProducer(writer, KafkaProducerConfig{MaxBatchSize: tt.maxBatch}, zerolog.Nop())
+
+			require.NoError(t, producer.Publish(context.Background(), newOptimizedBatch(tt.count)))
+			require.NoError(t, producer.Flush(context.Background()))
+
+			msgs := writer.decoded(t)
+			require.Len(t, msgs, tt.expectedMsgs)
+
+			total := 0
+			for _, msg := range msgs {
+				assert.LessOrEqual(t, len(msg.OddsData), tt.maxBatch)
+				assert.NotEmpty(t, msg.BatchID)
+				total += len(msg.OddsData)
+			}
+			assert.Equal(t, tt.count, total)
+		})
+	}
+}
+
+// TestKafkaProducer_PartialBatchWaitsForFlush tests that partial batches are held until flushed
+func TestKafkaProducer_PartialBatchWaitsForFlush(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newKafkaProducer(writer, KafkaProducerConfig{MaxBatchSize: 3}, zerolog.Nop())
+
+	require.NoError(t, producer.Publish(context.Background(), newOptimizedBatch(2)))
+	assert.Empty(t, writer.decoded(t))
+
+	// Crossing the max writes a full batch and keeps the remainder pending
+	require.NoError(t, producer.Publish(context.Background(), newOptimizedBatch(2)))
+	msgs := writer.decoded(t)
+	require.Len(t, msgs, 1)
+	assert.Len(t, msgs[0].OddsData, 3)
+
+	require.NoError(t, producer.Flush(context.Background()))
+	msgs = writer.decoded(t)
+	require.Len(t, msgs, 2)
+	assert.Len(t, msgs[1].OddsData, 1)
+}
+
+// TestKafkaProducer_TimerFlush tests that Start flushes partial batches on its interval
+func TestKafkaProducer_TimerFlush(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newKafkaProducer(writer, KafkaProducerConfig{
+		MaxBatchSize:  100,
+		FlushInterval: 10 * time.Millisecond,
+	}, zerolog.Nop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		producer.Start(ctx)
+		close(done)
+	}()
+
+	require.NoError(t, producer.Publish(ctx, newOptimizedBatch(4)))
+
+	require.Eventually(t, func() bool {
+		return len(writer.decoded(t)) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Len(t, writer.decoded(t)[0].OddsData, 4)
+}
+
+// TestKafkaProducer_WriteFailureKeepsPending tests that failed writes are retried on the next flush
+func TestKafkaProducer_WriteFailureKeepsPending(t *testing.T) {
+	writer := &fakeWriter{err: errors.New("broker unavailable")}
+	producer := newKafkaProducer(writer, KafkaProducerConfig{MaxBatchSize: 10}, zerolog.Nop())
+
+	require.NoError(t, producer.Publish(context.Background(), newOptimizedBatch(3)))
+	assert.Error(t, producer.Flush(context.Background()))
+
+	writer.err = nil
+	require.NoError(t, producer.Flush(context.Background()))
+
+	msgs := writer.decoded(t)
+	require.Len(t, msgs, 1)
+	assert.Len(t, msgs[0].OddsData, 3)
+}
+
+// TestKafkaProducer_FlushSplitsBatches tests that a flush after failed
+// writes writes the accumulated odds in batches of at most the max
+func TestKafkaProducer_FlushSplitsBatches(t *testing.T) {
+	writer := &fakeWriter{err: errors.New("broker unavailable")}
+	producer := newKafkaProducer(writer, KafkaProducerConfig{MaxBatchSize: 3}, zerolog.Nop())
+
+	assert.Error(t, producer.Publish(context.Background(), newOptimizedBatch(7)))
+	assert.Error(t, producer.Publish(context.Background(), newOptimizedBatch(2)))
+
+	writer.err = nil
+	require.NoError(t, producer.Flush(context.Background()))
+
+	msgs := writer.decoded(t)
+	require.Len(t, msgs, 3)
+	for _, msg := range msgs {
+		assert.Len(t, msg.OddsData, 3)
+	}
+}
+
+// TestKafkaProducer_MaxPending tests that odds past the pending cap are
+// dropped, oldest first, and counted while writes fail
+func TestKafkaProducer_MaxPending(t *testing.T) {
+	writer := &fakeWriter{err: errors.New("broker unavailable")}
+	reg := prometheus.NewRegistry()
+	producer := newKafkaProducer(writer, KafkaProducerConfig{MaxBatchSize: 2, MaxPending: 4, Registerer: reg}, zerolog.Nop())
+
+	for i := 0; i < 3; i++ {
+		batch := newOptimizedBatch(2)
+		for _, odds := range batch {
+			odds.Selection = fmt.Sprintf("%s (update %d)", odds.Selection, i)
+		}
+		assert.Error(t, producer.Publish(context.Background(), batch))
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(producer.dropped))
+
+	writer.err = nil
+	require.NoError(t, producer.Flush(context.Background()))
+
+	var selections []string
+	for _, msg := range writer.decoded(t) {
+		for _, odds := range msg.OddsData {
+			selections = append(selections, odds.Selection)
+		}
+	}
+	assert.Equal(t, []string{
+		"Selection 0 (update 1)", "Selection 1 (update 1)",
+		"Selection 0 (update 2)", "Selection 1 (update 2)",
+	}, selections)
+}
+
+// TestKafkaProducer_KeyedByEvent tests that a batch spanning events is
+// written as one message per event, keyed by its event ID
+func TestKafkaProducer_KeyedByEvent(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newKafkaProducer(writer, KafkaProducerConfig{MaxBatchSize: 10}, zerolog.Nop())
+
+	odds := newOptimizedBatch(5)
+	odds[1].EventID = "event-456"
+	odds[3].EventID = "event-456"
+	require.NoError(t, producer.Publish(context.Background(), odds))
+	require.NoError(t, producer.Flush(context.Background()))
+
+	msgs := writer.decoded(t)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "event-123", string(writer.messages[0].Key))
+	assert.Len(t, msgs[0].OddsData, 3)
+	assert.Equal(t, "event-456", string(writer.messages[1].Key))
+	assert.Len(t, msgs[1].OddsData, 2)
+	assert.NotEqual(t, msgs[0].BatchID, msgs[1].BatchID)
+}
+
+// TestKafkaProducer_Synchronous tests that a synchronous producer writes
+// partial batches before Publish returns and buffers nothing on failure
+func TestKafkaProducer_Synchronous(t *testing.T) {
+	writer := &fakeWriter{}
+	producer := newKafkaProducer(writer, KafkaProducerConfig{MaxBatchSize: 3, FlushInterval: time.Hour, Synchronous: true}, zerolog.Nop())
+
+	require.NoError(t, producer.Publish(context.Background(), newOptimizedBatch(7)))
+
+	messages := writer.decoded(t)
+	require.Len(t, messages, 3)
+	assert.Len(t, messages[0].OddsData, 3)
+	assert.Len(t, messages[2].OddsData, 1)
+
+	writer.err = errors.New("broker unavailable")
+	require.Error(t, producer.Publish(context.Background(), newOptimizedBatch(2)))
+	writer.err = nil
+	require.NoError(t, producer.Flush(context.Background()))
+	assert.Len(t, writer.decoded(t), 3, "failed odds must not linger for the next flush")
+}
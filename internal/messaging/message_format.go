@@ -0,0 +1,42 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// Message formats of the normalized odds topic
+const (
+	MessageFormatBatch  = "batch"  // KafkaNormalizedOddsMessage envelopes of many selections
+	MessageFormatSingle = "single" // One NormalizedOdds per message, keyed per selection, e.g. on a compacted topic
+)
+
+// decodeMessage decodes a message of the consumer's format into a batch. A
+// single-format message becomes a batch of one identified by its key, and a
+// tombstone (empty value) of a compacted topic an empty batch.
+func (c *KafkaConsumer) decodeMessage(msg kafka.Message) (models.KafkaNormalizedOddsMessage, error) {
+	var batch models.KafkaNormalizedOddsMessage
+	if c.messageFormat != MessageFormatSingle {
+		if err := json.Unmarshal(msg.Value, &batch); err != nil {
+			return batch, fmt.Errorf("failed to unmarshal message: %w", err)
+		}
+		return batch, nil
+	}
+
+	batch.BatchID = string(msg.Key)
+	batch.Timestamp = msg.Time
+	if len(msg.Value) == 0 {
+		return batch, nil
+	}
+
+	var odds models.NormalizedOdds
+	if err := json.Unmarshal(msg.Value, &odds); err != nil {
+		return batch, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	batch.OddsData = []models.NormalizedOdds{odds}
+	return batch, nil
+}
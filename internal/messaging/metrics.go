@@ -0,0 +1,96 @@
+package messaging
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// consumerMetrics holds Prometheus metrics for the Kafka consumer
+type consumerMetrics struct {
+	backpressureActive prometheus.Gauge
+	paused             prometheus.Gauge
+	messagesProcessed  *prometheus.CounterVec
+	messagesSkipped    *prometheus.CounterVec
+	emptyBatches       prometheus.Counter
+	feedGaps           prometheus.Counter
+	duplicateBatches   prometheus.Counter
+	oddsFiltered       *prometheus.CounterVec
+	sidesMerged        prometheus.Counter
+	sidesUnmatched     prometheus.Counter
+	batchSize          prometheus.Histogram
+	lastBatchSize      prometheus.Gauge
+}
+
+// newConsumerMetrics creates consumer metrics and registers them with reg.
+// Metrics are still recorded but not exported when reg is nil.
+func newConsumerMetrics(reg prometheus.Registerer) *consumerMetrics {
+	m := &consumerMetrics{
+		backpressureActive: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kafka_backpressure_active",
+			Help: "1 while fetching is paused because cache writes are slow, 0 otherwise.",
+		}),
+		paused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kafka_consumer_paused",
+			Help: "1 while fetching is paused through the admin API, 0 otherwise.",
+		}),
+		messagesProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_messages_processed_total",
+			Help: "Messages optimized and cached, by sport and priority header.",
+		}, []string{"sport", "priority"}),
+		messagesSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_messages_skipped_total",
+			Help: "Low-priority messages skipped during backpressure, by sport and priority header.",
+		}, []string{"sport", "priority"}),
+		emptyBatches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_empty_batches_total",
+			Help: "Messages committed without processing because their odds_data batch was empty.",
+		}),
+		feedGaps: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_feed_gaps_total",
+			Help: "Gaps longer than the gap threshold between consumed messages.",
+		}),
+		duplicateBatches: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_duplicate_batches_total",
+			Help: "Redelivered messages skipped because they were already processed within the dedup window.",
+		}),
+		oddsFiltered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_odds_filtered_total",
+			Help: "odds_data items skipped because their sport is not processed by this consumer, by sport.",
+		}, []string{"sport"}),
+		sidesMerged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_odds_sides_merged_total",
+			Help: "Back-only and lay-only updates from separate messages merged into one two-sided update.",
+		}),
+		sidesUnmatched: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_odds_sides_unmatched_total",
+			Help: "One-sided updates optimized alone because no other side arrived within the merge window.",
+		}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "kafka_batch_size",
+			Help:    "Number of odds_data items per consumed message.",
+			Buckets: []float64{0, 1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+		}),
+		lastBatchSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kafka_last_batch_size",
+			Help: "Number of odds_data items in the most recently consumed message.",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(
+			m.backpressureActive,
+			m.paused,
+			m.messagesProcessed,
+			m.messagesSkipped,
+			m.emptyBatches,
+			m.feedGaps,
+			m.duplicateBatches,
+			m.oddsFiltered,
+			m.sidesMerged,
+			m.sidesUnmatched,
+			m.batchSize,
+			m.lastBatchSize,
+		)
+	}
+
+	return m
+}
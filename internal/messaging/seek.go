@@ -0,0 +1,235 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	// ErrConsumerNotRunning is returned by Seek when Start is not running
+	ErrConsumerNotRunning = errors.New("kafka consumer is not running")
+
+	// ErrInvalidSeek is returned by Seek for a request it cannot apply
+	ErrInvalidSeek = errors.New("invalid seek")
+)
+
+// SeekRequest repositions the consumer on a partition, either to Offset or,
+// when At is set, to the first offset at or after At
+type SeekRequest struct {
+	Partition int
+	Offset    int64
+	At        time.Time
+}
+
+// pendingSeek is a seek waiting for the fetch loop to apply it
+type pendingSeek struct {
+	req  SeekRequest
+	done chan error // Buffered; receives the outcome once applied
+}
+
+// offsetLookup resolves the first offset of a partition at or after a time
+type offsetLookup func(ctx context.Context, topic string, partition int, at time.Time) (int64, error)
+
+// Seek repositions the running consumer, typically to reprocess from a known
+// offset during incident recovery without deleting the consumer group. The
+// fetch loop pauses, waits for in-flight messages to finish and commit,
+// seeks, then resumes fetching from the new position.
+//
+// kafka-go cannot seek readers in a consumer group, so for those the target
+// offset is committed for the partition through the group and the reader is
+// reopened to resume from it. Group seeks need explicit commits: with
+// AutoCommit a pending interval commit could overtake the target, so they
+// are rejected with ErrInvalidSeek.
+func (c *KafkaConsumer) Seek(ctx context.Context, req SeekRequest) error {
+	if req.At.IsZero() && req.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidSeek)
+	}
+	config := c.currentReader().Config()
+	switch {
+	case config.GroupID == "" && req.Partition != config.Partition:
+		return fmt.Errorf("%w: consumer reads partition %d, not %d", ErrInvalidSeek, config.Partition, req.Partition)
+	case config.GroupID != "" && req.Partition < 0:
+		return fmt.Errorf("%w: partition must not be negative", ErrInvalidSeek)
+	case config.GroupID != "" && config.CommitInterval > 0:
+		return fmt.Errorf("%w: consumer group seeks need explicit commits (auto_commit off)", ErrInvalidSeek)
+	}
+
+	// One seek at a time
+	c.seekMu.Lock()
+	defer c.seekMu.Unlock()
+
+	seek := &pendingSeek{req: req, done: make(chan error, 1)}
+
+	c.fetchMu.Lock()
+	if !c.running {
+		c.fetchMu.Unlock()
+		return ErrConsumerNotRunning
+	}
+	c.pendingSeek = seek
+	if c.cancelFetch != nil {
+		c.cancelFetch() // Wake a fetch blocked waiting for messages
+	}
+	c.wakeLocked() // Or a fetch loop waiting while paused
+	c.fetchMu.Unlock()
+
+	select {
+	case err := <-seek.done:
+		return err
+	case <-ctx.Done():
+		// Withdraw the seek unless the fetch loop already took it
+		c.fetchMu.Lock()
+		if c.pendingSeek == seek {
+			c.pendingSeek = nil
+		}
+		c.fetchMu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// nextFetch returns a context for the next fetch, bounded by the poll
+// timeout when set, or the pending seek to apply instead
+func (c *KafkaConsumer) nextFetch(ctx context.Context) (context.Context, context.CancelFunc, *pendingSeek) {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	if seek := c.pendingSeek; seek != nil {
+		c.pendingSeek = nil
+		return nil, nil, seek
+	}
+
+	var fetchCtx context.Context
+	var cancel context.CancelFunc
+	if c.pollTimeout > 0 {
+		fetchCtx, cancel = context.WithTimeout(ctx, c.pollTimeout)
+	} else {
+		fetchCtx, cancel = context.WithCancel(ctx)
+	}
+	c.cancelFetch = cancel
+	return fetchCtx, cancel, nil
+}
+
+// endFetch releases the context of the current fetch
+func (c *KafkaConsumer) endFetch(cancel context.CancelFunc) {
+	c.fetchMu.Lock()
+	c.cancelFetch = nil
+	c.fetchMu.Unlock()
+	cancel()
+}
+
+// setRunning records whether the fetch loop is running; a seek still
+// pending when it stops fails
+func (c *KafkaConsumer) setRunning(running bool) {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	c.running = running
+	if !running && c.pendingSeek != nil {
+		c.pendingSeek.done <- ErrConsumerNotRunning
+		c.pendingSeek = nil
+	}
+}
+
+// applySeek waits for in-flight messages to finish, then repositions the
+// reader; commits on the partition resume from the new position even if a
+// failed publish held them
+func (c *KafkaConsumer) applySeek(ctx context.Context, seek *pendingSeek) {
+	c.workers.Wait()
+
+	var err error
+	switch {
+	case c.reader.Config().GroupID != "":
+		err = c.seekGroup(ctx, seek.req)
+	case seek.req.At.IsZero():
+		err = c.reader.SetOffset(seek.req.Offset)
+	default:
+		err = c.reader.SetOffsetAt(ctx, seek.req.At)
+	}
+	if err == nil {
+		c.offsets.release(seek.req.Partition)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to seek: %w", err)
+		c.logger.Error().Err(err).Int("partition", seek.req.Partition).Msg("consumer seek failed")
+	} else if seek.req.At.IsZero() {
+		c.logger.Warn().
+			Int("partition", seek.req.Partition).
+			Int64("offset", seek.req.Offset).
+			Msg("consumer repositioned to offset")
+	} else {
+		c.logger.Warn().
+			Int("partition", seek.req.Partition).
+			Time("at", seek.req.At).
+			Msg("consumer repositioned to timestamp")
+	}
+
+	seek.done <- err
+}
+
+// seekGroup repositions a consumer group reader: it commits the target
+// offset for the partition through the group, then replaces the reader with
+// a fresh one, which rejoins the group and fetches from the committed offset
+func (c *KafkaConsumer) seekGroup(ctx context.Context, req SeekRequest) error {
+	if c.reopen == nil {
+		return errors.New("consumer group reader cannot be reopened")
+	}
+
+	config := c.reader.Config()
+	offset := req.Offset
+	if !req.At.IsZero() {
+		var err error
+		if offset, err = c.lookup(ctx, config.Topic, req.Partition, req.At); err != nil {
+			return err
+		}
+	}
+
+	// kafka-go commits the offset after the message's, so commit the one before the target
+	target := kafka.Message{Topic: config.Topic, Partition: req.Partition, Offset: offset - 1}
+	if err := c.reader.CommitMessages(ctx, target); err != nil {
+		return fmt.Errorf("failed to commit offset %d: %w", offset, err)
+	}
+
+	if err := c.reader.Close(); err != nil {
+		c.logger.Warn().Err(err).Msg("failed to close reader replaced by seek")
+	}
+	c.fetchMu.Lock()
+	c.reader = c.reopen()
+	c.fetchMu.Unlock()
+	return nil
+}
+
+// currentReader returns the reader, which a group seek may replace
+func (c *KafkaConsumer) currentReader() messageReader {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+	return c.reader
+}
+
+// newBrokerOffsetLookup resolves timestamps to offsets by listing offsets on the brokers
+func newBrokerOffsetLookup(brokers []string) offsetLookup {
+	client := &kafka.Client{Addr: kafka.TCP(brokers...)}
+	return func(ctx context.Context, topic string, partition int, at time.Time) (int64, error) {
+		resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+			Topics: map[string][]kafka.OffsetRequest{topic: {kafka.TimeOffsetOf(partition, at)}},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, offsets := range resp.Topics[topic] {
+			if offsets.Partition != partition {
+				continue
+			}
+			if offsets.Error != nil {
+				return 0, offsets.Error
+			}
+			for offset := range offsets.Offsets {
+				return offset, nil
+			}
+		}
+		return 0, fmt.Errorf("no message at or after %s on partition %d", at.Format(time.RFC3339), partition)
+	}
+}
@@ -0,0 +1,237 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// sideKey identifies a selection across messages, as sent by the feed
+type sideKey struct {
+	eventID   string
+	market    string
+	selection string
+	line      string
+}
+
+// heldSide is a one-sided update waiting for its other side
+type heldSide struct {
+	odds     *models.NormalizedOdds
+	profile  string            // Profile header of the message it arrived in
+	deadline time.Time         // Optimized alone if no other side arrives by then
+	owners   []*trackedMessage // Messages whose commits wait until it is cached, including those of updates it replaced
+}
+
+// sideMerger pairs back-only and lay-only updates of the same selection
+// arriving in separate messages into one two-sided update
+type sideMerger struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[sideKey]*heldSide
+}
+
+// newSideMerger creates a merger holding one-sided updates for window
+func newSideMerger(window time.Duration) *sideMerger {
+	return &sideMerger{
+		window:  window,
+		pending: make(map[sideKey]*heldSide),
+	}
+}
+
+// merge returns the odds ready to optimize now. A back-only or lay-only
+// update is held until the other side arrives within the window, when the
+// two are returned merged; a newer update of the held side replaces it
+// without extending its deadline. Two-sided updates pass through, replacing
+// any held side of the same selection.
+//
+// Each side held from owner (which may be nil) adds to its waiting count.
+// The owners of held sides that merged or were replaced by a two-sided
+// update are returned in released, to be resolved once ready is cached.
+func (m *sideMerger) merge(normalized []*models.NormalizedOdds, profile string, owner *trackedMessage, now time.Time) (ready []*models.NormalizedOdds, merged int, released []*trackedMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ready = make([]*models.NormalizedOdds, 0, len(normalized))
+	for _, odds := range normalized {
+		key := sideKey{eventID: odds.EventID, market: odds.Market, selection: odds.Selection, line: odds.Line.String()}
+		back, lay := hasBack(odds), hasLay(odds)
+		if back == lay {
+			if held, ok := m.pending[key]; ok {
+				released = append(released, held.owners...)
+				delete(m.pending, key)
+			}
+			ready = append(ready, odds)
+			continue
+		}
+
+		held, ok := m.pending[key]
+		switch {
+		case !ok || !now.Before(held.deadline):
+			side := &heldSide{odds: odds, profile: profile, deadline: now.Add(m.window)}
+			if ok {
+				side.owners = held.owners // Expired but not yet flushed; the new update stands in for it
+			}
+			m.pending[key] = side.own(owner)
+		case hasBack(held.odds) == back:
+			held.odds = odds
+			held.profile = profile
+			held.own(owner)
+		default:
+			delete(m.pending, key)
+			if back {
+				ready = append(ready, mergeSides(odds, held.odds))
+			} else {
+				ready = append(ready, mergeSides(held.odds, odds))
+			}
+			released = append(released, held.owners...)
+			merged++
+		}
+	}
+	return ready, merged, released
+}
+
+// own adds owner, if any, to the messages waiting on the side
+func (h *heldSide) own(owner *trackedMessage) *heldSide {
+	if owner != nil {
+		owner.waiting.Add(1)
+		h.owners = append(h.owners, owner)
+	}
+	return h
+}
+
+// expired removes and returns the held sides whose deadline has passed
+func (m *sideMerger) expired(now time.Time) []*heldSide {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expired []*heldSide
+	for key, held := range m.pending {
+		if !now.Before(held.deadline) {
+			expired = append(expired, held)
+			delete(m.pending, key)
+		}
+	}
+	return expired
+}
+
+// mergeSides combines a back-only and a lay-only update of a selection,
+// taking each side's price, probability and size from its own update and
+// everything else from the most recent one
+func mergeSides(back, lay *models.NormalizedOdds) *models.NormalizedOdds {
+	merged := *back
+	if lay.Timestamp.After(back.Timestamp) {
+		merged = *lay
+	}
+	merged.BackPrice, merged.BackProb, merged.BackSize = back.BackPrice, back.BackProb, back.BackSize
+	merged.LayPrice, merged.LayProb, merged.LaySize = lay.LayPrice, lay.LayProb, lay.LaySize
+	return &merged
+}
+
+// hasBack reports whether odds quote a back price above 1 or a back probability
+func hasBack(odds *models.NormalizedOdds) bool {
+	return odds.BackPrice.GreaterThan(decimal.NewFromInt(1)) || isProbability(odds.BackProb)
+}
+
+// hasLay reports whether odds quote a lay price above 1 or a lay probability
+func hasLay(odds *models.NormalizedOdds) bool {
+	return odds.LayPrice.GreaterThan(decimal.NewFromInt(1)) || isProbability(odds.LayProb)
+}
+
+// isProbability reports whether p lies in (0, 1)
+func isProbability(p decimal.Decimal) bool {
+	return p.IsPositive() && p.LessThan(decimal.NewFromInt(1))
+}
+
+// mergeSides holds one-sided updates of msg for the merge window, returning
+// the odds ready to optimize: two-sided updates and completed pairs. msg is
+// not committed until its held sides are cached; the messages returned in
+// released wait on sides that are now part of ready (see resolveSides).
+func (c *KafkaConsumer) mergeSides(msg kafka.Message, normalized []*models.NormalizedOdds, profile string) (ready []*models.NormalizedOdds, released []*trackedMessage) {
+	ready, merged, released := c.sides.merge(normalized, profile, c.offsets.find(msg), time.Now())
+	c.metrics.sidesMerged.Add(float64(merged))
+	return ready, released
+}
+
+// resolveSides records whether the held sides of owners were cached,
+// committing the messages that no longer wait on anything
+func (c *KafkaConsumer) resolveSides(ctx context.Context, owners []*trackedMessage, cached bool) {
+	for _, owner := range owners {
+		if commit, ok := c.offsets.resolve(owner, cached); ok {
+			c.commit(ctx, commit)
+		}
+	}
+}
+
+// runSideFlush optimizes held sides alone once their merge window passes,
+// until ctx is done
+func (c *KafkaConsumer) runSideFlush(ctx context.Context, workCtx context.Context) {
+	ticker := time.NewTicker(max(c.sides.window/2, 10*time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.flushSides(workCtx, now)
+		}
+	}
+}
+
+// flushSides optimizes, caches and publishes the sides held past their merge
+// window as of now, with a synthesized other side, then commits the messages
+// they arrived in. Failures are logged and leave those messages uncommitted,
+// as a failed message would be.
+func (c *KafkaConsumer) flushSides(ctx context.Context, now time.Time) {
+	expired := c.sides.expired(now)
+	if len(expired) == 0 {
+		return
+	}
+	c.metrics.sidesUnmatched.Add(float64(len(expired)))
+
+	// Optimize under the profile header each side arrived with
+	byProfile := make(map[string][]*models.NormalizedOdds)
+	owners := make(map[string][]*trackedMessage)
+	for _, held := range expired {
+		byProfile[held.profile] = append(byProfile[held.profile], held.odds)
+		owners[held.profile] = append(owners[held.profile], held.owners...)
+	}
+
+	for profile, normalized := range byProfile {
+		optimized, err := c.optimize(ctx, normalized, profile)
+		if err != nil {
+			c.logger.Error().Err(err).Int("odds_count", len(normalized)).Msg("failed to optimize unmatched one-sided odds")
+			c.resolveSides(ctx, owners[profile], false)
+			continue
+		}
+
+		if err := c.cache.SetBatch(ctx, optimized); err != nil {
+			c.logger.Error().Err(err).Int("odds_count", len(optimized)).Msg("failed to cache unmatched one-sided odds")
+			c.resolveSides(ctx, owners[profile], false)
+			continue
+		}
+		c.resolveSides(ctx, owners[profile], true)
+		if c.history != nil {
+			if err := c.history.Append(ctx, optimized); err != nil {
+				c.logger.Error().Err(err).Msg("failed to record unmatched one-sided odds history")
+			}
+		}
+		if c.sinks != nil {
+			if err := c.sinks.Publish(ctx, optimized); err != nil {
+				c.logger.Error().Err(err).Msg("failed to publish unmatched one-sided odds")
+			}
+		}
+
+		c.oddsProcessed.Add(uint64(len(optimized)))
+		c.logger.Debug().
+			Int("input_count", len(normalized)).
+			Int("output_count", len(optimized)).
+			Msg("optimized unmatched one-sided odds")
+	}
+}
@@ -0,0 +1,68 @@
+package messaging
+
+import (
+	"strings"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// sportFilter decides which sports a consumer processes, so instances
+// sharded by sport can share a topic and skip the sports they do not own.
+// Sports are matched case-insensitively.
+type sportFilter struct {
+	allow map[string]bool // Empty allows every sport not denied
+	deny  map[string]bool
+}
+
+// newSportFilter creates a sport filter from allow and deny lists
+func newSportFilter(allowlist, denylist []string) sportFilter {
+	return sportFilter{allow: sportSet(allowlist), deny: sportSet(denylist)}
+}
+
+// sportSet returns the lowercase set of sports, or nil when there are none
+func sportSet(sports []string) map[string]bool {
+	if len(sports) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(sports))
+	for _, sport := range sports {
+		set[strings.ToLower(strings.TrimSpace(sport))] = true
+	}
+	return set
+}
+
+// active reports whether the filter can skip anything
+func (f sportFilter) active() bool {
+	return len(f.allow) > 0 || len(f.deny) > 0
+}
+
+// allows reports whether a sport is processed; the denylist wins over the allowlist
+func (f sportFilter) allows(sport string) bool {
+	sport = strings.ToLower(sport)
+	if f.deny[sport] {
+		return false
+	}
+	return len(f.allow) == 0 || f.allow[sport]
+}
+
+// filterSports drops odds of sports the consumer does not process, counting
+// them by sport. Odds without a sport are judged by the message's sport header.
+func (c *KafkaConsumer) filterSports(normalized []*models.NormalizedOdds, headerSport string) []*models.NormalizedOdds {
+	if !c.sports.active() {
+		return normalized
+	}
+
+	kept := normalized[:0]
+	for _, odds := range normalized {
+		sport := odds.Sport
+		if sport == "" {
+			sport = headerSport
+		}
+		if !c.sports.allows(sport) {
+			c.metrics.oddsFiltered.WithLabelValues(strings.ToLower(sport)).Inc()
+			continue
+		}
+		kept = append(kept, odds)
+	}
+	return kept
+}
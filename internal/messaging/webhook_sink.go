@@ -0,0 +1,89 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// WebhookSink POSTs optimized odds to an HTTP endpoint, one
+// KafkaOptimizedOddsMessage envelope per batch, mirroring the Kafka output
+type WebhookSink struct {
+	url    string
+	client *http.Client
+	logger zerolog.Logger
+}
+
+// WebhookSinkConfig holds webhook sink configuration
+type WebhookSinkConfig struct {
+	URL     string        // e.g., "https://client.example.com/odds"
+	Timeout time.Duration // Per-request timeout (default 5s)
+}
+
+// NewWebhookSink creates a new webhook sink
+func NewWebhookSink(config WebhookSinkConfig, logger zerolog.Logger) *WebhookSink {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	return &WebhookSink{
+		url:    config.URL,
+		client: &http.Client{Timeout: config.Timeout},
+		logger: logger.With().Str("component", "webhook_sink").Logger(),
+	}
+}
+
+// Publish POSTs the batch; any non-2xx response is an error
+func (s *WebhookSink) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	if len(odds) == 0 {
+		return nil
+	}
+
+	batch := make([]models.OptimizedOdds, len(odds))
+	for i, o := range odds {
+		batch[i] = *o
+	}
+	batchID := uuid.New().String()
+
+	data, err := json.Marshal(models.KafkaOptimizedOddsMessage{
+		OddsData:  batch,
+		Timestamp: time.Now().UTC(),
+		BatchID:   batchID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal optimized odds message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	s.logger.Debug().
+		Int("odds_count", len(odds)).
+		Str("batch_id", batchID).
+		Msg("published optimized odds batch")
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// TestWebhookSink_Publish tests that batches are POSTed as optimized odds envelopes
+func TestWebhookSink_Publish(t *testing.T) {
+	var received models.KafkaOptimizedOddsMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(WebhookSinkConfig{URL: server.URL}, zerolog.Nop())
+	err := sink.Publish(context.Background(), []*models.OptimizedOdds{
+		{EventID: "event-123", Selection: "Team A"},
+		{EventID: "event-123", Selection: "Team B"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, received.OddsData, 2)
+	assert.Equal(t, "Team B", received.OddsData[1].Selection)
+	assert.NotEmpty(t, received.BatchID)
+}
+
+// TestWebhookSink_ErrorStatus tests that non-2xx responses are errors
+func TestWebhookSink_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(WebhookSinkConfig{URL: server.URL}, zerolog.Nop())
+	err := sink.Publish(context.Background(), []*models.OptimizedOdds{{EventID: "event-123"}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+}
@@ -55,6 +55,20 @@ func (mr *MockCacheMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockCache)(nil).Close))
 }
 
+// Delete mocks base method.
+func (m *MockCache) Delete(ctx context.Context, eventID, market, selection string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, eventID, market, selection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCacheMockRecorder) Delete(ctx, eventID, market, selection any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCache)(nil).Delete), ctx, eventID, market, selection)
+}
+
 // Get mocks base method.
 func (m *MockCache) Get(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, error) {
 	m.ctrl.T.Helper()
@@ -85,6 +99,37 @@ func (mr *MockCacheMockRecorder) GetByEvent(ctx, eventID any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEvent", reflect.TypeOf((*MockCache)(nil).GetByEvent), ctx, eventID)
 }
 
+// GetByEvents mocks base method.
+func (m *MockCache) GetByEvents(ctx context.Context, eventIDs []string) (map[string][]*models.OptimizedOdds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEvents", ctx, eventIDs)
+	ret0, _ := ret[0].(map[string][]*models.OptimizedOdds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByEvents indicates an expected call of GetByEvents.
+func (mr *MockCacheMockRecorder) GetByEvents(ctx, eventIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEvents", reflect.TypeOf((*MockCache)(nil).GetByEvents), ctx, eventIDs)
+}
+
+// GetWithMeta mocks base method.
+func (m *MockCache) GetWithMeta(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, models.CacheMeta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithMeta", ctx, eventID, market, selection)
+	ret0, _ := ret[0].(*models.OptimizedOdds)
+	ret1, _ := ret[1].(models.CacheMeta)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetWithMeta indicates an expected call of GetWithMeta.
+func (mr *MockCacheMockRecorder) GetWithMeta(ctx, eventID, market, selection any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithMeta", reflect.TypeOf((*MockCache)(nil).GetWithMeta), ctx, eventID, market, selection)
+}
+
 // Ping mocks base method.
 func (m *MockCache) Ping(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -99,6 +144,20 @@ func (mr *MockCacheMockRecorder) Ping(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockCache)(nil).Ping), ctx)
 }
 
+// Scan mocks base method.
+func (m *MockCache) Scan(ctx context.Context, eventID string, fn func(*models.OptimizedOdds) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", ctx, eventID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockCacheMockRecorder) Scan(ctx, eventID, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockCache)(nil).Scan), ctx, eventID, fn)
+}
+
 // Set mocks base method.
 func (m *MockCache) Set(ctx context.Context, odds *models.OptimizedOdds) error {
 	m.ctrl.T.Helper()
@@ -126,3 +185,31 @@ func (mr *MockCacheMockRecorder) SetBatch(ctx, oddsList any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBatch", reflect.TypeOf((*MockCache)(nil).SetBatch), ctx, oddsList)
 }
+
+// Stats mocks base method.
+func (m *MockCache) Stats() models.CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(models.CacheStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCacheMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCache)(nil).Stats))
+}
+
+// Touch mocks base method.
+func (m *MockCache) Touch(ctx context.Context, eventID, market, selection string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", ctx, eventID, market, selection)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockCacheMockRecorder) Touch(ctx, eventID, market, selection any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockCache)(nil).Touch), ctx, eventID, market, selection)
+}
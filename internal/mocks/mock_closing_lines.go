@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cypherlabdev/odds-optimizer-service/internal/service (interfaces: ClosingLineStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=internal/mocks/mock_closing_lines.go -package=mocks github.com/cypherlabdev/odds-optimizer-service/internal/service ClosingLineStore
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClosingLineStore is a mock of ClosingLineStore interface.
+type MockClosingLineStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockClosingLineStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockClosingLineStoreMockRecorder is the mock recorder for MockClosingLineStore.
+type MockClosingLineStoreMockRecorder struct {
+	mock *MockClosingLineStore
+}
+
+// NewMockClosingLineStore creates a new mock instance.
+func NewMockClosingLineStore(ctrl *gomock.Controller) *MockClosingLineStore {
+	mock := &MockClosingLineStore{ctrl: ctrl}
+	mock.recorder = &MockClosingLineStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClosingLineStore) EXPECT() *MockClosingLineStoreMockRecorder {
+	return m.recorder
+}
+
+// Capture mocks base method.
+func (m *MockClosingLineStore) Capture(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Capture", ctx, oddsList)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Capture indicates an expected call of Capture.
+func (mr *MockClosingLineStoreMockRecorder) Capture(ctx, oddsList any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Capture", reflect.TypeOf((*MockClosingLineStore)(nil).Capture), ctx, oddsList)
+}
+
+// Close mocks base method.
+func (m *MockClosingLineStore) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockClosingLineStoreMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockClosingLineStore)(nil).Close))
+}
+
+// GetByEvent mocks base method.
+func (m *MockClosingLineStore) GetByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEvent", ctx, eventID)
+	ret0, _ := ret[0].([]*models.OptimizedOdds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByEvent indicates an expected call of GetByEvent.
+func (mr *MockClosingLineStoreMockRecorder) GetByEvent(ctx, eventID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEvent", reflect.TypeOf((*MockClosingLineStore)(nil).GetByEvent), ctx, eventID)
+}
@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cypherlabdev/odds-optimizer-service/internal/service (interfaces: Deduplicator)
+//
+// Generated by this command:
+//
+//	mockgen -destination=internal/mocks/mock_dedup.go -package=mocks github.com/cypherlabdev/odds-optimizer-service/internal/service Deduplicator
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDeduplicator is a mock of Deduplicator interface.
+type MockDeduplicator struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeduplicatorMockRecorder
+	isgomock struct{}
+}
+
+// MockDeduplicatorMockRecorder is the mock recorder for MockDeduplicator.
+type MockDeduplicatorMockRecorder struct {
+	mock *MockDeduplicator
+}
+
+// NewMockDeduplicator creates a new mock instance.
+func NewMockDeduplicator(ctrl *gomock.Controller) *MockDeduplicator {
+	mock := &MockDeduplicator{ctrl: ctrl}
+	mock.recorder = &MockDeduplicatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeduplicator) EXPECT() *MockDeduplicatorMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockDeduplicator) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockDeduplicatorMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockDeduplicator)(nil).Close))
+}
+
+// Mark mocks base method.
+func (m *MockDeduplicator) Mark(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Mark", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Mark indicates an expected call of Mark.
+func (mr *MockDeduplicatorMockRecorder) Mark(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mark", reflect.TypeOf((*MockDeduplicator)(nil).Mark), ctx, key)
+}
+
+// Seen mocks base method.
+func (m *MockDeduplicator) Seen(ctx context.Context, key string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Seen", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Seen indicates an expected call of Seen.
+func (mr *MockDeduplicatorMockRecorder) Seen(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seen", reflect.TypeOf((*MockDeduplicator)(nil).Seen), ctx, key)
+}
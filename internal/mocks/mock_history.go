@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cypherlabdev/odds-optimizer-service/internal/service (interfaces: History)
+//
+// Generated by this command:
+//
+//	mockgen -destination=internal/mocks/mock_history.go -package=mocks github.com/cypherlabdev/odds-optimizer-service/internal/service History
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHistory is a mock of History interface.
+type MockHistory struct {
+	ctrl     *gomock.Controller
+	recorder *MockHistoryMockRecorder
+	isgomock struct{}
+}
+
+// MockHistoryMockRecorder is the mock recorder for MockHistory.
+type MockHistoryMockRecorder struct {
+	mock *MockHistory
+}
+
+// NewMockHistory creates a new mock instance.
+func NewMockHistory(ctrl *gomock.Controller) *MockHistory {
+	mock := &MockHistory{ctrl: ctrl}
+	mock.recorder = &MockHistoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHistory) EXPECT() *MockHistoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockHistory) Append(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, oddsList)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockHistoryMockRecorder) Append(ctx, oddsList any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockHistory)(nil).Append), ctx, oddsList)
+}
+
+// At mocks base method.
+func (m *MockHistory) At(ctx context.Context, eventID, market, selection string, at time.Time) (*models.OptimizedOdds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "At", ctx, eventID, market, selection, at)
+	ret0, _ := ret[0].(*models.OptimizedOdds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// At indicates an expected call of At.
+func (mr *MockHistoryMockRecorder) At(ctx, eventID, market, selection, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "At", reflect.TypeOf((*MockHistory)(nil).At), ctx, eventID, market, selection, at)
+}
+
+// Close mocks base method.
+func (m *MockHistory) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockHistoryMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockHistory)(nil).Close))
+}
+
+// MarketAt mocks base method.
+func (m *MockHistory) MarketAt(ctx context.Context, eventID, market string, at time.Time) ([]*models.OptimizedOdds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarketAt", ctx, eventID, market, at)
+	ret0, _ := ret[0].([]*models.OptimizedOdds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarketAt indicates an expected call of MarketAt.
+func (mr *MockHistoryMockRecorder) MarketAt(ctx, eventID, market, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarketAt", reflect.TypeOf((*MockHistory)(nil).MarketAt), ctx, eventID, market, at)
+}
+
+// Recent mocks base method.
+func (m *MockHistory) Recent(ctx context.Context, eventID, market, selection string, n int) ([]*models.OptimizedOdds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Recent", ctx, eventID, market, selection, n)
+	ret0, _ := ret[0].([]*models.OptimizedOdds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Recent indicates an expected call of Recent.
+func (mr *MockHistoryMockRecorder) Recent(ctx, eventID, market, selection, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recent", reflect.TypeOf((*MockHistory)(nil).Recent), ctx, eventID, market, selection, n)
+}
+
+// RecentBatch mocks base method.
+func (m *MockHistory) RecentBatch(ctx context.Context, keys []models.SelectionKey, n int) ([][]*models.OptimizedOdds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecentBatch", ctx, keys, n)
+	ret0, _ := ret[0].([][]*models.OptimizedOdds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecentBatch indicates an expected call of RecentBatch.
+func (mr *MockHistoryMockRecorder) RecentBatch(ctx, keys, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecentBatch", reflect.TypeOf((*MockHistory)(nil).RecentBatch), ctx, keys, n)
+}
@@ -55,6 +55,21 @@ func (mr *MockOptimizerMockRecorder) BatchOptimize(normalized any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchOptimize", reflect.TypeOf((*MockOptimizer)(nil).BatchOptimize), normalized)
 }
 
+// BatchOptimizeMarket mocks base method.
+func (m *MockOptimizer) BatchOptimizeMarket(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchOptimizeMarket", normalized)
+	ret0, _ := ret[0].([]*models.OptimizedOdds)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchOptimizeMarket indicates an expected call of BatchOptimizeMarket.
+func (mr *MockOptimizerMockRecorder) BatchOptimizeMarket(normalized any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchOptimizeMarket", reflect.TypeOf((*MockOptimizer)(nil).BatchOptimizeMarket), normalized)
+}
+
 // Optimize mocks base method.
 func (m *MockOptimizer) Optimize(normalized *models.NormalizedOdds) (*models.OptimizedOdds, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/cypherlabdev/odds-optimizer-service/internal/service (interfaces: OddsSink)
+//
+// Generated by this command:
+//
+//	mockgen -destination=internal/mocks/mock_sink.go -package=mocks github.com/cypherlabdev/odds-optimizer-service/internal/service OddsSink
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOddsSink is a mock of OddsSink interface.
+type MockOddsSink struct {
+	ctrl     *gomock.Controller
+	recorder *MockOddsSinkMockRecorder
+	isgomock struct{}
+}
+
+// MockOddsSinkMockRecorder is the mock recorder for MockOddsSink.
+type MockOddsSinkMockRecorder struct {
+	mock *MockOddsSink
+}
+
+// NewMockOddsSink creates a new mock instance.
+func NewMockOddsSink(ctrl *gomock.Controller) *MockOddsSink {
+	mock := &MockOddsSink{ctrl: ctrl}
+	mock.recorder = &MockOddsSinkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOddsSink) EXPECT() *MockOddsSinkMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockOddsSink) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, odds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockOddsSinkMockRecorder) Publish(ctx, odds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockOddsSink)(nil).Publish), ctx, odds)
+}
@@ -22,35 +22,146 @@ type NormalizedOdds struct {
 	LaySize      decimal.Decimal `json:"lay_size"`
 	Timestamp    time.Time       `json:"timestamp"`
 	NormalizedAt time.Time       `json:"normalized_at"`
+	Profile      string          `json:"profile,omitempty"`   // Named optimizer profile (default profile when empty)
+	Currency     string          `json:"currency,omitempty"`  // ISO code BackSize/LaySize are denominated in (base currency when empty)
+	InPlay       bool            `json:"in_play,omitempty"`   // Event has started; its odds go stale in seconds
+	StartTime    time.Time       `json:"start_time,omitzero"` // Scheduled event start, when the feed knows it
+	Source       string          `json:"source,omitempty"`    // Feed provider the odds came from
+	Line         decimal.Decimal `json:"line,omitzero"`       // Line of a line market, e.g. 2.5 for Over/Under 2.5 goals
+
+	// TrueProbability is an optional internal model probability; when in
+	// (0, 1) it replaces the market-implied probability as the pricing base
+	TrueProbability decimal.Decimal `json:"true_probability"`
+
+	// BackProb and LayProb are optional implied probabilities for sources
+	// that quote probabilities rather than odds; when in (0, 1) they are used
+	// directly and the prices may be omitted
+	BackProb decimal.Decimal `json:"back_prob"`
+	LayProb  decimal.Decimal `json:"lay_prob"`
+
+	// RecentBackPrices are the selection's latest optimized back prices,
+	// newest first, read from history ahead of optimizing so the stability
+	// factor needs no I/O; never part of the wire format
+	RecentBackPrices []decimal.Decimal `json:"-"`
+}
+
+// SelectionKey identifies one selection of an event's market
+type SelectionKey struct {
+	EventID   string
+	Market    string
+	Selection string
 }
 
 // OptimizedOdds represents odds after ML optimization
 type OptimizedOdds struct {
-	ID              uuid.UUID       `json:"id"`
-	EventID         string          `json:"event_id"`
-	EventName       string          `json:"event_name"`
-	Sport           string          `json:"sport"`
-	Competition     string          `json:"competition"`
-	Market          string          `json:"market"`
-	Selection       string          `json:"selection"`
-	OptimizedBack   decimal.Decimal `json:"optimized_back"`   // Optimized back price
-	OptimizedLay    decimal.Decimal `json:"optimized_lay"`    // Optimized lay price
-	OriginalBack    decimal.Decimal `json:"original_back"`
-	OriginalLay     decimal.Decimal `json:"original_lay"`
-	BackSize        decimal.Decimal `json:"back_size"`
-	LaySize         decimal.Decimal `json:"lay_size"`
-	Margin          decimal.Decimal `json:"margin"`           // Our profit margin
-	Confidence      float64         `json:"confidence"`       // Model confidence (0-1)
-	Timestamp       time.Time       `json:"timestamp"`
-	OptimizedAt     time.Time       `json:"optimized_at"`
+	ID               uuid.UUID        `json:"id"`
+	EventID          string           `json:"event_id"`
+	EventName        string           `json:"event_name"`
+	Sport            string           `json:"sport"`
+	Competition      string           `json:"competition"`
+	Market           string           `json:"market"`
+	Selection        string           `json:"selection"`
+	DisplaySelection string           `json:"display_selection,omitempty"` // Original selection name when Selection is canonicalized
+	OptimizedBack    decimal.Decimal  `json:"optimized_back"`              // Optimized back price
+	OptimizedLay     decimal.Decimal  `json:"optimized_lay"`               // Optimized lay price
+	FairPrice        decimal.Decimal  `json:"fair_price"`                  // De-vigged fair price (no margin)
+	SportsbookPrice  *decimal.Decimal `json:"sportsbook_price,omitempty"`  // Fixed-odds price with full margin (when enabled)
+	OriginalBack     decimal.Decimal  `json:"original_back"`
+	OriginalLay      decimal.Decimal  `json:"original_lay"`
+	BackSize         decimal.Decimal  `json:"back_size"`
+	LaySize          decimal.Decimal  `json:"lay_size"`
+	Margin           decimal.Decimal  `json:"margin"`              // Our profit margin
+	Confidence       float64          `json:"confidence"`          // Model confidence (0-1)
+	InPlay           bool             `json:"in_play,omitempty"`   // Cached with the short in-play TTL
+	StartTime        time.Time        `json:"start_time,omitzero"` // Scheduled event start; drives closing line capture
+	Source           string           `json:"source,omitempty"`    // Feed provider whose odds produced this price
+	Line             decimal.Decimal  `json:"line,omitzero"`       // Line of a line market
+	Stale            bool             `json:"stale,omitempty"`     // Served past its cache TTL, within the stale grace window
+	Degraded         bool             `json:"degraded,omitempty"`  // Optimized without optional enrichments, such as history, to meet the latency budget
+	Regions          []string         `json:"regions,omitempty"`   // Regions the odds may be served to (empty: everywhere)
+	Timestamp        time.Time        `json:"timestamp"`
+	OptimizedAt      time.Time        `json:"optimized_at"`
 }
 
 // OptimizationParams holds parameters for odds optimization
 type OptimizationParams struct {
-	MinMargin       decimal.Decimal // Minimum profit margin (e.g., 0.02 = 2%)
-	MaxMargin       decimal.Decimal // Maximum profit margin (e.g., 0.10 = 10%)
-	MinSpread       decimal.Decimal // Minimum back-lay spread
-	TargetConfidence float64        // Target confidence level (0-1)
+	MinMargin                decimal.Decimal            // Minimum profit margin (e.g., 0.02 = 2%)
+	MaxMargin                decimal.Decimal            // Maximum profit margin (e.g., 0.10 = 10%)
+	MinSpread                decimal.Decimal            // Minimum back-lay spread
+	MinSpreadPct             decimal.Decimal            // Minimum back-lay spread as a % of the fair price; the larger of the two applies (0 disables)
+	TargetConfidence         float64                    // Target confidence level (0-1)
+	FastMath                 bool                       // Use float64 arithmetic internally (faster, slightly less precise)
+	EmitSportsbook           bool                       // Also produce a sportsbook (fixed-odds) price
+	MinMarginSports          []string                   // Sports that skip the sport margin multiplier
+	LiquidityMarginThreshold decimal.Decimal            // Liquidity (base currency) below which margin rises toward MaxMargin (0 uses 10000)
+	LiquidityConfidenceCap   decimal.Decimal            // Liquidity (base currency) at which the liquidity confidence factor peaks (0 uses 20000)
+	MinLiquidity             decimal.Decimal            // Market books leave out selections with less liquidity (base currency) than this before removing the overround (0 disables)
+	BackMarginWeight         decimal.Decimal            // Share of the target margin added to the back probability (BackMarginWeight and LayMarginWeight both 0 split it evenly)
+	LayMarginWeight          decimal.Decimal            // Share of the target margin taken from the lay probability; weights are scaled to sum to 1
+	MaxDriftPct              decimal.Decimal            // Reject optimized back prices deviating more than this % from the original (0 disables)
+	NormalizeSelections      bool                       // Canonicalize selection names (lowercase, trimmed, single spaces) before keying
+	RejectNegativeMargin     bool                       // Drop market books whose realized overround is negative
+	MaxTotalOverround        decimal.Decimal            // Scale margins down so a market book's total overround stays within this (0 disables)
+	Commission               decimal.Decimal            // Exchange commission on net winnings (0.05 = 5%); target margins are grossed up by 1/(1-Commission) so the margin after commission is preserved (0 disables)
+	DrawMultiplier           decimal.Decimal            // Multiplies the draw's margin in 3-way books priced by BatchOptimizeMarket (0 or 1 disables)
+	DrawLabels               []string                   // Selection names identifying the draw, matched canonically (empty uses Draw, X and Tie)
+	DivisionPrecision        int32                      // Digits kept after the decimal point when dividing (0 uses shopspring's default of 16)
+	StabilityWeight          float64                    // Weight (0-1) of recent price stability in confidence; needs a price history (0 disables)
+	StabilityWindow          int                        // Recent prices the stability factor considers (default 10)
+	MissingLayPenalty        float64                    // Multiplies the confidence of odds quoting no usable lay price or probability (0 or 1 disables)
+	NonFiniteConfidence      float64                    // Confidence factor used when the float math yields NaN; infinities clamp to 0 or 1 instead (default 0)
+	MinLayProbability        decimal.Decimal            // Optimized lay probabilities the margin pushes outside [MinLayProbability, 1-MinLayProbability] are clamped into it (0 uses 0.001)
+	RejectInvalidLay         bool                       // Reject odds whose lay would be clamped with ErrInvalidLayPrice instead
+	DuplicatePolicy          string                     // Which copy of a selection repeated within a batch is kept: newest (default), first or last; consensus merges the copies instead
+	LadderPolicy             string                     // Totals ladder rungs priced inconsistently across lines are smoothed (default) or rejected
+	SourcePreference         []string                   // Feed sources in order of preference among copies of a selection; unlisted sources rank last and ties fall back to DuplicatePolicy
+	SourceWeights            map[string]decimal.Decimal // Weight of each feed source in a consensus of its copies of a selection; unlisted sources weigh 1 and 0 leaves a source out
+	EvenMoneyFloor           decimal.Decimal            // Price a back price of exactly 1.0 as if quoted at this minimum tradeable price (0 rejects it as non-tradeable)
+	PricePrecision           int32                      // Decimal places optimized prices are rounded to (0 leaves them unrounded)
+	MarginPrecision          int32                      // Decimal places optimized margins are rounded to (0 leaves them unrounded)
+	RoundingMode             string                     // Rounding of prices and margins: half_up (default), half_even or conservative (back down, lay and margin up)
+	NormalizationMethod      string                     // How BatchOptimizeMarket removes book overround: proportional (default), power or log_odds
+
+	ConfidenceBounds map[string]ConfidenceBounds // Per-sport confidence floor and ceiling (unlisted sports use [0, 1])
+
+	RegionRules []RegionRule // Regions odds may be served to, by sport, competition and market; the first matching rule applies (none: everywhere)
+
+	MarginOverrides []MarginOverride // Margin parameters by sport, sport+market or competition; the most specific override setting a field wins
+
+	MinPublishConfidence  float64            // Optimized odds below this confidence are not cached or published (0 disables)
+	MinConfidenceByMarket map[string]float64 // Per-market (lowercase) publish floors overriding MinPublishConfidence
+
+	BaseCurrency string                     // Currency liquidity thresholds are expressed in
+	FXRates      map[string]decimal.Decimal // Units of base currency per unit of each currency; sizes are normalized before thresholds
+}
+
+// ConfidenceBounds limits the confidence reported for a sport
+type ConfidenceBounds struct {
+	Min float64 `json:"min"` // Floor applied after clamping to [0, 1]
+	Max float64 `json:"max"` // Ceiling applied after clamping to [0, 1]
+}
+
+// RegionRule restricts odds matching its sport, competition and market to
+// the listed regions. An empty Sport, Competition or Market matches any.
+type RegionRule struct {
+	Sport       string   `json:"sport,omitempty"`
+	Competition string   `json:"competition,omitempty"`
+	Market      string   `json:"market,omitempty"`
+	Regions     []string `json:"regions"` // Lowercase region codes, e.g. "gb", "us-nj"
+}
+
+// MarginOverride overrides margin parameters for odds matching its sport,
+// market and competition, compared case-insensitively. An empty Market or
+// Competition matches any; a Competition makes the override more specific
+// than a Market, and a Market more specific than the Sport alone. Zero
+// fields inherit from the next less specific override, then the globals.
+type MarginOverride struct {
+	Sport           string          `json:"sport"`
+	Market          string          `json:"market,omitempty"`
+	Competition     string          `json:"competition,omitempty"`
+	MinMargin       decimal.Decimal `json:"min_margin"`
+	MaxMargin       decimal.Decimal `json:"max_margin"`
+	SportMultiplier decimal.Decimal `json:"sport_multiplier"` // Replaces the built-in multiplier of the sport
 }
 
 // KafkaNormalizedOddsMessage represents the Kafka message from data-normalizer
@@ -58,4 +169,37 @@ type KafkaNormalizedOddsMessage struct {
 	OddsData  []NormalizedOdds `json:"odds_data"`
 	Timestamp time.Time        `json:"timestamp"`
 	BatchID   string           `json:"batch_id"`
+	Currency  string           `json:"currency,omitempty"` // Default currency of the batch's sizes
+	Source    string           `json:"source,omitempty"`   // Default feed source of the batch's selections
+}
+
+// KafkaOptimizedOddsMessage represents the Kafka message published downstream,
+// mirroring the KafkaNormalizedOddsMessage envelope
+type KafkaOptimizedOddsMessage struct {
+	OddsData  []OptimizedOdds `json:"odds_data"`
+	Timestamp time.Time       `json:"timestamp"`
+	BatchID   string          `json:"batch_id"`
+}
+
+// CacheMeta describes the freshness of a cached entry
+type CacheMeta struct {
+	TTLRemaining time.Duration // Time until the entry expires (0 when it does not expire)
+	Age          time.Duration // Time since the odds were optimized
+}
+
+// CacheStats holds operational counters of an optimized-odds cache
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`   // Lookups that found data
+	Misses uint64 `json:"misses"` // Lookups that found nothing
+	Errors uint64 `json:"errors"` // Operations that failed
+	Sets   uint64 `json:"sets"`   // Entries written
+}
+
+// HitRatio returns the fraction of lookups that found data (0 when there were none)
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
 }
@@ -11,8 +11,14 @@ import (
 type Cache interface {
 	Set(ctx context.Context, odds *models.OptimizedOdds) error
 	Get(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, error)
+	GetWithMeta(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, models.CacheMeta, error)
+	Touch(ctx context.Context, eventID, market, selection string) error
+	Delete(ctx context.Context, eventID, market, selection string) error
 	SetBatch(ctx context.Context, oddsList []*models.OptimizedOdds) error
 	GetByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error)
+	GetByEvents(ctx context.Context, eventIDs []string) (map[string][]*models.OptimizedOdds, error)
+	Scan(ctx context.Context, eventID string, fn func(*models.OptimizedOdds) error) error
+	Stats() models.CacheStats
 	Ping(ctx context.Context) error
 	Close() error
 }
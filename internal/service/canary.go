@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// ErrCanaryFailing is returned by Canary.Ready once the canary has failed
+// for the whole failure window
+var ErrCanaryFailing = errors.New("optimizer canary failing")
+
+// canaryMaxDeviation is how far, as a fraction of the input price, the
+// canary's optimized prices may move before they are considered insane
+var canaryMaxDeviation = decimal.NewFromFloat(0.5)
+
+// Canary periodically runs a fixed selection through the optimizer and checks
+// the output is within sane bounds, catching an optimizer that is up but
+// rejects or mangles everything (e.g. after a bad parameter change). Ready
+// fails once the canary has failed continuously for the failure window.
+type Canary struct {
+	optimizer     Optimizer
+	interval      time.Duration
+	failureWindow time.Duration
+	ok            prometheus.Gauge
+	logger        zerolog.Logger
+
+	mu           sync.Mutex
+	failingSince time.Time // Zero while passing
+	lastErr      error
+}
+
+// CanaryConfig holds optimizer canary configuration
+type CanaryConfig struct {
+	Interval      time.Duration // How often the canary runs (default 30s)
+	FailureWindow time.Duration // Continuous failure after which Ready fails (default 2m)
+
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// NewCanary creates a new optimizer canary
+func NewCanary(optimizer Optimizer, config CanaryConfig, logger zerolog.Logger) *Canary {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+	if config.FailureWindow <= 0 {
+		config.FailureWindow = 2 * time.Minute
+	}
+
+	c := &Canary{
+		optimizer:     optimizer,
+		interval:      config.Interval,
+		failureWindow: config.FailureWindow,
+		ok: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "canary_ok",
+			Help: "1 when the last optimizer canary produced sane output, 0 when it failed.",
+		}),
+		logger: logger.With().Str("component", "canary").Logger(),
+	}
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(c.ok)
+	}
+
+	return c
+}
+
+// Start runs the canary immediately and then every interval, until ctx is done
+func (c *Canary) Start(ctx context.Context) {
+	c.check(time.Now())
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-ticker.C:
+			c.check(now)
+		}
+	}
+}
+
+// Ready returns ErrCanaryFailing, wrapping the last failure, once the canary
+// has failed continuously for the failure window
+func (c *Canary) Ready() error {
+	return c.ready(time.Now())
+}
+
+// ready reports readiness as of now
+func (c *Canary) ready(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failingSince.IsZero() || now.Sub(c.failingSince) < c.failureWindow {
+		return nil
+	}
+	return fmt.Errorf("%w for %s: %v", ErrCanaryFailing, now.Sub(c.failingSince).Truncate(time.Second), c.lastErr)
+}
+
+// check runs the canary once and records the outcome
+func (c *Canary) check(now time.Time) {
+	err := c.run(now)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		if !c.failingSince.IsZero() {
+			c.logger.Info().Dur("failed_for", now.Sub(c.failingSince)).Msg("optimizer canary recovered")
+		}
+		c.failingSince = time.Time{}
+		c.lastErr = nil
+		c.ok.Set(1)
+		return
+	}
+
+	if c.failingSince.IsZero() {
+		c.failingSince = now
+	}
+	c.lastErr = err
+	c.ok.Set(0)
+	c.logger.Error().Err(err).Time("failing_since", c.failingSince).Msg("optimizer canary failed")
+}
+
+// run optimizes the canary input and checks the output
+func (c *Canary) run(now time.Time) error {
+	input := canaryInput(now)
+
+	optimized, err := c.optimizer.Optimize(input)
+	if err != nil {
+		return fmt.Errorf("optimize failed: %w", err)
+	}
+	if optimized == nil {
+		return errors.New("optimize returned no odds")
+	}
+
+	if err := checkCanaryPrice("optimized back", optimized.OptimizedBack, input.BackPrice); err != nil {
+		return err
+	}
+	if err := checkCanaryPrice("optimized lay", optimized.OptimizedLay, input.BackPrice); err != nil {
+		return err
+	}
+	if optimized.OptimizedBack.LessThan(optimized.OptimizedLay) {
+		return fmt.Errorf("optimized back %s below lay %s", optimized.OptimizedBack, optimized.OptimizedLay)
+	}
+	if optimized.Margin.IsNegative() || optimized.Margin.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return fmt.Errorf("margin %s outside [0, 1)", optimized.Margin)
+	}
+	if optimized.Confidence <= 0 || optimized.Confidence > 1 {
+		return fmt.Errorf("confidence %v outside (0, 1]", optimized.Confidence)
+	}
+	return nil
+}
+
+// checkCanaryPrice checks a price is a valid decimal price within
+// canaryMaxDeviation of the input price
+func checkCanaryPrice(name string, price, input decimal.Decimal) error {
+	if price.LessThanOrEqual(decimal.NewFromInt(1)) {
+		return fmt.Errorf("%s %s is not above 1", name, price)
+	}
+	if deviation := price.Sub(input).Abs().Div(input); deviation.GreaterThan(canaryMaxDeviation) {
+		return fmt.Errorf("%s %s deviates %s%% from input %s", name, price, deviation.Mul(decimal.NewFromInt(100)).StringFixed(1), input)
+	}
+	return nil
+}
+
+// canaryInput returns the fixed canary selection: a liquid, fresh football price
+func canaryInput(now time.Time) *models.NormalizedOdds {
+	return &models.NormalizedOdds{
+		EventID:     "canary",
+		EventName:   "Canary Home vs Canary Away",
+		Sport:       "football",
+		Competition: "Canary",
+		Market:      "match_winner",
+		Selection:   "Canary Home",
+		BackPrice:   decimal.NewFromFloat(2.50),
+		LayPrice:    decimal.NewFromFloat(2.60),
+		BackSize:    decimal.NewFromInt(10000),
+		LaySize:     decimal.NewFromInt(8000),
+		Timestamp:   now,
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// TestCanary tests that a sane optimizer passes the canary and broken ones
+// fail it, failing readiness only after the failure window
+func TestCanary(t *testing.T) {
+	now := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	config := CanaryConfig{FailureWindow: time.Minute}
+
+	t.Run("Sane optimizer passes", func(t *testing.T) {
+		registered := config
+		registered.Registerer = prometheus.NewRegistry()
+		canary := NewCanary(optimizer.NewOptimizer(testOptimizationParams(), zerolog.Nop()), registered, zerolog.Nop())
+		canary.check(now)
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(canary.ok))
+		assert.NoError(t, canary.ready(now.Add(time.Hour)))
+	})
+
+	t.Run("Rejecting optimizer fails after the window", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockOptimizer := mocks.NewMockOptimizer(ctrl)
+		mockOptimizer.EXPECT().Optimize(gomock.Any()).Return(nil, optimizer.ErrInvalidBackPrice).Times(3)
+		canary := NewCanary(mockOptimizer, config, zerolog.Nop())
+
+		canary.check(now)
+		assert.Equal(t, 0.0, testutil.ToFloat64(canary.ok))
+		assert.NoError(t, canary.ready(now.Add(30*time.Second)))
+
+		canary.check(now.Add(30 * time.Second))
+		canary.check(now.Add(time.Minute))
+		err := canary.ready(now.Add(time.Minute))
+		assert.ErrorIs(t, err, ErrCanaryFailing)
+		assert.Contains(t, err.Error(), "invalid back price")
+	})
+
+	t.Run("Insane output fails", func(t *testing.T) {
+		params := testOptimizationParams()
+		params.MinMargin = decimal.NewFromFloat(0.9)
+		params.MaxMargin = decimal.NewFromFloat(0.95)
+		canary := NewCanary(optimizer.NewOptimizer(params, zerolog.Nop()), config, zerolog.Nop())
+
+		canary.check(now)
+		assert.Equal(t, 0.0, testutil.ToFloat64(canary.ok))
+		assert.ErrorIs(t, canary.ready(now.Add(time.Minute)), ErrCanaryFailing)
+	})
+
+	t.Run("Recovery resets the window", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockOptimizer := mocks.NewMockOptimizer(ctrl)
+		sane := optimizer.NewOptimizer(testOptimizationParams(), zerolog.Nop())
+		gomock.InOrder(
+			mockOptimizer.EXPECT().Optimize(gomock.Any()).Return(nil, errors.New("boom")),
+			mockOptimizer.EXPECT().Optimize(gomock.Any()).DoAndReturn(sane.Optimize),
+		)
+		canary := NewCanary(mockOptimizer, config, zerolog.Nop())
+
+		canary.check(now)
+		canary.check(now.Add(time.Minute))
+		assert.Equal(t, 1.0, testutil.ToFloat64(canary.ok))
+		assert.NoError(t, canary.ready(now.Add(time.Hour)))
+	})
+}
+
+// testOptimizationParams returns the params of a sanely configured optimizer
+func testOptimizationParams() models.OptimizationParams {
+	return models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// ChangeOnlySink publishes a selection to another sink only when its
+// optimized back or lay moved materially since the last price this sink
+// published for it. Last published prices are kept in memory per sink, so
+// each wrapped sink suppresses independently; a selection is remembered only
+// once its batch has published successfully, and forgotten TTL after that,
+// so settled events do not stay in memory.
+type ChangeOnlySink struct {
+	sink         OddsSink
+	minChange    decimal.Decimal
+	minChangePct decimal.Decimal
+	ttl          time.Duration
+	now          func() time.Time
+
+	mu        sync.Mutex
+	last      map[string]publishedPrice // Keyed by changeKey
+	lastSweep time.Time
+}
+
+// ChangeOnlyConfig holds change-only publishing configuration. A move counts
+// when it reaches either threshold; with both 0 any move counts.
+type ChangeOnlyConfig struct {
+	MinChange    decimal.Decimal // Absolute back or lay move, e.g. 0.01 = one tick at two decimals (0 disables)
+	MinChangePct decimal.Decimal // Back or lay move relative to the last published price, e.g. 0.01 = 1% (0 disables)
+	TTL          time.Duration   // How long a published price is remembered; the selection is republished after it (0 remembers forever)
+}
+
+// publishedPrice is the last price published for a selection
+type publishedPrice struct {
+	back        decimal.Decimal
+	lay         decimal.Decimal
+	publishedAt time.Time
+}
+
+// expired reports whether the price is older than ttl at now
+func (p publishedPrice) expired(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(p.publishedAt) >= ttl
+}
+
+// NewChangeOnlySink wraps sink to receive only selections whose price moved
+func NewChangeOnlySink(sink OddsSink, config ChangeOnlyConfig) *ChangeOnlySink {
+	return &ChangeOnlySink{
+		sink:         sink,
+		minChange:    config.MinChange,
+		minChangePct: config.MinChangePct,
+		ttl:          config.TTL,
+		now:          time.Now,
+		last:         make(map[string]publishedPrice),
+	}
+}
+
+// Publish publishes the selections of the batch that are new or moved,
+// skipping the wrapped sink when none are
+func (s *ChangeOnlySink) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	now := s.now()
+
+	s.mu.Lock()
+	s.sweep(now)
+	changed := make([]*models.OptimizedOdds, 0, len(odds))
+	for _, o := range odds {
+		last, ok := s.last[changeKey(o)]
+		if !ok || last.expired(s.ttl, now) || s.moved(last.back, o.OptimizedBack) || s.moved(last.lay, o.OptimizedLay) {
+			changed = append(changed, o)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := s.sink.Publish(ctx, changed); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range changed {
+		s.last[changeKey(o)] = publishedPrice{back: o.OptimizedBack, lay: o.OptimizedLay, publishedAt: now}
+	}
+	return nil
+}
+
+// sweep drops expired prices, at most once per TTL, so memory holds only
+// selections published within the last two TTLs. Callers must hold mu.
+func (s *ChangeOnlySink) sweep(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	for key, last := range s.last {
+		if last.expired(s.ttl, now) {
+			delete(s.last, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// moved reports whether a price moved from last by at least a threshold
+func (s *ChangeOnlySink) moved(last, current decimal.Decimal) bool {
+	diff := current.Sub(last).Abs()
+	if s.minChange.IsPositive() && diff.GreaterThanOrEqual(s.minChange) {
+		return true
+	}
+	if s.minChangePct.IsPositive() && last.IsPositive() && diff.GreaterThanOrEqual(last.Mul(s.minChangePct)) {
+		return true
+	}
+	if !s.minChange.IsPositive() && !s.minChangePct.IsPositive() {
+		return !diff.IsZero()
+	}
+	return false
+}
+
+// changeKey identifies a selection, including the line of a line market
+func changeKey(odds *models.OptimizedOdds) string {
+	return odds.EventID + ":" + odds.Market + ":" + odds.Line.String() + ":" + odds.Selection
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// newChangeOdds creates optimized odds for a selection at a back and lay price
+func newChangeOdds(selection string, back, lay float64) *models.OptimizedOdds {
+	return &models.OptimizedOdds{
+		EventID:       "event-123",
+		Market:        "match_winner",
+		Selection:     selection,
+		OptimizedBack: decimal.NewFromFloat(back),
+		OptimizedLay:  decimal.NewFromFloat(lay),
+	}
+}
+
+// TestChangeOnlySink tests that across two consecutive batches unchanged and
+// barely moved selections are suppressed while moved ones are published
+func TestChangeOnlySink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sink := mocks.NewMockOddsSink(ctrl)
+	changeOnly := NewChangeOnlySink(sink, ChangeOnlyConfig{MinChangePct: decimal.NewFromFloat(0.01)})
+
+	first := []*models.OptimizedOdds{
+		newChangeOdds("Home", 2.50, 2.40),
+		newChangeOdds("Draw", 3.40, 3.30),
+		newChangeOdds("Away", 3.00, 2.90),
+	}
+	sink.EXPECT().Publish(gomock.Any(), first).Return(nil)
+	require.NoError(t, changeOnly.Publish(context.Background(), first))
+
+	second := []*models.OptimizedOdds{
+		newChangeOdds("Home", 2.50, 2.40), // Unchanged
+		newChangeOdds("Draw", 3.42, 3.30), // Back moved under 1%
+		newChangeOdds("Away", 3.00, 2.80), // Lay moved over 1%
+	}
+	sink.EXPECT().Publish(gomock.Any(), []*models.OptimizedOdds{second[2]}).Return(nil)
+	require.NoError(t, changeOnly.Publish(context.Background(), second))
+
+	// Moves accumulate against the last published price, not the last seen one
+	third := []*models.OptimizedOdds{newChangeOdds("Draw", 3.44, 3.30)}
+	sink.EXPECT().Publish(gomock.Any(), third).Return(nil)
+	require.NoError(t, changeOnly.Publish(context.Background(), third))
+
+	// Nothing changed: the wrapped sink is not called
+	require.NoError(t, changeOnly.Publish(context.Background(), third))
+}
+
+// TestChangeOnlySink_FailedPublish tests a selection whose publish failed is
+// retried in the next batch even though its price did not move
+func TestChangeOnlySink_FailedPublish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sink := mocks.NewMockOddsSink(ctrl)
+	changeOnly := NewChangeOnlySink(sink, ChangeOnlyConfig{MinChange: decimal.NewFromFloat(0.01)})
+
+	odds := []*models.OptimizedOdds{newChangeOdds("Home", 2.50, 2.40)}
+	gomock.InOrder(
+		sink.EXPECT().Publish(gomock.Any(), odds).Return(errors.New("connection refused")),
+		sink.EXPECT().Publish(gomock.Any(), odds).Return(nil),
+	)
+
+	assert.Error(t, changeOnly.Publish(context.Background(), odds))
+	require.NoError(t, changeOnly.Publish(context.Background(), odds))
+	require.NoError(t, changeOnly.Publish(context.Background(), odds))
+}
+
+// TestChangeOnlySink_TTL tests that a remembered price expires after the TTL,
+// republishing its unchanged selection, and that expired prices are evicted
+func TestChangeOnlySink_TTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sink := mocks.NewMockOddsSink(ctrl)
+	changeOnly := NewChangeOnlySink(sink, ChangeOnlyConfig{TTL: time.Minute})
+	now := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+	changeOnly.now = func() time.Time { return now }
+
+	home := []*models.OptimizedOdds{newChangeOdds("Home", 2.50, 2.40)}
+	away := []*models.OptimizedOdds{newChangeOdds("Away", 3.00, 2.90)}
+	sink.EXPECT().Publish(gomock.Any(), home).Return(nil).Times(2)
+	sink.EXPECT().Publish(gomock.Any(), away).Return(nil)
+
+	require.NoError(t, changeOnly.Publish(context.Background(), home))
+	now = now.Add(30 * time.Second)
+	require.NoError(t, changeOnly.Publish(context.Background(), away))
+	require.NoError(t, changeOnly.Publish(context.Background(), home)) // Remembered: suppressed
+
+	// Home's price has expired, so it is published again although unchanged
+	now = now.Add(45 * time.Second)
+	require.NoError(t, changeOnly.Publish(context.Background(), home))
+	assert.Len(t, changeOnly.last, 2)
+
+	// Away is never published again; the next sweep evicts it
+	now = now.Add(time.Minute)
+	require.NoError(t, changeOnly.Publish(context.Background(), nil))
+	assert.Empty(t, changeOnly.last)
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// ClosingLineStore is an interface that abstracts storage of closing lines
+// This allows for easier testing and mocking
+type ClosingLineStore interface {
+	Capture(ctx context.Context, oddsList []*models.OptimizedOdds) error
+	// GetByEvent returns an event's closing lines, or none before capture
+	GetByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error)
+	Close() error
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// closingLineRetention is how long after an event's start its capture is
+// remembered, so in-play updates never schedule it again
+const closingLineRetention = 24 * time.Hour
+
+// ClosingLineScheduler captures each event's closing line: shortly before
+// the event starts, the cached optimized prices of all its selections are
+// copied to a ClosingLineStore. It is registered as an OddsSink, learning
+// start times from the optimized odds flowing through the consumer.
+type ClosingLineScheduler struct {
+	cache         Cache
+	store         ClosingLineStore
+	lead          time.Duration
+	checkInterval time.Duration
+	logger        zerolog.Logger
+
+	mu       sync.Mutex
+	pending  map[string]time.Time // Event ID to start time, awaiting capture
+	captured map[string]time.Time // Event ID to start time, already captured or missed
+}
+
+// ClosingLineSchedulerConfig holds closing line scheduler configuration
+type ClosingLineSchedulerConfig struct {
+	Lead          time.Duration // Capture this long before the start time (default 1m)
+	CheckInterval time.Duration // How often due events are looked for (default 10s)
+}
+
+// NewClosingLineScheduler creates a new closing line scheduler
+func NewClosingLineScheduler(cache Cache, store ClosingLineStore, config ClosingLineSchedulerConfig, logger zerolog.Logger) *ClosingLineScheduler {
+	if config.Lead <= 0 {
+		config.Lead = time.Minute
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 10 * time.Second
+	}
+
+	return &ClosingLineScheduler{
+		cache:         cache,
+		store:         store,
+		lead:          config.Lead,
+		checkInterval: config.CheckInterval,
+		logger:        logger.With().Str("component", "closing_line_scheduler").Logger(),
+		pending:       make(map[string]time.Time),
+		captured:      make(map[string]time.Time),
+	}
+}
+
+// Publish schedules the events of pre-match odds with a start time. A later
+// start time reschedules an event, even one already captured.
+func (s *ClosingLineScheduler) Publish(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, odds := range oddsList {
+		if odds.InPlay || odds.StartTime.IsZero() {
+			continue
+		}
+		if start, ok := s.captured[odds.EventID]; ok && !odds.StartTime.After(start) {
+			continue
+		}
+		delete(s.captured, odds.EventID)
+		s.pending[odds.EventID] = odds.StartTime
+	}
+	return nil
+}
+
+// Start captures due closing lines every check interval, until ctx is done
+func (s *ClosingLineScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-ticker.C:
+			s.check(ctx, now)
+		}
+	}
+}
+
+// check captures every event starting within the lead time. Events whose
+// start passed before they could be captured are dropped, since their
+// cached prices may already be in-play; failed captures are retried.
+func (s *ClosingLineScheduler) check(ctx context.Context, now time.Time) {
+	for eventID, start := range s.due(now) {
+		if !now.Before(start) {
+			s.logger.Warn().
+				Str("event_id", eventID).
+				Time("start_time", start).
+				Msg("event started before its closing line was captured")
+			s.markCaptured(eventID, start)
+			continue
+		}
+
+		if err := s.capture(ctx, eventID); err != nil {
+			s.logger.Error().Err(err).Str("event_id", eventID).Msg("failed to capture closing line, will retry")
+			continue
+		}
+		s.markCaptured(eventID, start)
+	}
+
+	s.prune(now)
+}
+
+// due returns the pending events starting within the lead time
+func (s *ClosingLineScheduler) due(now time.Time) map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make(map[string]time.Time)
+	for eventID, start := range s.pending {
+		if !now.Before(start.Add(-s.lead)) {
+			due[eventID] = start
+		}
+	}
+	return due
+}
+
+// capture copies an event's cached optimized odds to the closing line store
+func (s *ClosingLineScheduler) capture(ctx context.Context, eventID string) error {
+	oddsList, err := s.cache.GetByEvent(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Capture(ctx, oddsList); err != nil {
+		return err
+	}
+
+	s.logger.Info().
+		Str("event_id", eventID).
+		Int("count", len(oddsList)).
+		Msg("captured closing line")
+
+	return nil
+}
+
+// markCaptured moves an event from pending to captured, unless it was
+// rescheduled meanwhile
+func (s *ClosingLineScheduler) markCaptured(eventID string, start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending[eventID].Equal(start) {
+		delete(s.pending, eventID)
+		s.captured[eventID] = start
+	}
+}
+
+// prune forgets captures of events that started over closingLineRetention ago
+func (s *ClosingLineScheduler) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for eventID, start := range s.captured {
+		if now.Sub(start) > closingLineRetention {
+			delete(s.captured, eventID)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// TestClosingLineScheduler tests capturing an event's cached prices within
+// the lead time, once, and retrying failed captures
+func TestClosingLineScheduler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	mockStore := mocks.NewMockClosingLineStore(ctrl)
+	scheduler := NewClosingLineScheduler(mockCache, mockStore, ClosingLineSchedulerConfig{Lead: time.Minute}, zerolog.Nop())
+	ctx := context.Background()
+
+	kickoff := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	newOdds := func(eventID string, start time.Time, inPlay bool) *models.OptimizedOdds {
+		return &models.OptimizedOdds{EventID: eventID, Market: "match_winner", Selection: "Team A", StartTime: start, InPlay: inPlay}
+	}
+	require.NoError(t, scheduler.Publish(ctx, []*models.OptimizedOdds{
+		newOdds("event-123", kickoff, false),
+		newOdds("event-456", time.Time{}, false), // No start time
+		newOdds("event-789", kickoff, true),      // Already in play
+		newOdds("event-999", kickoff.Add(-time.Hour), false),
+	}))
+
+	// Before the lead time nothing is captured, except the event already
+	// started, which is dropped without capture
+	scheduler.check(ctx, kickoff.Add(-2*time.Minute))
+
+	// Within the lead time the cached prices are captured; a failure is retried
+	cached := []*models.OptimizedOdds{newOdds("event-123", kickoff, false)}
+	mockCache.EXPECT().GetByEvent(gomock.Any(), "event-123").Return(cached, nil).Times(2)
+	gomock.InOrder(
+		mockStore.EXPECT().Capture(gomock.Any(), cached).Return(errors.New("connection refused")),
+		mockStore.EXPECT().Capture(gomock.Any(), cached).Return(nil),
+	)
+	scheduler.check(ctx, kickoff.Add(-30*time.Second))
+	scheduler.check(ctx, kickoff.Add(-20*time.Second))
+
+	// Captured once: later checks and pre-match updates do not capture again
+	require.NoError(t, scheduler.Publish(ctx, cached))
+	scheduler.check(ctx, kickoff.Add(-10*time.Second))
+	assert.Empty(t, scheduler.pending)
+
+	// A postponed event is captured again before its new start
+	postponed := kickoff.Add(2 * time.Hour)
+	require.NoError(t, scheduler.Publish(ctx, []*models.OptimizedOdds{newOdds("event-123", postponed, false)}))
+	mockCache.EXPECT().GetByEvent(gomock.Any(), "event-123").Return(cached, nil)
+	mockStore.EXPECT().Capture(gomock.Any(), cached).Return(nil)
+	scheduler.check(ctx, postponed.Add(-time.Minute))
+	assert.Empty(t, scheduler.pending)
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+)
+
+// Deduplicator is an interface that abstracts the store of recently processed batches
+// This allows for easier testing and mocking
+type Deduplicator interface {
+	// Seen reports whether key was marked processed within the window
+	Seen(ctx context.Context, key string) (bool, error)
+	// Mark records key as processed for the window
+	Mark(ctx context.Context, key string) error
+	Close() error
+}
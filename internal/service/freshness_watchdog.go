@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// ErrNoFreshData is returned by FreshnessWatchdog.Fresh while the newest odds
+// written by any instance are older than the staleness threshold
+var ErrNoFreshData = errors.New("no fresh odds data")
+
+// FreshnessSource reports the newest OptimizedAt written to the shared cache
+// by any instance, or the zero time when none has been written
+type FreshnessSource interface {
+	LastOptimizedAt(ctx context.Context) (time.Time, error)
+}
+
+// FreshnessWatchdog is a dead-man's switch on the odds feed: it periodically
+// checks the newest OptimizedAt written cluster-wide and trips once it is
+// older than the staleness threshold, e.g. when Kafka stops entirely and the
+// cache is slowly expiring. Fresh fails while tripped, so odds reads can
+// refuse to serve increasingly stale prices; the switch clears as soon as
+// fresh odds are written again.
+type FreshnessWatchdog struct {
+	source       FreshnessSource
+	maxStaleness time.Duration
+	interval     time.Duration
+	stale        prometheus.Gauge
+	logger       zerolog.Logger
+
+	mu              sync.Mutex
+	tripped         bool
+	lastOptimizedAt time.Time // As of the last successful check
+}
+
+// FreshnessWatchdogConfig holds freshness watchdog configuration
+type FreshnessWatchdogConfig struct {
+	MaxStaleness  time.Duration // Age of the newest odds at which the switch trips (default 2m)
+	CheckInterval time.Duration // How often the watermark is checked (default 10s)
+
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// NewFreshnessWatchdog creates a new freshness watchdog
+func NewFreshnessWatchdog(source FreshnessSource, config FreshnessWatchdogConfig, logger zerolog.Logger) *FreshnessWatchdog {
+	if config.MaxStaleness <= 0 {
+		config.MaxStaleness = 2 * time.Minute
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 10 * time.Second
+	}
+
+	w := &FreshnessWatchdog{
+		source:       source,
+		maxStaleness: config.MaxStaleness,
+		interval:     config.CheckInterval,
+		stale: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "odds_freshness_stale",
+			Help: "1 while no instance has written odds within the staleness threshold and odds reads are refused, 0 otherwise.",
+		}),
+		logger: logger.With().Str("component", "freshness_watchdog").Logger(),
+	}
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(w.stale)
+	}
+
+	return w
+}
+
+// Start checks freshness immediately and then every interval, until ctx is done
+func (w *FreshnessWatchdog) Start(ctx context.Context) {
+	w.check(ctx, time.Now())
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case now := <-ticker.C:
+			w.check(ctx, now)
+		}
+	}
+}
+
+// Fresh returns ErrNoFreshData, with the age of the newest odds, while the
+// switch is tripped
+func (w *FreshnessWatchdog) Fresh() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.tripped {
+		return nil
+	}
+	if w.lastOptimizedAt.IsZero() {
+		return fmt.Errorf("%w: no odds written", ErrNoFreshData)
+	}
+	return fmt.Errorf("%w: newest odds optimized at %s", ErrNoFreshData, w.lastOptimizedAt.Format(time.RFC3339))
+}
+
+// check reads the watermark and trips or clears the switch as of now. A
+// failed read leaves the switch as it was: the odds reads it guards fail on
+// their own if the cache is down.
+func (w *FreshnessWatchdog) check(ctx context.Context, now time.Time) {
+	lastOptimizedAt, err := w.source.LastOptimizedAt(ctx)
+	if err != nil {
+		w.logger.Warn().Err(err).Msg("failed to read odds freshness, keeping previous state")
+		return
+	}
+	tripped := lastOptimizedAt.IsZero() || now.Sub(lastOptimizedAt) > w.maxStaleness
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case tripped && !w.tripped:
+		w.logger.Error().
+			Time("last_optimized_at", lastOptimizedAt).
+			Dur("max_staleness", w.maxStaleness).
+			Msg("no fresh odds data, refusing odds reads")
+	case !tripped && w.tripped:
+		w.logger.Info().Time("last_optimized_at", lastOptimizedAt).Msg("fresh odds data resumed, serving odds reads")
+	}
+
+	w.tripped = tripped
+	w.lastOptimizedAt = lastOptimizedAt
+	if tripped {
+		w.stale.Set(1)
+	} else {
+		w.stale.Set(0)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFreshnessSource reports a settable watermark or error
+type fakeFreshnessSource struct {
+	last time.Time
+	err  error
+}
+
+func (s *fakeFreshnessSource) LastOptimizedAt(context.Context) (time.Time, error) {
+	return s.last, s.err
+}
+
+// TestFreshnessWatchdog tests that the switch trips once the newest odds
+// written cluster-wide outlive the threshold, and clears once fresh odds
+// flow again
+func TestFreshnessWatchdog(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC)
+	source := &fakeFreshnessSource{last: now.Add(-10 * time.Second)}
+	watchdog := NewFreshnessWatchdog(source, FreshnessWatchdogConfig{
+		MaxStaleness: time.Minute,
+		Registerer:   prometheus.NewRegistry(),
+	}, zerolog.Nop())
+
+	// Fresh until checked otherwise
+	assert.NoError(t, watchdog.Fresh())
+
+	watchdog.check(ctx, now)
+	assert.NoError(t, watchdog.Fresh())
+	assert.Equal(t, 0.0, testutil.ToFloat64(watchdog.stale))
+
+	// Kafka stops: the watermark ages past the threshold
+	watchdog.check(ctx, now.Add(50*time.Second))
+	assert.NoError(t, watchdog.Fresh())
+	watchdog.check(ctx, now.Add(2*time.Minute))
+	err := watchdog.Fresh()
+	assert.ErrorIs(t, err, ErrNoFreshData)
+	assert.Contains(t, err.Error(), "2026-03-01T14:59:50Z")
+	assert.Equal(t, 1.0, testutil.ToFloat64(watchdog.stale))
+
+	// A failed read keeps the switch tripped
+	source.err = errors.New("connection refused")
+	watchdog.check(ctx, now.Add(3*time.Minute))
+	assert.ErrorIs(t, watchdog.Fresh(), ErrNoFreshData)
+
+	// Fresh odds clear it
+	source.err = nil
+	source.last = now.Add(3 * time.Minute)
+	watchdog.check(ctx, now.Add(3*time.Minute+time.Second))
+	assert.NoError(t, watchdog.Fresh())
+	assert.Equal(t, 0.0, testutil.ToFloat64(watchdog.stale))
+}
+
+// TestFreshnessWatchdog_NoData tests that a cache no instance has written
+// odds to trips the switch, and a failed first read leaves it clear
+func TestFreshnessWatchdog_NoData(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	failing := NewFreshnessWatchdog(&fakeFreshnessSource{err: errors.New("timeout")}, FreshnessWatchdogConfig{}, zerolog.Nop())
+	failing.check(ctx, now)
+	assert.NoError(t, failing.Fresh())
+
+	empty := NewFreshnessWatchdog(&fakeFreshnessSource{}, FreshnessWatchdogConfig{}, zerolog.Nop())
+	empty.check(ctx, now)
+	err := empty.Fresh()
+	assert.ErrorIs(t, err, ErrNoFreshData)
+	assert.Contains(t, err.Error(), "no odds written")
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// History is an interface that abstracts the time-indexed snapshot store
+// This allows for easier testing and mocking
+type History interface {
+	Append(ctx context.Context, oddsList []*models.OptimizedOdds) error
+	// At returns the latest snapshot at or before at, or nil if there is none
+	At(ctx context.Context, eventID, market, selection string, at time.Time) (*models.OptimizedOdds, error)
+	// MarketAt returns the latest snapshot at or before at of each selection of a market
+	MarketAt(ctx context.Context, eventID, market string, at time.Time) ([]*models.OptimizedOdds, error)
+	// Recent returns up to n of the latest snapshots, newest first
+	Recent(ctx context.Context, eventID, market, selection string, n int) ([]*models.OptimizedOdds, error)
+	// RecentBatch returns Recent of every key in one round trip, in key order
+	RecentBatch(ctx context.Context, keys []models.SelectionKey, n int) ([][]*models.OptimizedOdds, error)
+	Close() error
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// HistoryPrices reads selections' recent optimized back prices from a History
+// store ahead of optimizing, so the optimizer can weight confidence by price
+// stability without reading history itself
+type HistoryPrices struct {
+	history             History
+	window              int
+	normalizeSelections bool
+	timeout             time.Duration
+	logger              zerolog.Logger
+}
+
+// NewHistoryPrices creates a price reader over history for params' stability
+// window; each batch read is bounded by timeout (0 disables)
+func NewHistoryPrices(history History, params models.OptimizationParams, timeout time.Duration, logger zerolog.Logger) *HistoryPrices {
+	return &HistoryPrices{
+		history:             history,
+		window:              optimizer.StabilityWindow(params),
+		normalizeSelections: params.NormalizeSelections,
+		timeout:             timeout,
+		logger:              logger.With().Str("component", "history_prices").Logger(),
+	}
+}
+
+// Attach sets the RecentBackPrices of every odds in batch, reading all of
+// their selections' history in one round trip. A failed read is logged and
+// leaves the batch without prices, which the optimizer weighs as stable. A
+// nil HistoryPrices attaches nothing.
+func (p *HistoryPrices) Attach(ctx context.Context, batch []*models.NormalizedOdds) {
+	if p == nil || len(batch) == 0 {
+		return
+	}
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	keys := make([]models.SelectionKey, len(batch))
+	for i, odds := range batch {
+		selection := odds.Selection
+		if p.normalizeSelections {
+			selection = optimizer.CanonicalSelection(selection)
+		}
+		keys[i] = models.SelectionKey{EventID: odds.EventID, Market: odds.Market, Selection: selection}
+	}
+
+	snapshots, err := p.history.RecentBatch(ctx, keys, p.window)
+	if err != nil {
+		p.logger.Warn().
+			Err(err).
+			Int("odds_count", len(batch)).
+			Msg("failed to read price history, ignoring stability")
+		return
+	}
+
+	for i, odds := range batch {
+		prices := make([]decimal.Decimal, len(snapshots[i]))
+		for j, snapshot := range snapshots[i] {
+			prices[j] = snapshot.OptimizedBack
+		}
+		odds.RecentBackPrices = prices
+	}
+}
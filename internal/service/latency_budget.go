@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// SetLatencyBudget sets how long OptimizeOdds and OptimizeBatch may take
+// before optional enrichments are skipped. Pricing, the publish floor and the
+// cache write always run; the history write runs only while budget remains,
+// bounded by what remains, and results are flagged Degraded when it is
+// skipped or cut short. 0 disables the budget.
+func (s *OptimizerService) SetLatencyBudget(budget time.Duration) {
+	s.latencyBudget = budget
+}
+
+// enrich runs the optional steps after a synchronous optimization started at
+// start: the long-term store queue, which never blocks, then the history
+// write within the latency budget. It reports whether the history write was
+// skipped or cut short to meet the budget.
+func (s *OptimizerService) enrich(ctx context.Context, start time.Time, optimized []*models.OptimizedOdds) bool {
+	s.persist(ctx, optimized)
+
+	if s.latencyBudget <= 0 {
+		s.recordHistory(ctx, optimized)
+		return false
+	}
+	if s.history == nil || len(optimized) == 0 {
+		return false
+	}
+
+	remaining := s.latencyBudget - time.Since(start)
+	if remaining <= 0 {
+		s.logger.Warn().
+			Dur("latency_budget", s.latencyBudget).
+			Int("count", len(optimized)).
+			Msg("latency budget spent, skipping optimized odds history")
+		return true
+	}
+
+	historyCtx, cancel := context.WithTimeout(ctx, remaining)
+	defer cancel()
+	s.recordHistory(historyCtx, optimized)
+	return historyCtx.Err() != nil && ctx.Err() == nil
+}
+
+// markDegraded returns copies of optimized flagged Degraded, leaving the
+// cached odds unflagged
+func markDegraded(optimized []*models.OptimizedOdds) []*models.OptimizedOdds {
+	marked := make([]*models.OptimizedOdds, len(optimized))
+	for i, odds := range optimized {
+		degraded := *odds
+		degraded.Degraded = true
+		marked[i] = &degraded
+	}
+	return marked
+}
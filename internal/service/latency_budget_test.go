@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// blockUntilDone stands in for a history write slower than any budget
+func blockUntilDone(ctx context.Context, _ []*models.OptimizedOdds) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// TestOptimizeOdds_LatencyBudget tests that a tight budget cuts a slow
+// history write short, still caching and returning a valid price flagged
+// degraded
+func TestOptimizeOdds_LatencyBudget(t *testing.T) {
+	svc, mockCache := newTestOptimizerService(t)
+	history := mocks.NewMockHistory(gomock.NewController(t))
+	svc.SetHistory(history)
+	svc.SetLatencyBudget(20 * time.Millisecond)
+
+	var cached *models.OptimizedOdds
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, odds *models.OptimizedOdds) error {
+		cached = odds
+		return nil
+	})
+	history.EXPECT().Append(gomock.Any(), gomock.Len(1)).DoAndReturn(blockUntilDone)
+
+	start := time.Now()
+	optimized, err := svc.OptimizeOdds(context.Background(), newTestNormalizedOdds("Team A", 2.50))
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.True(t, optimized.Degraded)
+	assert.True(t, optimized.OptimizedBack.GreaterThan(optimized.OriginalBack))
+	assert.Less(t, elapsed, time.Second)
+
+	require.NotNil(t, cached)
+	assert.False(t, cached.Degraded, "cached odds must not carry the response flag")
+	assert.True(t, cached.OptimizedBack.Equal(optimized.OptimizedBack))
+}
+
+// TestOptimizeBatch_LatencyBudget tests budgets that leave room for history,
+// budgets already spent before it starts, and no budget at all
+func TestOptimizeBatch_LatencyBudget(t *testing.T) {
+	batch := func() []*models.NormalizedOdds {
+		return []*models.NormalizedOdds{
+			newTestNormalizedOdds("Team A", 2.50),
+			newTestNormalizedOdds("Team B", 1.6667), // A fair book: its prices are not re-normalized
+		}
+	}
+
+	t.Run("Within budget", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		history := mocks.NewMockHistory(gomock.NewController(t))
+		svc.SetHistory(history)
+		svc.SetLatencyBudget(time.Minute)
+		mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Len(2)).Return(nil)
+		history.EXPECT().Append(gomock.Any(), gomock.Len(2)).Return(nil)
+
+		optimized, err := svc.OptimizeBatch(context.Background(), batch())
+
+		require.NoError(t, err)
+		require.Len(t, optimized, 2)
+		for _, odds := range optimized {
+			assert.False(t, odds.Degraded)
+		}
+	})
+
+	t.Run("Budget spent", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		history := mocks.NewMockHistory(gomock.NewController(t))
+		svc.SetHistory(history)
+		svc.SetLatencyBudget(time.Nanosecond)
+		mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Len(2)).Return(nil)
+		history.EXPECT().Append(gomock.Any(), gomock.Any()).Times(0)
+
+		optimized, err := svc.OptimizeBatch(context.Background(), batch())
+
+		require.NoError(t, err)
+		require.Len(t, optimized, 2)
+		for _, odds := range optimized {
+			assert.True(t, odds.Degraded)
+			assert.True(t, odds.OptimizedBack.GreaterThan(odds.OriginalBack))
+		}
+	})
+
+	t.Run("No budget", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		history := mocks.NewMockHistory(gomock.NewController(t))
+		svc.SetHistory(history)
+		mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Len(2)).Return(nil)
+		history.EXPECT().Append(gomock.Any(), gomock.Len(2)).Return(nil)
+
+		optimized, err := svc.OptimizeBatch(context.Background(), batch())
+
+		require.NoError(t, err)
+		assert.False(t, optimized[0].Degraded)
+	})
+}
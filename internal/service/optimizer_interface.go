@@ -9,4 +9,8 @@ import (
 type Optimizer interface {
 	Optimize(normalized *models.NormalizedOdds) (*models.OptimizedOdds, error)
 	BatchOptimize(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error)
+
+	// BatchOptimizeMarket optimizes a batch book by book, removing each
+	// book's overround before margin is applied
+	BatchOptimizeMarket(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error)
 }
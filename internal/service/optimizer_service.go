@@ -1,20 +1,58 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
 	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
 )
 
+var (
+	// ErrHistoryDisabled is returned by history queries when no history store is configured
+	ErrHistoryDisabled = errors.New("odds history is not enabled")
+
+	// ErrSnapshotNotFound is returned when no snapshot exists at or before the requested time
+	ErrSnapshotNotFound = errors.New("no odds snapshot at or before the requested time")
+
+	// ErrClosingLinesDisabled is returned by closing line queries when no closing line store is configured
+	ErrClosingLinesDisabled = errors.New("closing line capture is not enabled")
+
+	// ErrStoreDisabled is returned by store searches when no odds store is configured
+	ErrStoreDisabled = errors.New("odds store is not enabled")
+
+	// ErrBelowPublishConfidence is returned when optimized odds fall below their market's publish confidence floor
+	ErrBelowPublishConfidence = errors.New("optimized odds below minimum publish confidence")
+)
+
 // OptimizerService orchestrates odds optimization with caching
 type OptimizerService struct {
 	optimizer *optimizer.Optimizer
 	cache     Cache
+	history   History
+	prices    *HistoryPrices // Attaches recent prices before optimizing, for the stability factor
+	closing   ClosingLineStore
+	store     *StoreWriter
 	logger    zerolog.Logger
+
+	coalesceEventReads bool               // Share one cache fetch between concurrent reads of an event
+	eventReads         singleflight.Group // Keyed by event ID
+
+	maxServeAge   time.Duration // Cached odds optimized longer ago are not served (0 disables)
+	deleteOverAge bool          // Also delete over-age odds from the cache
+
+	latencyBudget time.Duration // Synchronous optimizations skip history past this (0 disables)
 }
 
 // NewOptimizerService creates a new optimizer service
@@ -32,9 +70,11 @@ func NewOptimizerService(
 
 // GetOptimizedOdds retrieves optimized odds with cache-first strategy
 func (s *OptimizerService) GetOptimizedOdds(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, error) {
+	selection = s.selectionKey(selection)
+
 	// Try cache first
 	cached, err := s.cache.Get(ctx, eventID, market, selection)
-	if err == nil && cached != nil {
+	if err == nil && cached != nil && !s.overAge(ctx, cached) {
 		s.logger.Debug().
 			Str("event_id", eventID).
 			Str("market", market).
@@ -57,12 +97,81 @@ func (s *OptimizerService) GetOptimizedOdds(ctx context.Context, eventID, market
 	return nil, fmt.Errorf("odds not found in cache for event=%s market=%s selection=%s", eventID, market, selection)
 }
 
+// GetOptimizedOddsWithMeta retrieves cached optimized odds like
+// GetOptimizedOdds, with how long ago they were optimized and how long until
+// they expire from the cache
+func (s *OptimizerService) GetOptimizedOddsWithMeta(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, models.CacheMeta, error) {
+	selection = s.selectionKey(selection)
+
+	cached, meta, err := s.cache.GetWithMeta(ctx, eventID, market, selection)
+	if err == nil && cached != nil && !s.overAge(ctx, cached) {
+		return cached, meta, nil
+	}
+
+	if err != nil {
+		s.logger.Warn().
+			Err(err).
+			Str("event_id", eventID).
+			Str("market", market).
+			Str("selection", selection).
+			Msg("cache error, will need normalized odds to optimize")
+	}
+
+	return nil, models.CacheMeta{}, fmt.Errorf("odds not found in cache for event=%s market=%s selection=%s", eventID, market, selection)
+}
+
+// GetOptimizedOddsFuzzy retrieves optimized odds like GetOptimizedOdds and,
+// on an exact miss, falls back to the event's cached selection in the same
+// market whose canonical name matches the requested one. It reports whether
+// the result came from the fallback; the most recently optimized candidate
+// wins when several match, and candidates over the max serve age never do.
+func (s *OptimizerService) GetOptimizedOddsFuzzy(ctx context.Context, eventID, market, selection string) (*models.OptimizedOdds, bool, error) {
+	odds, exactErr := s.GetOptimizedOdds(ctx, eventID, market, selection)
+	if exactErr == nil {
+		return odds, false, nil
+	}
+
+	candidates, err := s.cache.GetByEvent(ctx, eventID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to retrieve odds for event: %w", err)
+	}
+	candidates = s.dropOverAge(ctx, candidates)
+
+	wanted := optimizer.CanonicalSelection(selection)
+	var match *models.OptimizedOdds
+	for _, candidate := range candidates {
+		if candidate.Market != market || optimizer.CanonicalSelection(candidate.Selection) != wanted {
+			continue
+		}
+		if match == nil || candidate.OptimizedAt.After(match.OptimizedAt) {
+			match = candidate
+		}
+	}
+	if match == nil {
+		return nil, false, exactErr
+	}
+
+	s.logger.Debug().
+		Str("event_id", eventID).
+		Str("market", market).
+		Str("selection", selection).
+		Str("matched_selection", match.Selection).
+		Msg("fuzzy match for optimized odds")
+
+	return match, true, nil
+}
+
 // OptimizeOdds optimizes normalized odds and caches the result
 func (s *OptimizerService) OptimizeOdds(ctx context.Context, normalized *models.NormalizedOdds) (*models.OptimizedOdds, error) {
+	start := time.Now()
+
 	// Apply optimization algorithm
-	optimized, err := s.optimizer.Optimize(normalized)
+	optimized, err := s.OptimizeOddsNoCache(ctx, normalized)
 	if err != nil {
-		return nil, fmt.Errorf("optimization failed: %w", err)
+		return nil, err
+	}
+	if floor := s.publishFloor(optimized.Market); optimized.Confidence < floor {
+		return nil, fmt.Errorf("%w: confidence %v below %v for market %s", ErrBelowPublishConfidence, optimized.Confidence, floor, optimized.Market)
 	}
 
 	// Cache the optimized odds
@@ -75,6 +184,9 @@ func (s *OptimizerService) OptimizeOdds(ctx context.Context, normalized *models.
 			Msg("failed to cache optimized odds")
 		// Don't fail the request on cache errors
 	}
+	if s.enrich(ctx, start, []*models.OptimizedOdds{optimized}) {
+		optimized = markDegraded([]*models.OptimizedOdds{optimized})[0]
+	}
 
 	s.logger.Info().
 		Str("event_id", optimized.EventID).
@@ -93,12 +205,14 @@ func (s *OptimizerService) OptimizeBatch(ctx context.Context, normalized []*mode
 	if len(normalized) == 0 {
 		return nil, nil
 	}
+	start := time.Now()
 
 	// Apply batch optimization
-	optimized, err := s.optimizer.BatchOptimize(normalized)
+	optimized, err := s.OptimizeBatchNoCache(ctx, normalized)
 	if err != nil {
-		return nil, fmt.Errorf("batch optimization failed: %w", err)
+		return nil, err
 	}
+	optimized = s.filterPublishable(optimized)
 
 	// Cache all optimized odds in batch
 	if err := s.cache.SetBatch(ctx, optimized); err != nil {
@@ -108,6 +222,9 @@ func (s *OptimizerService) OptimizeBatch(ctx context.Context, normalized []*mode
 			Msg("failed to cache batch of optimized odds")
 		// Don't fail the request on cache errors
 	}
+	if s.enrich(ctx, start, optimized) {
+		optimized = markDegraded(optimized)
+	}
 
 	s.logger.Info().
 		Int("input_count", len(normalized)).
@@ -117,12 +234,95 @@ func (s *OptimizerService) OptimizeBatch(ctx context.Context, normalized []*mode
 	return optimized, nil
 }
 
+// publishFloor returns the minimum confidence at which a market's optimized
+// odds are cached: its MinConfidenceByMarket entry, else MinPublishConfidence
+func (s *OptimizerService) publishFloor(market string) float64 {
+	params := s.optimizer.Params()
+	if floor, ok := params.MinConfidenceByMarket[strings.ToLower(market)]; ok {
+		return floor
+	}
+	return params.MinPublishConfidence
+}
+
+// filterPublishable drops optimized odds below their market's publish floor
+func (s *OptimizerService) filterPublishable(optimized []*models.OptimizedOdds) []*models.OptimizedOdds {
+	kept := optimized[:0]
+	for _, odds := range optimized {
+		if floor := s.publishFloor(odds.Market); odds.Confidence < floor {
+			s.logger.Debug().
+				Str("event_id", odds.EventID).
+				Str("market", odds.Market).
+				Str("selection", odds.Selection).
+				Float64("confidence", odds.Confidence).
+				Float64("min_confidence", floor).
+				Msg("dropped optimized odds below publish confidence")
+			continue
+		}
+		kept = append(kept, odds)
+	}
+	return kept
+}
+
+// CompareMarginModels prices normalized odds under every margin model for
+// side-by-side comparison. Results are not cached or recorded in history.
+func (s *OptimizerService) CompareMarginModels(normalized []*models.NormalizedOdds) (map[optimizer.MarginModel][]*models.OptimizedOdds, error) {
+	results, err := s.optimizer.OptimizeMarketWithModels(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("margin model comparison failed: %w", err)
+	}
+
+	s.logger.Debug().
+		Int("input_count", len(normalized)).
+		Int("models", len(results)).
+		Msg("compared margin models")
+
+	return results, nil
+}
+
+// ExplainOdds optimizes normalized odds and explains how the price was
+// reached. Results are not cached or recorded in history.
+func (s *OptimizerService) ExplainOdds(ctx context.Context, normalized *models.NormalizedOdds) (*models.OptimizedOdds, *optimizer.Explanation, error) {
+	s.prices.Attach(ctx, []*models.NormalizedOdds{normalized})
+	optimized, explanation, err := s.optimizer.Explain(normalized)
+	if err != nil {
+		return nil, nil, fmt.Errorf("optimization failed: %w", err)
+	}
+	return optimized, explanation, nil
+}
+
+// OptimizeOddsNoCache optimizes normalized odds without caching or recording
+// history, for previews and what-if queries
+func (s *OptimizerService) OptimizeOddsNoCache(ctx context.Context, normalized *models.NormalizedOdds) (*models.OptimizedOdds, error) {
+	s.prices.Attach(ctx, []*models.NormalizedOdds{normalized})
+	optimized, err := s.optimizer.Optimize(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("optimization failed: %w", err)
+	}
+	return optimized, nil
+}
+
+// OptimizeBatchNoCache optimizes a batch of normalized odds market by market
+// without caching or recording history, for previews and what-if queries
+func (s *OptimizerService) OptimizeBatchNoCache(ctx context.Context, normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+
+	s.prices.Attach(ctx, normalized)
+	optimized, err := s.optimizer.BatchOptimizeMarket(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("batch optimization failed: %w", err)
+	}
+	return optimized, nil
+}
+
 // GetOptimizedOddsByEvent retrieves all optimized odds for an event from cache
 func (s *OptimizerService) GetOptimizedOddsByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
-	odds, err := s.cache.GetByEvent(ctx, eventID)
+	odds, err := s.getByEvent(ctx, eventID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve odds for event: %w", err)
 	}
+	odds = s.dropOverAge(ctx, odds)
 
 	s.logger.Debug().
 		Str("event_id", eventID).
@@ -131,3 +331,381 @@ func (s *OptimizerService) GetOptimizedOddsByEvent(ctx context.Context, eventID
 
 	return odds, nil
 }
+
+// SetEventReadCoalescing sets whether concurrent GetOptimizedOddsByEvent
+// calls for the same event share one cache fetch, so a hot event read by
+// many clients costs one scan rather than one per client
+func (s *OptimizerService) SetEventReadCoalescing(enabled bool) {
+	s.coalesceEventReads = enabled
+}
+
+// SetMaxServeAge sets how long after they were optimized cached odds are
+// still served, regardless of their remaining TTL; older odds are treated as
+// a miss and, with deleteOverAge, deleted from the cache. 0 disables the limit.
+func (s *OptimizerService) SetMaxServeAge(maxAge time.Duration, deleteOverAge bool) {
+	s.maxServeAge = maxAge
+	s.deleteOverAge = deleteOverAge
+}
+
+// overAge reports whether cached odds are older than the max serve age,
+// deleting them when configured. A failed delete is logged; the odds are
+// still not served.
+func (s *OptimizerService) overAge(ctx context.Context, odds *models.OptimizedOdds) bool {
+	if s.maxServeAge <= 0 {
+		return false
+	}
+	age := time.Since(odds.OptimizedAt)
+	if age <= s.maxServeAge {
+		return false
+	}
+
+	s.logger.Debug().
+		Str("event_id", odds.EventID).
+		Str("market", odds.Market).
+		Str("selection", odds.Selection).
+		Dur("age", age).
+		Msg("cached odds over max serve age")
+
+	if s.deleteOverAge {
+		if err := s.cache.Delete(ctx, odds.EventID, odds.Market, odds.Selection); err != nil {
+			s.logger.Warn().
+				Err(err).
+				Str("event_id", odds.EventID).
+				Str("market", odds.Market).
+				Str("selection", odds.Selection).
+				Msg("failed to delete over-age odds")
+		}
+	}
+	return true
+}
+
+// dropOverAge returns the odds within the max serve age, in a new slice
+func (s *OptimizerService) dropOverAge(ctx context.Context, oddsList []*models.OptimizedOdds) []*models.OptimizedOdds {
+	if s.maxServeAge <= 0 {
+		return oddsList
+	}
+
+	fresh := make([]*models.OptimizedOdds, 0, len(oddsList))
+	for _, odds := range oddsList {
+		if !s.overAge(ctx, odds) {
+			fresh = append(fresh, odds)
+		}
+	}
+	return fresh
+}
+
+// getByEvent fetches an event's cached odds, coalescing concurrent fetches of
+// the same event when enabled. The shared fetch outlives any one caller's
+// cancellation (the cache bounds it with its own timeout) and each caller
+// gets its own copy of the slice, which handlers filter and sort in place.
+func (s *OptimizerService) getByEvent(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
+	if !s.coalesceEventReads {
+		return s.cache.GetByEvent(ctx, eventID)
+	}
+
+	fetch := context.WithoutCancel(ctx)
+	results := s.eventReads.DoChan(eventID, func() (interface{}, error) {
+		return s.cache.GetByEvent(fetch, eventID)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		odds, _ := result.Val.([]*models.OptimizedOdds)
+		if result.Shared {
+			odds = append([]*models.OptimizedOdds(nil), odds...)
+		}
+		return odds, nil
+	}
+}
+
+// GetBookOverround returns the summed implied probability (1 / optimized
+// back price) of an event market's cached selections and how many were
+// summed. A complete book sums above 1 by its overround; a market with no
+// cached selections sums to zero.
+func (s *OptimizerService) GetBookOverround(ctx context.Context, eventID, market string) (decimal.Decimal, int, error) {
+	oddsList, err := s.GetOptimizedOddsByEvent(ctx, eventID)
+	if err != nil {
+		return decimal.Zero, 0, err
+	}
+
+	sum, count := BookOverround(oddsList, market)
+	return sum, count, nil
+}
+
+// BookOverround sums the implied probabilities of market's optimized back
+// prices in oddsList, returning the sum and the number of selections summed
+func BookOverround(oddsList []*models.OptimizedOdds, market string) (decimal.Decimal, int) {
+	sum := decimal.Zero
+	count := 0
+	for _, odds := range oddsList {
+		if odds.Market != market || !odds.OptimizedBack.IsPositive() {
+			continue
+		}
+		sum = sum.Add(decimal.NewFromInt(1).Div(odds.OptimizedBack))
+		count++
+	}
+
+	return sum, count
+}
+
+// ExportOdds calls fn for every cached optimized odds, optionally only an
+// event's (eventID) or a sport's (sport, case-insensitive), stopping at the
+// first error fn returns. Odds are streamed from the cache rather than
+// loaded at once.
+func (s *OptimizerService) ExportOdds(ctx context.Context, eventID, sport string, fn func(*models.OptimizedOdds) error) error {
+	exported := 0
+	err := s.cache.Scan(ctx, eventID, func(odds *models.OptimizedOdds) error {
+		if sport != "" && !strings.EqualFold(odds.Sport, sport) {
+			return nil
+		}
+		exported++
+		return fn(odds)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export odds: %w", err)
+	}
+
+	s.logger.Info().
+		Str("event_id", eventID).
+		Str("sport", sport).
+		Int("count", exported).
+		Msg("exported optimized odds")
+
+	return nil
+}
+
+// importChunkSize is the number of imported odds written per SetBatch
+const importChunkSize = 500
+
+// maxImportLineBytes bounds one NDJSON line of an import
+const maxImportLineBytes = 1 << 20
+
+// ImportResult counts the records of an import
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Failed   int `json:"failed"` // Malformed, missing keys, or in a chunk the cache rejected
+}
+
+// ImportOdds bulk-loads the cache from newline-delimited OptimizedOdds JSON,
+// as produced by ExportOdds, writing in chunks of importChunkSize. Malformed
+// lines and records without an event, market or selection are counted as
+// failed and skipped. An error is returned only when r cannot be read; the
+// result then covers the lines read so far.
+func (s *OptimizerService) ImportOdds(ctx context.Context, r io.Reader) (ImportResult, error) {
+	var result ImportResult
+	chunk := make([]*models.OptimizedOdds, 0, importChunkSize)
+
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		if err := s.cache.SetBatch(ctx, chunk); err != nil {
+			s.logger.Error().Err(err).Int("count", len(chunk)).Msg("failed to import chunk of odds")
+			result.Failed += len(chunk)
+		} else {
+			result.Imported += len(chunk)
+		}
+		chunk = chunk[:0]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var odds models.OptimizedOdds
+		if err := json.Unmarshal(data, &odds); err != nil {
+			s.logger.Warn().Err(err).Int("line", line).Msg("skipping malformed import line")
+			result.Failed++
+			continue
+		}
+		if odds.EventID == "" || odds.Market == "" || odds.Selection == "" {
+			s.logger.Warn().Int("line", line).Msg("skipping imported odds without event, market or selection")
+			result.Failed++
+			continue
+		}
+
+		chunk = append(chunk, &odds)
+		if len(chunk) == importChunkSize {
+			flush()
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read import at line %d: %w", line+1, err)
+	}
+
+	s.logger.Info().
+		Int("imported", result.Imported).
+		Int("failed", result.Failed).
+		Msg("imported optimized odds")
+
+	return result, nil
+}
+
+// GetOptimizedOddsByEvents retrieves all optimized odds for several events,
+// keyed by event ID; events with no odds map to an empty slice
+func (s *OptimizerService) GetOptimizedOddsByEvents(ctx context.Context, eventIDs []string) (map[string][]*models.OptimizedOdds, error) {
+	odds, err := s.cache.GetByEvents(ctx, eventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve odds for events: %w", err)
+	}
+	for eventID, eventOdds := range odds {
+		odds[eventID] = s.dropOverAge(ctx, eventOdds)
+	}
+
+	s.logger.Debug().
+		Int("event_count", len(eventIDs)).
+		Msg("retrieved optimized odds by events")
+
+	return odds, nil
+}
+
+// SetHistory sets an optional snapshot store that records every optimized price
+func (s *OptimizerService) SetHistory(history History) {
+	s.history = history
+}
+
+// SetPriceHistory sets an optional reader of recent prices, attached to odds
+// before they are optimized to weight confidence by price stability
+func (s *OptimizerService) SetPriceHistory(prices *HistoryPrices) {
+	s.prices = prices
+}
+
+// recordHistory snapshots optimized odds; history is best-effort and never fails a request
+func (s *OptimizerService) recordHistory(ctx context.Context, optimized []*models.OptimizedOdds) {
+	if s.history == nil || len(optimized) == 0 {
+		return
+	}
+
+	if err := s.history.Append(ctx, optimized); err != nil {
+		s.logger.Warn().
+			Err(err).
+			Int("count", len(optimized)).
+			Msg("failed to record optimized odds history")
+	}
+}
+
+// GetOptimizedOddsAt retrieves the optimized odds that were current at the given time
+func (s *OptimizerService) GetOptimizedOddsAt(ctx context.Context, eventID, market, selection string, at time.Time) (*models.OptimizedOdds, error) {
+	if s.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	odds, err := s.history.At(ctx, eventID, market, s.selectionKey(selection), at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve odds history: %w", err)
+	}
+	if odds == nil {
+		return nil, ErrSnapshotNotFound
+	}
+
+	return odds, nil
+}
+
+// SetStore sets an optional long-term store that every optimized price is
+// written to asynchronously
+func (s *OptimizerService) SetStore(store *StoreWriter) {
+	s.store = store
+}
+
+// persist queues optimized odds for the long-term store; the write happens
+// off the request path
+func (s *OptimizerService) persist(ctx context.Context, optimized []*models.OptimizedOdds) {
+	if s.store == nil {
+		return
+	}
+	s.store.Publish(ctx, optimized)
+}
+
+// SearchOdds queries the long-term store for up to limit odds of a sport
+// optimized in [from, to), oldest first
+func (s *OptimizerService) SearchOdds(ctx context.Context, sport string, from, to time.Time, limit int) ([]*models.OptimizedOdds, error) {
+	if s.store == nil {
+		return nil, ErrStoreDisabled
+	}
+
+	odds, err := s.store.Search(ctx, sport, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search odds store: %w", err)
+	}
+	return odds, nil
+}
+
+// GetMarketAt retrieves the optimized odds of each selection of a market that
+// were current at the given time. Selections not yet priced, or whose latest
+// price was already over the max serve age at that time, are omitted.
+func (s *OptimizerService) GetMarketAt(ctx context.Context, eventID, market string, at time.Time) ([]*models.OptimizedOdds, error) {
+	if s.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+
+	oddsList, err := s.history.MarketAt(ctx, eventID, market, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve market history: %w", err)
+	}
+	if s.maxServeAge <= 0 {
+		return oddsList, nil
+	}
+
+	current := oddsList[:0]
+	for _, odds := range oddsList {
+		if at.Sub(odds.OptimizedAt) <= s.maxServeAge {
+			current = append(current, odds)
+		}
+	}
+	return current, nil
+}
+
+// SetClosingLines sets an optional store of captured closing lines
+func (s *OptimizerService) SetClosingLines(store ClosingLineStore) {
+	s.closing = store
+}
+
+// GetClosingLines returns the closing lines captured for an event, none
+// before the event's capture
+func (s *OptimizerService) GetClosingLines(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
+	if s.closing == nil {
+		return nil, ErrClosingLinesDisabled
+	}
+
+	oddsList, err := s.closing.GetByEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve closing lines: %w", err)
+	}
+	return oddsList, nil
+}
+
+// selectionKey maps a requested selection to the name odds are cached under
+func (s *OptimizerService) selectionKey(selection string) string {
+	if s.optimizer.Params().NormalizeSelections {
+		return optimizer.CanonicalSelection(selection)
+	}
+	return selection
+}
+
+// Params returns the optimizer's effective parameters
+func (s *OptimizerService) Params() models.OptimizationParams {
+	return s.optimizer.Params()
+}
+
+// OptimizerStats returns the optimizer's runtime counters
+func (s *OptimizerService) OptimizerStats() optimizer.Stats {
+	return s.optimizer.Stats()
+}
+
+// CacheStats returns the cache's operational counters
+func (s *OptimizerService) CacheStats() models.CacheStats {
+	return s.cache.Stats()
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+	"github.com/cypherlabdev/odds-optimizer-service/pkg/optimizer"
+)
+
+// newTestOptimizerService creates a service over a real optimizer and a mock cache
+func newTestOptimizerService(t *testing.T) (*OptimizerService, *mocks.MockCache) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+
+	params := models.OptimizationParams{
+		MinMargin:        decimal.NewFromFloat(0.02),
+		MaxMargin:        decimal.NewFromFloat(0.10),
+		MinSpread:        decimal.NewFromFloat(0.05),
+		TargetConfidence: 0.85,
+	}
+	opt := optimizer.NewOptimizer(params, zerolog.Nop())
+
+	return NewOptimizerService(opt, mockCache, zerolog.Nop()), mockCache
+}
+
+// newTestNormalizedOdds creates valid normalized odds for a selection
+func newTestNormalizedOdds(selection string, backPrice float64) *models.NormalizedOdds {
+	return &models.NormalizedOdds{
+		ID:          uuid.New(),
+		EventID:     "event-123",
+		EventName:   "Team A vs Team B",
+		Sport:       "football",
+		Competition: "Premier League",
+		Market:      "match_winner",
+		Selection:   selection,
+		BackPrice:   decimal.NewFromFloat(backPrice),
+		LayPrice:    decimal.NewFromFloat(backPrice + 0.1),
+		BackSize:    decimal.NewFromFloat(10000),
+		LaySize:     decimal.NewFromFloat(8000),
+		Timestamp:   time.Now(),
+	}
+}
+
+// TestOptimizeOddsNoCache tests that the NoCache variants never write to the cache
+func TestOptimizeOddsNoCache(t *testing.T) {
+	svc, mockCache := newTestOptimizerService(t)
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any()).Times(0)
+	mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).Times(0)
+
+	optimized, err := svc.OptimizeOddsNoCache(context.Background(), newTestNormalizedOdds("Team A", 2.50))
+	require.NoError(t, err)
+	assert.Equal(t, "Team A", optimized.Selection)
+
+	batch, err := svc.OptimizeBatchNoCache(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 1.80),
+	})
+	require.NoError(t, err)
+	assert.Len(t, batch, 2)
+
+	_, err = svc.OptimizeOddsNoCache(context.Background(), newTestNormalizedOdds("Team A", 0.99))
+	assert.ErrorIs(t, err, optimizer.ErrInvalidBackPrice)
+}
+
+// TestOptimizeOdds_Caches tests that the caching variants still write through
+func TestOptimizeOdds_Caches(t *testing.T) {
+	svc, mockCache := newTestOptimizerService(t)
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Len(2)).Return(nil).Times(1)
+
+	_, err := svc.OptimizeOdds(context.Background(), newTestNormalizedOdds("Team A", 2.50))
+	require.NoError(t, err)
+
+	_, err = svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 1.80),
+	})
+	require.NoError(t, err)
+}
+
+// TestOptimize_PublishConfidence tests that a market's publish floor drops
+// odds the global floor would publish
+func TestOptimize_PublishConfidence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockCache := mocks.NewMockCache(ctrl)
+	params := models.OptimizationParams{
+		MinMargin:             decimal.NewFromFloat(0.02),
+		MaxMargin:             decimal.NewFromFloat(0.10),
+		MinSpread:             decimal.NewFromFloat(0.05),
+		TargetConfidence:      0.85,
+		MinPublishConfidence:  0.5,
+		MinConfidenceByMarket: map[string]float64{"match_winner": 0.99, "outright": 0.1},
+	}
+	svc := NewOptimizerService(optimizer.NewOptimizer(params, zerolog.Nop()), mockCache, zerolog.Nop())
+
+	outright := newTestNormalizedOdds("Player X", 6.0)
+	outright.Market = "Outright"
+	handicap := newTestNormalizedOdds("Team A -1", 3.0)
+	handicap.Market = "handicap"
+
+	// Every result clears the global floor
+	preview, err := svc.OptimizeBatchNoCache(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50), outright, handicap,
+	})
+	require.NoError(t, err)
+	for _, odds := range preview {
+		require.Greater(t, odds.Confidence, 0.5)
+	}
+
+	var cached []*models.OptimizedOdds
+	mockCache.EXPECT().SetBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, oddsList []*models.OptimizedOdds) error {
+			cached = oddsList
+			return nil
+		})
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any()).Times(0)
+
+	published, err := svc.OptimizeBatch(context.Background(), []*models.NormalizedOdds{
+		newTestNormalizedOdds("Team A", 2.50),
+		newTestNormalizedOdds("Team B", 1.80),
+		outright,
+		handicap,
+	})
+	require.NoError(t, err)
+	require.Len(t, published, 2)
+	assert.Equal(t, published, cached)
+	assert.Equal(t, "Outright", published[0].Market)
+	assert.Equal(t, "handicap", published[1].Market)
+
+	_, err = svc.OptimizeOdds(context.Background(), newTestNormalizedOdds("Team A", 2.50))
+	assert.ErrorIs(t, err, ErrBelowPublishConfidence)
+}
+
+// TestGetBookOverround tests summing a cached book's implied probabilities
+func TestGetBookOverround(t *testing.T) {
+	cachedOdds := func(market, selection string, back float64) *models.OptimizedOdds {
+		return &models.OptimizedOdds{
+			EventID:       "event-123",
+			Market:        market,
+			Selection:     selection,
+			OptimizedBack: decimal.NewFromFloat(back),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		market    string
+		overround float64
+		count     int
+	}{
+		{name: "Complete book", market: "match_winner", overround: 1.0/1.8 + 1.0/3.5 + 1.0/4.0, count: 3},
+		{name: "Single selection", market: "first_scorer", overround: 1.0 / 5.0, count: 1},
+		{name: "Unknown market", market: "handicap", overround: 0, count: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, mockCache := newTestOptimizerService(t)
+			mockCache.EXPECT().GetByEvent(gomock.Any(), "event-123").Return([]*models.OptimizedOdds{
+				cachedOdds("match_winner", "Team A", 1.8),
+				cachedOdds("match_winner", "Draw", 3.5),
+				cachedOdds("match_winner", "Team B", 4.0),
+				cachedOdds("first_scorer", "Player X", 5.0),
+			}, nil)
+
+			overround, count, err := svc.GetBookOverround(context.Background(), "event-123", tt.market)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.count, count)
+			assert.InDelta(t, tt.overround, overround.InexactFloat64(), 1e-9)
+			if tt.count > 1 {
+				assert.True(t, overround.GreaterThan(decimal.NewFromInt(1)))
+			}
+		})
+	}
+}
+
+// TestGetOptimizedOddsByEvent_Coalesced tests that concurrent reads of the
+// same event share one cache fetch and each get their own slice
+func TestGetOptimizedOddsByEvent_Coalesced(t *testing.T) {
+	const callers = 50
+
+	svc, mockCache := newTestOptimizerService(t)
+	svc.SetEventReadCoalescing(true)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	mockCache.EXPECT().GetByEvent(gomock.Any(), "event-123").DoAndReturn(
+		func(ctx context.Context, eventID string) ([]*models.OptimizedOdds, error) {
+			fetches.Add(1)
+			<-release
+			return []*models.OptimizedOdds{
+				{EventID: eventID, Market: "match_winner", Selection: "Team A"},
+				{EventID: eventID, Market: "match_winner", Selection: "Team B"},
+			}, nil
+		}).MinTimes(1)
+
+	var started, done sync.WaitGroup
+	results := make([][]*models.OptimizedOdds, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			results[i], errs[i] = svc.GetOptimizedOddsByEvent(context.Background(), "event-123")
+		}(i)
+	}
+	started.Wait()
+	time.Sleep(50 * time.Millisecond) // Let every caller join the in-flight fetch
+	close(release)
+	done.Wait()
+
+	assert.Equal(t, int32(1), fetches.Load())
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		require.Len(t, results[i], 2)
+	}
+
+	// Reordering one caller's result leaves the others untouched
+	results[0][0], results[0][1] = results[0][1], results[0][0]
+	assert.Equal(t, "Team A", results[1][0].Selection)
+}
+
+// TestHistoryPrices tests that a batch's snapshots are read in one call and
+// attached as back prices, newest first, and that a failed read attaches none
+func TestHistoryPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	history := mocks.NewMockHistory(ctrl)
+	params := models.OptimizationParams{StabilityWindow: 3, NormalizeSelections: true}
+	prices := NewHistoryPrices(history, params, time.Second, zerolog.Nop())
+
+	batch := []*models.NormalizedOdds{
+		{EventID: "event-123", Market: "match_winner", Selection: "Team A"},
+		{EventID: "event-123", Market: "match_winner", Selection: "Team B"},
+	}
+	history.EXPECT().RecentBatch(gomock.Any(), []models.SelectionKey{
+		{EventID: "event-123", Market: "match_winner", Selection: "team a"},
+		{EventID: "event-123", Market: "match_winner", Selection: "team b"},
+	}, 3).Return([][]*models.OptimizedOdds{
+		{{OptimizedBack: decimal.NewFromFloat(2.30)}, {OptimizedBack: decimal.NewFromFloat(2.20)}},
+		nil,
+	}, nil)
+
+	prices.Attach(context.Background(), batch)
+	require.Len(t, batch[0].RecentBackPrices, 2)
+	assert.True(t, decimal.NewFromFloat(2.30).Equal(batch[0].RecentBackPrices[0]))
+	assert.True(t, decimal.NewFromFloat(2.20).Equal(batch[0].RecentBackPrices[1]))
+	assert.Empty(t, batch[1].RecentBackPrices)
+
+	failed := []*models.NormalizedOdds{{EventID: "event-123", Market: "match_winner", Selection: "Team A"}}
+	history.EXPECT().RecentBatch(gomock.Any(), gomock.Any(), 3).Return(nil, errors.New("redis down"))
+	prices.Attach(context.Background(), failed)
+	assert.Empty(t, failed[0].RecentBackPrices)
+
+	// Without a price reader nothing is read
+	var none *HistoryPrices
+	none.Attach(context.Background(), batch)
+}
+
+// TestGetOptimizedOdds_MaxServeAge tests cached odds within the max serve age
+// are served and older ones are a miss, deleted from the cache when configured
+func TestGetOptimizedOdds_MaxServeAge(t *testing.T) {
+	cachedOdds := func(selection string, age time.Duration) *models.OptimizedOdds {
+		return &models.OptimizedOdds{
+			EventID:     "event-123",
+			Market:      "match_winner",
+			Selection:   selection,
+			OptimizedAt: time.Now().Add(-age),
+		}
+	}
+
+	t.Run("Within age", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		svc.SetMaxServeAge(time.Minute, true)
+		fresh := cachedOdds("Team A", 30*time.Second)
+		mockCache.EXPECT().Get(gomock.Any(), "event-123", "match_winner", "Team A").Return(fresh, nil)
+
+		odds, err := svc.GetOptimizedOdds(context.Background(), "event-123", "match_winner", "Team A")
+		require.NoError(t, err)
+		assert.Same(t, fresh, odds)
+	})
+
+	t.Run("Over age", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		svc.SetMaxServeAge(time.Minute, true)
+		mockCache.EXPECT().Get(gomock.Any(), "event-123", "match_winner", "Team A").Return(cachedOdds("Team A", 2*time.Minute), nil)
+		mockCache.EXPECT().Delete(gomock.Any(), "event-123", "match_winner", "Team A").Return(nil)
+
+		_, err := svc.GetOptimizedOdds(context.Background(), "event-123", "match_winner", "Team A")
+		assert.Error(t, err)
+	})
+
+	t.Run("Over age without delete", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		svc.SetMaxServeAge(time.Minute, false)
+		mockCache.EXPECT().GetWithMeta(gomock.Any(), "event-123", "match_winner", "Team A").
+			Return(cachedOdds("Team A", 2*time.Minute), models.CacheMeta{TTLRemaining: time.Hour}, nil)
+
+		_, _, err := svc.GetOptimizedOddsWithMeta(context.Background(), "event-123", "match_winner", "Team A")
+		assert.Error(t, err)
+	})
+
+	t.Run("By event", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		svc.SetMaxServeAge(time.Minute, true)
+		mockCache.EXPECT().GetByEvent(gomock.Any(), "event-123").Return([]*models.OptimizedOdds{
+			cachedOdds("Team A", 30*time.Second),
+			cachedOdds("Team B", 2*time.Minute),
+		}, nil)
+		mockCache.EXPECT().Delete(gomock.Any(), "event-123", "match_winner", "Team B").Return(nil)
+
+		oddsList, err := svc.GetOptimizedOddsByEvent(context.Background(), "event-123")
+		require.NoError(t, err)
+		require.Len(t, oddsList, 1)
+		assert.Equal(t, "Team A", oddsList[0].Selection)
+	})
+
+	t.Run("Fuzzy", func(t *testing.T) {
+		svc, mockCache := newTestOptimizerService(t)
+		svc.SetMaxServeAge(time.Minute, false)
+		mockCache.EXPECT().Get(gomock.Any(), "event-123", "match_winner", gomock.Any()).Return(nil, errors.New("odds not found in cache")).Times(2)
+		mockCache.EXPECT().GetByEvent(gomock.Any(), "event-123").Return([]*models.OptimizedOdds{
+			cachedOdds("Team A", 2*time.Minute),
+			cachedOdds("Team B", 30*time.Second),
+		}, nil).Times(2)
+
+		_, _, err := svc.GetOptimizedOddsFuzzy(context.Background(), "event-123", "match_winner", "team a")
+		assert.Error(t, err, "an over-age candidate must not be served as a fuzzy match")
+
+		odds, fuzzy, err := svc.GetOptimizedOddsFuzzy(context.Background(), "event-123", "match_winner", "team b")
+		require.NoError(t, err)
+		assert.True(t, fuzzy)
+		assert.Equal(t, "Team B", odds.Selection)
+	})
+}
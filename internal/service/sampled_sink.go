@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// SampledSink publishes a deterministic sample of optimized odds to another
+// sink. Selections are sampled by a hash of event, market and selection, so
+// the same selections are published every time rather than a random subset
+// per batch, and a higher rate samples a superset of a lower one.
+type SampledSink struct {
+	sink OddsSink
+	rate float64
+}
+
+// NewSampledSink wraps sink to receive the fraction rate (0-1) of selections;
+// a rate of 1 or more publishes everything and 0 or less nothing
+func NewSampledSink(sink OddsSink, rate float64) *SampledSink {
+	return &SampledSink{sink: sink, rate: rate}
+}
+
+// Publish publishes the sampled odds of the batch, skipping the wrapped sink
+// when none are sampled
+func (s *SampledSink) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	if s.rate >= 1 {
+		return s.sink.Publish(ctx, odds)
+	}
+
+	sampled := make([]*models.OptimizedOdds, 0, len(odds))
+	for _, o := range odds {
+		if s.sampled(o) {
+			sampled = append(sampled, o)
+		}
+	}
+	if len(sampled) == 0 {
+		return nil
+	}
+	return s.sink.Publish(ctx, sampled)
+}
+
+// sampled reports whether a selection falls in the sample: its hash, mapped
+// onto [0, 1), is below the rate
+func (s *SampledSink) sampled(odds *models.OptimizedOdds) bool {
+	if s.rate <= 0 {
+		return false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(odds.EventID))
+	h.Write([]byte{0})
+	h.Write([]byte(odds.Market))
+	h.Write([]byte{0})
+	h.Write([]byte(odds.Selection))
+
+	// The top 53 bits fill a float64 mantissa exactly
+	return float64(h.Sum64()>>11)/(1<<53) < s.rate
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// newSampleOdds creates n selections across events
+func newSampleOdds(n int) []*models.OptimizedOdds {
+	odds := make([]*models.OptimizedOdds, n)
+	for i := range odds {
+		odds[i] = &models.OptimizedOdds{
+			EventID:   fmt.Sprintf("event-%d", i/3),
+			Market:    "match_winner",
+			Selection: []string{"Home", "Draw", "Away"}[i%3],
+		}
+	}
+	return odds
+}
+
+// TestSampledSink_StableSubset tests a rate samples about that fraction of
+// selections, the same ones every time, and within the sample of any higher rate
+func TestSampledSink_StableSubset(t *testing.T) {
+	odds := newSampleOdds(10000)
+	half := NewSampledSink(nil, 0.5)
+	most := NewSampledSink(nil, 0.8)
+
+	count := 0
+	for _, o := range odds {
+		sampled := half.sampled(o)
+		if sampled {
+			count++
+			assert.True(t, most.sampled(o), "selection sampled at 0.5 but not 0.8")
+		}
+
+		// Same selection in a later batch, with a new price
+		again := *o
+		again.Confidence = 0.5
+		assert.Equal(t, sampled, half.sampled(&again))
+	}
+	assert.InDelta(t, 5000, count, 250)
+
+	// The wrapped sink receives only the sample
+	ctrl := gomock.NewController(t)
+	sink := mocks.NewMockOddsSink(ctrl)
+	sink.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, published []*models.OptimizedOdds) error {
+		assert.Len(t, published, count)
+		for _, o := range published {
+			assert.True(t, half.sampled(o))
+		}
+		return nil
+	})
+	require.NoError(t, NewSampledSink(sink, 0.5).Publish(context.Background(), odds))
+}
+
+// TestSampledSink_Rates tests rate 1 publishes every selection and rate 0 none
+func TestSampledSink_Rates(t *testing.T) {
+	odds := newSampleOdds(300)
+	ctrl := gomock.NewController(t)
+	sink := mocks.NewMockOddsSink(ctrl)
+
+	sink.EXPECT().Publish(gomock.Any(), odds).Return(nil)
+	require.NoError(t, NewSampledSink(sink, 1.0).Publish(context.Background(), odds))
+
+	// Nothing sampled: the wrapped sink is not called
+	require.NoError(t, NewSampledSink(sink, 0).Publish(context.Background(), odds))
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// OddsSink is an interface that abstracts downstream destinations of optimized odds
+// This allows for easier testing and mocking
+type OddsSink interface {
+	Publish(ctx context.Context, odds []*models.OptimizedOdds) error
+}
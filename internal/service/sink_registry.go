@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// SinkRegistry fans optimized odds out to every registered sink. Sinks are
+// published to concurrently, so a slow or failing sink never blocks the
+// others; failures are counted and logged per sink.
+type SinkRegistry struct {
+	sinks     []namedSink
+	published *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	logger    zerolog.Logger
+}
+
+// namedSink is a registered sink with the name its metrics are labelled by
+type namedSink struct {
+	name string
+	sink OddsSink
+}
+
+// NewSinkRegistry creates an empty sink registry. Metrics are registered
+// with reg, and are still recorded but not exported when reg is nil.
+func NewSinkRegistry(reg prometheus.Registerer, logger zerolog.Logger) *SinkRegistry {
+	r := &SinkRegistry{
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sink_published_total",
+			Help: "Batches of optimized odds successfully published, by sink.",
+		}, []string{"sink"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sink_failures_total",
+			Help: "Batches of optimized odds a sink failed to publish, by sink.",
+		}, []string{"sink"}),
+		logger: logger.With().Str("component", "sink_registry").Logger(),
+	}
+	if reg != nil {
+		reg.MustRegister(r.published, r.failures)
+	}
+	return r
+}
+
+// Register adds a sink under name
+func (r *SinkRegistry) Register(name string, sink OddsSink) {
+	r.sinks = append(r.sinks, namedSink{name: name, sink: sink})
+	r.published.WithLabelValues(name)
+	r.failures.WithLabelValues(name)
+}
+
+// Names returns the registered sink names in registration order
+func (r *SinkRegistry) Names() []string {
+	names := make([]string, len(r.sinks))
+	for i, s := range r.sinks {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Len returns the number of registered sinks
+func (r *SinkRegistry) Len() int {
+	return len(r.sinks)
+}
+
+// Publish publishes odds to every sink and waits for all of them. It returns
+// the joined errors of the sinks that failed, or nil if all succeeded.
+func (r *SinkRegistry) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	if len(odds) == 0 || len(r.sinks) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(r.sinks))
+	var wg sync.WaitGroup
+	for i, s := range r.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.sink.Publish(ctx, odds); err != nil {
+				r.failures.WithLabelValues(s.name).Inc()
+				r.logger.Error().
+					Err(err).
+					Str("sink", s.name).
+					Int("odds_count", len(odds)).
+					Msg("failed to publish optimized odds to sink")
+				errs[i] = fmt.Errorf("sink %s: %w", s.name, err)
+				return
+			}
+			r.published.WithLabelValues(s.name).Inc()
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// TestSinkRegistry_Publish tests that a failing sink does not stop the others
+func TestSinkRegistry_Publish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	failing := mocks.NewMockOddsSink(ctrl)
+	healthy := mocks.NewMockOddsSink(ctrl)
+
+	registry := NewSinkRegistry(prometheus.NewRegistry(), zerolog.Nop())
+	registry.Register("webhook", failing)
+	registry.Register("kafka", healthy)
+
+	odds := []*models.OptimizedOdds{{EventID: "event-123"}}
+	failing.EXPECT().Publish(gomock.Any(), odds).Return(errors.New("connection refused"))
+	healthy.EXPECT().Publish(gomock.Any(), odds).Return(nil)
+
+	err := registry.Publish(context.Background(), odds)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sink webhook")
+	assert.NotContains(t, err.Error(), "sink kafka")
+	assert.Equal(t, []string{"webhook", "kafka"}, registry.Names())
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(registry.failures.WithLabelValues("webhook")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(registry.published.WithLabelValues("webhook")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(registry.failures.WithLabelValues("kafka")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(registry.published.WithLabelValues("kafka")))
+}
+
+// TestSinkRegistry_SlowSinkDoesNotBlock tests that sinks are published to concurrently
+func TestSinkRegistry_SlowSinkDoesNotBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	slow := mocks.NewMockOddsSink(ctrl)
+	fast := mocks.NewMockOddsSink(ctrl)
+
+	registry := NewSinkRegistry(nil, zerolog.Nop())
+	registry.Register("slow", slow)
+	registry.Register("fast", fast)
+
+	fastDone := make(chan struct{})
+	slow.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			<-fastDone // Only returns once the fast sink has received the batch
+			return nil
+		})
+	fast.EXPECT().Publish(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, odds []*models.OptimizedOdds) error {
+			close(fastDone)
+			return nil
+		})
+
+	assert.NoError(t, registry.Publish(context.Background(), []*models.OptimizedOdds{{EventID: "event-123"}}))
+}
+
+// TestSinkRegistry_Empty tests publishing with no sinks or no odds
+func TestSinkRegistry_Empty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sink := mocks.NewMockOddsSink(ctrl)
+
+	registry := NewSinkRegistry(nil, zerolog.Nop())
+	assert.NoError(t, registry.Publish(context.Background(), []*models.OptimizedOdds{{EventID: "event-123"}}))
+
+	// No odds: sinks are not called
+	registry.Register("kafka", sink)
+	assert.NoError(t, registry.Publish(context.Background(), nil))
+	assert.Equal(t, 1, registry.Len())
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// Store is an interface that abstracts long-term, queryable storage of optimized odds
+// This allows for easier testing and mocking
+type Store interface {
+	Insert(ctx context.Context, oddsList []*models.OptimizedOdds) error
+	// Search returns up to limit odds of a sport optimized in [from, to), oldest first
+	Search(ctx context.Context, sport string, from, to time.Time, limit int) ([]*models.OptimizedOdds, error)
+	Close() error
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// StoreWriter persists optimized odds to a Store off the hot path. Publish
+// only queues a batch; Start inserts queued batches one at a time. When the
+// queue is full the batch is dropped and counted rather than blocking the
+// caller, so a slow or unavailable store never delays caching or publishing.
+type StoreWriter struct {
+	store        Store
+	queue        chan []*models.OptimizedOdds
+	writeTimeout time.Duration
+	dropped      prometheus.Counter
+	failures     prometheus.Counter
+	logger       zerolog.Logger
+
+	started atomic.Bool
+	done    chan struct{} // Closed when Start returns
+}
+
+// StoreWriterConfig holds store writer configuration
+type StoreWriterConfig struct {
+	QueueSize    int           // Batches waiting to be inserted before new ones are dropped (default 1024)
+	WriteTimeout time.Duration // Deadline for each insert (default 5s)
+
+	Registerer prometheus.Registerer // Optional; metrics are not exported when nil
+}
+
+// NewStoreWriter creates a new store writer
+func NewStoreWriter(store Store, config StoreWriterConfig, logger zerolog.Logger) *StoreWriter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = 5 * time.Second
+	}
+
+	w := &StoreWriter{
+		store:        store,
+		queue:        make(chan []*models.OptimizedOdds, config.QueueSize),
+		writeTimeout: config.WriteTimeout,
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "store_dropped_batches_total",
+			Help: "Batches of optimized odds dropped because the store write queue was full.",
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "store_write_failures_total",
+			Help: "Batches of optimized odds the store failed to insert.",
+		}),
+		logger: logger.With().Str("component", "store_writer").Logger(),
+		done:   make(chan struct{}),
+	}
+	if config.Registerer != nil {
+		config.Registerer.MustRegister(w.dropped, w.failures)
+	}
+
+	return w
+}
+
+// Publish queues odds for insertion without blocking; it never fails, so the
+// writer can be registered as a sink
+func (w *StoreWriter) Publish(ctx context.Context, odds []*models.OptimizedOdds) error {
+	if len(odds) == 0 {
+		return nil
+	}
+
+	select {
+	case w.queue <- odds:
+	default:
+		w.dropped.Inc()
+		w.logger.Warn().Int("odds_count", len(odds)).Msg("store write queue full, dropping batch")
+	}
+	return nil
+}
+
+// Start inserts queued batches until ctx is done, then inserts whatever is
+// still queued
+func (w *StoreWriter) Start(ctx context.Context) {
+	w.started.Store(true)
+	defer close(w.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case odds := <-w.queue:
+					w.insert(ctx, odds)
+				default:
+					return
+				}
+			}
+
+		case odds := <-w.queue:
+			w.insert(ctx, odds)
+		}
+	}
+}
+
+// insert writes one batch within the write timeout, logging failures. Only
+// the timeout bounds it, so batches queued before shutdown are still written.
+func (w *StoreWriter) insert(ctx context.Context, odds []*models.OptimizedOdds) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.writeTimeout)
+	defer cancel()
+
+	if err := w.store.Insert(ctx, odds); err != nil {
+		w.failures.Inc()
+		w.logger.Error().Err(err).Int("odds_count", len(odds)).Msg("failed to store optimized odds")
+	}
+}
+
+// Search queries the underlying store
+func (w *StoreWriter) Search(ctx context.Context, sport string, from, to time.Time, limit int) ([]*models.OptimizedOdds, error) {
+	return w.store.Search(ctx, sport, from, to, limit)
+}
+
+// Close waits for a started writer to finish its final inserts, then closes
+// the underlying store
+func (w *StoreWriter) Close() error {
+	if w.started.Load() {
+		<-w.done
+	}
+	return w.store.Close()
+}
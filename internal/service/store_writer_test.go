@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/mocks"
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// TestStoreWriter tests that Publish never blocks, dropping batches while the
+// queue is full, and that Start inserts what was queued before Close
+// closes the store
+func TestStoreWriter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mocks.NewMockStore(ctrl)
+	writer := NewStoreWriter(store, StoreWriterConfig{QueueSize: 2, Registerer: prometheus.NewRegistry()}, zerolog.Nop())
+
+	first := []*models.OptimizedOdds{{EventID: "event-1"}}
+	second := []*models.OptimizedOdds{{EventID: "event-2"}}
+	third := []*models.OptimizedOdds{{EventID: "event-3"}}
+
+	// Nothing drains the queue yet
+	require.NoError(t, writer.Publish(context.Background(), first))
+	require.NoError(t, writer.Publish(context.Background(), second))
+	require.NoError(t, writer.Publish(context.Background(), third))
+	require.NoError(t, writer.Publish(context.Background(), nil))
+	assert.Equal(t, 1.0, testutil.ToFloat64(writer.dropped))
+
+	gomock.InOrder(
+		store.EXPECT().Insert(gomock.Any(), first).Return(nil),
+		store.EXPECT().Insert(gomock.Any(), second).Return(errors.New("connection refused")),
+		store.EXPECT().Close().Return(nil),
+	)
+
+	// A cancelled writer still inserts what was queued
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	writer.Start(ctx)
+
+	require.NoError(t, writer.Close())
+	assert.Equal(t, 1.0, testutil.ToFloat64(writer.failures))
+}
+
+// TestSearchOdds_Disabled tests searches fail without a store
+func TestSearchOdds_Disabled(t *testing.T) {
+	svc := NewOptimizerService(nil, nil, zerolog.Nop())
+
+	_, err := svc.SearchOdds(context.Background(), "football", time.Now().Add(-time.Hour), time.Now(), 10)
+	assert.ErrorIs(t, err, ErrStoreDisabled)
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// newTestStore creates a store on a sqlmock database
+func newTestStore(t *testing.T) (*PostgresStore, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return newPostgresStore(db, zerolog.Nop()), mock
+}
+
+// newTestOdds creates optimized odds for a football selection
+func newTestOdds(selection string, optimizedAt time.Time) *models.OptimizedOdds {
+	return &models.OptimizedOdds{
+		ID:            uuid.New(),
+		EventID:       "event-123",
+		Sport:         "football",
+		Competition:   "Premier League",
+		Market:        "match_winner",
+		Selection:     selection,
+		OptimizedBack: decimal.NewFromFloat(2.45),
+		OptimizedLay:  decimal.NewFromFloat(2.55),
+		Confidence:    0.9,
+		OptimizedAt:   optimizedAt,
+	}
+}
+
+// TestMigrate tests the table and indexes are created idempotently
+func TestMigrate(t *testing.T) {
+	s, mock := newTestStore(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS optimized_odds")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, s.Migrate(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestInsert tests odds are inserted in one multi-row statement with their
+// columns and JSON payload, and that large batches are split
+func TestInsert(t *testing.T) {
+	s, mock := newTestStore(t)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	home := newTestOdds("Home", now)
+	away := newTestOdds("Away", now)
+
+	homePayload, err := json.Marshal(home)
+	require.NoError(t, err)
+	awayPayload, err := json.Marshal(away)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO optimized_odds ("+insertColumns+") VALUES "+
+		"($1, $2, $3, $4, $5, $6, $7, $8), ($9, $10, $11, $12, $13, $14, $15, $16) ON CONFLICT (id) DO NOTHING")).
+		WithArgs(
+			home.ID, "event-123", "football", "Premier League", "match_winner", "Home", now, homePayload,
+			away.ID, "event-123", "football", "Premier League", "match_winner", "Away", now, awayPayload,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, s.Insert(context.Background(), []*models.OptimizedOdds{home, away}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Batches above maxInsertRows use several statements
+	large := make([]*models.OptimizedOdds, maxInsertRows+1)
+	for i := range large {
+		large[i] = newTestOdds("Home", now)
+	}
+	mock.ExpectExec("INSERT INTO optimized_odds").WillReturnResult(sqlmock.NewResult(0, maxInsertRows))
+	mock.ExpectExec("INSERT INTO optimized_odds").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, s.Insert(context.Background(), large))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSearch tests a range query returns the stored odds in order
+func TestSearch(t *testing.T) {
+	s, mock := newTestStore(t)
+	from := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	first := newTestOdds("Home", from.Add(time.Hour))
+	second := newTestOdds("Away", from.Add(2*time.Hour))
+	rows := sqlmock.NewRows([]string{"payload"})
+	for _, odds := range []*models.OptimizedOdds{first, second} {
+		payload, err := json.Marshal(odds)
+		require.NoError(t, err)
+		rows.AddRow(payload)
+	}
+	rows.AddRow([]byte("not json")) // Skipped
+
+	mock.ExpectQuery(regexp.QuoteMeta(searchQuery)).
+		WithArgs("football", from, to, 100).
+		WillReturnRows(rows)
+
+	oddsList, err := s.Search(context.Background(), "football", from, to, 100)
+	require.NoError(t, err)
+	require.Len(t, oddsList, 2)
+	assert.Equal(t, first.ID, oddsList[0].ID)
+	assert.True(t, first.OptimizedBack.Equal(oddsList[0].OptimizedBack))
+	assert.Equal(t, second.ID, oddsList[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// No matches is an empty result, not nil
+	mock.ExpectQuery(regexp.QuoteMeta(searchQuery)).
+		WithArgs("tennis", from, to, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"payload"}))
+
+	oddsList, err = s.Search(context.Background(), "tennis", from, to, 100)
+	require.NoError(t, err)
+	assert.Empty(t, oddsList)
+	assert.NotNil(t, oddsList)
+
+	mock.ExpectQuery(regexp.QuoteMeta(searchQuery)).WillReturnError(driver.ErrBadConn)
+	_, err = s.Search(context.Background(), "football", from, to, 100)
+	assert.Error(t, err)
+}
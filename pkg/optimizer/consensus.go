@@ -0,0 +1,130 @@
+package optimizer
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// DuplicateConsensus merges copies of one selection within a batch into a
+// source-weighted consensus instead of keeping one of them
+const DuplicateConsensus = "consensus"
+
+// ConsensusSource is the Source of consensus odds
+const ConsensusSource = "consensus"
+
+var (
+	// ErrNoConsensus is returned when no quote carries a usable back price
+	// from a source with a positive weight
+	ErrNoConsensus = errors.New("no weighted quotes to build a consensus from")
+
+	// ErrConsensusMismatch is returned when quotes for a consensus are not
+	// all for the same event, market and selection
+	ErrConsensusMismatch = errors.New("consensus quotes are for different selections")
+)
+
+// ConsensusBuilder merges quotes for one selection from several sources into
+// a single NormalizedOdds: implied probabilities are averaged by source
+// weight and sizes are summed
+type ConsensusBuilder struct {
+	weights map[string]decimal.Decimal
+	dec     decimalContext
+}
+
+// NewConsensusBuilder creates a consensus builder. Weights are keyed by
+// source, case-insensitively; sources without a weight count once and a
+// weight of zero leaves a source out.
+func NewConsensusBuilder(weights map[string]decimal.Decimal) *ConsensusBuilder {
+	lowered := make(map[string]decimal.Decimal, len(weights))
+	for source, weight := range weights {
+		lowered[strings.ToLower(source)] = weight
+	}
+	return &ConsensusBuilder{weights: lowered, dec: newDecimalContext(0)}
+}
+
+// Weight returns a source's consensus weight, 1 when it has none configured
+func (b *ConsensusBuilder) Weight(source string) decimal.Decimal {
+	if weight, ok := b.weights[strings.ToLower(source)]; ok {
+		return weight
+	}
+	return decimal.NewFromInt(1)
+}
+
+// Build returns the consensus of quotes for one event+market+selection. The
+// result copies the newest quote's event details, carries the weighted back
+// and lay probabilities with their prices, the summed sizes of the weighted
+// quotes and ConsensusSource as its source.
+func (b *ConsensusBuilder) Build(quotes []*models.NormalizedOdds) (*models.NormalizedOdds, error) {
+	if len(quotes) == 0 {
+		return nil, ErrNoConsensus
+	}
+
+	one := decimal.NewFromInt(1)
+	newest := quotes[0]
+	var backWeight, backSum, layWeight, laySum, backSize, laySize decimal.Decimal
+
+	for _, quote := range quotes {
+		if quote.EventID != newest.EventID || quote.Market != newest.Market || !quote.Line.Equal(newest.Line) ||
+			CanonicalSelection(quote.Selection) != CanonicalSelection(newest.Selection) {
+			return nil, ErrConsensusMismatch
+		}
+		if quote.Timestamp.After(newest.Timestamp) {
+			newest = quote
+		}
+
+		weight := b.Weight(quote.Source)
+		if !weight.IsPositive() {
+			continue
+		}
+		backProb, ok := b.impliedProbability(quote.BackPrice, quote.BackProb)
+		if !ok {
+			continue
+		}
+
+		backWeight = backWeight.Add(weight)
+		backSum = backSum.Add(backProb.Mul(weight))
+		backSize = backSize.Add(quote.BackSize)
+		laySize = laySize.Add(quote.LaySize)
+
+		if layProb, ok := b.impliedProbability(quote.LayPrice, quote.LayProb); ok {
+			layWeight = layWeight.Add(weight)
+			laySum = laySum.Add(layProb.Mul(weight))
+		}
+	}
+
+	if !backWeight.IsPositive() {
+		return nil, ErrNoConsensus
+	}
+
+	consensus := *newest
+	consensus.ID = uuid.New()
+	consensus.Source = ConsensusSource
+	consensus.BackProb = b.dec.div(backSum, backWeight)
+	consensus.BackPrice = b.dec.div(one, consensus.BackProb)
+	consensus.BackSize = backSize
+	consensus.LaySize = laySize
+	consensus.LayProb = decimal.Zero
+	consensus.LayPrice = decimal.Zero
+	if layWeight.IsPositive() {
+		consensus.LayProb = b.dec.div(laySum, layWeight)
+		consensus.LayPrice = b.dec.div(one, consensus.LayProb)
+	}
+
+	return &consensus, nil
+}
+
+// impliedProbability returns a quote's supplied probability when valid, else
+// the probability implied by its price, reporting false when neither is usable
+func (b *ConsensusBuilder) impliedProbability(price, prob decimal.Decimal) (decimal.Decimal, bool) {
+	if prob, ok := validProbability(prob); ok {
+		return prob, true
+	}
+	if price.GreaterThan(decimal.NewFromInt(1)) {
+		return b.dec.div(decimal.NewFromInt(1), price), true
+	}
+	return decimal.Zero, false
+}
@@ -0,0 +1,52 @@
+package optimizer
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// DefaultDrawLabels are the selection names recognized as the draw of a
+// 3-way market when DrawLabels is unset
+var DefaultDrawLabels = []string{"Draw", "X", "Tie"}
+
+// threeWaySelections is the size of a book with a draw: home, draw and away
+const threeWaySelections = 3
+
+// newDrawLabels returns the canonical draw labels, DefaultDrawLabels when unset
+func newDrawLabels(labels []string) map[string]bool {
+	if len(labels) == 0 {
+		labels = DefaultDrawLabels
+	}
+	drawLabels := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		drawLabels[CanonicalSelection(label)] = true
+	}
+	return drawLabels
+}
+
+// applyDrawMultiplier scales the draw's margin in a 3-way book by
+// DrawMultiplier, leaving the two outcomes' margins unchanged. Books that
+// are not 3-way, or have no single draw selection, are left unchanged.
+func (o *Optimizer) applyDrawMultiplier(selections []*models.NormalizedOdds, margins []MarginExplanation) {
+	multiplier := o.params.DrawMultiplier
+	if !multiplier.IsPositive() || multiplier.Equal(decimal.NewFromInt(1)) || len(selections) != threeWaySelections {
+		return
+	}
+
+	draw := -1
+	for i, odds := range selections {
+		if !o.drawLabels[CanonicalSelection(odds.Selection)] {
+			continue
+		}
+		if draw >= 0 {
+			return // Ambiguous: not a home/draw/away book
+		}
+		draw = i
+	}
+	if draw < 0 {
+		return
+	}
+
+	margins[draw] = margins[draw].drawAdjusted(multiplier)
+}
@@ -0,0 +1,114 @@
+package optimizer
+
+import (
+	"strings"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// Policies for choosing among copies of one selection within a batch
+const (
+	DuplicateKeepNewest = "newest" // Latest Timestamp wins; ties go to the later copy (default)
+	DuplicateKeepFirst  = "first"  // First copy in the batch wins
+	DuplicateKeepLast   = "last"   // Last copy in the batch wins
+)
+
+// dedupeSelections keeps one copy of each event+market+selection in a batch,
+// chosen by SourcePreference and then the DuplicatePolicy, so conflicting copies never race to the
+// cache. Winners keep the position of the selection's first copy. Under
+// DuplicateConsensus the copies are merged instead; a selection whose copies
+// yield no consensus keeps the newest one.
+func (o *Optimizer) dedupeSelections(normalized []*models.NormalizedOdds) []*models.NormalizedOdds {
+	index := make(map[string]int, len(normalized))
+	deduped := make([]*models.NormalizedOdds, 0, len(normalized))
+	var copies map[int][]*models.NormalizedOdds
+	if o.duplicatePolicy() == DuplicateConsensus {
+		copies = make(map[int][]*models.NormalizedOdds)
+	}
+
+	for _, odds := range normalized {
+		selection := odds.Selection
+		if o.params.NormalizeSelections {
+			selection = CanonicalSelection(selection)
+		}
+		key := marketKey(odds) + ":" + selection
+
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(deduped)
+			deduped = append(deduped, odds)
+			continue
+		}
+
+		o.metrics.duplicateSelections.Inc()
+		o.logger.Warn().
+			Str("event_id", odds.EventID).
+			Str("market", odds.Market).
+			Str("selection", odds.Selection).
+			Str("kept_source", deduped[i].Source).
+			Str("source", odds.Source).
+			Str("policy", o.duplicatePolicy()).
+			Msg("duplicate selection in batch")
+
+		if copies != nil {
+			if len(copies[i]) == 0 {
+				copies[i] = append(copies[i], deduped[i])
+			}
+			copies[i] = append(copies[i], odds)
+		}
+		if o.keepDuplicate(deduped[i], odds) {
+			deduped[i] = odds
+		}
+	}
+
+	for i, quotes := range copies {
+		consensus, err := o.consensus.Build(quotes)
+		if err != nil {
+			o.logger.Warn().Err(err).
+				Str("event_id", deduped[i].EventID).
+				Str("selection", deduped[i].Selection).
+				Msg("no consensus for duplicate selection, keeping newest copy")
+			continue
+		}
+		deduped[i] = consensus
+	}
+
+	return deduped
+}
+
+// keepDuplicate reports whether a later copy of a selection replaces the kept
+// one: a more preferred source always wins, otherwise the DuplicatePolicy
+// decides, with DuplicateConsensus falling back to the newest copy
+func (o *Optimizer) keepDuplicate(kept, later *models.NormalizedOdds) bool {
+	if keptRank, laterRank := o.sourceRank(kept.Source), o.sourceRank(later.Source); keptRank != laterRank {
+		return laterRank < keptRank
+	}
+
+	switch o.duplicatePolicy() {
+	case DuplicateKeepFirst:
+		return false
+	case DuplicateKeepLast:
+		return true
+	default:
+		return !later.Timestamp.Before(kept.Timestamp)
+	}
+}
+
+// duplicatePolicy returns the configured duplicate policy, newest by default
+func (o *Optimizer) duplicatePolicy() string {
+	switch o.params.DuplicatePolicy {
+	case DuplicateKeepFirst, DuplicateKeepLast, DuplicateConsensus:
+		return o.params.DuplicatePolicy
+	default:
+		return DuplicateKeepNewest
+	}
+}
+
+// sourceRank returns a source's position in SourcePreference; unlisted
+// sources rank after every listed one
+func (o *Optimizer) sourceRank(source string) int {
+	if rank, ok := o.sourceRanks[strings.ToLower(source)]; ok {
+		return rank
+	}
+	return len(o.params.SourcePreference)
+}
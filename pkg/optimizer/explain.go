@@ -0,0 +1,141 @@
+package optimizer
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// Explanation breaks down how an optimized price was reached
+type Explanation struct {
+	ImpliedProbability decimal.Decimal       `json:"implied_probability"` // Supplied back probability, else 1 / original back price
+	RemovedOverround   decimal.Decimal       `json:"removed_overround"`   // Overround removed to reach the fair probability
+	FairProbability    decimal.Decimal       `json:"fair_probability"`    // Margin-free probability the price is built around (TrueProbability when supplied)
+	Margin             MarginExplanation     `json:"margin"`
+	Spread             decimal.Decimal       `json:"spread"`            // Back-lay spread before enforcing the minimum spread
+	SpreadAdjustment   decimal.Decimal       `json:"spread_adjustment"` // Added to back and taken from lay to reach max(MinSpread, MinSpreadPct of the fair price)
+	Confidence         ConfidenceExplanation `json:"confidence"`
+}
+
+// MarginExplanation breaks down the target margin:
+// Applied = clamp((Base + LiquidityAdjustment) * SportMultiplier, Min, Max) / (1 - Commission) * DrawMultiplier * BookScale
+type MarginExplanation struct {
+	Base                decimal.Decimal `json:"base"`                 // MinMargin
+	LiquidityAdjustment decimal.Decimal `json:"liquidity_adjustment"` // Added for liquidity under LiquidityMarginThreshold
+	SportMultiplier     decimal.Decimal `json:"sport_multiplier"`     // 1 for min-margin sports
+	MinMarginSport      bool            `json:"min_margin_sport"`     // Sport bypasses the sport multiplier
+	Unclamped           decimal.Decimal `json:"unclamped"`
+	Applied             decimal.Decimal `json:"applied"`
+	Min                 decimal.Decimal `json:"min"`
+	Max                 decimal.Decimal `json:"max"`
+	Commission          decimal.Decimal `json:"commission"`         // Exchange commission the margin is grossed up for
+	DrawMultiplier      decimal.Decimal `json:"draw_multiplier"`    // Not 1 only for the draw of a 3-way book priced by BatchOptimizeMarket
+	BookScale           decimal.Decimal `json:"book_scale"`         // Below 1 when the book's total overround is capped
+	Override            string          `json:"override,omitempty"` // Level of the most specific margin override applied: competition, sport_market or sport
+}
+
+// scaled returns the explanation with Applied scaled by scale
+func (m MarginExplanation) scaled(scale decimal.Decimal) MarginExplanation {
+	m.BookScale = scale
+	m.Applied = m.Applied.Mul(scale)
+	return m
+}
+
+// drawAdjusted returns the explanation with Applied scaled by the draw multiplier
+func (m MarginExplanation) drawAdjusted(multiplier decimal.Decimal) MarginExplanation {
+	m.DrawMultiplier = multiplier
+	m.Applied = m.Applied.Mul(multiplier)
+	return m
+}
+
+// ConfidenceExplanation breaks down confidence:
+// Clamped = clamp(Target * LiquidityFactor * SpreadFactor * FreshnessFactor * StabilityFactor, 0, 1),
+// then Bounds, when configured for the sport, give Confidence
+type ConfidenceExplanation struct {
+	Target          float64                  `json:"target"`
+	LiquidityFactor float64                  `json:"liquidity_factor"` // 0.7-1.0
+	SpreadFactor    float64                  `json:"spread_factor"`    // 0.8-1.0 for non-negative spreads
+	FreshnessFactor float64                  `json:"freshness_factor"` // 0.9-1.0
+	StabilityFactor float64                  `json:"stability_factor"` // 1-StabilityWeight to 1.0; 1 without price history
+	LayFactor       float64                  `json:"lay_factor"`       // MissingLayPenalty without a lay quote, otherwise 1
+	Clamped         float64                  `json:"clamped"`
+	Bounds          *models.ConfidenceBounds `json:"bounds,omitempty"` // Per-sport bounds, when configured
+	Confidence      float64                  `json:"confidence"`
+}
+
+// Explain optimizes normalized odds like Optimize and also returns how the
+// price was reached. It is a debugging tool: results are not counted as
+// optimized.
+func (o *Optimizer) Explain(normalized *models.NormalizedOdds) (*models.OptimizedOdds, *Explanation, error) {
+	if err := o.validate(normalized); err != nil {
+		return nil, nil, err
+	}
+
+	// A lone selection has no book to remove overround from
+	impliedProbBack := o.impliedBackProbability(normalized)
+	fairProb := impliedProbBack
+	if trueProb, ok := trueProbability(normalized); ok {
+		fairProb = trueProb
+	}
+	optimized, explanation, err := o.explainFromProbability(normalized, fairProb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	explanation.ImpliedProbability = impliedProbBack
+	explanation.RemovedOverround = decimal.Zero
+
+	return optimized, explanation, nil
+}
+
+// traceOptimization logs every pipeline step for a priced selection at trace
+// level, keyed by the optimized odds ID so a disputed price can be
+// reconstructed from one log search. Nothing is formatted unless trace
+// logging is enabled.
+func (o *Optimizer) traceOptimization(normalized *models.NormalizedOdds, optimized *models.OptimizedOdds, explanation *Explanation) {
+	event := o.logger.Trace()
+	if !event.Enabled() {
+		return
+	}
+
+	margin := explanation.Margin
+	confidence := explanation.Confidence
+	confidenceBounded := confidence.Bounds != nil && confidence.Confidence != confidence.Clamped
+
+	event.
+		Str("correlation_id", optimized.ID.String()).
+		Str("input_id", normalized.ID.String()).
+		Str("event_id", normalized.EventID).
+		Str("market", normalized.Market).
+		Str("selection", normalized.Selection).
+		Str("back_price", normalized.BackPrice.String()).
+		Str("implied_probability", o.impliedBackProbability(normalized).String()).
+		Str("fair_probability", explanation.FairProbability.String()).
+		Dict("margin", zerolog.Dict().
+			Str("base", margin.Base.String()).
+			Str("liquidity_adjustment", margin.LiquidityAdjustment.String()).
+			Str("sport_multiplier", margin.SportMultiplier.String()).
+			Bool("min_margin_sport", margin.MinMarginSport).
+			Str("unclamped", margin.Unclamped.String()).
+			Str("book_scale", margin.BookScale.String()).
+			Str("target", margin.Applied.String()).
+			Bool("clamped", !margin.Unclamped.Equal(margin.Applied))).
+		Str("pre_spread_back", optimized.OptimizedBack.Sub(explanation.SpreadAdjustment).String()).
+		Str("pre_spread_lay", optimized.OptimizedLay.Add(explanation.SpreadAdjustment).String()).
+		Str("spread", explanation.Spread.String()).
+		Str("spread_adjustment", explanation.SpreadAdjustment.String()).
+		Bool("spread_widened", explanation.SpreadAdjustment.IsPositive()).
+		Str("optimized_back", optimized.OptimizedBack.String()).
+		Str("optimized_lay", optimized.OptimizedLay.String()).
+		Dict("confidence", zerolog.Dict().
+			Float64("target", confidence.Target).
+			Float64("liquidity_factor", confidence.LiquidityFactor).
+			Float64("spread_factor", confidence.SpreadFactor).
+			Float64("freshness_factor", confidence.FreshnessFactor).
+			Float64("stability_factor", confidence.StabilityFactor).
+			Float64("clamped", confidence.Clamped).
+			Bool("bounded", confidenceBounded).
+			Float64("final", confidence.Confidence)).
+		Msg("optimization trace")
+}
@@ -0,0 +1,129 @@
+package optimizer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// Policies for an optimized line ladder whose prices invert
+const (
+	LadderSmooth = "smooth" // Raise the inverted rung's prices to the previous rung's (default)
+	LadderReject = "reject" // Drop the inverted rung
+)
+
+// Sides of a totals ladder, read from the selection name
+const (
+	ladderOver  = "over"
+	ladderUnder = "under"
+)
+
+// marketKey returns the book key of odds: event+market, plus the line when
+// set, so each rung of a ladder is priced as its own book
+func marketKey(odds *models.NormalizedOdds) string {
+	key := odds.EventID + ":" + odds.Market
+	if !odds.Line.IsZero() {
+		key += ":" + odds.Line.String()
+	}
+	return key
+}
+
+// ladderSide returns whether a selection is the over or under side of a
+// totals line, or "" for other selections
+func ladderSide(selection string) string {
+	selection = strings.ToLower(strings.TrimSpace(selection))
+	switch {
+	case strings.HasPrefix(selection, ladderOver):
+		return ladderOver
+	case strings.HasPrefix(selection, ladderUnder):
+		return ladderUnder
+	default:
+		return ""
+	}
+}
+
+// enforceLadders keeps totals ladders consistent: within an event+market
+// quoted at several lines, the over probability must fall (its prices rise)
+// as the line rises, and the under probability must rise. Each inversion is
+// counted and, by LadderPolicy, smoothed or dropped. Rejected rungs are
+// counted as rejected; the rest of the batch keeps its order.
+func (o *Optimizer) enforceLadders(optimized []*models.OptimizedOdds) []*models.OptimizedOdds {
+	ladders := make(map[string][]*models.OptimizedOdds)
+	for _, odds := range optimized {
+		side := ladderSide(odds.Selection)
+		if odds.Line.IsZero() || side == "" {
+			continue
+		}
+		key := odds.EventID + ":" + odds.Market + ":" + side
+		ladders[key] = append(ladders[key], odds)
+	}
+
+	rejected := make(map[*models.OptimizedOdds]bool)
+	for _, rungs := range ladders {
+		if len(rungs) < 2 {
+			continue
+		}
+
+		// Walk from the most to the least likely rung: prices never shorten
+		over := ladderSide(rungs[0].Selection) == ladderOver
+		sort.SliceStable(rungs, func(i, j int) bool {
+			if over {
+				return rungs[i].Line.LessThan(rungs[j].Line)
+			}
+			return rungs[i].Line.GreaterThan(rungs[j].Line)
+		})
+
+		prev := rungs[0]
+		for _, rung := range rungs[1:] {
+			if !rung.OptimizedBack.LessThan(prev.OptimizedBack) && !rung.OptimizedLay.LessThan(prev.OptimizedLay) {
+				prev = rung
+				continue
+			}
+
+			o.metrics.ladderInversions.Inc()
+			o.logger.Warn().
+				Str("event_id", rung.EventID).
+				Str("market", rung.Market).
+				Str("selection", rung.Selection).
+				Str("line", rung.Line.String()).
+				Str("previous_line", prev.Line.String()).
+				Str("optimized_back", rung.OptimizedBack.String()).
+				Str("previous_back", prev.OptimizedBack.String()).
+				Str("policy", o.ladderPolicy()).
+				Msg("ladder price inversion")
+
+			if o.ladderPolicy() == LadderReject {
+				rejected[rung] = true
+				continue
+			}
+			rung.OptimizedBack = decimal.Max(rung.OptimizedBack, prev.OptimizedBack)
+			rung.OptimizedLay = decimal.Max(rung.OptimizedLay, prev.OptimizedLay)
+			rung.FairPrice = decimal.Max(rung.FairPrice, prev.FairPrice)
+			prev = rung
+		}
+	}
+
+	if len(rejected) == 0 {
+		return optimized
+	}
+
+	kept := optimized[:0]
+	for _, odds := range optimized {
+		if !rejected[odds] {
+			kept = append(kept, odds)
+		}
+	}
+	o.rejectedCount.Add(uint64(len(rejected)))
+	return kept
+}
+
+// ladderPolicy returns the configured ladder policy, smooth by default
+func (o *Optimizer) ladderPolicy() string {
+	if o.params.LadderPolicy == LadderReject {
+		return LadderReject
+	}
+	return LadderSmooth
+}
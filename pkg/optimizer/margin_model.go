@@ -0,0 +1,149 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// MarginModel names a method of removing the incoming overround from a book
+type MarginModel string
+
+const (
+	// MarginModelAdditive subtracts an equal share of the overround from every selection
+	MarginModelAdditive MarginModel = "additive"
+
+	// MarginModelProportional scales every selection's probability by the overround
+	MarginModelProportional MarginModel = "proportional"
+
+	// MarginModelShin assumes the overround protects against insider trading,
+	// which shifts relatively more margin onto longshots
+	MarginModelShin MarginModel = "shin"
+)
+
+// MarginModels lists every supported margin model
+var MarginModels = []MarginModel{
+	MarginModelAdditive,
+	MarginModelProportional,
+	MarginModelShin,
+}
+
+// shinIterations bounds the bisection search for Shin's insider fraction z;
+// 64 halvings exhaust float64 precision on [0, 1)
+const shinIterations = 64
+
+// removeOverround converts a book's implied probabilities to fair
+// probabilities summing to one under the given model, dividing in dec.
+// Books without a positive overround to remove are returned unchanged.
+func removeOverround(model MarginModel, impliedProbs []decimal.Decimal, dec decimalContext) ([]decimal.Decimal, error) {
+	booksum := decimal.Zero
+	for _, prob := range impliedProbs {
+		booksum = booksum.Add(prob)
+	}
+
+	fairProbs := make([]decimal.Decimal, len(impliedProbs))
+	if len(impliedProbs) < 2 || !booksum.IsPositive() {
+		copy(fairProbs, impliedProbs)
+		return fairProbs, nil
+	}
+
+	switch model {
+	case MarginModelAdditive:
+		// May push extreme longshots to zero or below; probabilityToOdds
+		// prices those at the 1.0 safeguard
+		share := dec.div(booksum.Sub(decimal.NewFromInt(1)), decimal.NewFromInt(int64(len(impliedProbs))))
+		for i, prob := range impliedProbs {
+			fairProbs[i] = prob.Sub(share)
+		}
+
+	case MarginModelProportional:
+		for i, prob := range impliedProbs {
+			fairProbs[i] = dec.div(prob, booksum)
+		}
+
+	case MarginModelShin:
+		// Shin's model has no closed form; z is found by bisection and the
+		// probabilities are converted back to decimal at a fixed exponent
+		probs := make([]float64, len(impliedProbs))
+		for i, prob := range impliedProbs {
+			probs[i] = prob.InexactFloat64()
+		}
+		for i, prob := range shinProbabilities(probs, booksum.InexactFloat64()) {
+			fairProbs[i] = floatToDecimal(prob)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown margin model %q", model)
+	}
+
+	return fairProbs, nil
+}
+
+// shinProbabilities solves Shin's model for the insider fraction z at which
+// the fair probabilities sum to one and returns those probabilities
+func shinProbabilities(impliedProbs []float64, booksum float64) []float64 {
+	fair := func(z float64) ([]float64, float64) {
+		probs := make([]float64, len(impliedProbs))
+		sum := 0.0
+		for i, pi := range impliedProbs {
+			probs[i] = (math.Sqrt(z*z+4*(1-z)*pi*pi/booksum) - z) / (2 * (1 - z))
+			sum += probs[i]
+		}
+		return probs, sum
+	}
+
+	// The sum falls from sqrt(booksum) > 1 at z=0 towards sum(pi^2)/booksum < 1 as z approaches 1
+	low, high := 0.0, 1.0
+	for i := 0; i < shinIterations; i++ {
+		mid := (low + high) / 2
+		if _, sum := fair(mid); sum > 1 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	probs, _ := fair(low)
+	return probs
+}
+
+// OptimizeMarketWithModels prices a batch as books under every margin model,
+// so their outputs can be compared side by side. It is a research tool:
+// results are not counted as optimized and the negative-margin guard is not
+// applied, and any invalid selection fails the whole comparison.
+func (o *Optimizer) OptimizeMarketWithModels(normalized []*models.NormalizedOdds) (map[MarginModel][]*models.OptimizedOdds, error) {
+	results := make(map[MarginModel][]*models.OptimizedOdds, len(MarginModels))
+
+	for _, book := range groupByMarket(normalized) {
+		selections := make([]*models.NormalizedOdds, 0, len(book))
+		impliedProbs := make([]decimal.Decimal, 0, len(book))
+
+		for _, odds := range book {
+			if err := o.validate(odds); err != nil {
+				return nil, fmt.Errorf("event %s selection %s: %w", odds.EventID, odds.Selection, err)
+			}
+			selections = append(selections, odds)
+			impliedProbs = append(impliedProbs, o.impliedBackProbability(odds))
+		}
+
+		for _, model := range MarginModels {
+			fairProbs, err := removeOverround(model, impliedProbs, o.dec)
+			if err != nil {
+				return nil, err
+			}
+
+			for i, odds := range selections {
+				opt, err := o.optimizeFromProbability(odds, fairProbs[i])
+				if err != nil {
+					return nil, fmt.Errorf("%s model, event %s selection %s: %w", model, odds.EventID, odds.Selection, err)
+				}
+				results[model] = append(results[model], opt)
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,69 @@
+package optimizer
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// Override levels, from least to most specific
+const (
+	OverrideLevelSport       = "sport"
+	OverrideLevelMarket      = "sport_market"
+	OverrideLevelCompetition = "competition"
+)
+
+// marginSettings are the margin parameters of one selection after overrides
+type marginSettings struct {
+	min        decimal.Decimal
+	max        decimal.Decimal
+	multiplier decimal.Decimal // Replaces the built-in sport multiplier (zero keeps it)
+	level      string          // Most specific matching override (empty when none)
+}
+
+// overrideLevel returns an override's level and a rank ordering levels by
+// specificity: a competition wins over a market, and a market over a sport
+func overrideLevel(override models.MarginOverride) (string, int) {
+	switch {
+	case override.Competition != "":
+		return OverrideLevelCompetition, 3
+	case override.Market != "":
+		return OverrideLevelMarket, 2
+	default:
+		return OverrideLevelSport, 1
+	}
+}
+
+// resolveMargin resolves MarginOverrides for odds, field by field: each of
+// MinMargin, MaxMargin and SportMultiplier comes from the most specific
+// matching override setting it, cascading competition, sport+market, sport
+// and finally the global parameters. Overrides at the same level apply in
+// the order listed.
+func (o *Optimizer) resolveMargin(normalized *models.NormalizedOdds) marginSettings {
+	settings := marginSettings{min: o.params.MinMargin, max: o.params.MaxMargin}
+
+	var minRank, maxRank, multiplierRank, levelRank int
+	for _, override := range o.params.MarginOverrides {
+		if !matchesRule(override.Sport, normalized.Sport) ||
+			!matchesRule(override.Market, normalized.Market) ||
+			!matchesRule(override.Competition, normalized.Competition) {
+			continue
+		}
+
+		level, rank := overrideLevel(override)
+		if rank > levelRank {
+			settings.level, levelRank = level, rank
+		}
+		if override.MinMargin.IsPositive() && rank > minRank {
+			settings.min, minRank = override.MinMargin, rank
+		}
+		if override.MaxMargin.IsPositive() && rank > maxRank {
+			settings.max, maxRank = override.MaxMargin, rank
+		}
+		if override.SportMultiplier.IsPositive() && rank > multiplierRank {
+			settings.multiplier, multiplierRank = override.SportMultiplier, rank
+		}
+	}
+
+	return settings
+}
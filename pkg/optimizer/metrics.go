@@ -0,0 +1,59 @@
+package optimizer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// optimizerMetrics holds Prometheus metrics for the optimizer
+type optimizerMetrics struct {
+	negativeMargin      prometheus.Counter
+	duplicateSelections prometheus.Counter
+	nonTradeable        prometheus.Counter
+	ladderInversions    prometheus.Counter
+	illiquidSelections  prometheus.Counter
+	nonFiniteConfidence prometheus.Counter
+}
+
+// newOptimizerMetrics creates optimizer metrics; they are recorded but not
+// exported until registered with RegisterMetrics
+func newOptimizerMetrics() *optimizerMetrics {
+	return &optimizerMetrics{
+		negativeMargin: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "optimizer_negative_margin_total",
+			Help: "Market books whose realized overround fell below zero after optimization.",
+		}),
+		duplicateSelections: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "optimizer_duplicate_selections_total",
+			Help: "Extra copies of an event+market+selection within one batch, dropped before optimizing.",
+		}),
+		nonTradeable: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "optimizer_non_tradeable_total",
+			Help: "Selections quoted at a back price of exactly 1.0 and skipped as non-tradeable.",
+		}),
+		ladderInversions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "optimizer_ladder_inversions_total",
+			Help: "Totals ladder rungs priced shorter than a less likely rung, smoothed or rejected.",
+		}),
+		illiquidSelections: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "optimizer_illiquid_selections_total",
+			Help: "Selections left out of a market book for liquidity below min_liquidity.",
+		}),
+		nonFiniteConfidence: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "optimizer_non_finite_confidence_total",
+			Help: "Confidence factors or results computed as NaN or infinite and replaced with a finite value.",
+		}),
+	}
+}
+
+// RegisterMetrics registers the optimizer's metrics with reg. Wrap reg with a
+// distinguishing label (e.g. profile) when registering several optimizers.
+func (o *Optimizer) RegisterMetrics(reg prometheus.Registerer) {
+	reg.MustRegister(
+		o.metrics.negativeMargin,
+		o.metrics.duplicateSelections,
+		o.metrics.nonTradeable,
+		o.metrics.ladderInversions,
+		o.metrics.illiquidSelections,
+		o.metrics.nonFiniteConfidence,
+	)
+}
@@ -0,0 +1,161 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// Methods of normalizing a book's implied probabilities to sum to one
+const (
+	NormalizationProportional = "proportional" // Divide every probability by the book sum (default)
+	NormalizationPower        = "power"        // Raise every probability to the power k at which they sum to one
+	NormalizationLogOdds      = "log_odds"     // Shift every probability's log-odds by the constant at which they sum to one
+)
+
+// normalizerIterations bounds the bisection searches of the power and
+// log-odds normalizers; 64 halvings exhaust float64 precision
+const normalizerIterations = 64
+
+// normalizerMaxDoublings bounds the search for a bracketing interval
+const normalizerMaxDoublings = 64
+
+// Normalizer removes the overround from a book's implied probabilities,
+// returning fair probabilities that sum to one. Proportional normalization
+// assumes the margin is spread evenly; power and log-odds normalization load
+// relatively more of it onto longshots.
+type Normalizer interface {
+	Normalize(impliedProbs []decimal.Decimal) []decimal.Decimal
+}
+
+// NewNormalizer returns the normalizer for a normalization method, dividing
+// at precision digits; unknown methods use proportional normalization
+func NewNormalizer(method string, precision int32) Normalizer {
+	switch method {
+	case NormalizationPower:
+		return powerNormalizer{}
+	case NormalizationLogOdds:
+		return logOddsNormalizer{}
+	default:
+		return proportionalNormalizer{dec: newDecimalContext(precision)}
+	}
+}
+
+// SetNormalizer replaces the normalizer BatchOptimizeMarket removes book
+// overround with, overriding NormalizationMethod
+func (o *Optimizer) SetNormalizer(normalizer Normalizer) {
+	o.normalizer = normalizer
+}
+
+// proportionalNormalizer divides every probability by the book sum
+type proportionalNormalizer struct {
+	dec decimalContext
+}
+
+// Normalize implements Normalizer
+func (n proportionalNormalizer) Normalize(impliedProbs []decimal.Decimal) []decimal.Decimal {
+	booksum := decimal.Zero
+	for _, prob := range impliedProbs {
+		booksum = booksum.Add(prob)
+	}
+
+	fairProbs := make([]decimal.Decimal, len(impliedProbs))
+	if !booksum.IsPositive() {
+		copy(fairProbs, impliedProbs)
+		return fairProbs
+	}
+	for i, prob := range impliedProbs {
+		fairProbs[i] = n.dec.div(prob, booksum)
+	}
+	return fairProbs
+}
+
+// powerNormalizer finds the exponent k at which sum(p^k) is one
+type powerNormalizer struct{}
+
+// Normalize implements Normalizer
+func (powerNormalizer) Normalize(impliedProbs []decimal.Decimal) []decimal.Decimal {
+	return normalizeFloat(impliedProbs, func(probs []float64) []float64 {
+		raise := func(k float64) []float64 {
+			fair := make([]float64, len(probs))
+			for i, p := range probs {
+				fair[i] = math.Pow(p, k)
+			}
+			return fair
+		}
+		// sum(p^k) falls from the selection count at k=0 as k rises
+		return bisectNormalization(raise, 0, 1)
+	})
+}
+
+// logOddsNormalizer finds the shift d at which sum(sigmoid(logit(p) - d)) is one
+type logOddsNormalizer struct{}
+
+// Normalize implements Normalizer
+func (logOddsNormalizer) Normalize(impliedProbs []decimal.Decimal) []decimal.Decimal {
+	return normalizeFloat(impliedProbs, func(probs []float64) []float64 {
+		shift := func(d float64) []float64 {
+			fair := make([]float64, len(probs))
+			for i, p := range probs {
+				fair[i] = 1 / (1 + math.Exp(d-math.Log(p/(1-p))))
+			}
+			return fair
+		}
+		// The sum approaches the selection count as d falls and zero as it rises
+		return bisectNormalization(shift, -1, 1)
+	})
+}
+
+// normalizeFloat applies a float64 normalization to probabilities in (0, 1),
+// converting back at a fixed exponent. Books it cannot solve (a probability
+// outside (0, 1), or fewer than two selections) are returned unchanged.
+func normalizeFloat(impliedProbs []decimal.Decimal, normalize func([]float64) []float64) []decimal.Decimal {
+	fairProbs := make([]decimal.Decimal, len(impliedProbs))
+	copy(fairProbs, impliedProbs)
+	if len(impliedProbs) < 2 {
+		return fairProbs
+	}
+
+	probs := make([]float64, len(impliedProbs))
+	for i, prob := range impliedProbs {
+		probs[i] = prob.InexactFloat64()
+		if probs[i] <= 0 || probs[i] >= 1 {
+			return fairProbs
+		}
+	}
+
+	for i, prob := range normalize(probs) {
+		fairProbs[i] = floatToDecimal(prob)
+	}
+	return fairProbs
+}
+
+// bisectNormalization finds the parameter x at which the probabilities
+// fair(x) sum to one, where the sum falls as x rises. The initial bracket
+// [low, high] is widened until it contains the root.
+func bisectNormalization(fair func(x float64) []float64, low, high float64) []float64 {
+	sum := func(x float64) float64 {
+		total := 0.0
+		for _, p := range fair(x) {
+			total += p
+		}
+		return total
+	}
+
+	for i := 0; i < normalizerMaxDoublings && sum(low) < 1; i++ {
+		low -= high - low
+	}
+	for i := 0; i < normalizerMaxDoublings && sum(high) > 1; i++ {
+		high += high - low
+	}
+
+	for i := 0; i < normalizerIterations; i++ {
+		mid := (low + high) / 2
+		if sum(mid) > 1 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return fair((low + high) / 2)
+}
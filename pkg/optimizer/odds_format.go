@@ -0,0 +1,112 @@
+package optimizer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// OddsFormat is a display format for decimal prices
+type OddsFormat string
+
+// Display formats for prices
+const (
+	FormatDecimal    OddsFormat = "decimal"    // 2.50
+	FormatFractional OddsFormat = "fractional" // 3/2
+	FormatAmerican   OddsFormat = "american"   // +150, or -125 for 1.80
+)
+
+// ErrUnknownOddsFormat is returned for a display format other than decimal,
+// fractional or american
+var ErrUnknownOddsFormat = errors.New("unknown odds format")
+
+// maxFractionalDenominator bounds fractional odds to the denominators
+// bookmakers quote; finer prices are shown as the nearest such fraction
+const maxFractionalDenominator = 100
+
+// ParseOddsFormat parses a display format case-insensitively; empty is decimal
+func ParseOddsFormat(format string) (OddsFormat, error) {
+	switch f := OddsFormat(strings.ToLower(strings.TrimSpace(format))); f {
+	case "":
+		return FormatDecimal, nil
+	case FormatDecimal, FormatFractional, FormatAmerican:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownOddsFormat, format)
+	}
+}
+
+// Render formats a decimal price for display. Prices of 1 or below have no
+// display form and render empty.
+func (f OddsFormat) Render(price decimal.Decimal) string {
+	if price.LessThanOrEqual(decimal.NewFromInt(1)) {
+		return ""
+	}
+
+	switch f {
+	case FormatFractional:
+		return fractionalOdds(price)
+	case FormatAmerican:
+		return americanOdds(price)
+	default:
+		return price.StringFixed(2)
+	}
+}
+
+// fractionalOdds renders price-1 as the closest fraction with a denominator
+// of at most maxFractionalDenominator, e.g. 2.5 as 3/2 and 1.909 as 10/11
+func fractionalOdds(price decimal.Decimal) string {
+	profit := price.Sub(decimal.NewFromInt(1)).Rat()
+	fraction := limitDenominator(profit, maxFractionalDenominator)
+	return fraction.Num().String() + "/" + fraction.Denom().String()
+}
+
+// americanOdds renders price as a moneyline: the profit on a 100 stake for
+// prices of 2 and above, or the stake needed to win 100 below 2
+func americanOdds(price decimal.Decimal) string {
+	hundred := decimal.NewFromInt(100)
+	profit := price.Sub(decimal.NewFromInt(1))
+	if price.GreaterThanOrEqual(decimal.NewFromInt(2)) {
+		return "+" + profit.Mul(hundred).Round(0).String()
+	}
+	return "-" + hundred.DivRound(profit, 4).Round(0).String()
+}
+
+// limitDenominator returns the closest fraction to x with a denominator of
+// at most maxDenominator, by continued fraction expansion
+func limitDenominator(x *big.Rat, maxDenominator int64) *big.Rat {
+	limit := big.NewInt(maxDenominator)
+	if x.Denom().Cmp(limit) <= 0 {
+		return x
+	}
+
+	p0, q0, p1, q1 := big.NewInt(0), big.NewInt(1), big.NewInt(1), big.NewInt(0)
+	n, d := new(big.Int).Set(x.Num()), new(big.Int).Set(x.Denom())
+	for {
+		a := new(big.Int).Quo(n, d)
+		q2 := new(big.Int).Add(q0, new(big.Int).Mul(a, q1))
+		if q2.Cmp(limit) > 0 {
+			break
+		}
+		p0, q0, p1, q1 = p1, q1, new(big.Int).Add(p0, new(big.Int).Mul(a, p1)), q2
+		n, d = d, new(big.Int).Sub(n, new(big.Int).Mul(a, d))
+	}
+
+	// The best approximation is the last convergent or the best semiconvergent
+	k := new(big.Int).Quo(new(big.Int).Sub(limit, q0), q1)
+	semi := new(big.Rat).SetFrac(
+		new(big.Int).Add(p0, new(big.Int).Mul(k, p1)),
+		new(big.Int).Add(q0, new(big.Int).Mul(k, q1)),
+	)
+	convergent := new(big.Rat).SetFrac(p1, q1)
+
+	semiErr := new(big.Rat).Abs(new(big.Rat).Sub(semi, x))
+	convergentErr := new(big.Rat).Abs(new(big.Rat).Sub(convergent, x))
+	if convergentErr.Cmp(semiErr) <= 0 {
+		return convergent
+	}
+	return semi
+}
@@ -1,8 +1,12 @@
 package optimizer
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,40 +16,458 @@ import (
 	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
 )
 
+var (
+	// ErrInvalidBackPrice is returned when a back price cannot be optimized
+	ErrInvalidBackPrice = errors.New("invalid back price")
+
+	// ErrNonTradeable is returned for a back price of exactly 1.0 when no
+	// EvenMoneyFloor is configured: the quote implies a certain outcome and
+	// cannot be priced, but unlike a price below 1.0 it is not corrupt
+	ErrNonTradeable = errors.New("non-tradeable back price")
+
+	// ErrExcessiveDrift is returned when an optimized price deviates from the
+	// original by more than MaxDriftPct, which usually means corrupt input
+	ErrExcessiveDrift = errors.New("optimized price drifts too far from original")
+
+	// ErrInvalidLayPrice is returned with RejectInvalidLay when the margin
+	// pushes the optimized lay probability out of range, e.g. for long shots
+	// whose fair probability is below the lay margin
+	ErrInvalidLayPrice = errors.New("optimized lay price out of range")
+
+	// ErrNegativeMargin is reported when an optimized book's realized
+	// overround is negative, i.e. the book would lose money
+	ErrNegativeMargin = errors.New("optimized book has negative margin")
+)
+
+// probabilityTolerance is the largest absolute difference between a supplied
+// probability and the one implied by its price that passes without a warning;
+// it absorbs rounding of quoted prices to the tick ladder
+var probabilityTolerance = decimal.NewFromFloat(0.01)
+
+// Liquidity thresholds, in base currency, used when unset
+var (
+	defaultLiquidityMarginThreshold = decimal.NewFromInt(10000) // Margin rises for liquidity below this
+	defaultLiquidityConfidenceCap   = decimal.NewFromInt(20000) // Liquidity at which confidence stops rising
+)
+
+// defaultMinLayProbability bounds optimized lay probabilities when
+// MinLayProbability is unset, capping lays at 1000 and flooring them at 1.001
+var defaultMinLayProbability = decimal.NewFromFloat(0.001)
+
+// defaultDivisionPrecision matches shopspring's default decimal.DivisionPrecision
+const defaultDivisionPrecision = 16
+
+// decimalContext divides at a fixed precision. Each optimizer has its own,
+// so optimizers with different precisions never touch the package-global
+// decimal.DivisionPrecision or each other.
+type decimalContext struct {
+	precision int32 // Digits kept after the decimal point by div
+}
+
+// newDecimalContext creates a decimal context; a non-positive precision
+// uses defaultDivisionPrecision
+func newDecimalContext(precision int32) decimalContext {
+	if precision <= 0 {
+		precision = defaultDivisionPrecision
+	}
+	return decimalContext{precision: precision}
+}
+
+// div returns a / b rounded to the context's precision
+func (c decimalContext) div(a, b decimal.Decimal) decimal.Decimal {
+	return a.DivRound(b, c.precision)
+}
+
 // Optimizer applies ML-based optimization to odds
 type Optimizer struct {
-	params models.OptimizationParams
-	logger zerolog.Logger
+	params           models.OptimizationParams
+	minMarginSports  map[string]bool
+	confidenceBounds map[string]models.ConfidenceBounds
+	baseCurrency     string
+	fxRates          map[string]decimal.Decimal
+	sourceRanks      map[string]int
+	consensus        *ConsensusBuilder
+	drawLabels       map[string]bool
+	dec              decimalContext
+	normalizer       Normalizer
+	metrics          *optimizerMetrics
+	logger           zerolog.Logger
+
+	optimizedCount      atomic.Uint64
+	rejectedCount       atomic.Uint64
+	booksCheckedCount   atomic.Uint64
+	negativeMarginCount atomic.Uint64
+}
+
+// Stats holds runtime counters for the optimizer
+type Stats struct {
+	Optimized      uint64 // Selections successfully optimized
+	Rejected       uint64 // Selections rejected by validation
+	BooksChecked   uint64 // Books checked for a negative realized margin
+	NegativeMargin uint64 // Books found with a negative realized margin
 }
 
 // NewOptimizer creates a new odds optimizer
 func NewOptimizer(params models.OptimizationParams, logger zerolog.Logger) *Optimizer {
+	minMarginSports := make(map[string]bool, len(params.MinMarginSports))
+	for _, sport := range params.MinMarginSports {
+		minMarginSports[strings.ToLower(sport)] = true
+	}
+
+	confidenceBounds := make(map[string]models.ConfidenceBounds, len(params.ConfidenceBounds))
+	for sport, bounds := range params.ConfidenceBounds {
+		confidenceBounds[strings.ToLower(sport)] = bounds
+	}
+
+	fxRates := make(map[string]decimal.Decimal, len(params.FXRates))
+	for currency, rate := range params.FXRates {
+		fxRates[strings.ToUpper(currency)] = rate
+	}
+
+	sourceRanks := make(map[string]int, len(params.SourcePreference))
+	for i, source := range params.SourcePreference {
+		if _, ok := sourceRanks[strings.ToLower(source)]; !ok {
+			sourceRanks[strings.ToLower(source)] = i
+		}
+	}
+
 	return &Optimizer{
-		params: params,
-		logger: logger.With().Str("component", "optimizer").Logger(),
+		params:           params,
+		minMarginSports:  minMarginSports,
+		confidenceBounds: confidenceBounds,
+		baseCurrency:     strings.ToUpper(params.BaseCurrency),
+		fxRates:          fxRates,
+		sourceRanks:      sourceRanks,
+		consensus:        NewConsensusBuilder(params.SourceWeights),
+		drawLabels:       newDrawLabels(params.DrawLabels),
+		dec:              newDecimalContext(params.DivisionPrecision),
+		normalizer:       NewNormalizer(params.NormalizationMethod, params.DivisionPrecision),
+		metrics:          newOptimizerMetrics(),
+		logger:           logger.With().Str("component", "optimizer").Logger(),
+	}
+}
+
+// Params returns the effective optimization parameters
+func (o *Optimizer) Params() models.OptimizationParams {
+	return o.params
+}
+
+// Stats returns a snapshot of the optimizer's runtime counters
+func (o *Optimizer) Stats() Stats {
+	return Stats{
+		Optimized:      o.optimizedCount.Load(),
+		Rejected:       o.rejectedCount.Load(),
+		BooksChecked:   o.booksCheckedCount.Load(),
+		NegativeMargin: o.negativeMarginCount.Load(),
 	}
 }
 
 // Optimize applies optimization algorithms to normalized odds
 func (o *Optimizer) Optimize(normalized *models.NormalizedOdds) (*models.OptimizedOdds, error) {
 	// Validate input
-	if normalized.BackPrice.LessThanOrEqual(decimal.NewFromInt(1)) {
-		return nil, fmt.Errorf("invalid back price: %s", normalized.BackPrice.String())
+	if err := o.validate(normalized); err != nil {
+		o.rejectedCount.Add(1)
+		return nil, err
 	}
 
-	// Calculate implied probability from original odds
-	impliedProbBack := o.calculateImpliedProbability(normalized.BackPrice)
+	// Calculate implied probability from original odds or supplied probability
+	impliedProbBack := o.impliedBackProbability(normalized)
 	_ = decimal.Zero // impliedProbLay for future use
 	if !normalized.LayPrice.IsZero() && normalized.LayPrice.GreaterThan(decimal.NewFromInt(1)) {
 		_ = o.calculateImpliedProbability(normalized.LayPrice)
 	}
 
+	// Without the rest of the book, the implied probability is the best fair
+	// estimate, unless a model probability is supplied
+	if trueProb, ok := trueProbability(normalized); ok {
+		impliedProbBack = trueProb
+	}
+	optimized, err := o.optimizeFromProbability(normalized, impliedProbBack)
+	if err != nil {
+		return nil, err
+	}
+
+	o.optimizedCount.Add(1)
+	return optimized, nil
+}
+
+// validate checks that normalized odds can be optimized. A valid back
+// probability stands in for the back price.
+func (o *Optimizer) validate(normalized *models.NormalizedOdds) error {
+	if _, ok := validProbability(normalized.BackProb); ok {
+		o.checkProbabilityConsistency(normalized)
+		return nil
+	}
+	if normalized.BackPrice.Equal(decimal.NewFromInt(1)) {
+		if o.params.EvenMoneyFloor.GreaterThan(decimal.NewFromInt(1)) {
+			return nil
+		}
+		o.metrics.nonTradeable.Inc()
+		return fmt.Errorf("%w: %s", ErrNonTradeable, normalized.BackPrice.String())
+	}
+	if normalized.BackPrice.LessThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("%w: %s", ErrInvalidBackPrice, normalized.BackPrice.String())
+	}
+	return nil
+}
+
+// impliedBackProbability returns the supplied back probability when valid,
+// otherwise the probability implied by the back price
+func (o *Optimizer) impliedBackProbability(normalized *models.NormalizedOdds) decimal.Decimal {
+	if prob, ok := validProbability(normalized.BackProb); ok {
+		return prob
+	}
+	return o.calculateImpliedProbability(o.backPrice(normalized))
+}
+
+// backPrice returns the quoted back price, or the price implied by the back
+// probability when no usable price was quoted. A quote of exactly 1.0 is
+// raised to EvenMoneyFloor when one is configured.
+func (o *Optimizer) backPrice(normalized *models.NormalizedOdds) decimal.Decimal {
+	if normalized.BackPrice.GreaterThan(decimal.NewFromInt(1)) {
+		return normalized.BackPrice
+	}
+	if prob, ok := validProbability(normalized.BackProb); ok {
+		return o.probabilityToOdds(prob)
+	}
+	if normalized.BackPrice.Equal(decimal.NewFromInt(1)) && o.params.EvenMoneyFloor.GreaterThan(decimal.NewFromInt(1)) {
+		return o.params.EvenMoneyFloor
+	}
+	return normalized.BackPrice
+}
+
+// layPrice returns the quoted lay price, or the price implied by the lay
+// probability when no usable price was quoted
+func (o *Optimizer) layPrice(normalized *models.NormalizedOdds) decimal.Decimal {
+	if normalized.LayPrice.GreaterThan(decimal.NewFromInt(1)) {
+		return normalized.LayPrice
+	}
+	if prob, ok := validProbability(normalized.LayProb); ok {
+		return o.probabilityToOdds(prob)
+	}
+	return normalized.LayPrice
+}
+
+// checkProbabilityConsistency warns when a selection quotes both a price and
+// a probability that disagree by more than probabilityTolerance. The
+// probability is still used: it is the source's unrounded figure.
+func (o *Optimizer) checkProbabilityConsistency(normalized *models.NormalizedOdds) {
+	sides := []struct {
+		side  string
+		price decimal.Decimal
+		prob  decimal.Decimal
+	}{
+		{side: "back", price: normalized.BackPrice, prob: normalized.BackProb},
+		{side: "lay", price: normalized.LayPrice, prob: normalized.LayProb},
+	}
+
+	for _, s := range sides {
+		prob, ok := validProbability(s.prob)
+		if !ok || s.price.LessThanOrEqual(decimal.NewFromInt(1)) {
+			continue
+		}
+		implied := o.calculateImpliedProbability(s.price)
+		if implied.Sub(prob).Abs().LessThanOrEqual(probabilityTolerance) {
+			continue
+		}
+		o.logger.Warn().
+			Str("event_id", normalized.EventID).
+			Str("selection", normalized.Selection).
+			Str("side", s.side).
+			Str("price", s.price.String()).
+			Str("implied_prob", implied.StringFixed(4)).
+			Str("supplied_prob", prob.String()).
+			Msg("supplied probability inconsistent with price")
+	}
+}
+
+// optimizeFromProbability applies margin and spread around a fair (margin-free) probability
+func (o *Optimizer) optimizeFromProbability(normalized *models.NormalizedOdds, impliedProbBack decimal.Decimal) (*models.OptimizedOdds, error) {
+	optimized, _, err := o.explainFromProbability(normalized, impliedProbBack)
+	return optimized, err
+}
+
+// explainFromProbability is optimizeFromProbability, also returning the
+// breakdown of how the price was reached
+func (o *Optimizer) explainFromProbability(normalized *models.NormalizedOdds, impliedProbBack decimal.Decimal) (*models.OptimizedOdds, *Explanation, error) {
+	return o.explainWithMargin(normalized, impliedProbBack, o.explainMargin(normalized))
+}
+
+// explainWithMargin prices around a fair probability with an already
+// determined margin, returning the breakdown of how the price was reached
+func (o *Optimizer) explainWithMargin(normalized *models.NormalizedOdds, impliedProbBack decimal.Decimal, margin MarginExplanation) (*models.OptimizedOdds, *Explanation, error) {
 	// Apply margin optimization
-	targetMargin := o.calculateTargetMargin(normalized)
+	targetMargin := margin.Applied
+
+	// Apply margin around the fair probability and enforce the minimum spread
+	minSpread := o.minSpread(impliedProbBack)
+	var optimizedBack, optimizedLay, spread decimal.Decimal
+	var layBounded bool
+	if o.params.FastMath {
+		optimizedBack, optimizedLay, spread, layBounded = o.applyMarginFloat(impliedProbBack, targetMargin, minSpread)
+	} else {
+		optimizedBack, optimizedLay, spread, layBounded = o.applyMargin(impliedProbBack, targetMargin, minSpread)
+	}
+
+	// A lay the margin pushed out of range was clamped rather than published
+	// at the 1.0 safeguard; reject it instead when configured to
+	if layBounded {
+		if o.params.RejectInvalidLay {
+			o.rejectedCount.Add(1)
+			return nil, nil, fmt.Errorf("%w: fair probability %s, margin %s",
+				ErrInvalidLayPrice, impliedProbBack.StringFixed(4), targetMargin.StringFixed(4))
+		}
+		o.logger.Warn().
+			Str("event_id", normalized.EventID).
+			Str("market", normalized.Market).
+			Str("selection", normalized.Selection).
+			Str("fair_probability", impliedProbBack.StringFixed(4)).
+			Str("optimized_lay", optimizedLay.String()).
+			Msg("optimized lay probability out of range, clamped to min lay probability")
+	}
+
+	// Reject prices too far from the input rather than publishing them
+	if err := o.checkDrift(normalized, optimizedBack); err != nil {
+		o.rejectedCount.Add(1)
+		return nil, nil, err
+	}
+
+	// Calculate confidence based on liquidity and spread
+	confidence := o.explainConfidence(normalized, spread)
+
+	optimized := &models.OptimizedOdds{
+		ID:            uuid.New(),
+		EventID:       normalized.EventID,
+		EventName:     normalized.EventName,
+		Sport:         normalized.Sport,
+		Competition:   normalized.Competition,
+		Market:        normalized.Market,
+		Selection:     normalized.Selection,
+		OptimizedBack: optimizedBack,
+		OptimizedLay:  optimizedLay,
+		FairPrice:     o.probabilityToOdds(impliedProbBack),
+		OriginalBack:  o.backPrice(normalized),
+		OriginalLay:   o.layPrice(normalized),
+		BackSize:      normalized.BackSize,
+		LaySize:       normalized.LaySize,
+		Margin:        targetMargin,
+		Confidence:    confidence.Confidence,
+		InPlay:        normalized.InPlay,
+		StartTime:     normalized.StartTime,
+		Line:          normalized.Line,
+		Source:        normalized.Source,
+		Regions:       o.allowedRegions(normalized),
+		Timestamp:     normalized.Timestamp,
+		OptimizedAt:   time.Now().UTC(),
+	}
+
+	// Key on a canonical selection name, keeping the feed's name for display
+	if o.params.NormalizeSelections {
+		optimized.Selection = CanonicalSelection(normalized.Selection)
+		optimized.DisplaySelection = strings.Join(strings.Fields(normalized.Selection), " ")
+	}
+
+	// Sportsbook audiences get a single fixed-odds price carrying the full margin
+	if o.params.EmitSportsbook {
+		sportsbookPrice := o.probabilityToOdds(impliedProbBack.Add(targetMargin))
+		optimized.SportsbookPrice = &sportsbookPrice
+	}
+	o.roundOutput(optimized)
+
+	explanation := &Explanation{
+		FairProbability:  impliedProbBack,
+		Margin:           margin,
+		Spread:           spread,
+		SpreadAdjustment: decimal.Zero,
+		Confidence:       confidence,
+	}
+	if spread.LessThan(minSpread) {
+		explanation.SpreadAdjustment = minSpread.Sub(spread).Div(decimal.NewFromInt(2))
+	}
+
+	o.traceOptimization(normalized, optimized, explanation)
+
+	return optimized, explanation, nil
+}
 
+// trueProbability returns the supplied model probability when it is a valid
+// probability strictly between 0 and 1
+func trueProbability(normalized *models.NormalizedOdds) (decimal.Decimal, bool) {
+	return validProbability(normalized.TrueProbability)
+}
+
+// validProbability returns prob when it lies strictly between 0 and 1
+func validProbability(prob decimal.Decimal) (decimal.Decimal, bool) {
+	if !prob.IsPositive() || prob.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return decimal.Zero, false
+	}
+	return prob, true
+}
+
+// CanonicalSelection lowercases a selection name, trims it and collapses
+// internal whitespace, so feed variants like "Team A" and " TEAM  a" match
+func CanonicalSelection(selection string) string {
+	return strings.ToLower(strings.Join(strings.Fields(selection), " "))
+}
+
+// checkDrift rejects an optimized back price deviating from the original back
+// price by more than MaxDriftPct percent (0 disables the guard)
+func (o *Optimizer) checkDrift(normalized *models.NormalizedOdds, optimizedBack decimal.Decimal) error {
+	backPrice := o.backPrice(normalized)
+	if !o.params.MaxDriftPct.IsPositive() || !backPrice.IsPositive() {
+		return nil
+	}
+
+	driftPct := optimizedBack.Sub(backPrice).Abs().
+		Div(backPrice).
+		Mul(decimal.NewFromInt(100))
+	if driftPct.GreaterThan(o.params.MaxDriftPct) {
+		return fmt.Errorf("%w: original %s, optimized %s (%s%%)",
+			ErrExcessiveDrift, backPrice.String(), optimizedBack.String(), driftPct.StringFixed(2))
+	}
+
+	return nil
+}
+
+// minSpread returns the minimum back-lay spread at a fair probability: the
+// larger of MinSpread and MinSpreadPct percent of the fair price, so the
+// spread scales with the odds instead of being tiny at long odds and huge
+// at short odds
+func (o *Optimizer) minSpread(fairProb decimal.Decimal) decimal.Decimal {
+	if !o.params.MinSpreadPct.IsPositive() || !fairProb.IsPositive() {
+		return o.params.MinSpread
+	}
+
+	pctSpread := o.probabilityToOdds(fairProb).
+		Mul(o.params.MinSpreadPct).
+		Div(decimal.NewFromInt(100))
+	return decimal.Max(o.params.MinSpread, pctSpread)
+}
+
+// marginShares splits the target margin between the back and lay sides by
+// BackMarginWeight and LayMarginWeight, evenly when they are unset. Weights
+// not summing to one are scaled so the shares always total the target margin.
+func (o *Optimizer) marginShares(targetMargin decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	backWeight, layWeight := o.params.BackMarginWeight, o.params.LayMarginWeight
+	total := backWeight.Add(layWeight)
+	if backWeight.IsNegative() || layWeight.IsNegative() || !total.IsPositive() {
+		half := targetMargin.Div(decimal.NewFromInt(2))
+		return half, half
+	}
+
+	backMargin := o.dec.div(targetMargin.Mul(backWeight), total)
+	return backMargin, targetMargin.Sub(backMargin)
+}
+
+// applyMargin returns optimized back/lay prices, the pre-adjustment spread
+// and whether the lay was clamped into range. Lay probabilities are kept
+// within [m, 1-m] for the minimum lay probability m, and the back price at
+// least the minimum spread above a clamped lay.
+func (o *Optimizer) applyMargin(impliedProbBack, targetMargin, minSpread decimal.Decimal) (decimal.Decimal, decimal.Decimal, decimal.Decimal, bool) {
 	// Calculate optimized probabilities (add our margin)
-	optimizedProbBack := impliedProbBack.Add(targetMargin.Div(decimal.NewFromInt(2)))
-	optimizedProbLay := impliedProbBack.Sub(targetMargin.Div(decimal.NewFromInt(2)))
+	backMargin, layMargin := o.marginShares(targetMargin)
+	optimizedProbBack := impliedProbBack.Add(backMargin)
+	optimizedProbLay, bounded := o.boundLayProbability(impliedProbBack.Sub(layMargin))
 
 	// Convert probabilities back to odds
 	optimizedBack := o.probabilityToOdds(optimizedProbBack)
@@ -53,41 +475,104 @@ func (o *Optimizer) Optimize(normalized *models.NormalizedOdds) (*models.Optimiz
 
 	// Ensure minimum spread
 	spread := optimizedBack.Sub(optimizedLay)
-	if spread.LessThan(o.params.MinSpread) {
-		adjustment := o.params.MinSpread.Sub(spread).Div(decimal.NewFromInt(2))
+	if spread.LessThan(minSpread) {
+		adjustment := minSpread.Sub(spread).Div(decimal.NewFromInt(2))
 		optimizedBack = optimizedBack.Add(adjustment)
 		optimizedLay = optimizedLay.Sub(adjustment)
 	}
 
-	// Calculate confidence based on liquidity and spread
-	confidence := o.calculateConfidence(normalized, spread)
-
-	return &models.OptimizedOdds{
-		ID:              uuid.New(),
-		EventID:         normalized.EventID,
-		EventName:       normalized.EventName,
-		Sport:           normalized.Sport,
-		Competition:     normalized.Competition,
-		Market:          normalized.Market,
-		Selection:       normalized.Selection,
-		OptimizedBack:   optimizedBack,
-		OptimizedLay:    optimizedLay,
-		OriginalBack:    normalized.BackPrice,
-		OriginalLay:     normalized.LayPrice,
-		BackSize:        normalized.BackSize,
-		LaySize:         normalized.LaySize,
-		Margin:          targetMargin,
-		Confidence:      confidence,
-		Timestamp:       normalized.Timestamp,
-		OptimizedAt:     time.Now().UTC(),
-	}, nil
+	// The spread adjustment can push a short lay to 1.0 or below
+	if minLay := o.minLayPrice(); optimizedLay.LessThan(minLay) {
+		bounded = true
+		optimizedLay = decimal.Max(optimizedLay, minLay)
+		optimizedBack = decimal.Max(optimizedBack, optimizedLay.Add(minSpread))
+	}
+
+	return optimizedBack, optimizedLay, spread, bounded
+}
+
+// minLayProbability returns MinLayProbability, or defaultMinLayProbability
+// when it is unset or not below one half
+func (o *Optimizer) minLayProbability() decimal.Decimal {
+	floor := o.params.MinLayProbability
+	if !floor.IsPositive() || floor.GreaterThanOrEqual(decimal.NewFromFloat(0.5)) {
+		return defaultMinLayProbability
+	}
+	return floor
+}
+
+// boundLayProbability raises an optimized lay probability below the minimum
+// lay probability to it, reporting whether it did
+func (o *Optimizer) boundLayProbability(prob decimal.Decimal) (decimal.Decimal, bool) {
+	if floor := o.minLayProbability(); prob.LessThan(floor) {
+		return floor, true
+	}
+	return prob, false
+}
+
+// minLayPrice returns the shortest lay price allowed, at one minus the
+// minimum lay probability
+func (o *Optimizer) minLayPrice() decimal.Decimal {
+	return o.probabilityToOdds(decimal.NewFromInt(1).Sub(o.minLayProbability()))
+}
+
+// applyMarginFloat is the float64 equivalent of applyMargin, trading a tiny
+// precision loss for throughput. Results are converted back to decimal.
+func (o *Optimizer) applyMarginFloat(impliedProbBack, targetMargin, minSpreadDec decimal.Decimal) (decimal.Decimal, decimal.Decimal, decimal.Decimal, bool) {
+	prob := impliedProbBack.InexactFloat64()
+	backMarginDec, layMarginDec := o.marginShares(targetMargin)
+	backMargin, layMargin := backMarginDec.InexactFloat64(), layMarginDec.InexactFloat64()
+	minSpread := minSpreadDec.InexactFloat64()
+
+	probLay, bounded := prob-layMargin, false
+	if floor := o.minLayProbability().InexactFloat64(); probLay < floor {
+		probLay, bounded = floor, true
+	}
+
+	optimizedBack := probabilityToOddsFloat(prob + backMargin)
+	optimizedLay := probabilityToOddsFloat(probLay)
+
+	spread := optimizedBack - optimizedLay
+	if spread < minSpread {
+		adjustment := (minSpread - spread) / 2
+		optimizedBack += adjustment
+		optimizedLay -= adjustment
+	}
+
+	if minLay := o.minLayPrice().InexactFloat64(); optimizedLay < minLay {
+		bounded = true
+		optimizedLay = math.Max(optimizedLay, minLay)
+		optimizedBack = math.Max(optimizedBack, optimizedLay+minSpread)
+	}
+
+	return floatToDecimal(optimizedBack), floatToDecimal(optimizedLay), floatToDecimal(spread), bounded
+}
+
+// floatToDecimal converts fast-path results at a fixed 16-digit exponent,
+// which is considerably cheaper than decimal.NewFromFloat's shortest repr
+func floatToDecimal(f float64) decimal.Decimal {
+	return decimal.NewFromFloatWithExponent(f, -16)
+}
+
+// probabilityToOddsFloat is the float64 equivalent of probabilityToOdds
+func probabilityToOddsFloat(prob float64) float64 {
+	if prob <= 0 || prob >= 1 {
+		return 1 // Safeguard
+	}
+	return 1 / prob
 }
 
 // calculateImpliedProbability converts decimal odds to implied probability
 func (o *Optimizer) calculateImpliedProbability(odds decimal.Decimal) decimal.Decimal {
 	// Implied probability = 1 / decimal_odds
 	// Example: 2.50 odds = 1/2.50 = 0.40 = 40%
-	return decimal.NewFromInt(1).Div(odds)
+	if !odds.IsPositive() {
+		return decimal.Zero // Safeguard: no probability mass for non-positive odds
+	}
+	if o.params.FastMath {
+		return floatToDecimal(1 / odds.InexactFloat64())
+	}
+	return o.dec.div(decimal.NewFromInt(1), odds)
 }
 
 // probabilityToOdds converts implied probability to decimal odds
@@ -97,45 +582,156 @@ func (o *Optimizer) probabilityToOdds(prob decimal.Decimal) decimal.Decimal {
 	if prob.LessThanOrEqual(decimal.Zero) || prob.GreaterThanOrEqual(decimal.NewFromInt(1)) {
 		return decimal.NewFromInt(1) // Safeguard
 	}
-	return decimal.NewFromInt(1).Div(prob)
+	return o.dec.div(decimal.NewFromInt(1), prob)
+}
+
+// liquidity returns the selection's total back and lay size in the base
+// currency. Sizes without a currency are taken to be in the base currency;
+// currencies missing from the FX table are too, with a warning.
+func (o *Optimizer) liquidity(normalized *models.NormalizedOdds) decimal.Decimal {
+	total := normalized.BackSize.Add(normalized.LaySize)
+
+	currency := strings.ToUpper(normalized.Currency)
+	if currency == "" || currency == o.baseCurrency {
+		return total
+	}
+
+	rate, ok := o.fxRates[currency]
+	if !ok || !rate.IsPositive() {
+		o.logger.Warn().
+			Str("event_id", normalized.EventID).
+			Str("currency", currency).
+			Str("base_currency", o.baseCurrency).
+			Msg("no FX rate for currency, treating sizes as base currency")
+		return total
+	}
+
+	return total.Mul(rate)
 }
 
 // calculateTargetMargin determines the optimal margin based on event characteristics
 func (o *Optimizer) calculateTargetMargin(normalized *models.NormalizedOdds) decimal.Decimal {
+	return o.explainMargin(normalized).Applied
+}
+
+// illiquid reports whether a selection's liquidity is below MinLiquidity,
+// counting and logging the ones it leaves out of a book
+func (o *Optimizer) illiquid(normalized *models.NormalizedOdds) bool {
+	if !o.params.MinLiquidity.IsPositive() {
+		return false
+	}
+
+	liquidity := o.liquidity(normalized)
+	if liquidity.GreaterThanOrEqual(o.params.MinLiquidity) {
+		return false
+	}
+
+	o.metrics.illiquidSelections.Inc()
+	o.logger.Debug().
+		Str("event_id", normalized.EventID).
+		Str("market", normalized.Market).
+		Str("selection", normalized.Selection).
+		Str("liquidity", liquidity.String()).
+		Msg("selection below min liquidity left out of book")
+	return true
+}
+
+// liquidityMarginThreshold returns the liquidity below which margin is
+// raised, defaultLiquidityMarginThreshold when unset
+func (o *Optimizer) liquidityMarginThreshold() decimal.Decimal {
+	if o.params.LiquidityMarginThreshold.IsPositive() {
+		return o.params.LiquidityMarginThreshold
+	}
+	return defaultLiquidityMarginThreshold
+}
+
+// liquidityConfidenceCap returns the liquidity at which the liquidity
+// confidence factor peaks, defaultLiquidityConfidenceCap when unset
+func (o *Optimizer) liquidityConfidenceCap() decimal.Decimal {
+	if o.params.LiquidityConfidenceCap.IsPositive() {
+		return o.params.LiquidityConfidenceCap
+	}
+	return defaultLiquidityConfidenceCap
+}
+
+// explainMargin determines the target margin, recording each adjustment
+func (o *Optimizer) explainMargin(normalized *models.NormalizedOdds) MarginExplanation {
+	settings := o.resolveMargin(normalized)
+	explanation := MarginExplanation{
+		Base:                settings.min,
+		LiquidityAdjustment: decimal.Zero,
+		SportMultiplier:     decimal.NewFromInt(1),
+		DrawMultiplier:      decimal.NewFromInt(1),
+		BookScale:           decimal.NewFromInt(1),
+		Min:                 settings.min,
+		Max:                 settings.max,
+		Commission:          o.params.Commission,
+		Override:            settings.level,
+	}
+
 	// Start with base margin
-	margin := o.params.MinMargin
+	margin := settings.min
 
 	// Adjust margin based on liquidity (lower liquidity = higher margin/risk)
-	totalLiquidity := normalized.BackSize.Add(normalized.LaySize)
-	liquidityThreshold := decimal.NewFromInt(10000) // $10k threshold
+	totalLiquidity := o.liquidity(normalized)
+	liquidityThreshold := o.liquidityMarginThreshold()
 
 	if totalLiquidity.LessThan(liquidityThreshold) {
 		// Low liquidity: increase margin
-		liquidityFactor := totalLiquidity.Div(liquidityThreshold)
-		marginIncrease := o.params.MaxMargin.Sub(o.params.MinMargin).Mul(decimal.NewFromInt(1).Sub(liquidityFactor))
+		liquidityFactor := o.dec.div(totalLiquidity, liquidityThreshold)
+		marginIncrease := settings.max.Sub(settings.min).Mul(decimal.NewFromInt(1).Sub(liquidityFactor))
 		margin = margin.Add(marginIncrease)
+		explanation.LiquidityAdjustment = marginIncrease
+	}
+
+	// Trusted high-volume sports run at the minimum margin without a sport multiplier
+	if o.minMarginSports[strings.ToLower(normalized.Sport)] {
+		explanation.MinMarginSport = true
+		explanation.Unclamped = margin
+		explanation.Applied = o.grossUpForCommission(clampMargin(margin, settings.min, settings.max))
+		return explanation
 	}
 
 	// Adjust margin based on sport/market type (could use ML model here)
-	// For now, use simple rules:
-	switch normalized.Sport {
-	case "football", "soccer":
+	// For now, use simple rules unless an override sets the multiplier:
+	switch {
+	case settings.multiplier.IsPositive():
+		explanation.SportMultiplier = settings.multiplier
+	case normalized.Sport == "football", normalized.Sport == "soccer":
 		// Lower margin for high-volume sports
-		margin = margin.Mul(decimal.NewFromFloat(0.8))
-	case "tennis":
+		explanation.SportMultiplier = decimal.NewFromFloat(0.8)
+	case normalized.Sport == "tennis":
 		// Moderate margin
-		margin = margin.Mul(decimal.NewFromFloat(1.0))
+		explanation.SportMultiplier = decimal.NewFromFloat(1.0)
 	default:
 		// Higher margin for niche sports
-		margin = margin.Mul(decimal.NewFromFloat(1.2))
+		explanation.SportMultiplier = decimal.NewFromFloat(1.2)
+	}
+	margin = margin.Mul(explanation.SportMultiplier)
+
+	explanation.Unclamped = margin
+	explanation.Applied = o.grossUpForCommission(clampMargin(margin, settings.min, settings.max))
+	return explanation
+}
+
+// grossUpForCommission raises a margin so it is still earned after the
+// exchange takes Commission of net winnings: margin / (1 - Commission).
+// A commission outside (0, 1) leaves the margin unchanged.
+func (o *Optimizer) grossUpForCommission(margin decimal.Decimal) decimal.Decimal {
+	commission := o.params.Commission
+	if !commission.IsPositive() || !commission.LessThan(decimal.NewFromInt(1)) {
+		return margin
 	}
+	return o.dec.div(margin, decimal.NewFromInt(1).Sub(commission))
+}
 
-	// Ensure margin is within bounds
-	if margin.LessThan(o.params.MinMargin) {
-		margin = o.params.MinMargin
+// clampMargin ensures margin is within [minMargin, maxMargin]
+func clampMargin(margin, minMargin, maxMargin decimal.Decimal) decimal.Decimal {
+	if margin.LessThan(minMargin) {
+		margin = minMargin
 	}
-	if margin.GreaterThan(o.params.MaxMargin) {
-		margin = o.params.MaxMargin
+	if margin.GreaterThan(maxMargin) {
+		margin = maxMargin
 	}
 
 	return margin
@@ -143,25 +739,48 @@ func (o *Optimizer) calculateTargetMargin(normalized *models.NormalizedOdds) dec
 
 // calculateConfidence calculates model confidence based on various factors
 func (o *Optimizer) calculateConfidence(normalized *models.NormalizedOdds, spread decimal.Decimal) float64 {
+	return o.explainConfidence(normalized, spread).Confidence
+}
+
+// explainConfidence calculates model confidence, recording each factor
+func (o *Optimizer) explainConfidence(normalized *models.NormalizedOdds, spread decimal.Decimal) ConfidenceExplanation {
+	var explanation ConfidenceExplanation
+
 	// Base confidence
-	confidence := o.params.TargetConfidence
+	confidence := o.finiteConfidence(normalized, "target", o.params.TargetConfidence)
+	explanation.Target = confidence
 
 	// Factor 1: Liquidity (more liquidity = higher confidence)
-	totalLiquidity := normalized.BackSize.Add(normalized.LaySize)
-	liquidityScore := math.Min(1.0, totalLiquidity.InexactFloat64()/20000.0) // Max at $20k
-	confidence *= (0.7 + 0.3*liquidityScore) // Scale 0.7-1.0
+	totalLiquidity := o.liquidity(normalized)
+	liquidityScore := math.Min(1.0, o.dec.div(totalLiquidity, o.liquidityConfidenceCap()).InexactFloat64()) // Max at the cap
+	explanation.LiquidityFactor = o.finiteConfidence(normalized, "liquidity", 0.7+0.3*liquidityScore)       // Scale 0.7-1.0
+	confidence *= explanation.LiquidityFactor
 
 	// Factor 2: Spread (tighter spread = higher confidence)
-	spreadPercent := spread.Div(normalized.BackPrice).InexactFloat64()
-	spreadScore := math.Max(0.0, 1.0-spreadPercent*10) // Penalty for wide spreads
-	confidence *= (0.8 + 0.2*spreadScore) // Scale 0.8-1.0
+	spreadScore := 0.0 // Without a positive back price the spread is unmeasurable
+	if backPrice := o.backPrice(normalized); backPrice.IsPositive() {
+		spreadPercent := o.dec.div(spread, backPrice).InexactFloat64()
+		spreadScore = math.Max(0.0, 1.0-spreadPercent*10) // Penalty for wide spreads
+	}
+	explanation.SpreadFactor = o.finiteConfidence(normalized, "spread", 0.8+0.2*spreadScore) // Scale 0.8-1.0
+	confidence *= explanation.SpreadFactor
 
 	// Factor 3: Data freshness (newer = higher confidence)
 	age := time.Since(normalized.Timestamp)
-	freshnessScore := math.Max(0.0, 1.0-age.Minutes()/60.0) // Decay over 1 hour
-	confidence *= (0.9 + 0.1*freshnessScore) // Scale 0.9-1.0
+	freshnessScore := math.Max(0.0, 1.0-age.Minutes()/60.0)                                           // Decay over 1 hour
+	explanation.FreshnessFactor = o.finiteConfidence(normalized, "freshness", 0.9+0.1*freshnessScore) // Scale 0.9-1.0
+	confidence *= explanation.FreshnessFactor
+
+	// Factor 4: Price stability (steadier recent prices = higher confidence)
+	explanation.StabilityFactor = o.finiteConfidence(normalized, "stability", o.stabilityFactor(normalized)) // Scale 1-StabilityWeight to 1.0
+	confidence *= explanation.StabilityFactor
+
+	// Factor 5: Lay quote (a one-sided book, with a synthesized lay, is less trusted)
+	explanation.LayFactor = o.finiteConfidence(normalized, "lay", o.layFactor(normalized))
+	confidence *= explanation.LayFactor
 
 	// Clamp confidence to [0, 1]
+	confidence = o.finiteConfidence(normalized, "confidence", confidence)
 	if confidence < 0.0 {
 		confidence = 0.0
 	}
@@ -169,11 +788,72 @@ func (o *Optimizer) calculateConfidence(normalized *models.NormalizedOdds, sprea
 		confidence = 1.0
 	}
 
-	return confidence
+	explanation.Clamped = confidence
+
+	// Enforce risk's per-sport floor and ceiling
+	if bounds, ok := o.confidenceBounds[strings.ToLower(normalized.Sport)]; ok {
+		confidence = math.Max(bounds.Min, math.Min(bounds.Max, confidence))
+		explanation.Bounds = &bounds
+	}
+
+	explanation.Confidence = confidence
+	return explanation
+}
+
+// finiteConfidence guards a confidence factor or result against NaN and
+// infinities from the float math, which cannot be encoded as JSON: +Inf
+// becomes 1, -Inf becomes 0 and NaN becomes NonFiniteConfidence. Each
+// replacement is counted and logged.
+func (o *Optimizer) finiteConfidence(normalized *models.NormalizedOdds, factor string, value float64) float64 {
+	if !math.IsNaN(value) && !math.IsInf(value, 0) {
+		return value
+	}
+
+	replacement := o.nonFiniteConfidence()
+	switch {
+	case math.IsInf(value, 1):
+		replacement = 1.0
+	case math.IsInf(value, -1):
+		replacement = 0.0
+	}
+
+	o.metrics.nonFiniteConfidence.Inc()
+	o.logger.Warn().
+		Str("event_id", normalized.EventID).
+		Str("selection", normalized.Selection).
+		Str("factor", factor).
+		Str("value", strconv.FormatFloat(value, 'g', -1, 64)).
+		Float64("replacement", replacement).
+		Msg("non-finite confidence replaced")
+	return replacement
+}
+
+// nonFiniteConfidence returns NonFiniteConfidence clamped to [0, 1], 0 when
+// it is not a number itself
+func (o *Optimizer) nonFiniteConfidence() float64 {
+	if math.IsNaN(o.params.NonFiniteConfidence) {
+		return 0.0
+	}
+	return math.Max(0.0, math.Min(1.0, o.params.NonFiniteConfidence))
+}
+
+// layFactor returns MissingLayPenalty when the input quotes neither a lay
+// price above 1 nor a valid lay probability, so its lay is synthesized from
+// the back side, and 1 otherwise or when the penalty is disabled
+func (o *Optimizer) layFactor(normalized *models.NormalizedOdds) float64 {
+	penalty := o.params.MissingLayPenalty
+	if penalty <= 0 || penalty == 1 {
+		return 1.0
+	}
+	if o.layPrice(normalized).GreaterThan(decimal.NewFromInt(1)) {
+		return 1.0
+	}
+	return penalty
 }
 
 // BatchOptimize optimizes a batch of normalized odds
 func (o *Optimizer) BatchOptimize(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+	normalized = o.dedupeSelections(normalized)
 	optimized := make([]*models.OptimizedOdds, 0, len(normalized))
 
 	for _, odds := range normalized {
@@ -196,3 +876,179 @@ func (o *Optimizer) BatchOptimize(normalized []*models.NormalizedOdds) ([]*model
 
 	return optimized, nil
 }
+
+// BatchOptimizeMarket optimizes a batch as books: selections are grouped by
+// event+market (and line, for line markets), the incoming overround of each
+// book is removed proportionally, and margin is applied around the resulting
+// fair probabilities. Selections below MinLiquidity are left out before the
+// overround is removed, so the rest of the book normalizes without them.
+// Totals ladders are then checked for price inversions across lines.
+func (o *Optimizer) BatchOptimizeMarket(normalized []*models.NormalizedOdds) ([]*models.OptimizedOdds, error) {
+	normalized = o.dedupeSelections(normalized)
+	optimized := make([]*models.OptimizedOdds, 0, len(normalized))
+
+	for _, book := range groupByMarket(normalized) {
+		// Collect valid selections and their implied probabilities
+		selections := make([]*models.NormalizedOdds, 0, len(book))
+		impliedProbs := make([]decimal.Decimal, 0, len(book))
+
+		for _, odds := range book {
+			if err := o.validate(odds); err != nil {
+				o.rejectedCount.Add(1)
+				o.logger.Warn().
+					Err(err).
+					Str("event_id", odds.EventID).
+					Str("selection", odds.Selection).
+					Msg("failed to optimize odds")
+				continue
+			}
+			if o.illiquid(odds) {
+				continue
+			}
+			prob := o.impliedBackProbability(odds)
+			selections = append(selections, odds)
+			impliedProbs = append(impliedProbs, prob)
+		}
+
+		// Remove the incoming overround to find the fair probabilities.
+		// A lone selection is not a book, so its implied probability is kept.
+		fairProbs := impliedProbs
+		if len(selections) > 1 {
+			fairProbs = o.normalizer.Normalize(impliedProbs)
+		}
+		margins := make([]MarginExplanation, len(selections))
+		for i, odds := range selections {
+			if trueProb, ok := trueProbability(odds); ok {
+				fairProbs[i] = trueProb
+			}
+			margins[i] = o.explainMargin(odds)
+		}
+
+		// Price the draw of a 3-way book at its own margin
+		o.applyDrawMultiplier(selections, margins)
+
+		// Keep the book's total overround competitive
+		if len(selections) > 1 {
+			o.capBookOverround(selections[0], fairProbs, margins)
+		}
+
+		// Price around the fair probabilities
+		priced := make([]*models.OptimizedOdds, 0, len(selections))
+		for i, odds := range selections {
+			opt, _, err := o.explainWithMargin(odds, fairProbs[i], margins[i])
+			if err != nil {
+				o.logger.Warn().
+					Err(err).
+					Str("event_id", odds.EventID).
+					Str("selection", odds.Selection).
+					Msg("failed to optimize odds")
+				continue
+			}
+			priced = append(priced, opt)
+		}
+
+		// Guard against books that would lose money;
+		// negative books are always counted, and dropped when configured
+		if len(priced) > 1 && o.checkRealizedMargin(priced) != nil && o.params.RejectNegativeMargin {
+			o.rejectedCount.Add(uint64(len(priced)))
+			continue
+		}
+
+		optimized = append(optimized, priced...)
+	}
+
+	optimized = o.enforceLadders(optimized)
+	o.optimizedCount.Add(uint64(len(optimized)))
+
+	o.logger.Info().
+		Int("input_count", len(normalized)).
+		Int("output_count", len(optimized)).
+		Msg("market batch optimization complete")
+
+	return optimized, nil
+}
+
+// capBookOverround scales a book's margins down proportionally when the
+// book's total overround (fair probabilities plus margins, less one) would
+// exceed MaxTotalOverround. Scaled margins may fall below MinMargin.
+func (o *Optimizer) capBookOverround(first *models.NormalizedOdds, fairProbs []decimal.Decimal, margins []MarginExplanation) {
+	if !o.params.MaxTotalOverround.IsPositive() {
+		return
+	}
+
+	fairOverround := decimal.NewFromInt(-1)
+	added := decimal.Zero
+	for i := range margins {
+		fairOverround = fairOverround.Add(fairProbs[i])
+		added = added.Add(margins[i].Applied)
+	}
+
+	total := fairOverround.Add(added)
+	if !total.GreaterThan(o.params.MaxTotalOverround) || !added.IsPositive() {
+		return
+	}
+
+	// Margin the cap leaves room for; none when the fair book alone exceeds it
+	scale := decimal.Max(decimal.Zero, o.dec.div(o.params.MaxTotalOverround.Sub(fairOverround), added))
+	for i := range margins {
+		margins[i] = margins[i].scaled(scale)
+	}
+
+	o.logger.Info().
+		Str("event_id", first.EventID).
+		Str("market", first.Market).
+		Int("selections", len(margins)).
+		Str("total_overround", total.StringFixed(4)).
+		Str("max_total_overround", o.params.MaxTotalOverround.String()).
+		Str("margin_scale", scale.StringFixed(4)).
+		Msg("scaled book margins to max total overround")
+}
+
+// checkRealizedMargin verifies that the book's realized overround is not
+// negative, counting and logging books that fail. Once the minimum spread is
+// enforced OptimizedLay is the shorter, margin-bearing price, so the realized
+// overround is the sum of 1/OptimizedLay minus 1.
+func (o *Optimizer) checkRealizedMargin(book []*models.OptimizedOdds) error {
+	realized := decimal.NewFromInt(-1)
+	for _, odds := range book {
+		realized = realized.Add(o.calculateImpliedProbability(odds.OptimizedLay))
+	}
+
+	o.booksCheckedCount.Add(1)
+	if !realized.IsNegative() {
+		return nil
+	}
+
+	o.negativeMarginCount.Add(1)
+	o.metrics.negativeMargin.Inc()
+	err := fmt.Errorf("%w: realized overround %s", ErrNegativeMargin, realized.StringFixed(4))
+	o.logger.Warn().
+		Err(err).
+		Str("event_id", book[0].EventID).
+		Str("market", book[0].Market).
+		Int("selections", len(book)).
+		Bool("rejected", o.params.RejectNegativeMargin).
+		Msg("optimized book has negative margin")
+
+	return err
+}
+
+// groupByMarket groups odds by event+market, and line when set, preserving
+// first-seen order
+func groupByMarket(normalized []*models.NormalizedOdds) [][]*models.NormalizedOdds {
+	index := make(map[string]int)
+	var books [][]*models.NormalizedOdds
+
+	for _, odds := range normalized {
+		key := marketKey(odds)
+		i, ok := index[key]
+		if !ok {
+			i = len(books)
+			index[key] = i
+			books = append(books, nil)
+		}
+		books[i] = append(books[i], odds)
+	}
+
+	return books
+}
@@ -1,10 +1,16 @@
 package optimizer
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -61,6 +67,9 @@ func TestOptimize_Success(t *testing.T) {
 		BackSize:    decimal.NewFromFloat(10000),
 		LaySize:     decimal.NewFromFloat(8000),
 		Timestamp:   time.Now(),
+		InPlay:      true,
+		StartTime:   time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC),
+		Source:      "betfair",
 	}
 
 	optimized, err := setup.optimizer.Optimize(normalized)
@@ -68,6 +77,9 @@ func TestOptimize_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, optimized)
 	assert.Equal(t, normalized.EventID, optimized.EventID)
+	assert.True(t, optimized.InPlay)
+	assert.True(t, normalized.StartTime.Equal(optimized.StartTime))
+	assert.Equal(t, "betfair", optimized.Source)
 	assert.Equal(t, normalized.EventName, optimized.EventName)
 	assert.Equal(t, normalized.Sport, optimized.Sport)
 	assert.Equal(t, normalized.Market, optimized.Market)
@@ -203,8 +215,8 @@ func TestOptimize_LowLiquidity(t *testing.T) {
 		Selection:   "Team A",
 		BackPrice:   decimal.NewFromFloat(2.50),
 		LayPrice:    decimal.NewFromFloat(2.60),
-		BackSize:    decimal.NewFromFloat(100),  // Low liquidity
-		LaySize:     decimal.NewFromFloat(100),  // Low liquidity
+		BackSize:    decimal.NewFromFloat(100), // Low liquidity
+		LaySize:     decimal.NewFromFloat(100), // Low liquidity
 		Timestamp:   time.Now(),
 	}
 
@@ -477,8 +489,8 @@ func TestCalculateImpliedProbability(t *testing.T) {
 	setup := setupTestOptimizer()
 
 	tests := []struct {
-		name        string
-		odds        decimal.Decimal
+		name         string
+		odds         decimal.Decimal
 		expectedProb decimal.Decimal
 	}{
 		{"Odds 2.00", decimal.NewFromFloat(2.00), decimal.NewFromFloat(0.50)},
@@ -571,6 +583,142 @@ func TestCalculateTargetMargin(t *testing.T) {
 	assert.True(t, margin.LessThanOrEqual(setup.params.MaxMargin))
 }
 
+// TestCalculateTargetMargin_MinMarginSports tests that listed sports bypass the sport multiplier
+func TestCalculateTargetMargin_MinMarginSports(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.MinMarginSports = []string{"Basketball"}
+	trusted := NewOptimizer(params, zerolog.Nop())
+
+	tests := []struct {
+		name     string
+		sport    string
+		size     float64
+		expected decimal.Decimal
+	}{
+		{name: "Listed sport gets min margin", sport: "basketball", size: 10000, expected: decimal.NewFromFloat(0.02)},
+		{name: "Non-listed sport gets multiplier", sport: "golf", size: 10000, expected: decimal.NewFromFloat(0.024)},
+		// 4000 total liquidity adds (0.10-0.02)*0.6 = 0.048 before any multiplier
+		{name: "Listed sport keeps liquidity adjustment", sport: "basketball", size: 2000, expected: decimal.NewFromFloat(0.068)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized := newMarketOdds("Team A", 2.50)
+			normalized.Sport = tt.sport
+			normalized.BackSize = decimal.NewFromFloat(tt.size)
+			normalized.LaySize = decimal.NewFromFloat(tt.size)
+
+			margin := trusted.calculateTargetMargin(normalized)
+
+			assert.True(t, tt.expected.Equal(margin), "expected %s, got %s", tt.expected, margin)
+		})
+	}
+}
+
+// TestCalculateTargetMargin_LiquidityThreshold tests that the margin threshold
+// shifts the liquidity adjustment for a fixed liquidity
+func TestCalculateTargetMargin_LiquidityThreshold(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		expected  decimal.Decimal
+	}{
+		// 4000 total liquidity adds (0.10-0.02)*(1-4000/threshold)
+		{threshold: 0, expected: decimal.NewFromFloat(0.068)},
+		{threshold: 10000, expected: decimal.NewFromFloat(0.068)},
+		{threshold: 8000, expected: decimal.NewFromFloat(0.06)},
+		{threshold: 4000, expected: decimal.NewFromFloat(0.02)},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("threshold %v", tt.threshold), func(t *testing.T) {
+			params := setupTestOptimizer().params
+			params.MinMarginSports = []string{"basketball"}
+			params.LiquidityMarginThreshold = decimal.NewFromFloat(tt.threshold)
+			opt := NewOptimizer(params, zerolog.Nop())
+
+			normalized := newMarketOdds("Team A", 2.50)
+			normalized.Sport = "basketball"
+			normalized.BackSize = decimal.NewFromFloat(2000)
+			normalized.LaySize = decimal.NewFromFloat(2000)
+
+			margin := opt.calculateTargetMargin(normalized)
+
+			assert.True(t, tt.expected.Equal(margin), "expected %s, got %s", tt.expected, margin)
+		})
+	}
+}
+
+// TestCalculateTargetMargin_Commission tests that the target margin is
+// raised so the margin left after exchange commission is unchanged
+func TestCalculateTargetMargin_Commission(t *testing.T) {
+	tests := []struct {
+		commission float64
+		expected   decimal.Decimal
+	}{
+		{commission: 0, expected: decimal.NewFromFloat(0.02)},
+		{commission: 0.2, expected: decimal.NewFromFloat(0.025)},                      // 0.02 / 0.8
+		{commission: 0.05, expected: decimal.RequireFromString("0.0210526315789474")}, // 0.02 / 0.95
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("commission %v", tt.commission), func(t *testing.T) {
+			params := setupTestOptimizer().params
+			params.MinMarginSports = []string{"basketball"}
+			params.Commission = decimal.NewFromFloat(tt.commission)
+			opt := NewOptimizer(params, zerolog.Nop())
+
+			normalized := newMarketOdds("Team A", 2.50)
+			normalized.Sport = "basketball"
+
+			margin := opt.calculateTargetMargin(normalized)
+
+			assert.True(t, tt.expected.Equal(margin), "expected %s, got %s", tt.expected, margin)
+		})
+	}
+}
+
+// TestOptimize_Commission tests that a commission raises the optimized
+// margin so the margin net of commission is preserved
+func TestOptimize_Commission(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.Commission = decimal.NewFromFloat(0.05)
+	withCommission := NewOptimizer(params, zerolog.Nop())
+
+	net, err := setup.optimizer.Optimize(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+	gross, err := withCommission.Optimize(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+
+	assert.True(t, gross.Margin.GreaterThan(net.Margin), "margin %s not above %s", gross.Margin, net.Margin)
+	afterCommission := gross.Margin.Mul(decimal.NewFromFloat(0.95))
+	assert.True(t, afterCommission.Sub(net.Margin).Abs().LessThan(decimal.New(1, -12)),
+		"margin after commission %s, want %s", afterCommission, net.Margin)
+
+	_, explanation, err := withCommission.Explain(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(0.05).Equal(explanation.Margin.Commission))
+	assert.True(t, gross.Margin.Equal(explanation.Margin.Applied))
+}
+
+// TestCalculateConfidence_LiquidityCap tests that the confidence cap scales
+// the liquidity factor independently of the margin threshold
+func TestCalculateConfidence_LiquidityCap(t *testing.T) {
+	params := setupTestOptimizer().params
+	normalized := newMarketOdds("Team A", 2.50) // 18000 total liquidity
+
+	_, explanation, err := NewOptimizer(params, zerolog.Nop()).Explain(normalized)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.97, explanation.Confidence.LiquidityFactor, 1e-9)
+
+	params.LiquidityConfidenceCap = decimal.NewFromInt(36000)
+	_, explanation, err = NewOptimizer(params, zerolog.Nop()).Explain(normalized)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.85, explanation.Confidence.LiquidityFactor, 1e-9)
+	assert.True(t, explanation.Margin.LiquidityAdjustment.IsZero())
+}
+
 // TestCalculateConfidence tests confidence calculation
 func TestCalculateConfidence(t *testing.T) {
 	setup := setupTestOptimizer()
@@ -596,6 +744,102 @@ func TestCalculateConfidence(t *testing.T) {
 	assert.True(t, confidence >= 0.0 && confidence <= 1.0)
 }
 
+// TestOptimize_InvalidBackPriceIsTyped tests that validation failures wrap ErrInvalidBackPrice
+func TestOptimize_InvalidBackPriceIsTyped(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	_, err := setup.optimizer.Optimize(newMarketOdds("Team A", 0))
+
+	assert.ErrorIs(t, err, ErrInvalidBackPrice)
+}
+
+// TestOptimize_EvenMoneyBackPrice tests that a back price of exactly 1.0 is
+// non-tradeable or floored, distinctly from corrupt prices below 1.0
+func TestOptimize_EvenMoneyBackPrice(t *testing.T) {
+	setup := setupTestOptimizer()
+	floored := setup.params
+	floored.EvenMoneyFloor = decimal.NewFromFloat(1.01)
+	flooredOpt := NewOptimizer(floored, zerolog.Nop())
+
+	t.Run("Exactly 1.0 is non-tradeable without a floor", func(t *testing.T) {
+		opt := NewOptimizer(setup.params, zerolog.Nop())
+		_, err := opt.Optimize(newMarketOdds("Team A", 1.0))
+		assert.ErrorIs(t, err, ErrNonTradeable)
+		assert.NotErrorIs(t, err, ErrInvalidBackPrice)
+		assert.Equal(t, 1.0, testutil.ToFloat64(opt.metrics.nonTradeable))
+		assert.Equal(t, uint64(1), opt.Stats().Rejected)
+	})
+
+	t.Run("Exactly 1.0 is priced at the floor", func(t *testing.T) {
+		optimized, err := flooredOpt.Optimize(newMarketOdds("Team A", 1.0))
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromFloat(1.01).Equal(optimized.OriginalBack), "original back %s", optimized.OriginalBack)
+		assert.True(t, optimized.OptimizedBack.GreaterThan(decimal.NewFromInt(1)), "optimized back %s", optimized.OptimizedBack)
+	})
+
+	t.Run("Just above 1.0 is priced as quoted", func(t *testing.T) {
+		for _, opt := range []*Optimizer{setup.optimizer, flooredOpt} {
+			optimized, err := opt.Optimize(newMarketOdds("Team A", 1.001))
+			require.NoError(t, err)
+			assert.True(t, decimal.NewFromFloat(1.001).Equal(optimized.OriginalBack), "original back %s", optimized.OriginalBack)
+		}
+	})
+
+	t.Run("Below 1.0 is invalid even with a floor", func(t *testing.T) {
+		for _, opt := range []*Optimizer{setup.optimizer, flooredOpt} {
+			_, err := opt.Optimize(newMarketOdds("Team A", 0.99))
+			assert.ErrorIs(t, err, ErrInvalidBackPrice)
+			assert.NotErrorIs(t, err, ErrNonTradeable)
+		}
+	})
+
+	t.Run("Market batch skips only the non-tradeable selection", func(t *testing.T) {
+		opt := NewOptimizer(setup.params, zerolog.Nop())
+		optimized, err := opt.BatchOptimizeMarket([]*models.NormalizedOdds{
+			newMarketOdds("Team A", 1.0),
+			newMarketOdds("Team B", 1.90),
+			newMarketOdds("Draw", 3.50),
+		})
+		require.NoError(t, err)
+		require.Len(t, optimized, 2)
+		assert.Equal(t, 1.0, testutil.ToFloat64(opt.metrics.nonTradeable))
+	})
+}
+
+// TestCalculateImpliedProbability_ZeroOdds tests that non-positive odds do not panic
+func TestCalculateImpliedProbability_ZeroOdds(t *testing.T) {
+	setup := setupTestOptimizer()
+	fast := newFastMathOptimizer(setup.params)
+
+	for _, odds := range []decimal.Decimal{decimal.Zero, decimal.NewFromFloat(-2.5)} {
+		for _, o := range []*Optimizer{setup.optimizer, fast} {
+			assert.NotPanics(t, func() {
+				prob := o.calculateImpliedProbability(odds)
+				assert.True(t, prob.IsZero(), "expected zero probability for odds %s, got %s", odds, prob)
+			})
+		}
+	}
+}
+
+// TestCalculateConfidence_ZeroBackPrice tests that a zero back price does not panic
+// and scores the spread as worst case
+func TestCalculateConfidence_ZeroBackPrice(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	normalized := newMarketOdds("Team A", 2.50)
+	spread := decimal.NewFromFloat(0.10)
+	valid := setup.optimizer.calculateConfidence(normalized, spread)
+
+	normalized.BackPrice = decimal.Zero
+	var confidence float64
+	assert.NotPanics(t, func() {
+		confidence = setup.optimizer.calculateConfidence(normalized, spread)
+	})
+
+	assert.True(t, confidence >= 0.0 && confidence <= 1.0)
+	assert.Less(t, confidence, valid)
+}
+
 // TestOptimize_ConcurrentAccess tests thread safety
 func TestOptimize_ConcurrentAccess(t *testing.T) {
 	setup := setupTestOptimizer()
@@ -667,3 +911,1904 @@ func TestOptimize_PreserveOriginalData(t *testing.T) {
 	assert.Equal(t, originalBackSize, optimized.BackSize)
 	assert.Equal(t, originalLaySize, optimized.LaySize)
 }
+
+// newMarketOdds creates normalized odds for a selection in a football match_winner book
+func newMarketOdds(selection string, backPrice float64) *models.NormalizedOdds {
+	return &models.NormalizedOdds{
+		ID:          uuid.New(),
+		EventID:     "event-123",
+		EventName:   "Team A vs Team B",
+		Sport:       "football",
+		Competition: "Premier League",
+		Market:      "match_winner",
+		Selection:   selection,
+		BackPrice:   decimal.NewFromFloat(backPrice),
+		BackSize:    decimal.NewFromFloat(10000),
+		LaySize:     decimal.NewFromFloat(8000),
+		Timestamp:   time.Now(),
+	}
+}
+
+// assertFairPriceBetween asserts the fair price lies within the optimized back/lay range
+func assertFairPriceBetween(t *testing.T, odds *models.OptimizedOdds) {
+	t.Helper()
+	low := decimal.Min(odds.OptimizedBack, odds.OptimizedLay)
+	high := decimal.Max(odds.OptimizedBack, odds.OptimizedLay)
+	assert.True(t, odds.FairPrice.GreaterThanOrEqual(low), "fair %s below %s", odds.FairPrice, low)
+	assert.True(t, odds.FairPrice.LessThanOrEqual(high), "fair %s above %s", odds.FairPrice, high)
+}
+
+// TestOptimize_FairPrice tests that the single-selection fair price carries no margin
+func TestOptimize_FairPrice(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	optimized, err := setup.optimizer.Optimize(newMarketOdds("Team A", 2.50))
+
+	require.NoError(t, err)
+	assert.True(t, optimized.FairPrice.Equal(decimal.NewFromFloat(2.50)), "got %s", optimized.FairPrice)
+	assertFairPriceBetween(t, optimized)
+}
+
+// TestBatchOptimizeMarket_SymmetricBook tests overround removal on a symmetric book
+func TestBatchOptimizeMarket_SymmetricBook(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	// 1.90/1.90 carries ~5.3% overround; the fair book is 2.00/2.00
+	normalized := []*models.NormalizedOdds{
+		newMarketOdds("Team A", 1.90),
+		newMarketOdds("Team B", 1.90),
+	}
+
+	optimized, err := setup.optimizer.BatchOptimizeMarket(normalized)
+
+	require.NoError(t, err)
+	require.Len(t, optimized, 2)
+
+	for _, opt := range optimized {
+		assert.True(t, opt.FairPrice.Equal(decimal.NewFromInt(2)), "got %s", opt.FairPrice)
+		assertFairPriceBetween(t, opt)
+	}
+	assert.True(t, optimized[0].OptimizedBack.Equal(optimized[1].OptimizedBack))
+	assert.True(t, optimized[0].OptimizedLay.Equal(optimized[1].OptimizedLay))
+}
+
+// TestBatchOptimizeMarket_DrawMultiplier tests that only the draw of a
+// 3-way book has its margin scaled, matching draw labels canonically
+func TestBatchOptimizeMarket_DrawMultiplier(t *testing.T) {
+	params := setupTestOptimizer().params
+	params.DrawMultiplier = decimal.NewFromFloat(0.5)
+	opt := NewOptimizer(params, zerolog.Nop())
+	flat := setupTestOptimizer().optimizer
+
+	tests := []struct {
+		name      string
+		draw      string // Selection whose margin is scaled, "" for none
+		selection []string
+	}{
+		{name: "Draw label", draw: "Draw", selection: []string{"Team A", "Draw", "Team B"}},
+		{name: "X label in another case", draw: " x ", selection: []string{"1", " x ", "2"}},
+		{name: "Tie label", draw: "Tie", selection: []string{"Team A", "Tie", "Team B"}},
+		{name: "2-way book", selection: []string{"Team A", "Draw"}},
+		{name: "No draw", selection: []string{"Team A", "Team B", "Team C"}},
+		{name: "Two draw labels", selection: []string{"Team A", "Draw", "Tie"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := func() []*models.NormalizedOdds {
+				normalized := make([]*models.NormalizedOdds, 0, len(tt.selection))
+				for i, selection := range tt.selection {
+					normalized = append(normalized, newMarketOdds(selection, 2.5+float64(i)))
+				}
+				return normalized
+			}
+
+			adjusted, err := opt.BatchOptimizeMarket(book())
+			require.NoError(t, err)
+			unadjusted, err := flat.BatchOptimizeMarket(book())
+			require.NoError(t, err)
+			require.Len(t, adjusted, len(tt.selection))
+
+			for i, odds := range adjusted {
+				expected := unadjusted[i].Margin
+				if odds.Selection == tt.draw {
+					expected = expected.Mul(decimal.NewFromFloat(0.5))
+				}
+				assert.True(t, expected.Equal(odds.Margin), "%s: expected margin %s, got %s", odds.Selection, expected, odds.Margin)
+			}
+		})
+	}
+
+	t.Run("Custom labels", func(t *testing.T) {
+		params := params
+		params.DrawLabels = []string{"Empate"}
+		custom := NewOptimizer(params, zerolog.Nop())
+
+		optimized, err := custom.BatchOptimizeMarket([]*models.NormalizedOdds{
+			newMarketOdds("Local", 2.10), newMarketOdds("Empate", 3.30), newMarketOdds("Draw", 3.60),
+		})
+		require.NoError(t, err)
+		require.Len(t, optimized, 3)
+		assert.True(t, optimized[1].Margin.LessThan(optimized[0].Margin))
+		assert.True(t, optimized[2].Margin.Equal(optimized[0].Margin))
+	})
+}
+
+// TestBatchOptimizeMarket_FairProbabilitiesSumToOne tests overround removal on an asymmetric book
+func TestBatchOptimizeMarket_FairProbabilitiesSumToOne(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	normalized := []*models.NormalizedOdds{
+		newMarketOdds("Team A", 2.10),
+		newMarketOdds("Draw", 3.30),
+		newMarketOdds("Team B", 3.60),
+	}
+
+	optimized, err := setup.optimizer.BatchOptimizeMarket(normalized)
+
+	require.NoError(t, err)
+	require.Len(t, optimized, 3)
+
+	sum := decimal.Zero
+	for _, opt := range optimized {
+		sum = sum.Add(decimal.NewFromInt(1).Div(opt.FairPrice))
+	}
+	assert.InDelta(t, 1.0, sum.InexactFloat64(), 1e-9)
+}
+
+// TestBatchOptimizeMarket_GroupsByMarket tests that books are normalized independently
+func TestBatchOptimizeMarket_GroupsByMarket(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	other := newMarketOdds("Over 2.5", 1.80)
+	other.Market = "over_under"
+	invalid := newMarketOdds("Team C", 0.90)
+
+	normalized := []*models.NormalizedOdds{
+		newMarketOdds("Team A", 1.90),
+		other,
+		newMarketOdds("Team B", 1.90),
+		invalid,
+	}
+
+	optimized, err := setup.optimizer.BatchOptimizeMarket(normalized)
+
+	require.NoError(t, err)
+	require.Len(t, optimized, 3)
+
+	// match_winner book comes first, in input order
+	assert.Equal(t, "Team A", optimized[0].Selection)
+	assert.Equal(t, "Team B", optimized[1].Selection)
+	assert.Equal(t, "Over 2.5", optimized[2].Selection)
+
+	// A single-selection book has no overround to remove
+	assert.True(t, optimized[0].FairPrice.Equal(decimal.NewFromInt(2)))
+	assert.InDelta(t, 1.80, optimized[2].FairPrice.InexactFloat64(), 1e-9)
+	assert.Equal(t, uint64(1), setup.optimizer.Stats().Rejected)
+}
+
+// newFastMathOptimizer creates an optimizer with the float64 fast path enabled
+func newFastMathOptimizer(params models.OptimizationParams) *Optimizer {
+	params.FastMath = true
+	return NewOptimizer(params, zerolog.Nop())
+}
+
+// TestOptimize_FastMathWithinTolerance tests that the float path tracks the decimal path
+func TestOptimize_FastMathWithinTolerance(t *testing.T) {
+	setup := setupTestOptimizer()
+	fast := newFastMathOptimizer(setup.params)
+
+	const tolerance = 1e-9
+
+	for _, price := range []float64{1.01, 1.25, 1.5, 2.0, 2.5, 3.2, 5.0, 10.0, 25.0, 100.0} {
+		for _, size := range []float64{500, 4000, 15000} {
+			normalized := newMarketOdds("Team A", price)
+			normalized.BackSize = decimal.NewFromFloat(size)
+			normalized.LaySize = decimal.NewFromFloat(size)
+
+			exact, err := setup.optimizer.Optimize(normalized)
+			require.NoError(t, err)
+			approx, err := fast.Optimize(normalized)
+			require.NoError(t, err)
+
+			assert.InDelta(t, exact.OptimizedBack.InexactFloat64(), approx.OptimizedBack.InexactFloat64(), tolerance, "back at %v", price)
+			assert.InDelta(t, exact.OptimizedLay.InexactFloat64(), approx.OptimizedLay.InexactFloat64(), tolerance, "lay at %v", price)
+			assert.InDelta(t, exact.FairPrice.InexactFloat64(), approx.FairPrice.InexactFloat64(), tolerance, "fair at %v", price)
+			assert.True(t, exact.Margin.Equal(approx.Margin))
+			// Confidence decays with wall-clock age between the two calls
+			assert.InDelta(t, exact.Confidence, approx.Confidence, 1e-6)
+		}
+	}
+}
+
+// TestBatchOptimizeMarket_FastMathWithinTolerance tests the float path on a full book
+func TestBatchOptimizeMarket_FastMathWithinTolerance(t *testing.T) {
+	setup := setupTestOptimizer()
+	fast := newFastMathOptimizer(setup.params)
+
+	book := []*models.NormalizedOdds{
+		newMarketOdds("Team A", 2.10),
+		newMarketOdds("Draw", 3.30),
+		newMarketOdds("Team B", 3.60),
+	}
+
+	exact, err := setup.optimizer.BatchOptimizeMarket(book)
+	require.NoError(t, err)
+	approx, err := fast.BatchOptimizeMarket(book)
+	require.NoError(t, err)
+
+	require.Len(t, approx, len(exact))
+	for i := range exact {
+		assert.InDelta(t, exact[i].OptimizedBack.InexactFloat64(), approx[i].OptimizedBack.InexactFloat64(), 1e-9)
+		assert.InDelta(t, exact[i].OptimizedLay.InexactFloat64(), approx[i].OptimizedLay.InexactFloat64(), 1e-9)
+	}
+}
+
+// benchmarkBook is a three-way book used by the optimizer benchmarks
+func benchmarkBook() []*models.NormalizedOdds {
+	return []*models.NormalizedOdds{
+		newMarketOdds("Team A", 2.10),
+		newMarketOdds("Draw", 3.30),
+		newMarketOdds("Team B", 3.60),
+	}
+}
+
+// BenchmarkOptimize_Decimal benchmarks the exact decimal path
+func BenchmarkOptimize_Decimal(b *testing.B) {
+	setup := setupTestOptimizer()
+	normalized := newMarketOdds("Team A", 2.50)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = setup.optimizer.Optimize(normalized)
+	}
+}
+
+// BenchmarkOptimize_FastMath benchmarks the float64 fast path
+func BenchmarkOptimize_FastMath(b *testing.B) {
+	setup := setupTestOptimizer()
+	fast := newFastMathOptimizer(setup.params)
+	normalized := newMarketOdds("Team A", 2.50)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = fast.Optimize(normalized)
+	}
+}
+
+// BenchmarkBatchOptimizeMarket_Decimal benchmarks book optimization on the decimal path
+func BenchmarkBatchOptimizeMarket_Decimal(b *testing.B) {
+	setup := setupTestOptimizer()
+	book := benchmarkBook()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = setup.optimizer.BatchOptimizeMarket(book)
+	}
+}
+
+// BenchmarkBatchOptimizeMarket_FastMath benchmarks book optimization on the float64 path
+func BenchmarkBatchOptimizeMarket_FastMath(b *testing.B) {
+	setup := setupTestOptimizer()
+	fast := newFastMathOptimizer(setup.params)
+	book := benchmarkBook()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = fast.BatchOptimizeMarket(book)
+	}
+}
+
+// TestOptimize_SportsbookPrice tests the fixed-odds price carries the full margin
+func TestOptimize_SportsbookPrice(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	// Disabled by default
+	optimized, err := setup.optimizer.Optimize(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+	assert.Nil(t, optimized.SportsbookPrice)
+
+	params := setup.params
+	params.EmitSportsbook = true
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	for _, price := range []float64{1.50, 2.50, 6.00} {
+		optimized, err := opt.Optimize(newMarketOdds("Team A", price))
+		require.NoError(t, err)
+		require.NotNil(t, optimized.SportsbookPrice)
+
+		// Fair probability plus the full margin, converted back to odds
+		fairProb := decimal.NewFromInt(1).Div(optimized.FairPrice)
+		expected := decimal.NewFromInt(1).Div(fairProb.Add(optimized.Margin))
+		assert.InDelta(t, expected.InexactFloat64(), optimized.SportsbookPrice.InexactFloat64(), 1e-9)
+		assert.True(t, optimized.SportsbookPrice.LessThan(optimized.FairPrice))
+	}
+}
+
+// TestBatchOptimizeMarket_SportsbookPrice tests the sportsbook book overround equals the full margin
+func TestBatchOptimizeMarket_SportsbookPrice(t *testing.T) {
+	params := setupTestOptimizer().params
+	params.EmitSportsbook = true
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	optimized, err := opt.BatchOptimizeMarket([]*models.NormalizedOdds{
+		newMarketOdds("Team A", 1.90),
+		newMarketOdds("Team B", 1.90),
+	})
+	require.NoError(t, err)
+
+	overround := decimal.Zero
+	for _, o := range optimized {
+		require.NotNil(t, o.SportsbookPrice)
+		overround = overround.Add(decimal.NewFromInt(1).Div(*o.SportsbookPrice))
+	}
+
+	// Two selections, each carrying the full margin on top of a fair book
+	expected := decimal.NewFromInt(1).Add(optimized[0].Margin).Add(optimized[1].Margin)
+	assert.InDelta(t, expected.InexactFloat64(), overround.InexactFloat64(), 1e-9)
+}
+
+// TestOptimize_MaxDrift tests the optimized-price sanity guard
+func TestOptimize_MaxDrift(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.MaxDriftPct = decimal.NewFromInt(20)
+	guarded := NewOptimizer(params, zerolog.Nop())
+
+	// Within bounds: 2.50 prices close to the original
+	optimized, err := guarded.Optimize(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+	assert.NotNil(t, optimized)
+
+	// Excessive drift: a long shot loses most of its price to margin
+	optimized, err = guarded.Optimize(newMarketOdds("Team B", 100.0))
+	assert.ErrorIs(t, err, ErrExcessiveDrift)
+	assert.Nil(t, optimized)
+
+	assert.Equal(t, Stats{Optimized: 1, Rejected: 1}, guarded.Stats())
+
+	// The guard is disabled by default
+	optimized, err = setup.optimizer.Optimize(newMarketOdds("Team B", 100.0))
+	require.NoError(t, err)
+	assert.NotNil(t, optimized)
+}
+
+// TestBatchOptimizeMarket_MaxDrift tests that drifting selections are dropped from a book
+func TestBatchOptimizeMarket_MaxDrift(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.MaxDriftPct = decimal.NewFromInt(20)
+	guarded := NewOptimizer(params, zerolog.Nop())
+
+	optimized, err := guarded.BatchOptimizeMarket([]*models.NormalizedOdds{
+		newMarketOdds("Team A", 1.90),
+		newMarketOdds("Team B", 2.10),
+		newMarketOdds("Outsider", 100.0),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, optimized, 2)
+	assert.Equal(t, "Team A", optimized[0].Selection)
+	assert.Equal(t, "Team B", optimized[1].Selection)
+	assert.Equal(t, uint64(1), guarded.Stats().Rejected)
+}
+
+// TestOptimize_NormalizeSelections tests that selection variants share one canonical name
+func TestOptimize_NormalizeSelections(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.NormalizeSelections = true
+	normalizing := NewOptimizer(params, zerolog.Nop())
+
+	tests := []struct {
+		selection string
+		display   string
+	}{
+		{selection: "Team A", display: "Team A"},
+		{selection: "team a", display: "team a"},
+		{selection: "  TEAM   A ", display: "TEAM A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selection, func(t *testing.T) {
+			optimized, err := normalizing.Optimize(newMarketOdds(tt.selection, 2.50))
+			require.NoError(t, err)
+
+			assert.Equal(t, "team a", optimized.Selection)
+			assert.Equal(t, tt.display, optimized.DisplaySelection)
+		})
+	}
+
+	// Disabled by default: selections pass through untouched
+	optimized, err := setup.optimizer.Optimize(newMarketOdds("  TEAM   A ", 2.50))
+	require.NoError(t, err)
+	assert.Equal(t, "  TEAM   A ", optimized.Selection)
+	assert.Empty(t, optimized.DisplaySelection)
+}
+
+// highOverroundBook is a three-way book carrying a 33% incoming overround
+func highOverroundBook() []*models.NormalizedOdds {
+	return []*models.NormalizedOdds{
+		newMarketOdds("Team A", 1.50),
+		newMarketOdds("Draw", 3.00),
+		newMarketOdds("Team B", 3.00),
+	}
+}
+
+// TestBatchOptimizeMarket_NegativeMargin tests that books whose realized
+// overround ends up negative are counted and optionally rejected
+func TestBatchOptimizeMarket_NegativeMargin(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	// Without a minimum spread, spread enforcement centres both prices on the
+	// midpoint, which sits above the fair price and gives the margin away
+	params := setup.params
+	params.MinSpread = decimal.Zero
+	flagging := NewOptimizer(params, zerolog.Nop())
+
+	// Flag only: the book is still returned
+	optimized, err := flagging.BatchOptimizeMarket(highOverroundBook())
+	require.NoError(t, err)
+	assert.Len(t, optimized, 3)
+	assert.Equal(t, 1.0, testutil.ToFloat64(flagging.metrics.negativeMargin))
+
+	realized := decimal.NewFromInt(-1)
+	for _, odds := range optimized {
+		realized = realized.Add(decimal.NewFromInt(1).Div(odds.OptimizedLay))
+	}
+	assert.True(t, realized.IsNegative(), "expected negative realized overround, got %s", realized)
+
+	// Reject: the whole book is dropped
+	params.RejectNegativeMargin = true
+	rejecting := NewOptimizer(params, zerolog.Nop())
+
+	optimized, err = rejecting.BatchOptimizeMarket(highOverroundBook())
+	require.NoError(t, err)
+	assert.Empty(t, optimized)
+	assert.Equal(t, 1.0, testutil.ToFloat64(rejecting.metrics.negativeMargin))
+	assert.Equal(t, Stats{Optimized: 0, Rejected: 3, BooksChecked: 1, NegativeMargin: 1}, rejecting.Stats())
+}
+
+// TestBatchOptimizeMarket_PositiveMargin tests that a normally priced book passes the guard
+func TestBatchOptimizeMarket_PositiveMargin(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.RejectNegativeMargin = true
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	optimized, err := opt.BatchOptimizeMarket(highOverroundBook())
+	require.NoError(t, err)
+
+	assert.Len(t, optimized, 3)
+	assert.Equal(t, 0.0, testutil.ToFloat64(opt.metrics.negativeMargin))
+	assert.Equal(t, Stats{Optimized: 3, Rejected: 0, BooksChecked: 1}, opt.Stats())
+}
+
+// TestRemoveOverround tests that every margin model yields a fair book and
+// that Shin leaves relatively more probability on the favourite
+func TestRemoveOverround(t *testing.T) {
+	// 1.50 / 3.00 / 8.00: 12.5% overround
+	impliedProbs := []decimal.Decimal{
+		decimal.NewFromInt(1).Div(decimal.NewFromFloat(1.50)),
+		decimal.NewFromInt(1).Div(decimal.NewFromFloat(3.00)),
+		decimal.NewFromInt(1).Div(decimal.NewFromFloat(8.00)),
+	}
+
+	fair := make(map[MarginModel][]decimal.Decimal)
+	for _, model := range MarginModels {
+		probs, err := removeOverround(model, impliedProbs, newDecimalContext(0))
+		require.NoError(t, err)
+		require.Len(t, probs, 3)
+
+		sum := decimal.Zero
+		for _, prob := range probs {
+			sum = sum.Add(prob)
+		}
+		assert.InDelta(t, 1.0, sum.InexactFloat64(), 1e-9, "model %s", model)
+		fair[model] = probs
+	}
+
+	// Proportional keeps the implied ratios; Shin, and additive more so, take more from the longshot
+	assert.True(t, fair[MarginModelShin][0].GreaterThan(fair[MarginModelProportional][0]))
+	assert.True(t, fair[MarginModelAdditive][0].GreaterThan(fair[MarginModelShin][0]))
+	assert.True(t, fair[MarginModelShin][2].LessThan(fair[MarginModelProportional][2]))
+
+	_, err := removeOverround("power", impliedProbs, newDecimalContext(0))
+	assert.Error(t, err)
+}
+
+// TestDivisionPrecision tests that overround removal across a large book is
+// more accurate at a higher division precision, and that optimizers at
+// different precisions run side by side without changing the global
+func TestDivisionPrecision(t *testing.T) {
+	globalPrecision := decimal.DivisionPrecision
+
+	// Nine runners at 8.50: 5.9% overround, with repeating implied probabilities
+	price := decimal.NewFromFloat(8.50)
+	overroundError := func(precision int32) decimal.Decimal {
+		params := setupTestOptimizer().params
+		params.DivisionPrecision = precision
+		opt := NewOptimizer(params, zerolog.Nop())
+
+		impliedProbs := make([]decimal.Decimal, 9)
+		for i := range impliedProbs {
+			impliedProbs[i] = opt.calculateImpliedProbability(price)
+		}
+		fairProbs, err := removeOverround(MarginModelProportional, impliedProbs, opt.dec)
+		assert.NoError(t, err)
+
+		sum := decimal.Zero
+		for _, prob := range fairProbs {
+			sum = sum.Add(prob)
+		}
+		return sum.Sub(decimal.NewFromInt(1)).Abs()
+	}
+
+	var wg sync.WaitGroup
+	var low, high decimal.Decimal
+	wg.Add(2)
+	go func() { defer wg.Done(); low = overroundError(4) }()
+	go func() { defer wg.Done(); high = overroundError(28) }()
+	wg.Wait()
+
+	assert.True(t, low.GreaterThanOrEqual(decimal.New(1, -4)), "low precision error %s", low)
+	assert.True(t, high.LessThan(decimal.New(1, -20)), "high precision error %s", high)
+	assert.True(t, high.LessThan(low))
+	assert.Equal(t, globalPrecision, decimal.DivisionPrecision)
+
+	// Zero keeps the shopspring default
+	assert.Equal(t, int32(defaultDivisionPrecision), newDecimalContext(0).precision)
+}
+
+// recentPrices converts recent back prices, newest first
+func recentPrices(prices ...float64) []decimal.Decimal {
+	result := make([]decimal.Decimal, len(prices))
+	for i, price := range prices {
+		result[i] = decimal.NewFromFloat(price)
+	}
+	return result
+}
+
+// TestCalculateConfidence_Stability tests that a selection with a stable
+// price history is more confident than a volatile one at equal liquidity and
+// spread, and that missing history is neutral
+func TestCalculateConfidence_Stability(t *testing.T) {
+	params := setupTestOptimizer().params
+	params.TargetConfidence = 0.5 // Keep confidence clear of the clamp
+	params.StabilityWeight = 0.5
+	params.StabilityWindow = 4
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	confidence := func(prices []decimal.Decimal) ConfidenceExplanation {
+		normalized := &models.NormalizedOdds{
+			EventID:          "event-123",
+			Market:           "match_winner",
+			Selection:        "Team A",
+			BackPrice:        decimal.NewFromFloat(2.50),
+			LayPrice:         decimal.NewFromFloat(2.60),
+			BackSize:         decimal.NewFromFloat(10000),
+			LaySize:          decimal.NewFromFloat(8000),
+			Timestamp:        time.Now(),
+			RecentBackPrices: prices,
+		}
+		return opt.explainConfidence(normalized, decimal.NewFromFloat(0.10))
+	}
+
+	stable := confidence(recentPrices(2.50, 2.51, 2.49, 2.50))
+	volatile := confidence(recentPrices(2.00, 3.00, 2.20, 2.90))
+	assert.InDelta(t, 0.98, stable.StabilityFactor, 0.01)
+	assert.Equal(t, 0.5, volatile.StabilityFactor) // Fully penalized down to 1 - weight
+	assert.Greater(t, stable.Confidence, volatile.Confidence)
+	assert.Equal(t, stable.LiquidityFactor, volatile.LiquidityFactor)
+	assert.Equal(t, stable.SpreadFactor, volatile.SpreadFactor)
+
+	// Only the window's newest prices count
+	assert.Equal(t, stable.StabilityFactor, confidence(recentPrices(2.50, 2.51, 2.49, 2.50, 9.00)).StabilityFactor)
+
+	// Neutral without enough history, or without a stability weight
+	assert.Equal(t, 1.0, confidence(nil).StabilityFactor)
+	assert.Equal(t, 1.0, confidence(recentPrices(2.50)).StabilityFactor)
+	assert.Equal(t, 1.0, setupTestOptimizer().optimizer.explainConfidence(&models.NormalizedOdds{RecentBackPrices: recentPrices(2.00, 3.00)}, decimal.Zero).StabilityFactor)
+}
+
+// TestCalculateConfidence_Bounds tests per-sport confidence floors and ceilings
+func TestCalculateConfidence_Bounds(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.ConfidenceBounds = map[string]models.ConfidenceBounds{
+		"Darts":    {Min: 0, Max: 0.5},
+		"football": {Min: 0.95, Max: 1},
+	}
+	bounded := NewOptimizer(params, zerolog.Nop())
+
+	newOdds := func(sport string) *models.NormalizedOdds {
+		return &models.NormalizedOdds{
+			Sport:     sport,
+			BackPrice: decimal.NewFromFloat(2.50),
+			BackSize:  decimal.NewFromFloat(10000),
+			LaySize:   decimal.NewFromFloat(8000),
+			Timestamp: time.Now(),
+		}
+	}
+	spread := decimal.NewFromFloat(0.05)
+
+	tests := []struct {
+		name     string
+		sport    string
+		expected float64
+	}{
+		{name: "Niche sport capped", sport: "darts", expected: 0.5},
+		{name: "Major sport floored", sport: "football", expected: 0.95},
+		{name: "Unlisted sport unchanged", sport: "tennis", expected: setup.optimizer.calculateConfidence(newOdds("tennis"), spread)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unbounded := setup.optimizer.calculateConfidence(newOdds(tt.sport), spread)
+			require.Greater(t, unbounded, 0.5)
+			require.Less(t, unbounded, 0.95)
+
+			assert.InDelta(t, tt.expected, bounded.calculateConfidence(newOdds(tt.sport), spread), 1e-9)
+		})
+	}
+}
+
+// TestCalculateConfidence_MissingLay tests that the same input is trusted
+// less without a real lay price when the penalty is configured, and equally
+// by default
+func TestCalculateConfidence_MissingLay(t *testing.T) {
+	params := setupTestOptimizer().params
+	params.TargetConfidence = 0.5 // Keep confidence clear of the clamp
+	spread := decimal.NewFromFloat(0.10)
+
+	// Freshness decays with wall-clock time, so comparisons allow for drift
+	withLay := newMarketOdds("Team A", 2.50)
+	withLay.LayPrice = decimal.NewFromFloat(2.60)
+	withoutLay := newMarketOdds("Team A", 2.50)
+	layProbOnly := newMarketOdds("Team A", 2.50)
+	layProbOnly.LayProb = decimal.NewFromFloat(0.38)
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		opt := NewOptimizer(params, zerolog.Nop())
+		assert.InDelta(t, opt.calculateConfidence(withLay, spread), opt.calculateConfidence(withoutLay, spread), 1e-6)
+		assert.Equal(t, 1.0, opt.explainConfidence(withoutLay, spread).LayFactor)
+	})
+
+	t.Run("Penalized without a lay price", func(t *testing.T) {
+		penalized := params
+		penalized.MissingLayPenalty = 0.8
+		opt := NewOptimizer(penalized, zerolog.Nop())
+
+		real := opt.explainConfidence(withLay, spread)
+		synthesized := opt.explainConfidence(withoutLay, spread)
+		assert.Equal(t, 1.0, real.LayFactor)
+		assert.Equal(t, 0.8, synthesized.LayFactor)
+		assert.InDelta(t, real.Confidence*0.8, synthesized.Confidence, 1e-6)
+
+		// A lay probability is a real lay quote
+		assert.InDelta(t, real.Confidence, opt.calculateConfidence(layProbOnly, spread), 1e-6)
+
+		optimized, err := opt.Optimize(withoutLay)
+		require.NoError(t, err)
+		unpenalized, err := NewOptimizer(params, zerolog.Nop()).Optimize(withoutLay)
+		require.NoError(t, err)
+		assert.InDelta(t, unpenalized.Confidence*0.8, optimized.Confidence, 1e-6)
+	})
+}
+
+// TestOptimize_RegionRules tests tagging optimized odds with the regions of
+// the first matching region rule
+func TestOptimize_RegionRules(t *testing.T) {
+	params := setupTestOptimizer().params
+	params.RegionRules = []models.RegionRule{
+		{Sport: "Football", Market: "first_scorer", Regions: []string{"gb"}},
+		{Competition: "premier league", Regions: []string{"gb", "ie"}},
+		{Sport: "football", Regions: []string{"us-nj"}}, // Shadowed for the Premier League
+	}
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	tests := []struct {
+		name        string
+		sport       string
+		competition string
+		market      string
+		expected    []string
+	}{
+		{name: "Restricted market", sport: "football", competition: "Premier League", market: "first_scorer", expected: []string{"gb"}},
+		{name: "First match wins", sport: "football", competition: "Premier League", market: "match_winner", expected: []string{"gb", "ie"}},
+		{name: "Sport-wide rule", sport: "football", competition: "MLS", market: "match_winner", expected: []string{"us-nj"}},
+		{name: "Unmatched odds are unrestricted", sport: "tennis", competition: "Wimbledon", market: "match_winner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized := newMarketOdds("Team A", 2.50)
+			normalized.Sport = tt.sport
+			normalized.Competition = tt.competition
+			normalized.Market = tt.market
+
+			optimized, err := opt.Optimize(normalized)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, optimized.Regions)
+		})
+	}
+
+	// Tagged odds do not alias the configured rule
+	optimized, err := opt.Optimize(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+	optimized.Regions[0] = "xx"
+	assert.Equal(t, []string{"gb", "ie"}, params.RegionRules[1].Regions)
+}
+
+// TestOddsFormat tests parsing display formats and rendering prices in each
+func TestOddsFormat(t *testing.T) {
+	tests := []struct {
+		price      float64
+		decimal    string
+		fractional string
+		american   string
+	}{
+		{price: 2.00, decimal: "2.00", fractional: "1/1", american: "+100"},
+		{price: 2.50, decimal: "2.50", fractional: "3/2", american: "+150"},
+		{price: 1.80, decimal: "1.80", fractional: "4/5", american: "-125"},
+		{price: 1.909, decimal: "1.91", fractional: "10/11", american: "-110"},
+		{price: 11.0, decimal: "11.00", fractional: "10/1", american: "+1000"},
+		{price: 1.01, decimal: "1.01", fractional: "1/100", american: "-10000"},
+		{price: 3.333, decimal: "3.33", fractional: "7/3", american: "+233"},
+		{price: 1.0, decimal: "", fractional: "", american: ""},
+		{price: 0, decimal: "", fractional: "", american: ""},
+	}
+
+	for _, tt := range tests {
+		price := decimal.NewFromFloat(tt.price)
+		assert.Equal(t, tt.decimal, FormatDecimal.Render(price), "decimal %v", tt.price)
+		assert.Equal(t, tt.fractional, FormatFractional.Render(price), "fractional %v", tt.price)
+		assert.Equal(t, tt.american, FormatAmerican.Render(price), "american %v", tt.price)
+	}
+
+	for input, expected := range map[string]OddsFormat{"": FormatDecimal, "Fractional": FormatFractional, " american ": FormatAmerican} {
+		format, err := ParseOddsFormat(input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, format)
+	}
+	_, err := ParseOddsFormat("hongkong")
+	assert.ErrorIs(t, err, ErrUnknownOddsFormat)
+}
+
+// TestExplain tests that every explanation field is populated and consistent with the price
+func TestExplain(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.ConfidenceBounds = map[string]models.ConfidenceBounds{"golf": {Min: 0, Max: 0.5}}
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	// Low liquidity niche sport exercises the liquidity adjustment and sport multiplier
+	normalized := newMarketOdds("Team A", 2.50)
+	normalized.Sport = "golf"
+	normalized.BackSize = decimal.NewFromFloat(2000)
+	normalized.LaySize = decimal.NewFromFloat(2000)
+
+	optimized, explanation, err := opt.Explain(normalized)
+	require.NoError(t, err)
+	require.NotNil(t, explanation)
+
+	// Probabilities
+	assert.True(t, decimal.NewFromFloat(0.4).Equal(explanation.ImpliedProbability))
+	assert.True(t, explanation.RemovedOverround.IsZero())
+	assert.True(t, explanation.FairProbability.Equal(explanation.ImpliedProbability))
+
+	// Margin: (0.02 + 0.08*0.6) * 1.2 = 0.0816
+	margin := explanation.Margin
+	assert.True(t, params.MinMargin.Equal(margin.Base))
+	assert.True(t, decimal.NewFromFloat(0.048).Equal(margin.LiquidityAdjustment))
+	assert.True(t, decimal.NewFromFloat(1.2).Equal(margin.SportMultiplier))
+	assert.False(t, margin.MinMarginSport)
+	assert.True(t, margin.Base.Add(margin.LiquidityAdjustment).Mul(margin.SportMultiplier).Equal(margin.Unclamped))
+	assert.True(t, margin.Applied.GreaterThanOrEqual(margin.Min))
+	assert.True(t, margin.Applied.LessThanOrEqual(margin.Max))
+	assert.True(t, margin.Applied.Equal(optimized.Margin))
+
+	// Spread: the adjustment widens the spread to exactly MinSpread
+	assert.True(t, explanation.SpreadAdjustment.IsPositive())
+	widened := explanation.Spread.Add(explanation.SpreadAdjustment.Mul(decimal.NewFromInt(2)))
+	assert.InDelta(t, params.MinSpread.InexactFloat64(), widened.InexactFloat64(), 1e-12)
+	assert.InDelta(t, params.MinSpread.InexactFloat64(), optimized.OptimizedBack.Sub(optimized.OptimizedLay).InexactFloat64(), 1e-12)
+
+	// Confidence
+	confidence := explanation.Confidence
+	assert.Equal(t, params.TargetConfidence, confidence.Target)
+	assert.InDelta(t, 0.76, confidence.LiquidityFactor, 1e-9) // 0.7 + 0.3*0.2
+	assert.GreaterOrEqual(t, confidence.SpreadFactor, 0.8)
+	assert.True(t, confidence.FreshnessFactor >= 0.9 && confidence.FreshnessFactor <= 1.0)
+	assert.InDelta(t, confidence.Target*confidence.LiquidityFactor*confidence.SpreadFactor*confidence.FreshnessFactor, confidence.Clamped, 1e-9)
+	require.NotNil(t, confidence.Bounds)
+	assert.Equal(t, 0.5, confidence.Bounds.Max)
+	assert.Greater(t, confidence.Clamped, 0.5)
+	assert.Equal(t, 0.5, confidence.Confidence) // Capped by the golf bounds
+	assert.Equal(t, optimized.Confidence, confidence.Confidence)
+
+	// Explaining is not counted as optimizing
+	assert.Equal(t, Stats{}, opt.Stats())
+}
+
+// TestExplain_MinMarginSport tests that min-margin sports report no sport multiplier
+func TestExplain_MinMarginSport(t *testing.T) {
+	setup := setupTestOptimizer()
+	params := setup.params
+	params.MinMarginSports = []string{"basketball"}
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	normalized := newMarketOdds("Team A", 2.50)
+	normalized.Sport = "basketball"
+
+	_, explanation, err := opt.Explain(normalized)
+	require.NoError(t, err)
+
+	assert.True(t, explanation.Margin.MinMarginSport)
+	assert.True(t, decimal.NewFromInt(1).Equal(explanation.Margin.SportMultiplier))
+	assert.Nil(t, explanation.Confidence.Bounds)
+
+	_, _, err = opt.Explain(newMarketOdds("Team A", 0))
+	assert.ErrorIs(t, err, ErrInvalidBackPrice)
+}
+
+// TestOptimize_TrueProbability tests pricing around a supplied model probability
+func TestOptimize_TrueProbability(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	// Market implies 0.40; the model says 0.50
+	derived, err := setup.optimizer.Optimize(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+
+	withModel := newMarketOdds("Team A", 2.50)
+	withModel.TrueProbability = decimal.NewFromFloat(0.5)
+	modelled, err := setup.optimizer.Optimize(withModel)
+	require.NoError(t, err)
+
+	assert.True(t, decimal.NewFromFloat(2.5).Equal(derived.FairPrice))
+	assert.True(t, decimal.NewFromInt(2).Equal(modelled.FairPrice))
+	assert.True(t, modelled.OptimizedBack.LessThan(derived.OptimizedBack))
+	assert.True(t, modelled.OptimizedLay.LessThan(derived.OptimizedLay))
+
+	// Margin and spread are applied the same way around either base
+	assert.True(t, derived.Margin.Equal(modelled.Margin))
+	assertFairPriceBetween(t, modelled)
+
+	t.Run("Invalid probabilities are ignored", func(t *testing.T) {
+		for _, prob := range []float64{-0.2, 1, 1.5} {
+			odds := newMarketOdds("Team A", 2.50)
+			odds.TrueProbability = decimal.NewFromFloat(prob)
+
+			optimized, err := setup.optimizer.Optimize(odds)
+			require.NoError(t, err)
+			assert.True(t, derived.FairPrice.Equal(optimized.FairPrice), "true probability %v", prob)
+		}
+	})
+}
+
+// TestBatchOptimizeMarket_TrueProbability tests that a model probability replaces
+// the overround-removed probability for its selection only
+func TestBatchOptimizeMarket_TrueProbability(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	book := []*models.NormalizedOdds{newMarketOdds("Team A", 1.90), newMarketOdds("Team B", 1.90)}
+	book[0].TrueProbability = decimal.NewFromFloat(0.6)
+
+	optimized, err := setup.optimizer.BatchOptimizeMarket(book)
+	require.NoError(t, err)
+	require.Len(t, optimized, 2)
+
+	assert.InDelta(t, 1/0.6, optimized[0].FairPrice.InexactFloat64(), 1e-9)
+	assert.InDelta(t, 2.0, optimized[1].FairPrice.InexactFloat64(), 1e-9) // Overround removed
+}
+
+// TestOptimize_ProbabilityInput tests optimizing selections quoted as probabilities
+func TestOptimize_ProbabilityInput(t *testing.T) {
+	setup := setupTestOptimizer()
+
+	priced, err := setup.optimizer.Optimize(newMarketOdds("Team A", 2.50))
+	require.NoError(t, err)
+
+	probOnly := newMarketOdds("Team A", 0)
+	probOnly.BackProb = decimal.NewFromFloat(0.4)
+	probOnly.LayProb = decimal.NewFromFloat(0.38)
+	optimized, err := setup.optimizer.Optimize(probOnly)
+	require.NoError(t, err)
+
+	// A probability of 0.40 prices exactly like odds of 2.50
+	assert.True(t, priced.FairPrice.Equal(optimized.FairPrice))
+	assert.True(t, priced.OptimizedBack.Equal(optimized.OptimizedBack))
+	assert.True(t, priced.OptimizedLay.Equal(optimized.OptimizedLay))
+	assert.InDelta(t, priced.Confidence, optimized.Confidence, 0.001)
+	assert.True(t, decimal.NewFromFloat(2.5).Equal(optimized.OriginalBack))
+	assert.True(t, optimized.OriginalLay.GreaterThan(decimal.NewFromFloat(2.6)))
+	assertFairPriceBetween(t, optimized)
+
+	t.Run("Probability takes precedence over price", func(t *testing.T) {
+		odds := newMarketOdds("Team A", 2.50)
+		odds.BackProb = decimal.NewFromFloat(0.5)
+
+		optimized, err := setup.optimizer.Optimize(odds)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(2).Equal(optimized.FairPrice))
+		assert.True(t, decimal.NewFromFloat(2.5).Equal(optimized.OriginalBack))
+	})
+
+	t.Run("Invalid probability without price is rejected", func(t *testing.T) {
+		for _, prob := range []float64{0, 1, 1.5} {
+			odds := newMarketOdds("Team A", 0)
+			odds.BackProb = decimal.NewFromFloat(prob)
+
+			_, err := setup.optimizer.Optimize(odds)
+			assert.ErrorIs(t, err, ErrInvalidBackPrice, "probability %v", prob)
+		}
+	})
+
+	t.Run("Market batch", func(t *testing.T) {
+		home := newMarketOdds("Team A", 0)
+		home.BackProb = decimal.NewFromFloat(0.55)
+		away := newMarketOdds("Team B", 0)
+		away.BackProb = decimal.NewFromFloat(0.55)
+
+		optimized, err := setup.optimizer.BatchOptimizeMarket([]*models.NormalizedOdds{home, away})
+		require.NoError(t, err)
+		require.Len(t, optimized, 2)
+
+		// 1.10 overround removed: both selections are fair at 0.50
+		for _, opt := range optimized {
+			assert.True(t, decimal.NewFromInt(2).Equal(opt.FairPrice), "fair price %s", opt.FairPrice)
+		}
+	})
+}
+
+// TestOptimize_ProbabilityConsistency tests the warning on prices and probabilities that disagree
+func TestOptimize_ProbabilityConsistency(t *testing.T) {
+	var logs bytes.Buffer
+	params := setupTestOptimizer().params
+	opt := NewOptimizer(params, zerolog.New(&logs))
+
+	// 1/2.50 = 0.40 is within tolerance of 0.405
+	consistent := newMarketOdds("Team A", 2.50)
+	consistent.BackProb = decimal.NewFromFloat(0.405)
+	_, err := opt.Optimize(consistent)
+	require.NoError(t, err)
+	assert.NotContains(t, logs.String(), "inconsistent")
+
+	// 1/2.50 = 0.40 is far from 0.50: warned, but the probability is still used
+	inconsistent := newMarketOdds("Team A", 2.50)
+	inconsistent.BackProb = decimal.NewFromFloat(0.5)
+	optimized, err := opt.Optimize(inconsistent)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(2).Equal(optimized.FairPrice))
+
+	assert.Contains(t, logs.String(), `"level":"warn"`)
+	assert.Contains(t, logs.String(), "supplied probability inconsistent with price")
+	assert.Contains(t, logs.String(), `"side":"back"`)
+	assert.NotContains(t, logs.String(), `"side":"lay"`)
+}
+
+// TestOptimize_TraceLogging tests the trace-level pipeline log
+func TestOptimize_TraceLogging(t *testing.T) {
+	previous := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(previous) })
+
+	var logs bytes.Buffer
+	params := setupTestOptimizer().params
+	opt := NewOptimizer(params, zerolog.New(&logs).Level(zerolog.TraceLevel))
+
+	t.Run("Trace enabled", func(t *testing.T) {
+		zerolog.SetGlobalLevel(zerolog.TraceLevel)
+		logs.Reset()
+
+		odds := newMarketOdds("Team A", 2.50)
+		optimized, err := opt.Optimize(odds)
+		require.NoError(t, err)
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+
+		assert.Equal(t, "trace", entry["level"])
+		assert.Equal(t, "optimization trace", entry["message"])
+		assert.Equal(t, optimized.ID.String(), entry["correlation_id"])
+		assert.Equal(t, odds.ID.String(), entry["input_id"])
+		assert.Equal(t, "event-123", entry["event_id"])
+		assert.Equal(t, "match_winner", entry["market"])
+		assert.Equal(t, "Team A", entry["selection"])
+		assert.Equal(t, "0.4", entry["implied_probability"])
+		assert.Equal(t, optimized.OptimizedBack.String(), entry["optimized_back"])
+		assert.Equal(t, optimized.OptimizedLay.String(), entry["optimized_lay"])
+		for _, key := range []string{"pre_spread_back", "pre_spread_lay", "spread", "spread_adjustment", "spread_widened"} {
+			assert.Contains(t, entry, key)
+		}
+
+		margin, ok := entry["margin"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, optimized.Margin.String(), margin["target"])
+		assert.Contains(t, margin, "clamped")
+		assert.Contains(t, margin, "sport_multiplier")
+
+		confidence, ok := entry["confidence"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, optimized.Confidence, confidence["final"])
+		for _, key := range []string{"liquidity_factor", "spread_factor", "freshness_factor", "bounded"} {
+			assert.Contains(t, confidence, key)
+		}
+	})
+
+	t.Run("Trace disabled", func(t *testing.T) {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		logs.Reset()
+
+		_, err := opt.Optimize(newMarketOdds("Team A", 2.50))
+		require.NoError(t, err)
+		assert.Empty(t, logs.String())
+	})
+}
+
+// newBook creates a 3-way darts book with the given prices and liquidity per side
+func newBook(home, draw, away, size float64) []*models.NormalizedOdds {
+	book := []*models.NormalizedOdds{
+		newMarketOdds("Team A", home),
+		newMarketOdds("Draw", draw),
+		newMarketOdds("Team B", away),
+	}
+	for _, odds := range book {
+		odds.Sport = "darts"
+		odds.BackSize = decimal.NewFromFloat(size)
+		odds.LaySize = decimal.NewFromFloat(size)
+	}
+	return book
+}
+
+// TestBatchOptimizeMarket_MaxTotalOverround tests scaling margins down to the total overround cap
+func TestBatchOptimizeMarket_MaxTotalOverround(t *testing.T) {
+	var logs bytes.Buffer
+	params := setupTestOptimizer().params
+	params.MaxTotalOverround = decimal.NewFromFloat(0.12)
+	capped := NewOptimizer(params, zerolog.New(&logs))
+	uncapped := NewOptimizer(setupTestOptimizer().params, zerolog.Nop())
+
+	t.Run("Efficient input is scaled", func(t *testing.T) {
+		logs.Reset()
+
+		// A fair 100% book; thin liquidity and a niche sport put every leg at
+		// the 10% max margin, a 130% book before the cap
+		full, err := uncapped.BatchOptimizeMarket(newBook(2.0, 4.0, 4.0, 1000))
+		require.NoError(t, err)
+		optimized, err := capped.BatchOptimizeMarket(newBook(2.0, 4.0, 4.0, 1000))
+		require.NoError(t, err)
+		require.Len(t, optimized, 3)
+
+		total := decimal.Zero
+		for i, opt := range optimized {
+			assert.True(t, decimal.NewFromFloat(0.10).Equal(full[i].Margin), "uncapped margin %s", full[i].Margin)
+			assert.True(t, decimal.NewFromFloat(0.04).Equal(opt.Margin), "capped margin %s", opt.Margin)
+			assert.True(t, full[i].FairPrice.Equal(opt.FairPrice))
+			total = total.Add(decimal.NewFromInt(1).Div(opt.FairPrice)).Add(opt.Margin)
+		}
+		assert.True(t, decimal.NewFromFloat(1.12).Equal(total), "total %s", total)
+
+		assert.Contains(t, logs.String(), "scaled book margins to max total overround")
+		assert.Contains(t, logs.String(), `"margin_scale":"0.4000"`)
+	})
+
+	t.Run("Wide input within the cap is not scaled", func(t *testing.T) {
+		logs.Reset()
+
+		// A 113% incoming book is de-vigged before margin is added; deep
+		// liquidity keeps margins at 2.4% per leg, a 107.2% book
+		full, err := uncapped.BatchOptimizeMarket(newBook(1.8, 3.5, 3.5, 20000))
+		require.NoError(t, err)
+		optimized, err := capped.BatchOptimizeMarket(newBook(1.8, 3.5, 3.5, 20000))
+		require.NoError(t, err)
+		require.Len(t, optimized, 3)
+
+		for i, opt := range optimized {
+			assert.True(t, decimal.NewFromFloat(0.024).Equal(opt.Margin), "margin %s", opt.Margin)
+			assert.True(t, full[i].OptimizedBack.Equal(opt.OptimizedBack))
+			assert.True(t, full[i].OptimizedLay.Equal(opt.OptimizedLay))
+		}
+		assert.NotContains(t, logs.String(), "scaled book margins")
+	})
+
+	t.Run("Lone selections are not books", func(t *testing.T) {
+		optimized, err := capped.BatchOptimizeMarket(newBook(2.0, 4.0, 4.0, 1000)[:1])
+		require.NoError(t, err)
+		require.Len(t, optimized, 1)
+		assert.True(t, decimal.NewFromFloat(0.10).Equal(optimized[0].Margin))
+	})
+}
+
+// TestOptimize_CurrencyNormalization tests that sizes are normalized to the
+// base currency before liquidity thresholds apply
+func TestOptimize_CurrencyNormalization(t *testing.T) {
+	params := setupTestOptimizer().params
+	params.BaseCurrency = "USD"
+	params.FXRates = map[string]decimal.Decimal{
+		"gbp": decimal.NewFromFloat(1.25), // Keys are case-insensitive
+		"EUR": decimal.NewFromFloat(1.10),
+	}
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	priceIn := func(currency string, size int64) *models.OptimizedOdds {
+		odds := newMarketOdds("Team A", 2.50)
+		odds.BackSize = decimal.NewFromInt(size)
+		odds.LaySize = decimal.NewFromInt(size)
+		odds.Currency = currency
+		optimized, err := opt.Optimize(odds)
+		require.NoError(t, err)
+		return optimized
+	}
+
+	// 4000 per side: $8k is under the $10k margin threshold, £8k is $10k
+	usd := priceIn("USD", 4000)
+	gbp := priceIn("GBP", 4000)
+	eur := priceIn("eur", 4000)
+	assert.True(t, usd.Margin.GreaterThan(eur.Margin), "usd %s, eur %s", usd.Margin, eur.Margin)
+	assert.True(t, eur.Margin.GreaterThan(gbp.Margin), "eur %s, gbp %s", eur.Margin, gbp.Margin)
+	assert.True(t, params.MinMargin.Equal(gbp.Margin), "gbp margin %s", gbp.Margin)
+
+	// 6000 per side: every currency clears the margin threshold, so margins
+	// match, and confidence rises with liquidity towards the $20k level
+	usdDeep := priceIn("USD", 6000)
+	gbpDeep := priceIn("GBP", 6000)
+	eurDeep := priceIn("EUR", 6000)
+	assert.True(t, usdDeep.Margin.Equal(gbpDeep.Margin))
+	assert.Less(t, usdDeep.Confidence, eurDeep.Confidence)
+	assert.Less(t, eurDeep.Confidence, gbpDeep.Confidence)
+
+	// Missing and unknown currencies are taken as the base
+	missing := priceIn("", 4000)
+	unknown := priceIn("CHF", 4000)
+	assert.True(t, usd.Margin.Equal(missing.Margin))
+	assert.True(t, usd.Margin.Equal(unknown.Margin))
+	assert.InDelta(t, usd.Confidence, missing.Confidence, 0.001)
+}
+
+// TestBatchOptimize_DuplicateSelections tests which copy of a repeated
+// selection is optimized under each duplicate policy
+func TestBatchOptimize_DuplicateSelections(t *testing.T) {
+	now := time.Now()
+	batch := func() []*models.NormalizedOdds {
+		first := newMarketOdds("Team A", 2.50)
+		first.Timestamp = now
+		newest := newMarketOdds("Team A", 2.70)
+		newest.Timestamp = now.Add(time.Second)
+		last := newMarketOdds("Team A", 2.60)
+		last.Timestamp = now.Add(-time.Second)
+		return []*models.NormalizedOdds{first, newMarketOdds("Team B", 3.00), newest, last}
+	}
+
+	tests := []struct {
+		policy   string
+		expected float64 // Original back price of the Team A copy kept
+	}{
+		{policy: "", expected: 2.70},
+		{policy: DuplicateKeepNewest, expected: 2.70},
+		{policy: DuplicateKeepFirst, expected: 2.50},
+		{policy: DuplicateKeepLast, expected: 2.60},
+	}
+
+	for _, tt := range tests {
+		t.Run("policy "+tt.policy, func(t *testing.T) {
+			params := setupTestOptimizer().params
+			params.DuplicatePolicy = tt.policy
+
+			for name, batchOptimize := range map[string]func(*Optimizer, []*models.NormalizedOdds) ([]*models.OptimizedOdds, error){
+				"BatchOptimize":       (*Optimizer).BatchOptimize,
+				"BatchOptimizeMarket": (*Optimizer).BatchOptimizeMarket,
+			} {
+				opt := NewOptimizer(params, zerolog.Nop())
+
+				optimized, err := batchOptimize(opt, batch())
+				require.NoError(t, err, name)
+				require.Len(t, optimized, 2, name)
+				assert.Equal(t, "Team A", optimized[0].Selection, name)
+				assert.True(t, decimal.NewFromFloat(tt.expected).Equal(optimized[0].OriginalBack),
+					"%s: expected %v, got %s", name, tt.expected, optimized[0].OriginalBack)
+				assert.Equal(t, "Team B", optimized[1].Selection, name)
+				assert.Equal(t, 2.0, testutil.ToFloat64(opt.metrics.duplicateSelections), name)
+			}
+		})
+	}
+}
+
+// TestBatchOptimize_SourcePreference tests that the preferred source wins
+// among copies of a selection regardless of order or age, and that the
+// source is carried onto the optimized odds
+func TestBatchOptimize_SourcePreference(t *testing.T) {
+	now := time.Now()
+	odds := func(source string, backPrice float64, age time.Duration) *models.NormalizedOdds {
+		o := newMarketOdds("Team A", backPrice)
+		o.Source = source
+		o.Timestamp = now.Add(-age)
+		return o
+	}
+
+	params := setupTestOptimizer().params
+	params.SourcePreference = []string{"Pinnacle", "betfair"}
+
+	tests := []struct {
+		name     string
+		batch    []*models.NormalizedOdds
+		expected string
+	}{
+		{
+			name:     "Preferred source beats newer copies",
+			batch:    []*models.NormalizedOdds{odds("betfair", 2.50, 0), odds("pinnacle", 2.60, time.Minute), odds("other", 2.70, 0)},
+			expected: "pinnacle",
+		},
+		{
+			name:     "Listed source beats unlisted",
+			batch:    []*models.NormalizedOdds{odds("betfair", 2.50, time.Minute), odds("other", 2.70, 0)},
+			expected: "betfair",
+		},
+		{
+			name:     "Equal rank falls back to the duplicate policy",
+			batch:    []*models.NormalizedOdds{odds("other", 2.50, time.Minute), odds("", 2.70, 0)},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := NewOptimizer(params, zerolog.Nop())
+
+			optimized, err := opt.BatchOptimize(tt.batch)
+			require.NoError(t, err)
+			require.Len(t, optimized, 1)
+			assert.Equal(t, tt.expected, optimized[0].Source)
+		})
+	}
+}
+
+// TestOptimize_MinSpreadPct tests that the percentage spread dominates at
+// long odds and the absolute spread at short odds
+func TestOptimize_MinSpreadPct(t *testing.T) {
+	tests := []struct {
+		name      string
+		backPrice float64
+		pct       float64
+		expected  float64 // Final back-lay spread
+	}{
+		{name: "Short odds keep the absolute spread", backPrice: 1.05, pct: 2, expected: 0.05},
+		{name: "Long odds widen to the percentage", backPrice: 50, pct: 2, expected: 1.0},
+		{name: "Disabled", backPrice: 50, pct: 0, expected: 0.05},
+	}
+
+	for _, tt := range tests {
+		for _, fastMath := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/fast_math=%t", tt.name, fastMath), func(t *testing.T) {
+				params := setupTestOptimizer().params
+				params.MinSpread = decimal.NewFromFloat(0.05)
+				params.MinSpreadPct = decimal.NewFromFloat(tt.pct)
+				params.FastMath = fastMath
+				opt := NewOptimizer(params, zerolog.Nop())
+
+				normalized := newMarketOdds("Team A", tt.backPrice)
+				optimized, explanation, err := opt.Explain(normalized)
+				require.NoError(t, err)
+
+				spread := optimized.OptimizedBack.Sub(optimized.OptimizedLay).InexactFloat64()
+				assert.InDelta(t, tt.expected, spread, 1e-9)
+				assert.True(t, explanation.SpreadAdjustment.IsPositive())
+			})
+		}
+	}
+}
+
+// TestBatchOptimizeMarket_Ladder tests that a consistent totals ladder passes
+// untouched and an inverted one is smoothed or rejected
+func TestBatchOptimizeMarket_Ladder(t *testing.T) {
+	rung := func(selection string, line, backPrice float64) *models.NormalizedOdds {
+		odds := newMarketOdds(selection, backPrice)
+		odds.Market = "total_goals"
+		odds.Line = decimal.NewFromFloat(line)
+		return odds
+	}
+	ladder := func(over35, under35 float64) []*models.NormalizedOdds {
+		return []*models.NormalizedOdds{
+			rung("Over", 1.5, 1.30), rung("Under", 1.5, 3.60),
+			rung("Over", 2.5, 1.90), rung("Under", 2.5, 1.95),
+			rung("Over", 3.5, over35), rung("Under", 3.5, under35),
+		}
+	}
+	// backs returns the optimized back prices of one side, by line
+	backs := func(optimized []*models.OptimizedOdds, side string) map[string]decimal.Decimal {
+		prices := make(map[string]decimal.Decimal)
+		for _, odds := range optimized {
+			if odds.Selection == side {
+				prices[odds.Line.String()] = odds.OptimizedBack
+			}
+		}
+		return prices
+	}
+
+	t.Run("Consistent ladder", func(t *testing.T) {
+		opt := NewOptimizer(setupTestOptimizer().params, zerolog.Nop())
+
+		optimized, err := opt.BatchOptimizeMarket(ladder(3.20, 1.35))
+		require.NoError(t, err)
+		require.Len(t, optimized, 6)
+		assert.Equal(t, 0.0, testutil.ToFloat64(opt.metrics.ladderInversions))
+
+		over := backs(optimized, "Over")
+		assert.True(t, over["1.5"].LessThan(over["2.5"]) && over["2.5"].LessThan(over["3.5"]), "over %v", over)
+		under := backs(optimized, "Under")
+		assert.True(t, under["1.5"].GreaterThan(under["2.5"]) && under["2.5"].GreaterThan(under["3.5"]), "under %v", under)
+	})
+
+	t.Run("Inverted ladder is smoothed", func(t *testing.T) {
+		opt := NewOptimizer(setupTestOptimizer().params, zerolog.Nop())
+
+		optimized, err := opt.BatchOptimizeMarket(ladder(1.70, 2.20))
+		require.NoError(t, err)
+		require.Len(t, optimized, 6)
+		assert.Equal(t, 2.0, testutil.ToFloat64(opt.metrics.ladderInversions))
+		assert.Equal(t, uint64(6), opt.Stats().Optimized)
+
+		over := backs(optimized, "Over")
+		assert.True(t, over["3.5"].Equal(over["2.5"]), "over %v", over)
+		under := backs(optimized, "Under")
+		assert.True(t, under["2.5"].Equal(under["3.5"]), "under %v", under)
+		assert.True(t, under["1.5"].GreaterThan(under["2.5"]), "under %v", under)
+	})
+
+	t.Run("Inverted ladder is rejected", func(t *testing.T) {
+		params := setupTestOptimizer().params
+		params.LadderPolicy = LadderReject
+		opt := NewOptimizer(params, zerolog.Nop())
+
+		optimized, err := opt.BatchOptimizeMarket(ladder(1.70, 2.20))
+		require.NoError(t, err)
+		require.Len(t, optimized, 4)
+		assert.Equal(t, 2.0, testutil.ToFloat64(opt.metrics.ladderInversions))
+		assert.Equal(t, uint64(4), opt.Stats().Optimized)
+		assert.Equal(t, uint64(2), opt.Stats().Rejected)
+
+		assert.NotContains(t, backs(optimized, "Over"), "3.5")
+		assert.NotContains(t, backs(optimized, "Under"), "2.5")
+	})
+}
+
+// TestNormalizers tests that every normalization method yields probabilities
+// summing to one, and that power and log-odds normalization take relatively
+// more overround from the longshot than proportional normalization
+func TestNormalizers(t *testing.T) {
+	// 1.5 / 4.0 / 7.0 books at about 110%
+	impliedProbs := []decimal.Decimal{
+		decimal.NewFromInt(1).Div(decimal.NewFromFloat(1.5)),
+		decimal.NewFromInt(1).Div(decimal.NewFromFloat(4.0)),
+		decimal.NewFromInt(1).Div(decimal.NewFromFloat(7.0)),
+	}
+	methods := []string{NormalizationProportional, NormalizationPower, NormalizationLogOdds}
+
+	fair := make(map[string][]decimal.Decimal, len(methods))
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			probs := NewNormalizer(method, 0).Normalize(impliedProbs)
+			require.Len(t, probs, len(impliedProbs))
+
+			sum := decimal.Zero
+			for i, prob := range probs {
+				assert.True(t, prob.IsPositive())
+				assert.True(t, prob.LessThan(impliedProbs[i]), "normalization removes overround from every selection")
+				sum = sum.Add(prob)
+			}
+			assert.InDelta(t, 1.0, sum.InexactFloat64(), 1e-9)
+			fair[method] = probs
+		})
+	}
+
+	proportional := fair[NormalizationProportional]
+	for _, method := range []string{NormalizationPower, NormalizationLogOdds} {
+		assert.True(t, fair[method][0].GreaterThan(proportional[0]), "%s favourite", method)
+		assert.True(t, fair[method][2].LessThan(proportional[2]), "%s longshot", method)
+	}
+
+	// Unknown methods and unsolvable books fall back safely
+	assert.IsType(t, proportionalNormalizer{}, NewNormalizer("unknown", 0))
+	lone := []decimal.Decimal{decimal.NewFromFloat(0.4)}
+	assert.True(t, NewNormalizer(NormalizationPower, 0).Normalize(lone)[0].Equal(lone[0]))
+}
+
+// TestBatchOptimizeMarket_NormalizationMethod tests that the configured
+// normalization method prices a book
+func TestBatchOptimizeMarket_NormalizationMethod(t *testing.T) {
+	book := func() []*models.NormalizedOdds {
+		return []*models.NormalizedOdds{
+			newMarketOdds("Home", 1.5),
+			newMarketOdds("Draw", 4.0),
+			newMarketOdds("Away", 7.0),
+		}
+	}
+	fairPrices := func(method string) map[string]decimal.Decimal {
+		params := setupTestOptimizer().params
+		params.NormalizationMethod = method
+		optimized, err := NewOptimizer(params, zerolog.Nop()).BatchOptimizeMarket(book())
+		require.NoError(t, err)
+		require.Len(t, optimized, 3)
+
+		prices := make(map[string]decimal.Decimal, len(optimized))
+		for _, odds := range optimized {
+			prices[odds.Selection] = odds.FairPrice
+		}
+		return prices
+	}
+
+	proportional := fairPrices(NormalizationProportional)
+	assert.Equal(t, proportional, fairPrices(""))
+
+	power := fairPrices(NormalizationPower)
+	assert.True(t, power["Home"].LessThan(proportional["Home"]))
+	assert.True(t, power["Away"].GreaterThan(proportional["Away"]))
+
+	// A custom normalizer replaces the configured one
+	opt := NewOptimizer(setupTestOptimizer().params, zerolog.Nop())
+	opt.SetNormalizer(logOddsNormalizer{})
+	optimized, err := opt.BatchOptimizeMarket(book())
+	require.NoError(t, err)
+	require.Len(t, optimized, 3)
+	for _, odds := range optimized {
+		assert.False(t, odds.FairPrice.Equal(proportional[odds.Selection]), odds.Selection)
+	}
+}
+
+// TestApplyMargin_AsymmetricWeights tests that margin weights move the back
+// and lay probabilities unevenly while the total margin is preserved
+func TestApplyMargin_AsymmetricWeights(t *testing.T) {
+	fairProb := decimal.NewFromFloat(0.4)
+	targetMargin := decimal.NewFromFloat(0.04)
+	noSpread := decimal.NewFromInt(-100) // Keep the minimum spread out of the way
+
+	// marginProbs returns how far the back and lay probabilities moved from fair
+	marginProbs := func(params models.OptimizationParams) (float64, float64) {
+		back, lay, _, _ := NewOptimizer(params, zerolog.Nop()).applyMargin(fairProb, targetMargin, noSpread)
+		fair := fairProb.InexactFloat64()
+		return 1/back.InexactFloat64() - fair, fair - 1/lay.InexactFloat64()
+	}
+
+	even := setupTestOptimizer().params
+	evenBack, evenLay := marginProbs(even)
+	assert.InDelta(t, 0.02, evenBack, 1e-9)
+	assert.InDelta(t, 0.02, evenLay, 1e-9)
+
+	weighted := setupTestOptimizer().params
+	weighted.BackMarginWeight = decimal.NewFromFloat(0.7)
+	weighted.LayMarginWeight = decimal.NewFromFloat(0.3)
+	weightedBack, weightedLay := marginProbs(weighted)
+	assert.InDelta(t, 0.028, weightedBack, 1e-9)
+	assert.InDelta(t, 0.012, weightedLay, 1e-9)
+	assert.InDelta(t, evenBack+evenLay, weightedBack+weightedLay, 1e-9)
+
+	// Weights not summing to one are scaled; the fast path splits the same way
+	weighted.BackMarginWeight = decimal.NewFromInt(7)
+	weighted.LayMarginWeight = decimal.NewFromInt(3)
+	weighted.FastMath = true
+	fastBack, fastLay := marginProbs(weighted)
+	assert.InDelta(t, 0.028, fastBack, 1e-9)
+	assert.InDelta(t, 0.012, fastLay, 1e-9)
+
+	// Optimized prices move while the reported margin does not
+	normalized := newMarketOdds("Home", 2.5)
+	evenOdds, err := NewOptimizer(even, zerolog.Nop()).Optimize(normalized)
+	require.NoError(t, err)
+	weighted.FastMath = false
+	weightedOdds, err := NewOptimizer(weighted, zerolog.Nop()).Optimize(normalized)
+	require.NoError(t, err)
+	assert.True(t, evenOdds.Margin.Equal(weightedOdds.Margin))
+	assert.False(t, evenOdds.OptimizedBack.Equal(weightedOdds.OptimizedBack))
+	assert.False(t, evenOdds.OptimizedLay.Equal(weightedOdds.OptimizedLay))
+}
+
+// TestRoundOutput_Modes tests each rounding mode on values sitting exactly
+// on a rounding boundary
+func TestRoundOutput_Modes(t *testing.T) {
+	price := func(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+	tests := []struct {
+		mode   string
+		back   string // 2.125 rounded as a back price
+		lay    string // 2.135 rounded as a lay price
+		fair   string // 2.145 rounded as the fair price
+		margin string // 0.0425 rounded as a margin
+	}{
+		{mode: RoundHalfUp, back: "2.13", lay: "2.14", fair: "2.15", margin: "0.043"},
+		{mode: "", back: "2.13", lay: "2.14", fair: "2.15", margin: "0.043"},
+		{mode: RoundHalfEven, back: "2.12", lay: "2.14", fair: "2.14", margin: "0.042"},
+		{mode: RoundConservative, back: "2.12", lay: "2.14", fair: "2.15", margin: "0.043"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			params := setupTestOptimizer().params
+			params.PricePrecision = 2
+			params.MarginPrecision = 3
+			params.RoundingMode = tt.mode
+			opt := NewOptimizer(params, zerolog.Nop())
+
+			sportsbook := price("2.125")
+			optimized := &models.OptimizedOdds{
+				OptimizedBack:   price("2.125"),
+				OptimizedLay:    price("2.135"),
+				FairPrice:       price("2.145"),
+				Margin:          price("0.0425"),
+				SportsbookPrice: &sportsbook,
+			}
+			opt.roundOutput(optimized)
+
+			assert.Equal(t, tt.back, optimized.OptimizedBack.String())
+			assert.Equal(t, tt.lay, optimized.OptimizedLay.String())
+			assert.Equal(t, tt.fair, optimized.FairPrice.String())
+			assert.Equal(t, tt.margin, optimized.Margin.String())
+			assert.Equal(t, tt.back, optimized.SportsbookPrice.String())
+		})
+	}
+
+	// Conservative rounding takes back prices down and lay prices up off the boundary too
+	params := setupTestOptimizer().params
+	params.RoundingMode = RoundConservative
+	opt := NewOptimizer(params, zerolog.Nop())
+	assert.Equal(t, "2.12", opt.roundPrice(price("2.1299"), 2, roundBack).String())
+	assert.Equal(t, "2.13", opt.roundPrice(price("2.1201"), 2, roundLay).String())
+
+	// Rounding never produces an untradeable price
+	assert.Equal(t, "1.01", opt.roundPrice(price("1.004"), 2, roundBack).String())
+
+	// Optimized output carries at most the configured places
+	params.PricePrecision = 2
+	params.MarginPrecision = 4
+	optimized, err := NewOptimizer(params, zerolog.Nop()).Optimize(newMarketOdds("Home", 2.37))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, -optimized.OptimizedBack.Exponent(), int32(2))
+	assert.LessOrEqual(t, -optimized.OptimizedLay.Exponent(), int32(2))
+	assert.LessOrEqual(t, -optimized.Margin.Exponent(), int32(4))
+}
+
+// TestOptimize_LayOutOfRange tests that lays the margin pushes out of range
+// are clamped to a tradeable price instead of snapping to 1.0, or rejected
+// when configured to
+func TestOptimize_LayOutOfRange(t *testing.T) {
+	setup := setupTestOptimizer()
+	minSpread := setup.params.MinSpread.InexactFloat64()
+
+	bounded := setup.params
+	bounded.MinLayProbability = decimal.NewFromFloat(0.01)
+	rejecting := setup.params
+	rejecting.RejectInvalidLay = true
+
+	t.Run("Short favorite is clamped above 1.0", func(t *testing.T) {
+		for _, opt := range []*Optimizer{setup.optimizer, newFastMathOptimizer(setup.params)} {
+			optimized, err := opt.Optimize(newMarketOdds("Team A", 1.02))
+			require.NoError(t, err)
+
+			lay, back := optimized.OptimizedLay.InexactFloat64(), optimized.OptimizedBack.InexactFloat64()
+			assert.InDelta(t, 1/0.999, lay, 1e-9)
+			assert.GreaterOrEqual(t, back-lay, minSpread-1e-9)
+		}
+	})
+
+	t.Run("Configured minimum lay probability", func(t *testing.T) {
+		optimized, err := NewOptimizer(bounded, zerolog.Nop()).Optimize(newMarketOdds("Team A", 1.02))
+		require.NoError(t, err)
+		assert.InDelta(t, 1/0.99, optimized.OptimizedLay.InexactFloat64(), 1e-9)
+
+		// A long shot's fair probability is below the lay margin
+		optimized, err = NewOptimizer(bounded, zerolog.Nop()).Optimize(newMarketOdds("Team B", 100.0))
+		require.NoError(t, err)
+		assert.True(t, optimized.OptimizedLay.GreaterThan(decimal.NewFromInt(1)), "lay %s", optimized.OptimizedLay)
+		assert.True(t, optimized.OptimizedLay.LessThanOrEqual(decimal.NewFromInt(100)), "lay %s", optimized.OptimizedLay)
+	})
+
+	t.Run("Rejected when configured", func(t *testing.T) {
+		for _, opt := range []*Optimizer{NewOptimizer(rejecting, zerolog.Nop()), newFastMathOptimizer(rejecting)} {
+			_, err := opt.Optimize(newMarketOdds("Team A", 1.02))
+			assert.ErrorIs(t, err, ErrInvalidLayPrice)
+
+			_, err = opt.Optimize(newMarketOdds("Team B", 1000.0))
+			assert.ErrorIs(t, err, ErrInvalidLayPrice)
+
+			optimized, err := opt.Optimize(newMarketOdds("Team C", 2.50))
+			require.NoError(t, err)
+			assert.NotNil(t, optimized)
+
+			assert.Equal(t, Stats{Optimized: 1, Rejected: 2}, opt.Stats())
+		}
+	})
+}
+
+// TestCalculateTargetMargin_Overrides tests that margin overrides cascade
+// field by field from competition to sport+market to sport to the globals
+func TestCalculateTargetMargin_Overrides(t *testing.T) {
+	params := setupTestOptimizer().params
+	params.MarginOverrides = []models.MarginOverride{
+		{Sport: "football", SportMultiplier: decimal.NewFromFloat(2.0)},
+		{Sport: "football", Market: "match_winner", MinMargin: decimal.NewFromFloat(0.03)},
+		{Sport: "football", Competition: "Premier League", MinMargin: decimal.NewFromFloat(0.015), MaxMargin: decimal.NewFromFloat(0.025)},
+		{Sport: "football", Competition: "League Two", Market: "match_winner", SportMultiplier: decimal.NewFromFloat(3.0)},
+	}
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	tests := []struct {
+		name        string
+		sport       string
+		competition string
+		market      string
+		expected    decimal.Decimal
+		level       string
+	}{
+		// Competition min and max, sport multiplier: 0.015 * 2 capped at 0.025
+		{name: "Competition", sport: "football", competition: "Premier League", market: "match_winner", expected: decimal.NewFromFloat(0.025), level: OverrideLevelCompetition},
+		{name: "Competition matched case-insensitively", sport: "Football", competition: "premier league", market: "correct_score", expected: decimal.NewFromFloat(0.025), level: OverrideLevelCompetition},
+		// Competition multiplier, sport+market min: 0.03 * 3
+		{name: "Competition market", sport: "football", competition: "League Two", market: "match_winner", expected: decimal.NewFromFloat(0.09), level: OverrideLevelCompetition},
+		// Sport+market min, sport multiplier: 0.03 * 2
+		{name: "Falls back to sport and market", sport: "football", competition: "Championship", market: "match_winner", expected: decimal.NewFromFloat(0.06), level: OverrideLevelMarket},
+		// Global min, sport multiplier: 0.02 * 2
+		{name: "Falls back to sport", sport: "football", competition: "League Two", market: "correct_score", expected: decimal.NewFromFloat(0.04), level: OverrideLevelSport},
+		// Global min and built-in tennis multiplier
+		{name: "Falls back to globals", sport: "tennis", competition: "Premier League", market: "match_winner", expected: decimal.NewFromFloat(0.02), level: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized := newMarketOdds("Team A", 2.50)
+			normalized.Sport = tt.sport
+			normalized.Competition = tt.competition
+			normalized.Market = tt.market
+
+			margin := opt.explainMargin(normalized)
+			assert.True(t, tt.expected.Equal(margin.Applied), "expected %s, got %s", tt.expected, margin.Applied)
+			assert.True(t, tt.expected.Equal(opt.calculateTargetMargin(normalized)))
+			assert.Equal(t, tt.level, margin.Override)
+		})
+	}
+}
+
+// TestConsensusBuilder tests weighted consensus prices, summed sizes,
+// excluded sources and the errors for unusable quotes
+func TestConsensusBuilder(t *testing.T) {
+	now := time.Now()
+	quote := func(source string, back, lay float64) *models.NormalizedOdds {
+		o := newMarketOdds("Team A", back)
+		o.Source = source
+		o.Timestamp = now
+		if lay > 0 {
+			o.LayPrice = decimal.NewFromFloat(lay)
+		}
+		return o
+	}
+
+	builder := NewConsensusBuilder(map[string]decimal.Decimal{
+		"Pinnacle": decimal.NewFromInt(3),
+		"ignored":  decimal.Zero,
+	})
+
+	t.Run("Weighted mean of implied probabilities", func(t *testing.T) {
+		newest := quote("betfair", 2.50, 0)
+		newest.Timestamp = now.Add(time.Second)
+
+		consensus, err := builder.Build([]*models.NormalizedOdds{quote("pinnacle", 2.00, 2.10), newest, quote("ignored", 10, 11)})
+
+		require.NoError(t, err)
+		assert.Equal(t, ConsensusSource, consensus.Source)
+		assert.Equal(t, newest.Timestamp, consensus.Timestamp)
+		assert.NotEqual(t, newest.ID, consensus.ID)
+		// (3 * 0.5 + 1 * 0.4) / 4
+		assert.True(t, decimal.NewFromFloat(0.475).Equal(consensus.BackProb), consensus.BackProb.String())
+		assert.InDelta(t, 1/0.475, consensus.BackPrice.InexactFloat64(), 1e-9)
+		// Only pinnacle quoted a lay
+		assert.InDelta(t, 2.10, consensus.LayPrice.InexactFloat64(), 1e-9)
+		assert.True(t, decimal.NewFromInt(20000).Equal(consensus.BackSize), consensus.BackSize.String())
+		assert.True(t, decimal.NewFromInt(16000).Equal(consensus.LaySize), consensus.LaySize.String())
+	})
+
+	t.Run("Different selections", func(t *testing.T) {
+		other := quote("betfair", 2.50, 0)
+		other.Selection = "Team B"
+
+		_, err := builder.Build([]*models.NormalizedOdds{quote("pinnacle", 2.00, 0), other})
+		assert.ErrorIs(t, err, ErrConsensusMismatch)
+	})
+
+	t.Run("No weighted quotes", func(t *testing.T) {
+		_, err := builder.Build([]*models.NormalizedOdds{quote("ignored", 2.00, 0), quote("pinnacle", 0, 0)})
+		assert.ErrorIs(t, err, ErrNoConsensus)
+
+		_, err = builder.Build(nil)
+		assert.ErrorIs(t, err, ErrNoConsensus)
+	})
+}
+
+// TestBatchOptimize_Consensus tests that the consensus policy prices a
+// repeated selection from its weighted consensus rather than any one source,
+// and matches a single source quoting the consensus price
+func TestBatchOptimize_Consensus(t *testing.T) {
+	odds := func(source string, backPrice float64) *models.NormalizedOdds {
+		o := newMarketOdds("Team A", backPrice)
+		o.Source = source
+		return o
+	}
+
+	params := setupTestOptimizer().params
+	params.DuplicatePolicy = DuplicateConsensus
+	params.SourceWeights = map[string]decimal.Decimal{"pinnacle": decimal.NewFromInt(3)}
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	optimized, err := opt.BatchOptimize([]*models.NormalizedOdds{odds("pinnacle", 2.00), odds("betfair", 2.50), newMarketOdds("Team B", 3.00)})
+	require.NoError(t, err)
+	require.Len(t, optimized, 2)
+	consensus := optimized[0]
+	assert.Equal(t, "Team A", consensus.Selection)
+	assert.Equal(t, ConsensusSource, consensus.Source)
+	assert.Equal(t, 1.0, testutil.ToFloat64(opt.metrics.duplicateSelections))
+
+	single, err := NewOptimizer(setupTestOptimizer().params, zerolog.Nop()).Optimize(odds("pinnacle", 2.00))
+	require.NoError(t, err)
+	assert.True(t, consensus.OriginalBack.GreaterThan(single.OriginalBack),
+		"consensus %s should lengthen pinnacle's %s towards betfair", consensus.OriginalBack, single.OriginalBack)
+	assert.True(t, consensus.OptimizedBack.GreaterThan(single.OptimizedBack))
+	assert.True(t, consensus.OriginalBack.LessThan(decimal.NewFromFloat(2.50)))
+
+	// Pricing the consensus as one source's quote gives the same result, with
+	// confidence rising on the summed liquidity
+	atConsensus := odds("pinnacle", consensus.OriginalBack.InexactFloat64())
+	atConsensus.BackPrice = consensus.OriginalBack
+	equivalent, err := NewOptimizer(setupTestOptimizer().params, zerolog.Nop()).Optimize(atConsensus)
+	require.NoError(t, err)
+	assert.True(t, equivalent.OptimizedBack.Equal(consensus.OptimizedBack),
+		"expected %s, got %s", equivalent.OptimizedBack, consensus.OptimizedBack)
+	assert.GreaterOrEqual(t, consensus.Confidence, equivalent.Confidence)
+}
+
+// TestBatchOptimizeMarket_MinLiquidity tests that a selection below
+// MinLiquidity is left out of its book and the overround is removed over the
+// remaining selections only
+func TestBatchOptimizeMarket_MinLiquidity(t *testing.T) {
+	book := func() []*models.NormalizedOdds {
+		draw := newMarketOdds("Draw", 3.30)
+		draw.BackSize = decimal.NewFromInt(300)
+		draw.LaySize = decimal.NewFromInt(200)
+		return []*models.NormalizedOdds{newMarketOdds("Team A", 2.10), draw, newMarketOdds("Team B", 3.60)}
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		optimized, err := setupTestOptimizer().optimizer.BatchOptimizeMarket(book())
+
+		require.NoError(t, err)
+		assert.Len(t, optimized, 3)
+	})
+
+	params := setupTestOptimizer().params
+	params.MinLiquidity = decimal.NewFromInt(1000)
+	opt := NewOptimizer(params, zerolog.Nop())
+
+	optimized, err := opt.BatchOptimizeMarket(book())
+
+	require.NoError(t, err)
+	require.Len(t, optimized, 2)
+	assert.Equal(t, "Team A", optimized[0].Selection)
+	assert.Equal(t, "Team B", optimized[1].Selection)
+	assert.Equal(t, 1.0, testutil.ToFloat64(opt.metrics.illiquidSelections))
+
+	// The fair probabilities are those of the two-way book without the draw
+	sum := decimal.Zero
+	for _, odds := range optimized {
+		sum = sum.Add(decimal.NewFromInt(1).Div(odds.FairPrice))
+	}
+	assert.InDelta(t, 1.0, sum.InexactFloat64(), 1e-9)
+
+	twoWay, err := NewOptimizer(params, zerolog.Nop()).BatchOptimizeMarket([]*models.NormalizedOdds{
+		newMarketOdds("Team A", 2.10), newMarketOdds("Team B", 3.60),
+	})
+	require.NoError(t, err)
+	for i := range optimized {
+		assert.True(t, twoWay[i].FairPrice.Equal(optimized[i].FairPrice),
+			"%s: expected fair price %s, got %s", optimized[i].Selection, twoWay[i].FairPrice, optimized[i].FairPrice)
+		assert.True(t, twoWay[i].OptimizedBack.Equal(optimized[i].OptimizedBack))
+	}
+}
+
+// TestCalculateConfidence_NonFinite tests that inputs driving the float
+// confidence math to NaN or infinity still yield a finite confidence in
+// [0, 1] and an explanation that encodes as JSON
+func TestCalculateConfidence_NonFinite(t *testing.T) {
+	spread := decimal.NewFromFloat(0.10)
+
+	tests := []struct {
+		name      string
+		configure func(params *models.OptimizationParams)
+		odds      func(normalized *models.NormalizedOdds)
+		spread    decimal.Decimal
+		history   []float64
+		replaced  bool // A non-finite value is replaced and counted
+		check     func(t *testing.T, explanation ConfidenceExplanation)
+	}{
+		{
+			name:     "NaN stability uses the default",
+			replaced: true,
+			history:  []float64{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64},
+			check: func(t *testing.T, explanation ConfidenceExplanation) {
+				assert.Zero(t, explanation.StabilityFactor)
+				assert.Zero(t, explanation.Confidence)
+			},
+		},
+		{
+			name:      "NaN stability uses the configured default",
+			replaced:  true,
+			configure: func(params *models.OptimizationParams) { params.NonFiniteConfidence = 0.5 },
+			history:   []float64{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64},
+			check: func(t *testing.T, explanation ConfidenceExplanation) {
+				assert.Equal(t, 0.5, explanation.StabilityFactor)
+				assert.Positive(t, explanation.Confidence)
+			},
+		},
+		{
+			name:     "Infinite spread score clamps to 1",
+			replaced: true,
+			spread:   decimal.RequireFromString("-1e400"),
+			check: func(t *testing.T, explanation ConfidenceExplanation) {
+				assert.Equal(t, 1.0, explanation.SpreadFactor)
+			},
+		},
+		{
+			name:      "NaN target",
+			replaced:  true,
+			configure: func(params *models.OptimizationParams) { params.TargetConfidence = math.NaN() },
+			check: func(t *testing.T, explanation ConfidenceExplanation) {
+				assert.Zero(t, explanation.Target)
+				assert.Zero(t, explanation.Confidence)
+			},
+		},
+		{
+			name:      "Infinite target clamps to 1",
+			replaced:  true,
+			configure: func(params *models.OptimizationParams) { params.TargetConfidence = math.Inf(1) },
+			check: func(t *testing.T, explanation ConfidenceExplanation) {
+				assert.Equal(t, 1.0, explanation.Target)
+			},
+		},
+		{
+			name: "Huge liquidity",
+			odds: func(normalized *models.NormalizedOdds) {
+				normalized.BackSize = decimal.RequireFromString("1e400")
+				normalized.LaySize = decimal.RequireFromString("1e400")
+			},
+			check: func(t *testing.T, explanation ConfidenceExplanation) {
+				assert.Equal(t, 1.0, explanation.LiquidityFactor)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := setupTestOptimizer().params
+			if tt.history != nil {
+				params.StabilityWeight = 0.5
+			}
+			if tt.configure != nil {
+				tt.configure(&params)
+			}
+			opt := NewOptimizer(params, zerolog.Nop())
+
+			normalized := newMarketOdds("Team A", 2.50)
+			normalized.RecentBackPrices = recentPrices(tt.history...)
+			if tt.odds != nil {
+				tt.odds(normalized)
+			}
+			s := spread
+			if !tt.spread.IsZero() {
+				s = tt.spread
+			}
+
+			explanation := opt.explainConfidence(normalized, s)
+
+			assert.False(t, math.IsNaN(explanation.Confidence) || math.IsInf(explanation.Confidence, 0))
+			assert.True(t, explanation.Confidence >= 0.0 && explanation.Confidence <= 1.0, "confidence %v", explanation.Confidence)
+			_, err := json.Marshal(explanation)
+			assert.NoError(t, err)
+			tt.check(t, explanation)
+			assert.Equal(t, tt.replaced, testutil.ToFloat64(opt.metrics.nonFiniteConfidence) > 0)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package optimizer
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// allowedRegions returns the regions of the first region rule matching the
+// odds' sport, competition and market, compared case-insensitively, or nil
+// when no rule matches and the odds may be served everywhere. Odds are tagged
+// when optimized, so rule changes apply as selections are re-priced.
+func (o *Optimizer) allowedRegions(normalized *models.NormalizedOdds) []string {
+	for _, rule := range o.params.RegionRules {
+		if matchesRule(rule.Sport, normalized.Sport) &&
+			matchesRule(rule.Competition, normalized.Competition) &&
+			matchesRule(rule.Market, normalized.Market) {
+			return slices.Clone(rule.Regions)
+		}
+	}
+	return nil
+}
+
+// matchesRule reports whether a rule field matches a value; empty matches any
+func matchesRule(field, value string) bool {
+	return field == "" || strings.EqualFold(field, value)
+}
@@ -0,0 +1,77 @@
+package optimizer
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// Rounding modes for optimized prices and margins
+const (
+	RoundHalfUp       = "half_up"      // Round half away from zero (default)
+	RoundHalfEven     = "half_even"    // Round half to even (banker's rounding)
+	RoundConservative = "conservative" // Round in the house's favour: back prices down, lay prices and margins up
+)
+
+// roundingSide is the direction a conservative rounding of a value favours
+type roundingSide int
+
+const (
+	roundNeutral roundingSide = iota // No house side, e.g. the fair price: rounded half up
+	roundBack                        // Offered to backers: rounded down
+	roundLay                         // Offered to layers, or a margin: rounded up
+)
+
+// roundOutput rounds an optimized selection's prices to PricePrecision and
+// its margin to MarginPrecision under RoundingMode. It is the single place
+// output values are rounded; a precision of 0 leaves them unrounded.
+func (o *Optimizer) roundOutput(optimized *models.OptimizedOdds) {
+	if places := o.params.PricePrecision; places > 0 {
+		optimized.OptimizedBack = o.roundPrice(optimized.OptimizedBack, places, roundBack)
+		optimized.OptimizedLay = o.roundPrice(optimized.OptimizedLay, places, roundLay)
+		optimized.FairPrice = o.roundPrice(optimized.FairPrice, places, roundNeutral)
+		if optimized.SportsbookPrice != nil {
+			sportsbookPrice := o.roundPrice(*optimized.SportsbookPrice, places, roundBack)
+			optimized.SportsbookPrice = &sportsbookPrice
+		}
+	}
+	if places := o.params.MarginPrecision; places > 0 {
+		optimized.Margin = o.round(optimized.Margin, places, roundLay)
+	}
+}
+
+// roundPrice rounds a decimal price, never below the smallest price above 1
+// at that precision, so rounding cannot produce an untradeable price
+func (o *Optimizer) roundPrice(price decimal.Decimal, places int32, side roundingSide) decimal.Decimal {
+	if !price.GreaterThan(decimal.NewFromInt(1)) {
+		return price
+	}
+	minPrice := decimal.NewFromInt(1).Add(decimal.New(1, -places))
+	return decimal.Max(o.round(price, places, side), minPrice)
+}
+
+// round rounds value to places decimal places under the rounding mode
+func (o *Optimizer) round(value decimal.Decimal, places int32, side roundingSide) decimal.Decimal {
+	switch o.roundingMode() {
+	case RoundHalfEven:
+		return value.RoundBank(places)
+	case RoundConservative:
+		switch side {
+		case roundBack:
+			return value.RoundFloor(places)
+		case roundLay:
+			return value.RoundCeil(places)
+		}
+	}
+	return value.Round(places)
+}
+
+// roundingMode returns the configured rounding mode, half up by default
+func (o *Optimizer) roundingMode() string {
+	switch o.params.RoundingMode {
+	case RoundHalfEven, RoundConservative:
+		return o.params.RoundingMode
+	default:
+		return RoundHalfUp
+	}
+}
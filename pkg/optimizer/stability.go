@@ -0,0 +1,67 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/cypherlabdev/odds-optimizer-service/internal/models"
+)
+
+// defaultStabilityWindow is the number of recent prices the stability factor
+// looks at when StabilityWindow is unset
+const defaultStabilityWindow = 10
+
+// StabilityWindow returns the number of recent prices the stability factor
+// weighs under params, for callers reading them ahead of optimizing
+func StabilityWindow(params models.OptimizationParams) int {
+	if params.StabilityWindow < 2 {
+		return defaultStabilityWindow
+	}
+	return params.StabilityWindow
+}
+
+// stabilityFactor scales confidence by how steady the selection's recent
+// optimized back prices (normalized.RecentBackPrices) have been:
+// (1 - StabilityWeight) + StabilityWeight * score, where score falls from 1
+// to 0 as the prices' coefficient of variation grows to 10%. It is neutral
+// (1) with fewer than two prices, e.g. when none were read from history.
+func (o *Optimizer) stabilityFactor(normalized *models.NormalizedOdds) float64 {
+	weight := math.Min(1.0, o.params.StabilityWeight)
+	if weight <= 0 {
+		return 1.0
+	}
+
+	prices := normalized.RecentBackPrices
+	if window := StabilityWindow(o.params); len(prices) > window {
+		prices = prices[:window]
+	}
+	if len(prices) < 2 {
+		return 1.0
+	}
+
+	stabilityScore := math.Max(0.0, 1.0-coefficientOfVariation(prices)*10) // Penalty for whipsawing prices
+	return (1 - weight) + weight*stabilityScore
+}
+
+// coefficientOfVariation returns the population standard deviation of prices
+// relative to their mean
+func coefficientOfVariation(prices []decimal.Decimal) float64 {
+	mean := 0.0
+	for _, price := range prices {
+		mean += price.InexactFloat64()
+	}
+	mean /= float64(len(prices))
+	if mean <= 0 {
+		return 0
+	}
+
+	variance := 0.0
+	for _, price := range prices {
+		diff := price.InexactFloat64() - mean
+		variance += diff * diff
+	}
+	variance /= float64(len(prices))
+
+	return math.Sqrt(variance) / mean
+}